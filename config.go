@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	settingsModel "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	formatFlag          = "format"
+	showSecretsFlag     = "show-secrets"
+	idFlag              = "id"
+	serverUriFlag       = "server-uri"
+	protocolVersionFlag = "protocol-version"
+	connectorCountFlag  = "connectors"
+	firstRelayPinFlag   = "relay-pin"
+	outputFlag          = "output"
+	interactiveFlag     = "interactive"
+	forceFlag           = "force"
+)
+
+var (
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Inspect or generate the charge point configuration.",
+	}
+
+	configPrintCmd = &cobra.Command{
+		Use:          "print",
+		Short:        "Print the effective settings, merged with the defaults and environment variables.",
+		SilenceUsage: true,
+		RunE:         printConfig,
+	}
+
+	configGenerateCmd = &cobra.Command{
+		Use:          "generate",
+		Short:        "Generate starter settings and connector files.",
+		SilenceUsage: true,
+		RunE:         generateConfig,
+	}
+)
+
+func printConfig(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString(formatFlag)
+	showSecrets, _ := cmd.Flags().GetBool(showSecretsFlag)
+
+	err := settings.ReadSettings(settingsFilePath)
+	if err != nil {
+		return err
+	}
+
+	conf, err := settings.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	if !showSecrets {
+		conf = settings.MaskSecrets(conf)
+	}
+
+	var out []byte
+	switch format {
+	case settings.JSON:
+		out, err = json.MarshalIndent(conf, "", "  ")
+		out = append(out, '\n')
+	case settings.YamlFile, settings.YmlFile:
+		out, err = yaml.Marshal(conf)
+	default:
+		return settings.ErrUnsupportedFileFormat
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = cmd.OutOrStdout().Write(out)
+	return err
+}
+
+func generateConfig(cmd *cobra.Command, args []string) error {
+	var (
+		flags                 = cmd.Flags()
+		id, _                 = flags.GetString(idFlag)
+		serverUri, _          = flags.GetString(serverUriFlag)
+		protocolVersion, _    = flags.GetString(protocolVersionFlag)
+		numberOfConnectors, _ = flags.GetInt(connectorCountFlag)
+		relayPin, _           = flags.GetInt(firstRelayPinFlag)
+		output, _             = flags.GetString(outputFlag)
+		format, _             = flags.GetString(formatFlag)
+		interactive, _        = flags.GetBool(interactiveFlag)
+		force, _              = flags.GetBool(forceFlag)
+	)
+
+	if interactive {
+		var (
+			reader = bufio.NewReader(cmd.InOrStdin())
+			out    = cmd.OutOrStdout()
+		)
+
+		id = prompt(reader, out, "Charge point ID", id)
+		serverUri = prompt(reader, out, "Central system URI (without the scheme)", serverUri)
+		protocolVersion = prompt(reader, out, "OCPP version", protocolVersion)
+		numberOfConnectors = promptInt(reader, out, "Number of connectors", numberOfConnectors)
+		relayPin = promptInt(reader, out, "Relay pin of the first connector", relayPin)
+		output = prompt(reader, out, "Output folder", output)
+		_, _ = fmt.Fprintln(out)
+	}
+
+	var (
+		conf       = settings.NewDefaultSettings(id, serverUri, protocolVersion)
+		connectors []*settingsModel.Connector
+	)
+
+	for i := 1; i <= numberOfConnectors; i++ {
+		connectors = append(connectors, settings.NewDefaultConnector(1, i, relayPin+i-1))
+	}
+
+	files, err := settings.GenerateFiles(output, format, conf, connectors, force)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Created %s\n", file)
+	}
+
+	return nil
+}
+
+// prompt asks for a value and returns the default value if the answer is empty.
+func prompt(reader *bufio.Reader, out io.Writer, question, defaultValue string) string {
+	_, _ = fmt.Fprintf(out, "%s [%s]: ", question, defaultValue)
+
+	answer, _ := reader.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return defaultValue
+	}
+
+	return answer
+}
+
+// promptInt asks for a number until a valid one is entered.
+func promptInt(reader *bufio.Reader, out io.Writer, question string, defaultValue int) int {
+	for {
+		answer := prompt(reader, out, question, strconv.Itoa(defaultValue))
+
+		number, err := strconv.Atoi(answer)
+		if err == nil && number >= 0 {
+			return number
+		}
+
+		_, _ = fmt.Fprintln(out, "Please enter a non-negative number.")
+
+		// Stop asking if there is no more input
+		if _, err = reader.Peek(1); err != nil {
+			return defaultValue
+		}
+	}
+}
+
+func setupConfigFlags() {
+	configPrintCmd.Flags().String(formatFlag, settings.YamlFile, "output format (yaml or json)")
+	configPrintCmd.Flags().Bool(showSecretsFlag, false, "print the passwords instead of masking them")
+
+	configGenerateCmd.Flags().String(idFlag, "ChargePi", "charge point ID")
+	configGenerateCmd.Flags().String(serverUriFlag, "localhost:8080/steve/websocket/CentralSystemService", "central system URI without the scheme")
+	configGenerateCmd.Flags().String(protocolVersionFlag, string(settingsModel.OCPP16), "OCPP protocol version")
+	configGenerateCmd.Flags().Int(connectorCountFlag, 1, "number of connectors")
+	configGenerateCmd.Flags().Int(firstRelayPinFlag, 26, "relay pin of the first connector, incremented for every next connector")
+	configGenerateCmd.Flags().StringP(outputFlag, "o", "./configs", "output folder")
+	configGenerateCmd.Flags().String(formatFlag, settings.YamlFile, "settings file format (yaml or json)")
+	configGenerateCmd.Flags().BoolP(interactiveFlag, "i", false, "ask for the values interactively")
+	configGenerateCmd.Flags().BoolP(forceFlag, "f", false, "overwrite existing files")
+
+	configCmd.AddCommand(configPrintCmd, configGenerateCmd)
+}
@@ -12,9 +12,12 @@ require (
 	github.com/d2r2/go-hd44780 v0.0.0-20181002113701-74cc28c83a3e
 	github.com/d2r2/go-i2c v0.0.0-20191123181816-73a8a799d6bc
 	github.com/d2r2/go-logger v0.0.0-20210606094344-60e9d1233e22 // indirect
+	github.com/eclipse/paho.mqtt.golang v1.3.5
+	github.com/fsnotify/fsnotify v1.5.1
 	github.com/gemnasium/logrus-graylog-hook/v3 v3.1.0
 	github.com/go-co-op/gocron v1.6.0
-	github.com/go-playground/validator v9.31.0+incompatible // indirect
+	github.com/go-playground/validator v9.31.0+incompatible
+	github.com/gorilla/websocket v1.4.2
 	github.com/kkyr/fig v0.3.0
 	github.com/lestrrat-go/file-rotatelogs v2.4.0+incompatible
 	github.com/lestrrat-go/strftime v1.0.5 // indirect
@@ -33,6 +36,7 @@ require (
 	github.com/teivah/onecontext v1.3.0 // indirect
 	github.com/warthog618/gpiod v0.6.0
 	github.com/xBlaz3kx/ocppManager-go v0.1.3
+	go.etcd.io/bbolt v1.3.6
 	golang.org/x/net v0.0.0-20220412020605-290c469a71a5
 	golang.org/x/text v0.3.7
 	google.golang.org/grpc v1.45.0
@@ -11,16 +11,22 @@ import (
 	"github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/util"
 	v16 "github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/v16"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/certificates"
 	connectorManager "github.com/xBlaz3kx/ChargePi-go/internal/components/connector-manager"
 	s "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/charge-point"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 	"github.com/xBlaz3kx/ChargePi-go/internal/pkg/grpc"
+	"github.com/xBlaz3kx/ChargePi-go/internal/pkg/mqtt"
+	"github.com/xBlaz3kx/ChargePi-go/internal/pkg/security"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/logging"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
 	"github.com/xBlaz3kx/ocppManager-go/configuration"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 )
 
@@ -32,6 +38,13 @@ func CreateChargePoint(
 	sch *gocron.Scheduler,
 	authCache *auth.Cache,
 	hardware settings.Hardware,
+	certificateManager *certificates.Manager,
+	sessionHistory store.SessionRepository,
+	chargePointState store.ChargePointRepository,
+	reservations store.ReservationRepository,
+	freeVendTransactions store.FreeVendRepository,
+	rebootHook v16.RebootHook,
+	softResetHook v16.SoftResetHook,
 ) chargePoint.ChargePoint {
 	switch protocolVersion {
 	case settings.OCPP16:
@@ -42,7 +55,17 @@ func CreateChargePoint(
 			authCache,
 			v16.WithDisplayFromSettings(ctx, hardware.Lcd),
 			v16.WithReaderFromSettings(ctx, hardware.TagReader),
+			v16.WithKeypadFromSettings(ctx, hardware.Keypad),
+			v16.WithIndicatorFromSettings(hardware.LedIndicator),
 			v16.WithLogger(logger),
+			v16.WithCertificateManager(certificateManager),
+			v16.WithSecurityLog(logging.SecurityLogFilePath),
+			v16.WithSessionHistory(sessionHistory),
+			v16.WithChargePointState(chargePointState),
+			v16.WithReservations(reservations),
+			v16.WithFreeVendTransactions(freeVendTransactions),
+			v16.WithRebootHook(rebootHook),
+			v16.WithSoftResetHook(softResetHook),
 		)
 	case settings.OCPP201:
 		logger.Fatal("Version 2.0.1 is not supported yet.")
@@ -53,19 +76,19 @@ func CreateChargePoint(
 	}
 }
 
-func Run(isDebug bool, config *settings.Settings, connectors []*settings.Connector, configurationFilePath, authFilePath string) {
+func Run(
+	isDebug bool,
+	config *settings.Settings,
+	connectors []*settings.Connector,
+	settingsFilePath, connectorsFolderPath, configurationFilePath, authFilePath, storeFilePath, certificatesFolderPath string,
+) {
 	var (
 		// ChargePoint components
-		handler   chargePoint.ChargePoint
-		authCache = auth.NewAuthCache(authFilePath)
-		logger    = log.StandardLogger()
-		manager   = connectorManager.GetManager()
-		sch       = scheduler.GetScheduler()
-		// Settings
-		chargePointInfo = config.ChargePoint.Info
-		hardware        = config.ChargePoint.Hardware
-		serverUrl       = util.CreateConnectionUrl(config.ChargePoint)
-		protocolVersion = settings.ProtocolVersion(chargePointInfo.ProtocolVersion)
+		handler chargePoint.ChargePoint
+		bridge  *mqtt.Bridge
+		logger  = log.StandardLogger()
+		manager = connectorManager.GetManager()
+		sch     = scheduler.GetScheduler()
 		// Execution
 		ctx, cancel = context.WithCancel(context.Background())
 		quitChannel = make(chan os.Signal, 5)
@@ -77,23 +100,40 @@ func Run(isDebug bool, config *settings.Settings, connectors []*settings.Connect
 	// Create the logger
 	logging.Setup(logger, config.ChargePoint.Logging, isDebug)
 
+	// Open the store and migrate the state from the files
+	stateStore, err := store.Open(storeFilePath)
+	if err != nil {
+		logger.WithError(err).Fatal("Unable to open the store")
+	}
+	defer stateStore.Close()
+
+	err = stateStore.Migrate(connectors, authFilePath)
+	if err != nil {
+		logger.WithError(err).Fatal("Unable to migrate the state to the store")
+	}
+
+	s.SetConnectorRepository(stateStore)
+
 	// Load tags
-	go authCache.LoadAuthFile()
+	authCache := auth.NewAuthCache(stateStore)
+	go authCache.LoadTags()
 
 	// Setup OCPP configuration manager
 	s.SetupOcppConfigurationManager(
 		configurationFilePath,
 		configuration.ProtocolVersion(config.ChargePoint.Info.ProtocolVersion),
+		stateStore,
 		core.ProfileName,
 		reservation.ProfileName)
 
-	// Initialize the client
-	handler = CreateChargePoint(ctx, protocolVersion, logger, manager, sch, authCache, hardware)
-	handler.Init(config)
-	handler.AddConnectors(connectors)
+	// Certificates of the OCPP security extension
+	maxCertificates, _ := ocppManager.GetConfigurationValue(security.CertificateStoreMaxLengthKey.String())
+	certificateStoreMaxLength, _ := strconv.Atoi(maxCertificates)
 
-	// Finally, connect to the central system
-	handler.Connect(ctx, serverUrl)
+	certificateManager, err := certificates.NewManager(certificatesFolderPath, certificateStoreMaxLength)
+	if err != nil {
+		logger.WithError(err).Fatal("Unable to create the certificate store")
+	}
 
 	if config.Api.Enabled {
 		var (
@@ -108,16 +148,97 @@ func Run(isDebug bool, config *settings.Settings, connectors []*settings.Connect
 		}()
 	}
 
+	var (
+		rebootHook    = newRebootHook(config.ChargePoint.Reset.RebootCommand)
+		softReset     = make(chan struct{}, 1)
+		softResetHook = func() {
+			select {
+			case softReset <- struct{}{}:
+			default:
+			}
+		}
+	)
+
 Loop:
 	for {
+		// The charge point, its connectors and hardware are rebuilt after a soft reset
+		chargePointCtx, cancelChargePoint := context.WithCancel(ctx)
+
+		// The settings are reloaded after a soft reset
+		var (
+			chargePointInfo = config.ChargePoint.Info
+			serverUrl       = util.CreateConnectionUrl(config.ChargePoint)
+			protocolVersion = settings.ProtocolVersion(chargePointInfo.ProtocolVersion)
+		)
+
+		// Initialize the client
+		handler = CreateChargePoint(chargePointCtx, protocolVersion, logger, manager, sch, authCache, config.ChargePoint.Hardware,
+			certificateManager, stateStore, stateStore, stateStore, stateStore, rebootHook, softResetHook)
+		handler.Init(config)
+		handler.AddConnectors(connectors)
+
+		bridge = nil
+		if config.Mqtt.Enabled {
+			var err error
+
+			bridge, err = mqtt.NewBridge(config.Mqtt, config.ChargePoint.Info, handler, logger)
+			if err != nil {
+				logger.WithError(err).Error("Unable to create the MQTT bridge")
+			} else {
+				handler.AddNotificationListener(bridge)
+
+				go func(bridge *mqtt.Bridge) {
+					if err := bridge.Connect(); err != nil {
+						logger.WithError(err).Error("Unable to connect to the MQTT broker")
+					}
+				}(bridge)
+			}
+		}
+
+		// Finally, connect to the central system
+		handler.Connect(chargePointCtx, serverUrl)
+
+		// Apply the configuration changes without restarting
+		watcher, err := s.NewWatcher(
+			settingsFilePath,
+			connectorsFolderPath,
+			configurationFilePath,
+			configuration.ProtocolVersion(chargePointInfo.ProtocolVersion),
+			newReloadHandler(handler, logger, isDebug, config.ChargePoint.Logging),
+			logger,
+		)
+		if err != nil {
+			logger.WithError(err).Error("Unable to watch the configuration files")
+		} else {
+			go watcher.Run(chargePointCtx)
+		}
+
 		select {
 		// Capture the terminate signal
 		case <-quitChannel:
 			handler.CleanUp(core.ReasonLocal)
+			cancelChargePoint()
 			break Loop
 		case <-ctx.Done():
 			handler.CleanUp(core.ReasonPowerLoss)
+			cancelChargePoint()
 			break Loop
+		case <-softReset:
+			// The charge point was cleaned up before the reset
+			logger.Info("Restarting the charge point after a soft reset")
+			cancelChargePoint()
+
+			if bridge != nil {
+				bridge.Close()
+			}
+
+			removeConnectors(manager, logger)
+			config, connectors = reloadSettings(config, connectors, connectorsFolderPath, logger)
+			sch.StartAsync()
 		}
 	}
+
+	if bridge != nil {
+		bridge.Close()
+	}
 }
@@ -0,0 +1,46 @@
+package chargepoint
+
+import (
+	"context"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/charge-point"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/logging"
+	"reflect"
+)
+
+// reloadHandler applies the reloaded settings to the charge point and reconfigures the logging.
+type reloadHandler struct {
+	handler chargePoint.ChargePoint
+	logger  *log.Logger
+	isDebug bool
+	logging settings.Logging
+}
+
+func newReloadHandler(handler chargePoint.ChargePoint, logger *log.Logger, isDebug bool, loggingSettings settings.Logging) *reloadHandler {
+	return &reloadHandler{
+		handler: handler,
+		logger:  logger,
+		isDebug: isDebug,
+		logging: loggingSettings,
+	}
+}
+
+func (r *reloadHandler) ApplySettings(ctx context.Context, newSettings *settings.Settings) error {
+	err := r.handler.ApplySettings(ctx, newSettings)
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(r.logging, newSettings.ChargePoint.Logging) {
+		r.logger.Info("Reconfiguring logging")
+		logging.Setup(r.logger, newSettings.ChargePoint.Logging, r.isDebug)
+		r.logging = newSettings.ChargePoint.Logging
+	}
+
+	return nil
+}
+
+func (r *reloadHandler) ApplyConnectors(connectors []*settings.Connector) error {
+	return r.handler.ApplyConnectors(connectors)
+}
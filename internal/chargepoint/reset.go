@@ -0,0 +1,69 @@
+package chargepoint
+
+import (
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/v16"
+	connectorManager "github.com/xBlaz3kx/ChargePi-go/internal/components/connector-manager"
+	s "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"os/exec"
+	"strings"
+)
+
+var ErrNoRebootCommand = errors.New("reboot command not configured")
+
+// newRebootHook creates a hook running the reboot command.
+func newRebootHook(command string) v16.RebootHook {
+	return func() error {
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			return ErrNoRebootCommand
+		}
+
+		output, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+		}
+
+		return nil
+	}
+}
+
+// removeConnectors removes the connectors from the manager, so they are created again with the charge point.
+func removeConnectors(manager connectorManager.Manager, logger *log.Logger) {
+	for _, c := range manager.GetConnectors() {
+		err := manager.RemoveConnector(c.GetEvseId(), c.GetConnectorId())
+		if err != nil {
+			logger.WithError(err).Warnf("Unable to remove connector %d", c.GetConnectorId())
+		}
+	}
+}
+
+// reloadSettings loads the settings and connectors, so the changes are applied after a soft reset. The current settings
+// and connectors are kept if the new ones are invalid.
+func reloadSettings(
+	config *settings.Settings,
+	connectors []*settings.Connector,
+	connectorsFolderPath string,
+	logger *log.Logger,
+) (*settings.Settings, []*settings.Connector) {
+	newConfig, err := s.LoadSettings()
+	if err != nil {
+		logger.WithError(err).Warn("Unable to reload the settings, using the current settings")
+		newConfig = config
+	}
+
+	newConnectors, err := s.LoadConnectors(connectorsFolderPath)
+	if err == nil {
+		err = s.ValidateConnectors(newConnectors)
+	}
+
+	if err != nil {
+		logger.WithError(err).Warn("Unable to reload the connectors, using the current connectors")
+		newConnectors = connectors
+	}
+
+	return newConfig, newConnectors
+}
@@ -0,0 +1,261 @@
+package v16
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+)
+
+// getPersistedAvailability returns the availability of the charge point before the restart. The charge point is
+// operative if the availability was never changed.
+func (cp *ChargePoint) getPersistedAvailability() core.AvailabilityType {
+	if util.IsNilInterfaceOrPointer(cp.chargePointState) {
+		return core.AvailabilityTypeOperative
+	}
+
+	state, err := cp.chargePointState.GetChargePointState()
+	if err != nil || state.Availability == "" {
+		return core.AvailabilityTypeOperative
+	}
+
+	return state.Availability
+}
+
+// getPersistedInoperativeConnectors returns the connectors that were inoperative before the restart.
+func (cp *ChargePoint) getPersistedInoperativeConnectors() map[int]bool {
+	inoperativeConnectors := map[int]bool{}
+	if util.IsNilInterfaceOrPointer(cp.chargePointState) {
+		return inoperativeConnectors
+	}
+
+	state, err := cp.chargePointState.GetChargePointState()
+	if err != nil {
+		return inoperativeConnectors
+	}
+
+	for _, connectorId := range state.InoperativeConnectors {
+		inoperativeConnectors[connectorId] = true
+	}
+
+	return inoperativeConnectors
+}
+
+// persistAvailability stores the availability, so it is restored after a restart.
+func (cp *ChargePoint) persistAvailability(availability core.AvailabilityType) {
+	if util.IsNilInterfaceOrPointer(cp.chargePointState) {
+		return
+	}
+
+	err := cp.chargePointState.UpdateAvailability(availability)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Unable to persist the availability")
+	}
+}
+
+// persistConnectorAvailability stores the availability of the connector, so it is restored after a restart.
+func (cp *ChargePoint) persistConnectorAvailability(connectorId int, availability core.AvailabilityType) {
+	if util.IsNilInterfaceOrPointer(cp.chargePointState) {
+		return
+	}
+
+	err := cp.chargePointState.UpdateConnectorAvailability(connectorId, availability)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Unable to persist the availability of connector %d", connectorId)
+	}
+}
+
+// getAvailability returns the availability of the charge point.
+func (cp *ChargePoint) getAvailability() core.AvailabilityType {
+	cp.availabilityMu.Lock()
+	defer cp.availabilityMu.Unlock()
+	return cp.availability
+}
+
+// isChargePointOperative checks if the charge point is operative and is not scheduled to become inoperative, so new
+// transactions and reservations are accepted.
+func (cp *ChargePoint) isChargePointOperative() bool {
+	cp.availabilityMu.Lock()
+	defer cp.availabilityMu.Unlock()
+	return cp.isOperative()
+}
+
+// isConnectorOperative checks if both the charge point and the connector are operative.
+func (cp *ChargePoint) isConnectorOperative(c connector.Connector) bool {
+	cp.availabilityMu.Lock()
+	defer cp.availabilityMu.Unlock()
+
+	return cp.isOperative() && !cp.inoperativeConnectors[c.GetConnectorId()]
+}
+
+// isOperative must be called with the availabilityMu locked.
+func (cp *ChargePoint) isOperative() bool {
+	return cp.availability == core.AvailabilityTypeOperative && cp.scheduledAvailability != core.AvailabilityTypeInoperative
+}
+
+// changeAvailability changes the availability of the charge point. While there are ongoing transactions, the change to
+// Inoperative is scheduled and applied after all the transactions end.
+func (cp *ChargePoint) changeAvailability(availability core.AvailabilityType) core.AvailabilityStatus {
+	switch availability {
+	case core.AvailabilityTypeOperative, core.AvailabilityTypeInoperative:
+	default:
+		return core.AvailabilityStatusRejected
+	}
+
+	cp.availabilityMu.Lock()
+	defer cp.availabilityMu.Unlock()
+
+	if availability == core.AvailabilityTypeInoperative && cp.hasOngoingTransactions() {
+		cp.logger.Info("Transactions are ongoing, the charge point will become inoperative after they end")
+		cp.scheduledAvailability = availability
+		cp.persistAvailability(availability)
+		return core.AvailabilityStatusScheduled
+	}
+
+	cp.setAvailability(availability)
+	return core.AvailabilityStatusAccepted
+}
+
+// changeConnectorAvailability changes the availability of the connector. While the connector is charging, the change to
+// Inoperative is scheduled and applied after the transaction ends.
+func (cp *ChargePoint) changeConnectorAvailability(connectorId int, availability core.AvailabilityType) core.AvailabilityStatus {
+	switch availability {
+	case core.AvailabilityTypeOperative, core.AvailabilityTypeInoperative:
+	default:
+		return core.AvailabilityStatusRejected
+	}
+
+	c := cp.connectorManager.FindConnectorById(connectorId)
+	if util.IsNilInterfaceOrPointer(c) {
+		return core.AvailabilityStatusRejected
+	}
+
+	cp.availabilityMu.Lock()
+	defer cp.availabilityMu.Unlock()
+
+	if availability == core.AvailabilityTypeInoperative && (c.IsCharging() || c.IsPreparing()) {
+		cp.logger.Infof("Connector %d will become inoperative after the transaction ends", connectorId)
+		cp.scheduledConnectorAvailability[connectorId] = availability
+		cp.persistConnectorAvailability(connectorId, availability)
+		return core.AvailabilityStatusScheduled
+	}
+
+	cp.setConnectorAvailability(c, availability)
+	return core.AvailabilityStatusAccepted
+}
+
+// applyScheduledAvailability applies the scheduled availability changes of the connectors that are not charging and of
+// the charge point, once there are no ongoing transactions.
+func (cp *ChargePoint) applyScheduledAvailability() {
+	cp.availabilityMu.Lock()
+	defer cp.availabilityMu.Unlock()
+
+	for connectorId, availability := range cp.scheduledConnectorAvailability {
+		c := cp.connectorManager.FindConnectorById(connectorId)
+		switch {
+		case util.IsNilInterfaceOrPointer(c):
+			delete(cp.scheduledConnectorAvailability, connectorId)
+		case !c.IsCharging() && !c.IsPreparing():
+			cp.setConnectorAvailability(c, availability)
+		}
+	}
+
+	if cp.scheduledAvailability == "" || cp.hasOngoingTransactions() {
+		return
+	}
+
+	cp.setAvailability(cp.scheduledAvailability)
+}
+
+// restoreAvailability applies the availability from before the restart to the connectors and notifies the central system.
+func (cp *ChargePoint) restoreAvailability() {
+	if cp.changeAvailability(cp.getAvailability()) == core.AvailabilityStatusScheduled {
+		cp.notifyChargePointStatus(cp.getAvailability())
+	}
+
+	cp.availabilityMu.Lock()
+	inoperativeConnectors := []int{}
+	for connectorId := range cp.inoperativeConnectors {
+		inoperativeConnectors = append(inoperativeConnectors, connectorId)
+	}
+	cp.availabilityMu.Unlock()
+
+	for _, connectorId := range inoperativeConnectors {
+		if cp.changeConnectorAvailability(connectorId, core.AvailabilityTypeInoperative) == core.AvailabilityStatusRejected {
+			// The connector was removed
+			cp.persistConnectorAvailability(connectorId, core.AvailabilityTypeOperative)
+		}
+	}
+}
+
+// setAvailability changes the availability of the charge point and the status of the idle connectors. The connectors
+// made inoperative by the central system stay unavailable. The reservations are cancelled when the charge point becomes
+// inoperative.
+func (cp *ChargePoint) setAvailability(availability core.AvailabilityType) {
+	cp.logger.Infof("Changing the availability of the charge point to %s", availability)
+
+	cp.availability = availability
+	cp.scheduledAvailability = ""
+	cp.persistAvailability(availability)
+
+	if availability == core.AvailabilityTypeInoperative {
+		cp.cancelReservations()
+	}
+
+	for _, c := range cp.connectorManager.GetConnectors() {
+		switch {
+		case availability == core.AvailabilityTypeInoperative && c.IsAvailable():
+			c.SetStatus(core.ChargePointStatusUnavailable, core.NoError)
+		case availability == core.AvailabilityTypeOperative && c.IsUnavailable() && !cp.inoperativeConnectors[c.GetConnectorId()]:
+			c.SetStatus(core.ChargePointStatusAvailable, core.NoError)
+		}
+	}
+
+	cp.notifyChargePointStatus(availability)
+}
+
+// setConnectorAvailability changes the availability and the status of the connector. The reservation of the connector
+// is cancelled when it becomes inoperative.
+func (cp *ChargePoint) setConnectorAvailability(c connector.Connector, availability core.AvailabilityType) {
+	connectorId := c.GetConnectorId()
+	cp.logger.Infof("Changing the availability of connector %d to %s", connectorId, availability)
+
+	delete(cp.scheduledConnectorAvailability, connectorId)
+	cp.persistConnectorAvailability(connectorId, availability)
+
+	if availability == core.AvailabilityTypeOperative {
+		delete(cp.inoperativeConnectors, connectorId)
+
+		if cp.availability == core.AvailabilityTypeOperative && c.IsUnavailable() {
+			c.SetStatus(core.ChargePointStatusAvailable, core.NoError)
+		}
+
+		return
+	}
+
+	cp.inoperativeConnectors[connectorId] = true
+
+	if c.IsReserved() {
+		if connectorReservation, err := cp.reservations.GetConnectorReservation(c.GetEvseId(), connectorId); err == nil {
+			_, _ = cp.reservations.RemoveReservation(connectorReservation.ReservationId)
+		}
+
+		_ = c.RemoveReservation()
+	}
+
+	if c.IsAvailable() {
+		c.SetStatus(core.ChargePointStatusUnavailable, core.NoError)
+	}
+}
+
+// cancelReservations removes all the reservations and releases their connectors.
+func (cp *ChargePoint) cancelReservations() {
+	for _, r := range cp.reservations.GetReservations() {
+		removedReservation, err := cp.reservations.RemoveReservation(r.ReservationId)
+		if err != nil {
+			continue
+		}
+
+		cp.logger.Infof("Cancelled the reservation %d of connector %d", removedReservation.ReservationId, removedReservation.ConnectorId)
+		cp.releaseReservedConnector(*removedReservation)
+	}
+}
@@ -0,0 +1,263 @@
+package v16
+
+import (
+	"github.com/go-co-op/gocron"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	reservationManager "github.com/xBlaz3kx/ChargePi-go/internal/components/reservation-manager"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
+	chargePointErrors "github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type availabilityTestSuite struct {
+	suite.Suite
+	cp            *ChargePoint
+	connectorMock *test.ConnectorMock
+	managerMock   *test.ManagerMock
+	store         *store.Store
+	tempDir       string
+}
+
+func (s *availabilityTestSuite) SetupTest() {
+	tempDir, err := ioutil.TempDir("", "chargepi")
+	s.Require().NoError(err)
+	s.tempDir = tempDir
+
+	s.store, err = store.Open(filepath.Join(tempDir, "chargepi.db"))
+	s.Require().NoError(err)
+
+	s.connectorMock = new(test.ConnectorMock)
+	s.connectorMock.On("GetConnectorId").Return(1).Maybe()
+	s.connectorMock.On("GetEvseId").Return(1).Maybe()
+
+	s.managerMock = new(test.ManagerMock)
+	s.managerMock.On("SetNotificationChannel", mock.Anything).Return()
+	s.managerMock.On("SetMeterValuesChannel", mock.Anything).Return()
+	s.managerMock.On("GetConnectors").Return([]connector.Connector{s.connectorMock})
+	s.managerMock.On("FindConnectorById", 1).Return(s.connectorMock)
+	s.managerMock.On("FindConnectorById", 2).Return(nil)
+	s.managerMock.On("GetEvseConnectors", 1).Return([]connector.Connector{s.connectorMock})
+
+	s.cp = NewChargePoint(s.managerMock, gocron.NewScheduler(time.UTC), nil,
+		WithLogger(log.StandardLogger()),
+		WithChargePointState(s.store),
+	)
+	s.cp.availability = s.cp.getPersistedAvailability()
+	s.cp.reservations = reservationManager.NewManager(nil, nil)
+}
+
+func (s *availabilityTestSuite) TearDownTest() {
+	s.cp.reservations.Stop()
+	_ = s.store.Close()
+	_ = os.RemoveAll(s.tempDir)
+}
+
+func (s *availabilityTestSuite) persistedAvailability() core.AvailabilityType {
+	state, err := s.store.GetChargePointState()
+	s.Require().NoError(err)
+	return state.Availability
+}
+
+func (s *availabilityTestSuite) TestChangeAvailability() {
+	s.Assert().EqualValues(core.AvailabilityTypeOperative, s.cp.availability)
+
+	// The idle connectors become unavailable
+	s.connectorMock.On("IsCharging").Return(false)
+	s.connectorMock.On("IsPreparing").Return(false)
+	s.connectorMock.On("IsAvailable").Return(true).Once()
+	s.connectorMock.On("SetStatus", core.ChargePointStatusUnavailable, core.NoError).Return().Once()
+
+	response, err := s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(0, core.AvailabilityTypeInoperative))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.AvailabilityStatusAccepted, response.Status)
+	s.Assert().EqualValues(core.AvailabilityTypeInoperative, s.cp.availability)
+	s.Assert().EqualValues(core.AvailabilityTypeInoperative, s.persistedAvailability())
+
+	// And available again
+	s.connectorMock.On("IsUnavailable").Return(true).Once()
+	s.connectorMock.On("SetStatus", core.ChargePointStatusAvailable, core.NoError).Return().Once()
+
+	response, err = s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(0, core.AvailabilityTypeOperative))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.AvailabilityStatusAccepted, response.Status)
+	s.Assert().EqualValues(core.AvailabilityTypeOperative, s.cp.availability)
+	s.Assert().EqualValues(core.AvailabilityTypeOperative, s.persistedAvailability())
+
+	s.connectorMock.AssertExpectations(s.T())
+}
+
+func (s *availabilityTestSuite) TestChangeAvailabilityCancelsReservations() {
+	s.Require().NoError(s.cp.reservations.AddReservation(store.Reservation{
+		ReservationId: 3,
+		EvseId:        1,
+		ConnectorId:   1,
+		IdTag:         tagId,
+		ExpiryDate:    time.Now().Add(time.Hour),
+	}))
+
+	// The reserved connector is released and becomes unavailable
+	s.managerMock.On("FindConnector", 1, 1).Return(s.connectorMock)
+	s.connectorMock.On("IsCharging").Return(false)
+	s.connectorMock.On("IsPreparing").Return(false)
+	s.connectorMock.On("IsReserved").Return(true).Once()
+	s.connectorMock.On("GetReservationId").Return(3).Once()
+	s.connectorMock.On("RemoveReservation").Return(nil).Once()
+	s.connectorMock.On("IsAvailable").Return(true).Once()
+	s.connectorMock.On("SetStatus", core.ChargePointStatusUnavailable, core.NoError).Return().Once()
+
+	response, err := s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(0, core.AvailabilityTypeInoperative))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.AvailabilityStatusAccepted, response.Status)
+	s.Assert().Empty(s.cp.reservations.GetReservations())
+	s.connectorMock.AssertExpectations(s.T())
+}
+
+func (s *availabilityTestSuite) TestChangeAvailabilityScheduled() {
+	s.connectorMock.On("IsCharging").Return(true).Twice()
+
+	response, err := s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(0, core.AvailabilityTypeInoperative))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.AvailabilityStatusScheduled, response.Status)
+	s.Assert().EqualValues(core.AvailabilityTypeOperative, s.cp.availability)
+	s.Assert().EqualValues(core.AvailabilityTypeInoperative, s.persistedAvailability())
+
+	// No new transactions can start while the change is scheduled
+	s.Assert().False(s.cp.isChargePointOperative())
+	s.Assert().False(s.cp.isConnectorOperative(s.connectorMock))
+
+	// The transaction is still ongoing
+	s.cp.applyScheduledAvailability()
+	s.Assert().EqualValues(core.AvailabilityTypeOperative, s.cp.availability)
+
+	// The transaction ended
+	s.connectorMock.On("IsCharging").Return(false)
+	s.connectorMock.On("IsPreparing").Return(false)
+	s.connectorMock.On("IsAvailable").Return(true).Once()
+	s.connectorMock.On("SetStatus", core.ChargePointStatusUnavailable, core.NoError).Return().Once()
+
+	s.cp.applyScheduledAvailability()
+	s.Assert().EqualValues(core.AvailabilityTypeInoperative, s.cp.availability)
+	s.Assert().EqualValues("", s.cp.scheduledAvailability)
+	s.connectorMock.AssertExpectations(s.T())
+}
+
+func (s *availabilityTestSuite) TestRestoreAvailability() {
+	s.Require().NoError(s.store.UpdateAvailability(core.AvailabilityTypeInoperative))
+	s.Assert().EqualValues(core.AvailabilityTypeInoperative, s.cp.getPersistedAvailability())
+
+	s.cp.availability = s.cp.getPersistedAvailability()
+
+	s.connectorMock.On("IsCharging").Return(false)
+	s.connectorMock.On("IsPreparing").Return(false)
+	s.connectorMock.On("IsAvailable").Return(true).Once()
+	s.connectorMock.On("SetStatus", core.ChargePointStatusUnavailable, core.NoError).Return().Once()
+
+	s.cp.restoreAvailability()
+	s.Assert().EqualValues(core.AvailabilityTypeInoperative, s.cp.availability)
+	s.connectorMock.AssertExpectations(s.T())
+}
+
+func (s *availabilityTestSuite) persistedInoperativeConnectors() []int {
+	state, err := s.store.GetChargePointState()
+	s.Require().NoError(err)
+	return state.InoperativeConnectors
+}
+
+func (s *availabilityTestSuite) TestChangeConnectorAvailability() {
+	s.connectorMock.On("IsCharging").Return(false)
+	s.connectorMock.On("IsPreparing").Return(false)
+	s.connectorMock.On("IsReserved").Return(false)
+
+	// The connector becomes unavailable
+	s.connectorMock.On("IsAvailable").Return(true).Once()
+	s.connectorMock.On("SetStatus", core.ChargePointStatusUnavailable, core.NoError).Return().Once()
+
+	response, err := s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(1, core.AvailabilityTypeInoperative))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.AvailabilityStatusAccepted, response.Status)
+	s.Assert().False(s.cp.isConnectorOperative(s.connectorMock))
+	s.Assert().EqualValues([]int{1}, s.persistedInoperativeConnectors())
+
+	// The connector stays unavailable when the charge point becomes operative
+	s.connectorMock.On("IsUnavailable").Return(true)
+	s.cp.setAvailability(core.AvailabilityTypeOperative)
+	s.connectorMock.AssertNumberOfCalls(s.T(), "SetStatus", 1)
+
+	// Transactions cannot be started on the connector
+	s.connectorMock.On("IsAvailable").Return(true).Once()
+	s.Assert().ErrorIs(s.cp.startChargingConnector(s.connectorMock, tagId), chargePointErrors.ErrConnectorUnavailable)
+
+	// Unknown connector
+	response, err = s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(2, core.AvailabilityTypeInoperative))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.AvailabilityStatusRejected, response.Status)
+
+	// And available again
+	s.connectorMock.On("SetStatus", core.ChargePointStatusAvailable, core.NoError).Return().Once()
+
+	response, err = s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(1, core.AvailabilityTypeOperative))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.AvailabilityStatusAccepted, response.Status)
+	s.Assert().True(s.cp.isConnectorOperative(s.connectorMock))
+	s.Assert().Empty(s.persistedInoperativeConnectors())
+	s.connectorMock.AssertExpectations(s.T())
+}
+
+func (s *availabilityTestSuite) TestChangeConnectorAvailabilityScheduled() {
+	s.connectorMock.On("IsCharging").Return(true).Twice()
+
+	response, err := s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(1, core.AvailabilityTypeInoperative))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.AvailabilityStatusScheduled, response.Status)
+	s.Assert().True(s.cp.isConnectorOperative(s.connectorMock))
+	s.Assert().EqualValues([]int{1}, s.persistedInoperativeConnectors())
+
+	// The transaction is still ongoing
+	s.cp.applyScheduledAvailability()
+	s.Assert().True(s.cp.isConnectorOperative(s.connectorMock))
+
+	// The transaction ended
+	s.connectorMock.On("IsCharging").Return(false)
+	s.connectorMock.On("IsPreparing").Return(false)
+	s.connectorMock.On("IsReserved").Return(false)
+	s.connectorMock.On("IsAvailable").Return(true).Once()
+	s.connectorMock.On("SetStatus", core.ChargePointStatusUnavailable, core.NoError).Return().Once()
+
+	s.cp.applyScheduledAvailability()
+	s.Assert().False(s.cp.isConnectorOperative(s.connectorMock))
+	s.Assert().Empty(s.cp.scheduledConnectorAvailability)
+	s.connectorMock.AssertExpectations(s.T())
+}
+
+func (s *availabilityTestSuite) TestRestoreConnectorAvailability() {
+	s.Require().NoError(s.store.UpdateConnectorAvailability(1, core.AvailabilityTypeInoperative))
+	s.Require().NoError(s.store.UpdateConnectorAvailability(2, core.AvailabilityTypeInoperative))
+	s.cp.inoperativeConnectors = s.cp.getPersistedInoperativeConnectors()
+
+	s.connectorMock.On("IsCharging").Return(false)
+	s.connectorMock.On("IsPreparing").Return(false)
+	s.connectorMock.On("IsReserved").Return(false)
+	s.connectorMock.On("IsUnavailable").Return(false)
+	s.connectorMock.On("IsAvailable").Return(true).Once()
+	s.connectorMock.On("SetStatus", core.ChargePointStatusUnavailable, core.NoError).Return().Once()
+
+	s.cp.restoreAvailability()
+	s.Assert().False(s.cp.isConnectorOperative(s.connectorMock))
+
+	// Connector 2 no longer exists
+	s.Assert().EqualValues([]int{1}, s.persistedInoperativeConnectors())
+	s.connectorMock.AssertExpectations(s.T())
+}
+
+func TestAvailability(t *testing.T) {
+	suite.Run(t, new(availabilityTestSuite))
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"github.com/lorenzodonini/ocpp-go/ocpp"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
-	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
 	configManager "github.com/xBlaz3kx/ocppManager-go"
 	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
@@ -14,7 +13,7 @@ import (
 // If the central system does not accept the charge point, exit the client.
 func (cp *ChargePoint) bootNotification() {
 	var (
-		ocppInfo = cp.Settings.ChargePoint.Info.OCPPInfo
+		ocppInfo = cp.getSettings().ChargePoint.Info.OCPPInfo
 		request  = core.BootNotificationRequest{
 			ChargeBoxSerialNumber:   ocppInfo.ChargeBoxSerialNumber,
 			ChargePointModel:        ocppInfo.Model,
@@ -34,6 +33,11 @@ func (cp *ChargePoint) bootNotification() {
 			cp.logger.Info("Notified and accepted from the central system")
 			cp.setHeartbeat(bootConf.Interval)
 			cp.restoreState()
+			cp.restoreReservations()
+			cp.restoreAvailability()
+			cp.startupEvent.Do(func() {
+				cp.sendSecurityEvent(SecurityEventStartupOfTheDevice, "")
+			})
 			break
 		case core.RegistrationStatusPending:
 			cp.logger.Info("Registration status pending")
@@ -57,17 +61,30 @@ func (cp *ChargePoint) setHeartbeat(interval int) {
 	}
 
 	heartBeatInterval = fmt.Sprintf("%ss", heartBeatInterval)
-	_, err := scheduler.GetScheduler().Every(heartBeatInterval).Tag("heartbeat").Do(cp.sendHeartBeat)
+	_, err := cp.scheduler.Every(heartBeatInterval).Tag("heartbeat").Do(cp.sendHeartBeat)
 	if err != nil {
 		cp.logger.WithError(err).Errorf("Error scheduling heartbeat")
 	}
 }
 
-// sendHeartBeat Send a setHeartbeat to the central system.
+// sendHeartBeat Send a setHeartbeat to the central system. The charge point is considered offline while the
+// heartbeats fail.
 func (cp *ChargePoint) sendHeartBeat() error {
-	return util.SendRequest(cp.chargePoint,
+	err := util.SendRequest(cp.chargePoint,
 		core.NewHeartbeatRequest(),
 		func(confirmation ocpp.Response, protoError error) {
+			if protoError != nil {
+				cp.logger.WithError(protoError).Warn("Heartbeat failed")
+				cp.setOffline(true)
+				return
+			}
+
 			cp.logger.Info("Sent heartbeat")
+			cp.setOffline(false)
 		})
+	if err != nil {
+		cp.setOffline(true)
+	}
+
+	return err
 }
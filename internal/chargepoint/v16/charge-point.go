@@ -5,37 +5,114 @@ import (
 	"github.com/go-co-op/gocron"
 	ocpp16 "github.com/lorenzodonini/ocpp-go/ocpp1.6"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ws"
 	"github.com/reactivex/rxgo/v2"
 	log "github.com/sirupsen/logrus"
 	"github.com/xBlaz3kx/ChargePi-go/internal/api"
 	chargePointUtil "github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/util"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	authInput "github.com/xBlaz3kx/ChargePi-go/internal/components/auth-input"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/certificates"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
 	connectorManager "github.com/xBlaz3kx/ChargePi-go/internal/components/connector-manager"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/firmware"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/indicator"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/keypad"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/reader"
+	reservationManager "github.com/xBlaz3kx/ChargePi-go/internal/components/reservation-manager"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/tariff"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models"
 	chargePoint "github.com/xBlaz3kx/ChargePi-go/internal/models/charge-point"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/internal/pkg/security"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"sync"
 )
 
 type (
 	ChargePoint struct {
-		chargePoint  ocpp16.ChargePoint
-		availability core.AvailabilityType
-		Settings     *settings.Settings
+		chargePoint ocpp16.ChargePoint
+		Settings    *settings.Settings
+		// The settings and the hardware components are replaced by the reloads while the charge point is running
+		settingsMu sync.RWMutex
+		reloadMu   sync.Mutex
+		// Availability of the charge point and the connectors and the changes scheduled until the transactions end
+		availabilityMu                 sync.Mutex
+		availability                   core.AvailabilityType
+		scheduledAvailability          core.AvailabilityType
+		inoperativeConnectors          map[int]bool
+		scheduledConnectorAvailability map[int]core.AvailabilityType
+		chargePointState               store.ChargePointRepository
 		// Hardware components
 		TagReader reader.Reader
+		Keypad    keypad.Keypad
 		Indicator indicator.Indicator
 		LCD       display.LCD
+		// Hardware listeners are stopped when the component is replaced
+		cancelReader  context.CancelFunc
+		cancelKeypad  context.CancelFunc
+		cancelDisplay context.CancelFunc
+		// Animations of the indicator and the state they depend on
+		indicatorSettings *settings.LedIndicator
+		indicatorMu       sync.Mutex
+		animator          *indicator.Animator
+		indicatorTheme    indicator.Theme
+		isOffline         bool
+		// Authorization requests from the tag reader, the keypad and the payment backend
+		authInputs *authInput.Bus
 		// Software components
+		connectorSettings  []*settings.Connector
 		connectorManager   connectorManager.Manager
 		connectorChannel   chan rxgo.Item
-		meterValuesChannel chan rxgo.Item
+		meterValuesChannel chan models.MeterValueNotification
+		listeners          []chargePoint.NotificationListener
 		scheduler          *gocron.Scheduler
 		authCache          *auth.Cache
 		logger             *log.Logger
+		// Security extension
+		wsClient           *ws.Client
+		securityClient     *security.Client
+		certificateManager *certificates.Manager
+		serverUrl          string
+		startupEvent       sync.Once
+		securityLogger     *log.Logger
+		securityLogPath    string
+		// Firmware updates and log uploads
+		firmwareManager      *firmware.Manager
+		transferMu           sync.Mutex
+		firmwareStatus       security.FirmwareStatus
+		firmwareRequestId    *int
+		cancelFirmwareUpdate context.CancelFunc
+		logStatus            security.UploadLogStatus
+		logRequestId         *int
+		cancelLogUpload      context.CancelFunc
+		// DataTransfer handlers and the pricing pushed by the central system
+		dataTransfer *dataTransfer.Registry
+		pricingMu    sync.Mutex
+		pricing      string
+		// Preferred languages of the tags for the messages on the LCD
+		languageMu   sync.Mutex
+		tagLanguages map[string]string
+		// Tariff pushed by the central system, running costs of the transactions and the finished sessions
+		tariffMu            sync.Mutex
+		centralSystemTariff *tariff.Tariff
+		sessionCosts        map[string]*sessionCost
+		sessionHistory      store.SessionRepository
+		// Reservations of the connectors
+		reservations          *reservationManager.Manager
+		reservationRepository store.ReservationRepository
+		// Free vend transactions that are not yet confirmed or stopped and the inputs starting them
+		freeVendMu           sync.Mutex
+		freeVendTransactions map[string]*store.FreeVendTransaction
+		freeVendRepository   store.FreeVendRepository
+		freeVendInputs       []hardware.Input
+		// Resets requested by the central system
+		rebootHook    RebootHook
+		softResetHook SoftResetHook
 	}
 
 	ChargePointV16 interface {
@@ -48,28 +125,50 @@ type (
 	}
 
 	Options func(point *ChargePoint)
+
+	// RebootHook reboots the system at a hard reset.
+	RebootHook func() error
+
+	// SoftResetHook restarts the charge point in-process at a soft reset.
+	SoftResetHook func()
 )
 
 // NewChargePoint creates a new ChargePoint for OCPP version 1.6.
 func NewChargePoint(manager connectorManager.Manager, scheduler *gocron.Scheduler, cache *auth.Cache, opts ...Options) *ChargePoint {
-	ch := make(chan rxgo.Item, 5)
-	// Set the channel
+	var (
+		ch                 = make(chan rxgo.Item, 5)
+		meterValuesChannel = make(chan models.MeterValueNotification, 5)
+	)
+
+	// Set the channels
 	manager.SetNotificationChannel(ch)
+	manager.SetMeterValuesChannel(meterValuesChannel)
 
 	cp := &ChargePoint{
-		availability:     core.AvailabilityTypeInoperative,
-		connectorChannel: ch,
-		scheduler:        scheduler,
-		connectorManager: manager,
-		authCache:        cache,
-		logger:           log.StandardLogger(),
+		availability:                   core.AvailabilityTypeInoperative,
+		inoperativeConnectors:          map[int]bool{},
+		scheduledConnectorAvailability: map[int]core.AvailabilityType{},
+		connectorChannel:               ch,
+		meterValuesChannel:             meterValuesChannel,
+		scheduler:                      scheduler,
+		connectorManager:               manager,
+		authCache:                      cache,
+		logger:                         log.StandardLogger(),
+		dataTransfer:                   dataTransfer.NewRegistry(),
+		sessionCosts:                   map[string]*sessionCost{},
+		freeVendTransactions:           map[string]*store.FreeVendTransaction{},
+		tagLanguages:                   map[string]string{},
 	}
 
+	cp.registerDataTransferHandlers()
+	cp.authInputs = authInput.NewBus(cp.handleAuthorizationRequest)
+
 	// Apply options
 	for _, opt := range opts {
 		opt(cp)
 	}
 
+	cp.reservations = reservationManager.NewManager(cp.reservationRepository, cp.onReservationExpired)
 	return cp
 }
 
@@ -79,7 +178,9 @@ func (cp *ChargePoint) Init(settings *settings.Settings) {
 		log.Fatal("no settings provided")
 	}
 
+	cp.settingsMu.Lock()
 	cp.Settings = settings
+	cp.settingsMu.Unlock()
 
 	var (
 		info      = settings.ChargePoint.Info
@@ -94,16 +195,20 @@ func (cp *ChargePoint) Init(settings *settings.Settings) {
 	)
 
 	logInfo.Debug("Creating charge point")
-	cp.chargePoint = ocpp16.NewChargePoint(info.Id, nil, wsClient)
+	cp.chargePoint = ocpp16.NewChargePoint(info.Id, nil, cp.setupSecurity(wsClient))
 
 	// Set charging profiles
 	chargePointUtil.SetProfilesFromConfig(cp.chargePoint, cp, cp, cp)
 
+	cp.firmwareManager = firmware.NewManager(settings.ChargePoint.Firmware.DownloadFolder, settings.ChargePoint.Firmware.InstallCommand)
 	cp.setMaxCachedTags()
 }
 
 // Connect to the central system and send a BootNotification
 func (cp *ChargePoint) Connect(ctx context.Context, serverUrl string) {
+	cp.serverUrl = serverUrl
+	serverUrl = cp.getConnectionUrl()
+
 	cp.logger.Infof("Trying to connect to the central system: %s", serverUrl)
 	connectErr := cp.chargePoint.Start(serverUrl)
 
@@ -114,14 +219,28 @@ func (cp *ChargePoint) Connect(ctx context.Context, serverUrl string) {
 	}
 
 	cp.logger.Infof("Successfully connected to: %s", serverUrl)
-	cp.availability = core.AvailabilityTypeOperative
+	cp.availabilityMu.Lock()
+	cp.availability = cp.getPersistedAvailability()
+	cp.inoperativeConnectors = cp.getPersistedInoperativeConnectors()
+	cp.availabilityMu.Unlock()
 
 	go cp.ListenForConnectorStatusChange(ctx, cp.connectorChannel)
 	cp.bootNotification()
 }
 
-// HandleChargingRequest Entry point for determining if the request is to start or stop charging. Trying to find a connector that has the tag stored in the Session; if such a connector exists,
-// execute stopChargingConnector, otherwise startCharging.
+// AddNotificationListener registers a listener for connector status and meter value notifications.
+// Listeners should be added before connecting to the central system.
+func (cp *ChargePoint) AddNotificationListener(listener chargePoint.NotificationListener) {
+	if util.IsNilInterfaceOrPointer(listener) {
+		return
+	}
+
+	cp.listeners = append(cp.listeners, listener)
+}
+
+// HandleChargingRequest Entry point for determining if the request is to start or stop charging. Trying to find a connector that has the tag
+// stored in the Session; if such a connector exists, execute stopChargingConnector, otherwise startCharging. A tag stops
+// the session of another tag of the same group (parentIdTag) before it starts charging.
 func (cp *ChargePoint) HandleChargingRequest(tagId string) (*api.HandleChargingResponse, error) {
 	var (
 		err      error
@@ -129,9 +248,9 @@ func (cp *ChargePoint) HandleChargingRequest(tagId string) (*api.HandleChargingR
 	)
 	cp.logger.Infof("Handling request for tag %s", tagId)
 
-	c := cp.connectorManager.FindConnectorWithTagId(tagId)
+	c := cp.findSessionConnector(tagId)
 	if !util.IsNilInterfaceOrPointer(c) {
-		err = cp.stopChargingConnector(c, core.ReasonLocal)
+		err = cp.stopChargingConnectorByTag(c, tagId, core.ReasonLocal)
 		if err != nil {
 			cp.logger.WithError(err).Errorf("Error stopping charging the connector")
 			response.ErrorMessage = err.Error()
@@ -157,35 +276,40 @@ func (cp *ChargePoint) CleanUp(reason core.Reason) {
 
 	switch reason {
 	case core.ReasonRemote, core.ReasonLocal, core.ReasonHardReset, core.ReasonSoftReset:
-		for _, c := range cp.connectorManager.GetConnectors() {
-			// Stop charging the connectors
-			err := cp.stopChargingConnector(c, reason)
-			if err != nil {
-				cp.logger.WithError(err).Errorf("Cannot stop the transaction at cleanup")
-			}
-		}
+		cp.stopAllTransactions(reason, cleanUpTimeout)
 		break
 	}
 
 	cp.logger.Infof("Disconnecting the client..")
 	cp.chargePoint.Stop()
 
+	cp.authInputs.Close()
+
+	cp.settingsMu.Lock()
 	if !util.IsNilInterfaceOrPointer(cp.TagReader) {
 		cp.logger.Info("Cleaning up the Tag Reader")
 		cp.TagReader.Cleanup()
 	}
 
+	if !util.IsNilInterfaceOrPointer(cp.Keypad) {
+		cp.logger.Info("Cleaning up the Keypad")
+		cp.Keypad.Cleanup()
+	}
+
 	if !util.IsNilInterfaceOrPointer(cp.LCD) {
 		cp.logger.Info("Cleaning up LCD")
 		cp.LCD.Cleanup()
 	}
+	cp.settingsMu.Unlock()
 
 	if !util.IsNilInterfaceOrPointer(cp.Indicator) {
 		cp.logger.Info("Cleaning up Indicator")
-		cp.Indicator.Cleanup()
+		cp.setIndicator(nil)
 	}
 
+	cp.closeFreeVendInputs()
 	close(cp.connectorChannel)
+	cp.reservations.Stop()
 	cp.logger.Info("Clearing the scheduler...")
 	cp.scheduler.Stop()
 	cp.scheduler.Clear()
@@ -0,0 +1,160 @@
+package v16
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"strconv"
+	"strings"
+)
+
+// rebootRequiredKeys are only read when the connection to the central system is established.
+var rebootRequiredKeys = map[configuration.Key]bool{
+	v16.WebSocketPingInterval: true,
+}
+
+// listMaxLengthKeys maps the list keys to the keys holding their maximum number of items.
+var listMaxLengthKeys = map[configuration.Key]configuration.Key{
+	v16.MeterValuesAlignedData: v16.MeterValuesAlignedDataMaxLength,
+	v16.MeterValuesSampledData: v16.MeterValuesSampledDataMaxLength,
+	v16.StopTxnAlignedData:     v16.StopTxnAlignedDataMaxLength,
+	v16.StopTxnSampledData:     v16.StopTxnSampledDataMaxLength,
+	v16.ConnectorPhaseRotation: v16.ConnectorPhaseRotationMaxLength,
+}
+
+// getConfigurationKey returns the configuration key from the OCPP configuration.
+func getConfigurationKey(key string) (*core.ConfigurationKey, error) {
+	keys, err := ocppManager.GetConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, configurationKey := range keys {
+		if configurationKey.Key == key {
+			return &configurationKey, nil
+		}
+	}
+
+	return nil, configuration.ErrKeyNotFound
+}
+
+// validateListLength checks that the list does not contain more items than allowed by the corresponding MaxLength key.
+func validateListLength(key, value string) error {
+	maxLengthKey, isList := listMaxLengthKeys[configuration.Key(key)]
+	if !isList {
+		return nil
+	}
+
+	maxLengthValue, err := ocppManager.GetConfigurationValue(maxLengthKey.String())
+	if err != nil {
+		return nil
+	}
+
+	maxLength, err := strconv.Atoi(maxLengthValue)
+	if err != nil {
+		return nil
+	}
+
+	items := 0
+	for _, item := range strings.Split(value, ",") {
+		if strings.TrimSpace(item) != "" {
+			items++
+		}
+	}
+
+	if items > maxLength {
+		return fmt.Errorf("%w: %s allows at most %d items", settings.ErrInvalidConfigurationValue, key, maxLength)
+	}
+
+	return nil
+}
+
+// changeConfiguration validates and stores the configuration key and applies the new value.
+func (cp *ChargePoint) changeConfiguration(key, value string) core.ConfigurationStatus {
+	logInfo := cp.logger.WithField("key", key)
+
+	configurationKey, err := getConfigurationKey(key)
+	switch {
+	case errors.Is(err, configuration.ErrKeyNotFound):
+		logInfo.Warn("Configuration key is not supported")
+		return core.ConfigurationStatusNotSupported
+	case err != nil:
+		logInfo.WithError(err).Error("Unable to read the configuration")
+		return core.ConfigurationStatusRejected
+	case configurationKey.Readonly:
+		logInfo.Warn("Configuration key is read-only")
+		return core.ConfigurationStatusRejected
+	}
+
+	err = settings.ValidateOcppKey(key, value)
+	if err == nil {
+		err = validateListLength(key, value)
+	}
+
+	if err != nil {
+		logInfo.WithError(err).Warn("Invalid configuration value")
+		return core.ConfigurationStatusRejected
+	}
+
+	previousValue := configurationKey.Value
+
+	err = ocppManager.UpdateKey(key, value)
+	if err != nil {
+		logInfo.WithError(err).Error("Unable to update the configuration")
+		return core.ConfigurationStatusRejected
+	}
+
+	err = ocppManager.UpdateConfigurationFile()
+	if err != nil {
+		logInfo.WithError(err).Error("Unable to save the configuration")
+		_ = ocppManager.UpdateKey(key, previousValue)
+		return core.ConfigurationStatusRejected
+	}
+
+	if rebootRequiredKeys[configuration.Key(key)] {
+		return core.ConfigurationStatusRebootRequired
+	}
+
+	cp.applyConfiguration(key, value)
+	return core.ConfigurationStatusAccepted
+}
+
+// applyConfiguration applies the keys that are cached by the charge point. Other keys are read when needed.
+func (cp *ChargePoint) applyConfiguration(key, value string) {
+	switch configuration.Key(key) {
+	case v16.HeartbeatInterval:
+		interval, _ := strconv.Atoi(value)
+		_ = cp.scheduler.RemoveByTag("heartbeat")
+		cp.setHeartbeat(interval)
+	case v16.MeterValueSampleInterval:
+		cp.rescheduleSampling(value)
+	case v16.LocalAuthListMaxLength:
+		cp.setMaxCachedTags()
+	}
+}
+
+// rescheduleSampling changes the sampling interval of the ongoing transactions.
+func (cp *ChargePoint) rescheduleSampling(interval string) {
+	for _, c := range cp.connectorManager.GetConnectors() {
+		jobTag := connector.SamplingJobTag(c.GetEvseId(), c.GetConnectorId())
+
+		// The connector is not sampling
+		if cp.scheduler.RemoveByTag(jobTag) != nil {
+			continue
+		}
+
+		_, err := cp.scheduler.Every(interval+"s").
+			Tag(jobTag).
+			Do(c.SamplePowerMeter, util.GetTypesToSample(), types.ReadingContextSamplePeriodic)
+		if err != nil {
+			cp.logger.WithError(err).Errorf("Unable to reschedule sampling for connector %d", c.GetConnectorId())
+		}
+	}
+}
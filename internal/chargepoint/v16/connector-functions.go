@@ -2,17 +2,14 @@ package v16
 
 import (
 	"context"
-	"fmt"
 	"github.com/lorenzodonini/ocpp-go/ocpp"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	"github.com/reactivex/rxgo/v2"
-	"github.com/spf13/viper"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	connectorManager "github.com/xBlaz3kx/ChargePi-go/internal/components/connector-manager"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display/i18n"
-	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/indicator"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
-	"github.com/xBlaz3kx/ChargePi-go/internal/models"
 	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
 	"time"
@@ -25,13 +22,18 @@ func (cp *ChargePoint) AddConnectors(connectors []*settingsData.Connector) {
 	}
 
 	cp.logger.Debugf("Adding connectors")
-	err := cp.connectorManager.AddConnectorsFromConfiguration(cp.Settings.ChargePoint.Info.MaxChargingTime, connectors)
+	err := cp.connectorManager.AddConnectorsFromConfiguration(cp.getSettings().ChargePoint.Info.MaxChargingTime, connectors)
 	if err != nil {
 		cp.logger.WithError(err).Fatalf("Unable to add connectors from configuration")
 	}
 
-	// Add an indicator with the length of valid connectors
-	cp.Indicator = indicator.NewIndicator(len(cp.connectorManager.GetConnectors()))
+	cp.connectorSettings = connectors
+	cp.setupFreeVend()
+
+	// The length of the indicator depends on the number of connectors
+	if cp.indicatorSettings != nil {
+		cp.resetIndicator()
+	}
 }
 
 // restoreState After connecting to the central system, try to restore the previous state of each ConnectorImpl and notify the system about its state.
@@ -39,30 +41,47 @@ func (cp *ChargePoint) AddConnectors(connectors []*settingsData.Connector) {
 func (cp *ChargePoint) restoreState() {
 	cp.logger.Debugf("Restoring connectors' state")
 
+	// Restored first, so the free vend transactions stopped below are reported
+	cp.restoreFreeVendTransactions()
+
 	for _, c := range cp.connectorManager.GetConnectors() {
-		var (
-			cacheKey = fmt.Sprintf("connectorEvse%dId%d", c.GetEvseId(), c.GetConnectorId())
-			conn     settingsData.Connector
-		)
-
-		// Fetch the viper configuration
-		connectorCfg, isFound := settings.ConnectorSettings.Load(cacheKey)
-		if !isFound {
+		connectorSettings := cp.findConnectorSettings(c.GetEvseId(), c.GetConnectorId())
+		if connectorSettings == nil {
 			continue
 		}
-		cfg := connectorCfg.(*viper.Viper)
 
-		// Unmarshall
-		err := cfg.Unmarshal(&conn)
+		// Fetch the persisted status and session
+		state, err := settings.GetConnectorState(c.GetEvseId(), c.GetConnectorId())
 		if err != nil {
 			continue
 		}
 
+		conn := *connectorSettings
+		conn.Status = string(state.Status)
+		conn.Session = state.Session
+
 		err = cp.connectorManager.RestoreConnectorStatus(&conn)
 		switch err {
 		case nil:
-			_, err = cp.scheduler.Every(c.GetMaxChargingTime()-0).Minutes().LimitRunsTo(1).
-				Tag(fmt.Sprintf("connector%dTimer", c.GetConnectorId())).Do(cp.stopChargingConnector, c, core.ReasonLocal)
+			started, parseErr := time.Parse(time.RFC3339, state.Session.Started)
+			if parseErr != nil {
+				started = time.Now()
+			}
+
+			evaluator, policyErr := cp.restoreSessionEvaluator(c, state.Session, started)
+			if policyErr != nil {
+				cp.logger.WithError(policyErr).Errorf("Invalid session policy, stopping the transaction")
+
+				err = cp.stopChargingConnector(c, core.ReasonOther)
+				if err != nil {
+					cp.logger.Debugf("Stopping the charging returned %v", err)
+				}
+
+				break
+			}
+
+			cp.startSessionPolicy(c, evaluator)
+			cp.restoreCostTracking(c, state.Session, started)
 			break
 		default:
 			// Attempt to stop charging
@@ -75,6 +94,17 @@ func (cp *ChargePoint) restoreState() {
 	}
 }
 
+// findConnectorSettings returns the settings of the connector or nil if the connector is not configured.
+func (cp *ChargePoint) findConnectorSettings(evseId, connectorId int) *settingsData.Connector {
+	for _, c := range cp.connectorSettings {
+		if c.EvseId == evseId && c.ConnectorId == connectorId {
+			return c
+		}
+	}
+
+	return nil
+}
+
 // notifyConnectorStatus Notify the central system about the connector's status and updates the LED indicator.
 func (cp *ChargePoint) notifyConnectorStatus(connector connector.Connector) {
 	if util.IsNilInterfaceOrPointer(connector) {
@@ -97,6 +127,34 @@ func (cp *ChargePoint) notifyConnectorStatus(connector connector.Connector) {
 	util.HandleRequestErr(err, "Cannot send status of connector")
 }
 
+// notifyChargePointStatus notifies the central system about the status of the charge point, represented by connector 0.
+func (cp *ChargePoint) notifyChargePointStatus(availability core.AvailabilityType) {
+	// Not connected to the central system yet
+	if util.IsNilInterfaceOrPointer(cp.chargePoint) {
+		return
+	}
+
+	status := core.ChargePointStatusAvailable
+	if availability == core.AvailabilityTypeInoperative {
+		status = core.ChargePointStatusUnavailable
+	}
+
+	request := core.NewStatusNotificationRequest(0, core.NoError, status)
+	request.Timestamp = types.NewDateTime(time.Now())
+
+	callback := func(confirmation ocpp.Response, protoError error) {
+		cp.logger.Infof("Notified status of the charge point: %s", status)
+	}
+
+	err := util.SendRequest(cp.chargePoint, request, callback)
+	util.HandleRequestErr(err, "Cannot send status of the charge point")
+}
+
+// isEvseInUse checks if another connector of the connector's EVSE is in use, as only one connector of an EVSE can be used at a time.
+func (cp *ChargePoint) isEvseInUse(c connector.Connector) bool {
+	return connectorManager.IsEvseInUse(cp.connectorManager, c.GetEvseId(), c.GetConnectorId())
+}
+
 // ListenForConnectorStatusChange listen for change in connector and notify the central system about the state
 func (cp *ChargePoint) ListenForConnectorStatusChange(ctx context.Context, ch <-chan rxgo.Item) {
 	cp.logger.Debug("Starting to listen for connector status change")
@@ -117,16 +175,22 @@ func (cp *ChargePoint) ListenForConnectorStatusChange(ctx context.Context, ch <-
 					cp.displayLEDStatus(connectorIndex, status)
 					go cp.displayConnectorStatus(c.GetConnectorId(), status)
 					cp.notifyConnectorStatus(c)
+
+					for _, listener := range cp.listeners {
+						listener.OnConnectorStatusChange(c)
+					}
 				}
 				break
-			case meterVal := <-cp.meterValuesChannel:
-				meterValues, canCast := meterVal.V.(models.MeterValueNotification)
-				if canCast {
-					values := core.NewMeterValuesRequest(meterValues.ConnectorId, meterValues.MeterValues)
-					err := util.SendRequest(cp.chargePoint, values, func(confirmation ocpp.Response, protoError error) {})
-					if err != nil {
-						cp.logger.WithError(err).Errorf("Cannot send meter values")
-					}
+			case meterValues := <-cp.meterValuesChannel:
+				values := core.NewMeterValuesRequest(meterValues.ConnectorId, meterValues.MeterValues)
+				values.TransactionId = meterValues.TransactionId
+				err := util.SendRequest(cp.chargePoint, values, func(confirmation ocpp.Response, protoError error) {})
+				if err != nil {
+					cp.logger.WithError(err).Errorf("Cannot send meter values")
+				}
+
+				for _, listener := range cp.listeners {
+					listener.OnMeterValues(meterValues)
 				}
 				break
 			case <-ctx.Done():
@@ -139,7 +203,7 @@ func (cp *ChargePoint) ListenForConnectorStatusChange(ctx context.Context, ch <-
 
 func (cp *ChargePoint) displayConnectorStatus(connectorId int, status core.ChargePointStatus) {
 	var (
-		language = cp.Settings.ChargePoint.Hardware.Lcd.Language
+		language = cp.getConnectorLanguage(connectorId)
 		message  []string
 		err      error
 	)
@@ -148,15 +212,27 @@ func (cp *ChargePoint) displayConnectorStatus(connectorId int, status core.Charg
 	case core.ChargePointStatusAvailable:
 		message, err = i18n.TranslateConnectorAvailableMessage(language, connectorId)
 		break
+	case core.ChargePointStatusPreparing:
+		message, err = i18n.TranslateConnectorPreparingMessage(language, connectorId)
+		break
 	case core.ChargePointStatusFinishing:
 		message, err = i18n.TranslateConnectorFinishingMessage(language, connectorId)
 		break
 	case core.ChargePointStatusCharging:
 		message, err = i18n.TranslateConnectorChargingMessage(language, connectorId)
 		break
+	case core.ChargePointStatusSuspendedEV, core.ChargePointStatusSuspendedEVSE:
+		message, err = i18n.TranslateConnectorSuspendedMessage(language, connectorId)
+		break
+	case core.ChargePointStatusReserved:
+		message, err = i18n.TranslateConnectorReservedMessage(language, connectorId)
+		break
 	case core.ChargePointStatusFaulted:
 		message, err = i18n.TranslateConnectorFaultedMessage(language, connectorId)
 		break
+	case core.ChargePointStatusUnavailable:
+		message, err = i18n.TranslateConnectorUnavailableMessage(language, connectorId)
+		break
 	default:
 		return
 	}
@@ -167,4 +243,8 @@ func (cp *ChargePoint) displayConnectorStatus(connectorId int, status core.Charg
 	}
 
 	cp.sendToLCD(message...)
+
+	if status == core.ChargePointStatusAvailable {
+		cp.displayPaymentCode(connectorId)
+	}
 }
@@ -158,6 +158,16 @@ var (
 				Readonly: false,
 				Value:    "20",
 			},
+			{
+				Key:      "MeterValuesSampledDataMaxLength",
+				Readonly: true,
+				Value:    "3",
+			},
+			{
+				Key:      "WebSocketPingInterval",
+				Readonly: false,
+				Value:    "54",
+			},
 		},
 	}
 )
@@ -6,42 +6,37 @@ import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	log "github.com/sirupsen/logrus"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/pkg/security"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
 	ocppManager "github.com/xBlaz3kx/ocppManager-go"
 	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
-	"os/exec"
+	"time"
 )
 
 func (cp *ChargePoint) OnChangeAvailability(request *core.ChangeAvailabilityRequest) (confirmation *core.ChangeAvailabilityConfirmation, err error) {
 	var response = core.AvailabilityStatusRejected
 
+	cp.logger.Infof("Received request %s for connector %d: %s", request.GetFeatureName(), request.ConnectorId, request.Type)
+
 	if request.ConnectorId == 0 {
-		// todo check if there are ongoing transactions
-		cp.availability = request.Type
-		response = core.AvailabilityStatusAccepted
+		response = cp.changeAvailability(request.Type)
 	} else {
-		// todo
+		response = cp.changeConnectorAvailability(request.ConnectorId, request.Type)
 	}
 
 	return core.NewChangeAvailabilityConfirmation(response), nil
 }
 
 func (cp *ChargePoint) OnChangeConfiguration(request *core.ChangeConfigurationRequest) (confirmation *core.ChangeConfigurationConfirmation, err error) {
-	var response = core.ConfigurationStatusRejected
-
 	cp.logger.Infof("Received request %s", request.GetFeatureName())
 
-	err = ocppManager.UpdateKey(request.Key, request.Value)
-	if err == nil {
-		response = core.ConfigurationStatusAccepted
-	}
-
-	err = ocppManager.UpdateConfigurationFile()
-	if err != nil {
-		response = core.ConfigurationStatusRejected
+	// The security keys are validated and applied by the security extension
+	switch request.Key {
+	case security.SecurityProfileKey.String(), security.AuthorizationKeyKey.String():
+		return core.NewChangeConfigurationConfirmation(cp.changeSecurityConfiguration(request.Key, request.Value)), nil
 	}
 
-	return core.NewChangeConfigurationConfirmation(response), nil
+	return core.NewChangeConfigurationConfirmation(cp.changeConfiguration(request.Key, request.Value)), nil
 }
 
 func (cp *ChargePoint) OnClearCache(request *core.ClearCacheRequest) (confirmation *core.ClearCacheConfirmation, err error) {
@@ -66,10 +61,16 @@ func (cp *ChargePoint) OnClearCache(request *core.ClearCacheRequest) (confirmati
 }
 
 func (cp *ChargePoint) OnDataTransfer(request *core.DataTransferRequest) (confirmation *core.DataTransferConfirmation, err error) {
-	cp.logger.Infof("Received request %s", request.GetFeatureName())
-	var response = core.DataTransferStatusRejected
+	cp.logger.WithFields(log.Fields{
+		"vendorId":  request.VendorId,
+		"messageId": request.MessageId,
+	}).Infof("Received request %s", request.GetFeatureName())
+
+	if cp.dataTransfer == nil {
+		return core.NewDataTransferConfirmation(core.DataTransferStatusUnknownVendorId), nil
+	}
 
-	return core.NewDataTransferConfirmation(response), nil
+	return cp.dataTransfer.Handle(request), nil
 }
 
 func (cp *ChargePoint) OnGetConfiguration(request *core.GetConfigurationRequest) (confirmation *core.GetConfigurationConfirmation, err error) {
@@ -90,7 +91,7 @@ func (cp *ChargePoint) OnGetConfiguration(request *core.GetConfigurationRequest)
 
 	// Get all configuration variables
 	if request.Key == nil || len(request.Key) == 0 {
-		response.ConfigurationKey = configArray
+		response.ConfigurationKey = hideAuthorizationKey(configArray)
 		response.UnknownKey = unknownKeys
 		return response, nil
 	}
@@ -115,34 +116,25 @@ func (cp *ChargePoint) OnGetConfiguration(request *core.GetConfigurationRequest)
 		}
 	}
 
-	response.ConfigurationKey = configArray2
+	response.ConfigurationKey = hideAuthorizationKey(configArray2)
 	response.UnknownKey = unknownKeys
 	return response, nil
 }
 
 func (cp *ChargePoint) OnReset(request *core.ResetRequest) (confirmation *core.ResetConfirmation, err error) {
-	cp.logger.Infof("Received request %s", request.GetFeatureName())
-	var response = core.ResetStatusRejected
-
-	switch request.Type {
-	case core.ResetTypeHard:
-		_, err = cp.scheduler.Every(3).Seconds().LimitRunsTo(1).Do(cp.CleanUp, core.ReasonHardReset)
-		_, err = cp.scheduler.Every(5).Seconds().LimitRunsTo(1).Do(exec.Command, "sudo reboot")
-		if err == nil {
-			response = core.ResetStatusAccepted
-		}
-		break
-	case core.ResetTypeSoft:
-		_, err = cp.scheduler.Every(3).Seconds().LimitRunsTo(1).Do(cp.CleanUp, core.ReasonSoftReset)
-		// todo restart ChargePi client only
-		_, err = cp.scheduler.Every(5).Seconds().LimitRunsTo(1).Do(exec.Command, "sudo reboot")
-		if err == nil {
-			response = core.ResetStatusAccepted
-		}
-		break
+	cp.logger.Infof("Received request %s: %s", request.GetFeatureName(), request.Type)
+
+	if !cp.canReset(request.Type) {
+		return core.NewResetConfirmation(core.ResetStatusRejected), nil
 	}
 
-	return core.NewResetConfirmation(response), nil
+	cp.sendSecurityEvent(SecurityEventResetOrReboot, string(request.Type))
+
+	time.AfterFunc(resetDelay, func() {
+		cp.reset(request.Type)
+	})
+
+	return core.NewResetConfirmation(core.ResetStatusAccepted), nil
 }
 
 func (cp *ChargePoint) OnUnlockConnector(request *core.UnlockConnectorRequest) (confirmation *core.UnlockConnectorConfirmation, err error) {
@@ -150,7 +142,7 @@ func (cp *ChargePoint) OnUnlockConnector(request *core.UnlockConnectorRequest) (
 
 	var (
 		response = core.UnlockStatusNotSupported
-		conn     = cp.connectorManager.FindConnector(1, request.ConnectorId)
+		conn     = cp.connectorManager.FindConnectorById(request.ConnectorId)
 	)
 
 	if util.IsNilInterfaceOrPointer(conn) {
@@ -173,7 +165,7 @@ func (cp *ChargePoint) OnRemoteStopTransaction(request *core.RemoteStopTransacti
 	var (
 		response      = types.RemoteStartStopStatusRejected
 		transactionId = fmt.Sprintf("%d", request.TransactionId)
-		conn          = cp.connectorManager.FindConnectorWithTransactionId(transactionId)
+		conn          = cp.findConnectorWithTransactionId(transactionId)
 	)
 
 	if !util.IsNilInterfaceOrPointer(conn) && conn.IsCharging() {
@@ -201,12 +193,12 @@ func (cp *ChargePoint) OnRemoteStartTransaction(request *core.RemoteStartTransac
 	logInfo.Infof("Received request %s", request.GetFeatureName())
 
 	if request.ConnectorId != nil {
-		conn = cp.connectorManager.FindConnector(1, *request.ConnectorId)
-	} else {
+		conn = cp.connectorManager.FindConnectorById(*request.ConnectorId)
+	} else if conn = cp.findReservedConnector(request.IdTag); util.IsNilInterfaceOrPointer(conn) {
 		conn = cp.connectorManager.FindAvailableConnector()
 	}
 
-	if !util.IsNilInterfaceOrPointer(conn) && conn.IsAvailable() {
+	if !util.IsNilInterfaceOrPointer(conn) && cp.isConnectorOperative(conn) && cp.canStartTransaction(conn, request.IdTag) && !cp.isEvseInUse(conn) {
 		// Delay the charging by 3 seconds
 		response = types.RemoteStartStopStatusAccepted
 		_, schedulerErr := cp.scheduler.Every(3).Seconds().LimitRunsTo(1).Do(cp.startChargingConnector, conn, request.IdTag)
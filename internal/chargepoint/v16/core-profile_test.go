@@ -1,16 +1,21 @@
 package v16
 
 import (
+	"github.com/go-co-op/gocron"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	chargePointConnector "github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	reservationManager "github.com/xBlaz3kx/ChargePi-go/internal/components/reservation-manager"
+	"github.com/xBlaz3kx/ChargePi-go/internal/pkg/security"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
 	"github.com/xBlaz3kx/ChargePi-go/test"
 	ocppManager "github.com/xBlaz3kx/ocppManager-go"
 	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
 	"testing"
+	"time"
 )
 
 type coreTestSuite struct {
@@ -20,17 +25,25 @@ type coreTestSuite struct {
 
 func (s *coreTestSuite) SetupTest() {
 	s.cp = &ChargePoint{
-		chargePoint: nil,
-		scheduler:   scheduler.GetScheduler(),
-		logger:      log.StandardLogger(),
+		chargePoint:  nil,
+		availability: core.AvailabilityTypeOperative,
+		scheduler:    scheduler.GetScheduler(),
+		logger:       log.StandardLogger(),
+		reservations: reservationManager.NewManager(nil, nil),
 	}
 }
 
 func (s *coreTestSuite) TestChangeAvailability() {
+	connectorManager := new(test.ManagerMock)
+	connectorManager.On("GetConnectors").Return([]chargePointConnector.Connector{})
+	s.cp.connectorManager = connectorManager
+
 	availability, err := s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(0, core.AvailabilityTypeOperative))
 	s.Assert().NoError(err)
 	s.Assert().EqualValues(core.AvailabilityStatusAccepted, availability.Status)
 
+	// Unknown connector
+	connectorManager.On("FindConnectorById", 1).Return(nil).Once()
 	availability, err = s.cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(1, core.AvailabilityTypeOperative))
 	s.Assert().NoError(err)
 	s.Assert().EqualValues(core.AvailabilityStatusRejected, availability.Status)
@@ -42,15 +55,78 @@ func (s *coreTestSuite) TestOnChangeConfiguration() {
 	s.Assert().NoError(err)
 	s.Assert().EqualValues(core.ConfigurationStatusAccepted, resp.Status)
 
-	// Invalid key
+	// Unknown key
 	resp, err = s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest("invalidKey", ""))
 	s.Assert().NoError(err)
-	s.Assert().EqualValues(core.ConfigurationStatusRejected, resp.Status)
+	s.Assert().EqualValues(core.ConfigurationStatusNotSupported, resp.Status)
 
 	// Readonly key
 	resp, err = s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(v16.SupportedFeatureProfiles.String(), ""))
 	s.Assert().NoError(err)
 	s.Assert().EqualValues(core.ConfigurationStatusRejected, resp.Status)
+
+	// Invalid values
+	resp, err = s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(v16.AuthorizationCacheEnabled.String(), "yes"))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusRejected, resp.Status)
+
+	resp, err = s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(v16.HeartbeatInterval.String(), "-5"))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusRejected, resp.Status)
+
+	resp, err = s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(v16.MeterValuesSampledData.String(), "Energy.Active.Import.Register,Watts"))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusRejected, resp.Status)
+
+	// Too many measurands
+	resp, err = s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(v16.MeterValuesSampledData.String(), "Energy.Active.Import.Register,Power.Active.Import,Voltage,Current.Import"))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusRejected, resp.Status)
+
+	resp, err = s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(v16.MeterValuesSampledData.String(), "Energy.Active.Import.Register,Voltage"))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusAccepted, resp.Status)
+
+	// The key is applied after reconnecting
+	resp, err = s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(v16.WebSocketPingInterval.String(), "30"))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusRebootRequired, resp.Status)
+
+	value, err := ocppManager.GetConfigurationValue(v16.WebSocketPingInterval.String())
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("30", value)
+}
+
+func (s *coreTestSuite) TestOnChangeConfigurationApply() {
+	connectorMock := new(test.ConnectorMock)
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(1)
+
+	connectorManager := new(test.ManagerMock)
+	connectorManager.On("GetConnectors").Return([]chargePointConnector.Connector{connectorMock})
+	s.cp.connectorManager = connectorManager
+	s.cp.scheduler = gocron.NewScheduler(time.UTC)
+
+	samplingTag := chargePointConnector.SamplingJobTag(1, 1)
+	_, err := s.cp.scheduler.Every("10s").Tag(samplingTag).Do(func() {})
+	s.Require().NoError(err)
+
+	// The heartbeat is rescheduled
+	resp, err := s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(v16.HeartbeatInterval.String(), "120"))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusAccepted, resp.Status)
+
+	// The sampling of the ongoing transaction is rescheduled
+	resp, err = s.cp.OnChangeConfiguration(core.NewChangeConfigurationRequest(v16.MeterValueSampleInterval.String(), "30"))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ConfigurationStatusAccepted, resp.Status)
+
+	var tags []string
+	for _, job := range s.cp.scheduler.Jobs() {
+		tags = append(tags, job.Tags()...)
+	}
+
+	s.Assert().ElementsMatch([]string{"heartbeat", samplingTag}, tags)
 }
 
 func (s *coreTestSuite) TestOnClearCache() {}
@@ -58,7 +134,7 @@ func (s *coreTestSuite) TestOnClearCache() {}
 func (s *coreTestSuite) TestOnDataTransfer() {
 	resp, err := s.cp.OnDataTransfer(core.NewDataTransferRequest(""))
 	s.Assert().NoError(err)
-	s.Assert().EqualValues(core.DataTransferStatusRejected, resp.Status)
+	s.Assert().EqualValues(core.DataTransferStatusUnknownVendorId, resp.Status)
 }
 
 func (s *coreTestSuite) TestGetConfiguration() {
@@ -80,7 +156,38 @@ func (s *coreTestSuite) TestGetConfiguration() {
 	s.Assert().Len(resp.ConfigurationKey, 0)
 }
 
-func (s *coreTestSuite) TestOnReset() {}
+func (s *coreTestSuite) TestOnReset() {
+	// No hooks are configured
+	resp, err := s.cp.OnReset(core.NewResetRequest(core.ResetTypeHard))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ResetStatusRejected, resp.Status)
+
+	resp, err = s.cp.OnReset(core.NewResetRequest(core.ResetTypeSoft))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ResetStatusRejected, resp.Status)
+
+	s.cp.rebootHook = func() error { return nil }
+	s.cp.softResetHook = func() {}
+	s.Assert().True(s.cp.canReset(core.ResetTypeHard))
+	s.Assert().True(s.cp.canReset(core.ResetTypeSoft))
+	s.Assert().False(s.cp.canReset("Invalid"))
+
+	// The firmware is being installed
+	s.cp.firmwareStatus = security.FirmwareStatusInstalling
+	s.cp.cancelFirmwareUpdate = func() {}
+
+	resp, err = s.cp.OnReset(core.NewResetRequest(core.ResetTypeHard))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ResetStatusRejected, resp.Status)
+
+	resp, err = s.cp.OnReset(core.NewResetRequest(core.ResetTypeSoft))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.ResetStatusRejected, resp.Status)
+
+	// The firmware was installed
+	s.cp.firmwareStatus = security.FirmwareStatusInstalled
+	s.Assert().True(s.cp.canReset(core.ResetTypeSoft))
+}
 
 func (s *coreTestSuite) TestOnUnlockConnector() {}
 
@@ -129,12 +236,19 @@ func (s *coreTestSuite) TestOnRemoteStartTransaction() {
 	var (
 		connectorManager       = new(test.ManagerMock)
 		connector              = new(test.ConnectorMock)
+		otherConnector         = new(test.ConnectorMock)
 		connectorId            = 1
 		nonExistingConnectorId = 14
 	)
 
 	connector.On("IsAvailable").Return(true).Twice()
+	connector.On("IsReserved").Return(false)
+	connector.On("GetEvseId").Return(1)
+	connector.On("GetConnectorId").Return(connectorId)
+	otherConnector.On("GetConnectorId").Return(2)
+	otherConnector.On("GetStatus").Return(string(core.ChargePointStatusAvailable), string(core.NoError)).Twice()
 	connectorManager.On("FindAvailableConnector").Return(connector).Once()
+	connectorManager.On("GetEvseConnectors", 1).Return([]chargePointConnector.Connector{connector, otherConnector})
 
 	s.cp.connectorManager = connectorManager
 
@@ -147,7 +261,7 @@ func (s *coreTestSuite) TestOnRemoteStartTransaction() {
 	s.cp.scheduler.Clear()
 
 	// Start charging a specific connector
-	connectorManager.On("FindConnector", 1, connectorId).Return(connector).Once()
+	connectorManager.On("FindConnectorById", connectorId).Return(connector).Once()
 	req = core.NewRemoteStartTransactionRequest(tagId)
 	req.ConnectorId = &connectorId
 	transaction, err = s.cp.OnRemoteStartTransaction(req)
@@ -158,7 +272,7 @@ func (s *coreTestSuite) TestOnRemoteStartTransaction() {
 	s.cp.scheduler.Clear()
 
 	// No such connector exists
-	connectorManager.On("FindConnector", 1, nonExistingConnectorId).Return(nil).Once()
+	connectorManager.On("FindConnectorById", nonExistingConnectorId).Return(nil).Once()
 	req = core.NewRemoteStartTransactionRequest(tagId)
 	req.ConnectorId = &nonExistingConnectorId
 	transaction, err = s.cp.OnRemoteStartTransaction(req)
@@ -170,7 +284,28 @@ func (s *coreTestSuite) TestOnRemoteStartTransaction() {
 
 	// Connector not available
 	connector.On("IsAvailable").Return(false).Once()
-	connectorManager.On("FindConnector", 1, connectorId).Return(nil).Once()
+	connectorManager.On("FindConnectorById", connectorId).Return(nil).Once()
+	req = core.NewRemoteStartTransactionRequest(tagId)
+	req.ConnectorId = &connectorId
+	transaction, err = s.cp.OnRemoteStartTransaction(req)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(types.RemoteStartStopStatusRejected, transaction.Status)
+	s.Assert().EqualValues(0, s.cp.scheduler.Len())
+
+	// Another connector of the EVSE is charging
+	connector.On("IsAvailable").Return(true).Once()
+	otherConnector.On("GetStatus").Return(string(core.ChargePointStatusCharging), string(core.NoError)).Once()
+	connectorManager.On("FindConnectorById", connectorId).Return(connector).Once()
+	req = core.NewRemoteStartTransactionRequest(tagId)
+	req.ConnectorId = &connectorId
+	transaction, err = s.cp.OnRemoteStartTransaction(req)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(types.RemoteStartStopStatusRejected, transaction.Status)
+	s.Assert().EqualValues(0, s.cp.scheduler.Len())
+
+	// The connector is inoperative
+	s.cp.inoperativeConnectors = map[int]bool{connectorId: true}
+	connectorManager.On("FindConnectorById", connectorId).Return(connector).Once()
 	req = core.NewRemoteStartTransactionRequest(tagId)
 	req.ConnectorId = &connectorId
 	transaction, err = s.cp.OnRemoteStartTransaction(req)
@@ -40,7 +40,12 @@ func (c *chargePointMock) Authorize(idTag string, props ...func(request *core.Au
 }
 
 func (c *chargePointMock) DataTransfer(vendorId string, props ...func(request *core.DataTransferRequest)) (*core.DataTransferConfirmation, error) {
-	args := c.Called(vendorId)
+	request := core.NewDataTransferRequest(vendorId)
+	for _, prop := range props {
+		prop(request)
+	}
+
+	args := c.Called(request)
 
 	if args.Get(0) != nil {
 		return args.Get(0).(*core.DataTransferConfirmation), args.Error(1)
@@ -0,0 +1,179 @@
+package v16
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	"github.com/xBlaz3kx/ChargePi-go/internal/pkg/security"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"sort"
+	"time"
+)
+
+// VendorId of the built-in DataTransfer messages.
+const VendorId = "ChargePi"
+
+// Built-in DataTransfer messages
+const (
+	MessageGetState        = "GetState"
+	MessageSetDisplayText  = "SetDisplayText"
+	MessageSetPricing      = "SetPricing"
+	MessageSetTariff       = "SetTariff"
+	MessageCostUpdated     = "CostUpdated"
+	MessageSetLanguagePack = "SetLanguagePack"
+	MessageSetTagLanguage  = "SetTagLanguage"
+)
+
+// defaultDisplayTextDuration is used if the SetDisplayText message has no duration.
+const defaultDisplayTextDuration = 10 * time.Second
+
+type (
+	// ConnectorState is the state of a connector in the GetState response.
+	ConnectorState struct {
+		EvseId        int                       `json:"evseId"`
+		ConnectorId   int                       `json:"connectorId"`
+		Status        core.ChargePointStatus    `json:"status"`
+		ErrorCode     core.ChargePointErrorCode `json:"errorCode"`
+		TransactionId string                    `json:"transactionId,omitempty"`
+	}
+
+	// ChargePointState is the response to the GetState message.
+	ChargePointState struct {
+		Availability   core.AvailabilityType   `json:"availability"`
+		FirmwareStatus security.FirmwareStatus `json:"firmwareStatus"`
+		Connectors     []ConnectorState        `json:"connectors"`
+		Pricing        string                  `json:"pricing,omitempty"`
+	}
+
+	// DisplayText is the payload of the SetDisplayText message. Each line is displayed in its own row.
+	DisplayText struct {
+		Lines []string `json:"lines"`
+		// Duration in seconds
+		Duration int `json:"duration,omitempty"`
+	}
+
+	// Pricing is the payload of the SetPricing message, e.g. "0.30 EUR/kWh".
+	Pricing struct {
+		Text string `json:"text"`
+	}
+)
+
+// registerDataTransferHandlers registers the built-in DataTransfer messages.
+func (cp *ChargePoint) registerDataTransferHandlers() {
+	_ = cp.dataTransfer.Register(VendorId, MessageGetState, cp.getState)
+	_ = cp.dataTransfer.Register(VendorId, MessageSetDisplayText, cp.setDisplayText)
+	_ = cp.dataTransfer.Register(VendorId, MessageSetPricing, cp.setPricing)
+	_ = cp.dataTransfer.Register(VendorId, MessageSetTariff, cp.setTariff)
+	_ = cp.dataTransfer.Register(VendorId, MessageCostUpdated, cp.costUpdated)
+	_ = cp.dataTransfer.Register(VendorId, MessageSetLanguagePack, cp.setLanguagePack)
+	_ = cp.dataTransfer.Register(VendorId, MessageSetTagLanguage, cp.setTagLanguageRequest)
+}
+
+// getState returns the availability, the firmware status and the state of all connectors.
+func (cp *ChargePoint) getState(request dataTransfer.Request) (interface{}, error) {
+	state := ChargePointState{
+		Availability:   cp.getAvailability(),
+		FirmwareStatus: security.FirmwareStatusIdle,
+		Connectors:     []ConnectorState{},
+	}
+
+	cp.transferMu.Lock()
+	if cp.cancelFirmwareUpdate != nil && cp.firmwareStatus != "" {
+		state.FirmwareStatus = cp.firmwareStatus
+	}
+	cp.transferMu.Unlock()
+
+	cp.pricingMu.Lock()
+	state.Pricing = cp.pricing
+	cp.pricingMu.Unlock()
+
+	if !util.IsNilInterfaceOrPointer(cp.connectorManager) {
+		for _, c := range cp.connectorManager.GetConnectors() {
+			status, errorCode := c.GetStatus()
+			state.Connectors = append(state.Connectors, ConnectorState{
+				EvseId:        c.GetEvseId(),
+				ConnectorId:   c.GetConnectorId(),
+				Status:        status,
+				ErrorCode:     errorCode,
+				TransactionId: c.GetTransactionId(),
+			})
+		}
+	}
+
+	sort.Slice(state.Connectors, func(i, j int) bool {
+		return state.Connectors[i].ConnectorId < state.Connectors[j].ConnectorId
+	})
+
+	return state, nil
+}
+
+// setDisplayText displays the text from the central system on the LCD.
+func (cp *ChargePoint) setDisplayText(request dataTransfer.Request) (interface{}, error) {
+	var text DisplayText
+
+	err := request.Decode(&text)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(text.Lines) == 0 || text.Duration < 0 {
+		return nil, dataTransfer.ErrInvalidPayload
+	}
+
+	duration := defaultDisplayTextDuration
+	if text.Duration > 0 {
+		duration = time.Duration(text.Duration) * time.Second
+	}
+
+	if !cp.isDisplayEnabled() {
+		return nil, display.ErrDisplayDisabled
+	}
+
+	cp.displayMessage(duration, text.Lines...)
+	return nil, nil
+}
+
+// setPricing stores the pricing from the central system and displays it on the LCD, if it is enabled.
+func (cp *ChargePoint) setPricing(request dataTransfer.Request) (interface{}, error) {
+	var pricing Pricing
+
+	err := request.Decode(&pricing)
+	if err != nil {
+		return nil, err
+	}
+
+	if pricing.Text == "" {
+		return nil, dataTransfer.ErrInvalidPayload
+	}
+
+	cp.pricingMu.Lock()
+	cp.pricing = pricing.Text
+	cp.pricingMu.Unlock()
+
+	cp.sendToLCD(pricing.Text)
+	return nil, nil
+}
+
+// SendDataTransfer sends a DataTransfer request to the central system and returns its response. Data which is not
+// a string is encoded as JSON.
+func (cp *ChargePoint) SendDataTransfer(vendorId, messageId string, data interface{}) (*core.DataTransferConfirmation, error) {
+	if util.IsNilInterfaceOrPointer(cp.chargePoint) {
+		return nil, errors.ErrChargePointNotConnected
+	}
+
+	var encodedData interface{}
+	if data != nil {
+		encoded, err := dataTransfer.Encode(data)
+		if err != nil {
+			return nil, err
+		}
+
+		encodedData = encoded
+	}
+
+	return cp.chargePoint.DataTransfer(vendorId, func(request *core.DataTransferRequest) {
+		request.MessageId = messageId
+		request.Data = encodedData
+	})
+}
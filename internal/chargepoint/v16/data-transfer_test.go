@@ -0,0 +1,202 @@
+package v16
+
+import (
+	"encoding/json"
+	"github.com/go-co-op/gocron"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display/i18n"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	"testing"
+	"time"
+)
+
+type dataTransferTestSuite struct {
+	suite.Suite
+	cp            *ChargePoint
+	managerMock   *test.ManagerMock
+	connectorMock *test.ConnectorMock
+}
+
+func newDataTransferRequest(vendorId, messageId string, data interface{}) *core.DataTransferRequest {
+	request := core.NewDataTransferRequest(vendorId)
+	request.MessageId = messageId
+	request.Data = data
+	return request
+}
+
+func (s *dataTransferTestSuite) SetupTest() {
+	s.managerMock = new(test.ManagerMock)
+	s.managerMock.On("SetNotificationChannel", mock.Anything).Return()
+	s.managerMock.On("SetMeterValuesChannel", mock.Anything).Return()
+
+	s.connectorMock = new(test.ConnectorMock)
+	s.connectorMock.On("GetEvseId").Return(1)
+	s.connectorMock.On("GetConnectorId").Return(1)
+	s.connectorMock.On("GetStatus").Return(string(core.ChargePointStatusCharging), string(core.NoError))
+	s.connectorMock.On("GetTransactionId").Return("1234")
+	s.managerMock.On("GetConnectors").Return([]connector.Connector{s.connectorMock})
+
+	s.cp = NewChargePoint(s.managerMock, gocron.NewScheduler(time.UTC), nil,
+		WithLogger(log.StandardLogger()),
+		WithDataTransferHandler("exampleVendor", dataTransfer.AnyMessage, func(request dataTransfer.Request) (interface{}, error) {
+			return "exampleResponse", nil
+		}),
+	)
+	s.cp.Settings = &settings.Settings{}
+}
+
+func (s *dataTransferTestSuite) TestUnknownVendor() {
+	response, err := s.cp.OnDataTransfer(newDataTransferRequest("unknownVendor", "", nil))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusUnknownVendorId, response.Status)
+
+	response, err = s.cp.OnDataTransfer(newDataTransferRequest(VendorId, "unknownMessage", nil))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusUnknownMessageId, response.Status)
+}
+
+func (s *dataTransferTestSuite) TestCustomHandler() {
+	response, err := s.cp.OnDataTransfer(newDataTransferRequest("exampleVendor", "exampleMessage", nil))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, response.Status)
+	s.Assert().EqualValues("exampleResponse", response.Data)
+}
+
+func (s *dataTransferTestSuite) TestGetState() {
+	response, err := s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageGetState, nil))
+	s.Require().NoError(err)
+	s.Require().EqualValues(core.DataTransferStatusAccepted, response.Status)
+
+	var state ChargePointState
+	err = json.Unmarshal([]byte(response.Data.(string)), &state)
+	s.Require().NoError(err)
+
+	s.Assert().EqualValues(core.AvailabilityTypeInoperative, state.Availability)
+	s.Assert().EqualValues("Idle", state.FirmwareStatus)
+	s.Assert().EqualValues([]ConnectorState{{
+		EvseId:        1,
+		ConnectorId:   1,
+		Status:        core.ChargePointStatusCharging,
+		ErrorCode:     core.NoError,
+		TransactionId: "1234",
+	}}, state.Connectors)
+}
+
+func (s *dataTransferTestSuite) TestSetDisplayText() {
+	var (
+		lcdChannel  = make(chan display.LCDMessage, 1)
+		displayMock = new(test.DisplayMock)
+	)
+
+	// The display is disabled
+	response, err := s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageSetDisplayText, `{"lines":["Hello"]}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusRejected, response.Status)
+
+	displayMock.On("GetLcdChannel").Return(lcdChannel)
+	s.cp.LCD = displayMock
+	s.cp.Settings.ChargePoint.Hardware.Lcd.IsEnabled = true
+
+	response, err = s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageSetDisplayText, `{"lines":["Hello","World"],"duration":30}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, response.Status)
+	s.Assert().EqualValues(display.NewMessage(30*time.Second, []string{"Hello", "World"}), <-lcdChannel)
+
+	// No lines
+	response, err = s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageSetDisplayText, `{"lines":[]}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusRejected, response.Status)
+}
+
+func (s *dataTransferTestSuite) TestSetPricing() {
+	response, err := s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageSetPricing, `{"text":"0.30 EUR/kWh"}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, response.Status)
+
+	state, err := s.cp.getState(dataTransfer.Request{})
+	s.Require().NoError(err)
+	s.Assert().EqualValues("0.30 EUR/kWh", state.(ChargePointState).Pricing)
+
+	response, err = s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageSetPricing, `{}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusRejected, response.Status)
+}
+
+func (s *dataTransferTestSuite) TestSetLanguagePack() {
+	pack := `{"language":"hr","messages":{"ConnectorTemplate":"Prikljucak {{.Id}}","ConnectorAvailable":"je slobodan.",` +
+		`"Minutes":{"one":"{{.Count}} minuta","few":"{{.Count}} minute","other":"{{.Count}} minuta"}}}`
+
+	response, err := s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageSetLanguagePack, pack))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, response.Status)
+
+	message, err := i18n.TranslateConnectorAvailableMessage("hr", 1)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues([]string{"Prikljucak 1", "je slobodan."}, message)
+
+	summary, err := i18n.TranslateSessionSummaryMessage("hr", 1, "EUR", 1000, 180)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("3 minute", summary[3])
+
+	// No messages
+	response, err = s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageSetLanguagePack, `{"language":"hr"}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusRejected, response.Status)
+
+	// Invalid language
+	response, err = s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageSetLanguagePack, `{"language":"not a language","messages":{"ConnectorAvailable":"x"}}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusRejected, response.Status)
+}
+
+func (s *dataTransferTestSuite) TestSetTagLanguage() {
+	s.cp.Settings.ChargePoint.Hardware.Lcd.Language = "en"
+
+	response, err := s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageSetTagLanguage, `{"idTag":"exampleTag","language":"de"}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, response.Status)
+	s.Assert().EqualValues("de", s.cp.getLanguage("exampleTag"))
+	s.Assert().EqualValues("en", s.cp.getLanguage("exampleTag2"))
+
+	// Removes the preference
+	response, err = s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageSetTagLanguage, `{"idTag":"exampleTag"}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, response.Status)
+	s.Assert().EqualValues("en", s.cp.getLanguage("exampleTag"))
+
+	response, err = s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageSetTagLanguage, `{"language":"de"}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusRejected, response.Status)
+
+	response, err = s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageSetTagLanguage, `{"idTag":"exampleTag","language":"not a language"}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusRejected, response.Status)
+}
+
+func (s *dataTransferTestSuite) TestSendDataTransfer() {
+	_, err := s.cp.SendDataTransfer("exampleVendor", "exampleMessage", nil)
+	s.Assert().ErrorIs(err, errors.ErrChargePointNotConnected)
+
+	chargePointMock := new(chargePointMock)
+	expectedRequest := newDataTransferRequest("exampleVendor", "exampleMessage", `{"value":1}`)
+	chargePointMock.On("DataTransfer", expectedRequest).
+		Return(core.NewDataTransferConfirmation(core.DataTransferStatusAccepted), nil)
+	s.cp.chargePoint = chargePointMock
+
+	response, err := s.cp.SendDataTransfer("exampleVendor", "exampleMessage", map[string]int{"value": 1})
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, response.Status)
+	chargePointMock.AssertExpectations(s.T())
+}
+
+func TestDataTransfer(t *testing.T) {
+	suite.Run(t, new(dataTransferTestSuite))
+}
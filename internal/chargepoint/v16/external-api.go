@@ -1,20 +1,127 @@
 package v16
 
 import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/xBlaz3kx/ChargePi-go/internal/api"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
 )
 
-// StartCharging Start charging on the first available Connector. If there is no available Connector, reject the request.
+// StartCharging Start charging on the connector with the connectorId. If the connectorId is 0, start charging on the
+// first available Connector. If there is no available Connector, reject the request.
 func (cp *ChargePoint) StartCharging(tagId string, connectorId int) (*api.StartTransactionResponse, error) {
-	return nil, nil
+	var (
+		response = &api.StartTransactionResponse{}
+		err      error
+	)
+
+	if connectorId == 0 {
+		err = cp.startCharging(tagId)
+	} else {
+		c := cp.connectorManager.FindConnectorById(connectorId)
+		if util.IsNilInterfaceOrPointer(c) {
+			return nil, errors.ErrConnectorNil
+		}
+
+		response.ConnectorId = int32(connectorId)
+		err = cp.startChargingConnector(c, tagId)
+	}
+
+	if err != nil {
+		response.ErrorMessage = err.Error()
+	}
+
+	return response, err
 }
 
-// StopCharging Stop charging a connector
+// StopCharging Stop charging a connector. If the connectorId is 0, stop the connector with the session of the tagId or its group.
 func (cp *ChargePoint) StopCharging(tagId string, connectorId int) (*api.StopTransactionResponse, error) {
-	return nil, nil
+	var (
+		response = &api.StopTransactionResponse{}
+		err      error
+	)
+
+	if connectorId == 0 {
+		c := cp.findSessionConnector(tagId)
+		if util.IsNilInterfaceOrPointer(c) {
+			return nil, errors.ErrNoConnectorWithTag
+		}
+
+		err = cp.stopChargingConnectorByTag(c, tagId, core.ReasonLocal)
+	} else {
+		err = cp.stopChargingConnectorByTag(cp.connectorManager.FindConnectorById(connectorId), tagId, core.ReasonLocal)
+	}
+
+	if err != nil {
+		response.ErrorMessage = err.Error()
+	}
+
+	return response, err
+}
+
+// ChangeAvailability changes the availability of the connector or the charge point, if the connectorId is 0.
+func (cp *ChargePoint) ChangeAvailability(connectorId int, availability core.AvailabilityType) error {
+	response, err := cp.OnChangeAvailability(core.NewChangeAvailabilityRequest(connectorId, availability))
+	if err != nil {
+		return err
+	}
+
+	if response.Status == core.AvailabilityStatusRejected {
+		return errors.ErrAvailabilityChangeRejected
+	}
+
+	return nil
+}
+
+// SetCurrentLimit limits the current per phase in A offered at the connector.
+func (cp *ChargePoint) SetCurrentLimit(connectorId int, limit float64) error {
+	c := cp.connectorManager.FindConnectorById(connectorId)
+	if util.IsNilInterfaceOrPointer(c) {
+		return errors.ErrConnectorNil
+	}
+
+	return c.SetCurrentLimit(limit)
 }
 
 // GetConnectorStatus Notify the central system about the connector's status and updates the LED indicator.
 func (cp *ChargePoint) GetConnectorStatus(evseId, connectorId int) (*api.GetConnectorStatusResponse, error) {
 	return nil, nil
 }
+
+// GetSessionHistory returns the receipts of the finished sessions, the most recent session first.
+func (cp *ChargePoint) GetSessionHistory() ([]session.Receipt, error) {
+	if util.IsNilInterfaceOrPointer(cp.sessionHistory) {
+		return nil, errors.ErrSessionHistoryDisabled
+	}
+
+	return cp.sessionHistory.GetReceipts()
+}
+
+// GetReceipt returns the receipt of the finished transaction.
+func (cp *ChargePoint) GetReceipt(transactionId string) (*session.Receipt, error) {
+	if util.IsNilInterfaceOrPointer(cp.sessionHistory) {
+		return nil, errors.ErrSessionHistoryDisabled
+	}
+
+	return cp.sessionHistory.GetReceipt(transactionId)
+}
+
+// GetTagGroup returns the group (parentIdTag) of the tag and the cached tags of the same group.
+func (cp *ChargePoint) GetTagGroup(tagId string) (*auth.TagGroup, error) {
+	if util.IsNilInterfaceOrPointer(cp.authCache) {
+		return nil, errors.ErrAuthCacheDisabled
+	}
+
+	tagInfo, err := cp.getTagInfo(tagId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.TagGroup{
+		IdTag:       tagId,
+		ParentIdTag: tagInfo.ParentIdTag,
+		Members:     cp.authCache.GetGroupMembers(tagInfo.ParentIdTag),
+	}, nil
+}
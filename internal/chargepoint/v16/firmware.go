@@ -0,0 +1,262 @@
+package v16
+
+import (
+	"context"
+	"crypto/x509"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/certificates"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/firmware"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display/i18n"
+	"github.com/xBlaz3kx/ChargePi-go/internal/pkg/security"
+	"time"
+)
+
+const (
+	SecurityEventInvalidFirmwareSignature          = "InvalidFirmwareSignature"
+	SecurityEventInvalidFirmwareSigningCertificate = "InvalidFirmwareSigningCertificate"
+
+	defaultTransferRetries       = 3
+	defaultTransferRetryInterval = 60
+	// installCheckInterval is the interval for checking if the firmware can be installed.
+	installCheckInterval = time.Second * 10
+)
+
+// getRetries returns the number of retries and the retry interval of a firmware or log transfer.
+func getRetries(retries, retryInterval *int) (int, time.Duration) {
+	var (
+		numberOfRetries = defaultTransferRetries
+		interval        = defaultTransferRetryInterval
+	)
+
+	if retries != nil {
+		numberOfRetries = *retries
+	}
+
+	if retryInterval != nil {
+		interval = *retryInterval
+	}
+
+	return numberOfRetries, time.Duration(interval) * time.Second
+}
+
+func (cp *ChargePoint) OnSignedUpdateFirmware(request *security.SignedUpdateFirmwareRequest) (*security.SignedUpdateFirmwareConfirmation, error) {
+	cp.logger.Infof("Received %s with request id %d", request.GetFeatureName(), request.RequestId)
+
+	if cp.firmwareManager == nil || !cp.firmwareManager.CanInstall() || !firmware.IsLocationSupported(request.Firmware.Location) {
+		return &security.SignedUpdateFirmwareConfirmation{Status: security.UpdateFirmwareStatusRejected}, nil
+	}
+
+	// The signing certificate must be issued by an installed manufacturer root certificate
+	var roots = cp.getManufacturerRoots()
+	signingCertificate, err := firmware.VerifyCertificate(request.Firmware.SigningCertificate, roots)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Rejected the firmware signing certificate")
+		defer cp.sendSecurityEvent(SecurityEventInvalidFirmwareSigningCertificate, err.Error())
+		return &security.SignedUpdateFirmwareConfirmation{Status: security.UpdateFirmwareStatusInvalidCertificate}, nil
+	}
+
+	status := security.UpdateFirmwareStatusAccepted
+
+	cp.transferMu.Lock()
+	if cp.cancelFirmwareUpdate != nil {
+		// Only one update can be in progress
+		cp.cancelFirmwareUpdate()
+		status = security.UpdateFirmwareStatusAcceptedCanceled
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cp.cancelFirmwareUpdate = cancel
+	cp.transferMu.Unlock()
+
+	go cp.updateFirmware(ctx, *request, signingCertificate)
+	return &security.SignedUpdateFirmwareConfirmation{Status: status}, nil
+}
+
+// updateFirmware downloads, verifies and installs the firmware at the requested times, while notifying the central
+// system about the progress.
+func (cp *ChargePoint) updateFirmware(ctx context.Context, request security.SignedUpdateFirmwareRequest, signingCertificate *x509.Certificate) {
+	var (
+		requestId              = request.RequestId
+		retries, retryInterval = getRetries(request.Retries, request.RetryInterval)
+		logInfo                = cp.logger.WithField("requestId", requestId)
+	)
+
+	defer cp.finishFirmwareUpdate(ctx)
+
+	if !cp.waitUntil(ctx, request.Firmware.RetrieveDateTime.Time, requestId, security.FirmwareStatusDownloadScheduled) {
+		return
+	}
+
+	cp.setFirmwareStatus(ctx, requestId, security.FirmwareStatusDownloading)
+	firmwarePath, err := cp.firmwareManager.Download(ctx, request.Firmware.Location, retries, retryInterval)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Unable to download the firmware")
+		cp.setFirmwareStatus(ctx, requestId, security.FirmwareStatusDownloadFailed)
+		return
+	}
+
+	cp.setFirmwareStatus(ctx, requestId, security.FirmwareStatusDownloaded)
+
+	err = firmware.VerifySignature(firmwarePath, signingCertificate, request.Firmware.Signature)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Invalid firmware signature")
+		cp.setFirmwareStatus(ctx, requestId, security.FirmwareStatusInvalidSignature)
+		cp.sendSecurityEvent(SecurityEventInvalidFirmwareSignature, err.Error())
+		return
+	}
+
+	cp.setFirmwareStatus(ctx, requestId, security.FirmwareStatusSignatureVerified)
+
+	if request.Firmware.InstallDateTime != nil &&
+		!cp.waitUntil(ctx, request.Firmware.InstallDateTime.Time, requestId, security.FirmwareStatusInstallScheduled) {
+		return
+	}
+
+	// Ongoing transactions are not interrupted by the installation
+	for cp.hasOngoingTransactions() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(installCheckInterval):
+		}
+	}
+
+	cp.setFirmwareStatus(ctx, requestId, security.FirmwareStatusInstalling)
+	err = cp.firmwareManager.Install(ctx, firmwarePath)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Unable to install the firmware")
+		cp.setFirmwareStatus(ctx, requestId, security.FirmwareStatusInstallationFailed)
+		return
+	}
+
+	cp.setFirmwareStatus(ctx, requestId, security.FirmwareStatusInstalled)
+}
+
+// waitUntil waits until the time, notifying the central system with the scheduled status if the time is in the future.
+// Returns false if the update was canceled while waiting.
+func (cp *ChargePoint) waitUntil(ctx context.Context, at time.Time, requestId int, scheduledStatus security.FirmwareStatus) bool {
+	delay := time.Until(at)
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+
+	cp.setFirmwareStatus(ctx, requestId, scheduledStatus)
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
+
+// hasOngoingTransactions checks if any of the connectors is preparing or charging.
+func (cp *ChargePoint) hasOngoingTransactions() bool {
+	for _, c := range cp.connectorManager.GetConnectors() {
+		if c.IsCharging() || c.IsPreparing() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cp *ChargePoint) finishFirmwareUpdate(ctx context.Context) {
+	cp.transferMu.Lock()
+	// Do not reset a newer update
+	if ctx.Err() == nil {
+		cp.cancelFirmwareUpdate()
+		cp.cancelFirmwareUpdate = nil
+	}
+	cp.transferMu.Unlock()
+
+	cp.refreshIndicator()
+}
+
+// isFirmwareUpdating checks if the firmware is being downloaded or installed.
+func (cp *ChargePoint) isFirmwareUpdating() bool {
+	cp.transferMu.Lock()
+	defer cp.transferMu.Unlock()
+
+	if cp.cancelFirmwareUpdate == nil {
+		return false
+	}
+
+	switch cp.firmwareStatus {
+	case security.FirmwareStatusDownloading,
+		security.FirmwareStatusDownloaded,
+		security.FirmwareStatusSignatureVerified,
+		security.FirmwareStatusInstalling,
+		security.FirmwareStatusInstallRebooting:
+		return true
+	default:
+		return false
+	}
+}
+
+// setFirmwareStatus stores the status of the update and notifies the central system, unless the update was canceled.
+func (cp *ChargePoint) setFirmwareStatus(ctx context.Context, requestId int, status security.FirmwareStatus) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	cp.transferMu.Lock()
+	cp.firmwareStatus = status
+	cp.firmwareRequestId = &requestId
+	cp.transferMu.Unlock()
+
+	cp.refreshIndicator()
+
+	if status == security.FirmwareStatusDownloading || status == security.FirmwareStatusInstalling {
+		go cp.displayTranslation(i18n.TranslateFirmwareUpdateMessage(cp.getDefaultLanguage()))
+	}
+
+	cp.sendFirmwareStatusNotification(status, &requestId)
+}
+
+// sendCurrentFirmwareStatus notifies the central system about the status of the firmware update in progress or
+// Idle, if there is no update in progress.
+func (cp *ChargePoint) sendCurrentFirmwareStatus() {
+	var (
+		status    = security.FirmwareStatusIdle
+		requestId *int
+	)
+
+	cp.transferMu.Lock()
+	if cp.cancelFirmwareUpdate != nil && cp.firmwareStatus != "" {
+		status = cp.firmwareStatus
+		requestId = cp.firmwareRequestId
+	}
+	cp.transferMu.Unlock()
+
+	cp.sendFirmwareStatusNotification(status, requestId)
+}
+
+func (cp *ChargePoint) sendFirmwareStatusNotification(status security.FirmwareStatus, requestId *int) {
+	if cp.securityClient == nil {
+		return
+	}
+
+	logInfo := cp.logger.WithField("status", status)
+	logInfo.Info("Sending firmware status notification")
+
+	err := cp.securityClient.SendRequestAsync(
+		&security.SignedFirmwareStatusNotificationRequest{Status: status, RequestId: requestId},
+		func(response ocpp.Response, err error) {
+			if err != nil {
+				logInfo.WithError(err).Errorf("Central system responded with an error to the firmware status")
+			}
+		})
+	if err != nil {
+		logInfo.WithError(err).Errorf("Unable to send the firmware status")
+	}
+}
+
+// getManufacturerRoots returns the installed manufacturer root certificates, used for verifying the firmware.
+func (cp *ChargePoint) getManufacturerRoots() *x509.CertPool {
+	if cp.certificateManager == nil {
+		return nil
+	}
+
+	return cp.certificateManager.GetCertificatePool(certificates.ManufacturerRootCertificate)
+}
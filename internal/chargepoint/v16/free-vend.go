@@ -0,0 +1,386 @@
+package v16
+
+import (
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFreeVendIdTag = "FreeVend"
+	// freeVendTransactionPrefix marks the local transaction ids of the free vend transactions.
+	freeVendTransactionPrefix = "FreeVend"
+)
+
+// getFreeVendSettings returns the free vend settings of the connector or nil if free vend is disabled.
+func (cp *ChargePoint) getFreeVendSettings(c connector.Connector) *settings.FreeVend {
+	connectorSettings := cp.findConnectorSettings(c.GetEvseId(), c.GetConnectorId())
+	if connectorSettings == nil || !connectorSettings.FreeVend.Enabled {
+		return nil
+	}
+
+	freeVend := connectorSettings.FreeVend
+	if freeVend.IdTag == "" {
+		freeVend.IdTag = defaultFreeVendIdTag
+	}
+
+	return &freeVend
+}
+
+// isFreeVendTag checks if free vend is enabled on the connector and the tag is the free vend tag.
+func (cp *ChargePoint) isFreeVendTag(c connector.Connector, tagId string) bool {
+	freeVend := cp.getFreeVendSettings(c)
+	return freeVend != nil && freeVend.IdTag == tagId
+}
+
+// StartFreeVend starts a free vend transaction on the connector.
+func (cp *ChargePoint) StartFreeVend(connectorId int) error {
+	c := cp.connectorManager.FindConnectorById(connectorId)
+	if util.IsNilInterfaceOrPointer(c) {
+		return errors.ErrConnectorNil
+	}
+
+	freeVend := cp.getFreeVendSettings(c)
+	if freeVend == nil {
+		return errors.ErrFreeVendDisabled
+	}
+
+	return cp.startChargingConnector(c, freeVend.IdTag)
+}
+
+// startFreeVendTransaction starts charging the connector without waiting for the central system, so it works offline.
+// The transaction has a local id until the central system confirms the StartTransaction request.
+func (cp *ChargePoint) startFreeVendTransaction(c connector.Connector, tagId string, reservationId *int) error {
+	var (
+		started       = time.Now()
+		transactionId = fmt.Sprintf("%s%d", freeVendTransactionPrefix, started.UnixNano())
+		logInfo       = cp.logger.WithFields(log.Fields{
+			"evseId":        c.GetEvseId(),
+			"connectorId":   c.GetConnectorId(),
+			"transactionId": transactionId,
+		})
+	)
+
+	evaluator, err := cp.newSessionEvaluator(c, "", started)
+	if err != nil {
+		return err
+	}
+
+	err = c.StartCharging(transactionId, tagId)
+	if err != nil {
+		return err
+	}
+
+	logInfo.Infof("Started a free vend transaction at %s", started)
+
+	transaction := store.FreeVendTransaction{
+		LocalTransactionId: transactionId,
+		ConnectorId:        c.GetConnectorId(),
+		IdTag:              tagId,
+		Started:            started,
+		ReservationId:      reservationId,
+	}
+
+	cp.freeVendMu.Lock()
+	if cp.freeVendTransactions == nil {
+		cp.freeVendTransactions = map[string]*store.FreeVendTransaction{}
+	}
+	cp.freeVendTransactions[transactionId] = &transaction
+	cp.freeVendMu.Unlock()
+
+	cp.persistFreeVendTransaction(transaction)
+
+	if reservationId != nil {
+		_, _ = cp.reservations.RemoveReservation(*reservationId)
+	}
+
+	cp.startSessionPolicy(c, evaluator)
+	cp.startCostTracking(c, tagId, started)
+	cp.sendFreeVendStartTransaction(transaction)
+	return nil
+}
+
+// sendFreeVendStartTransaction reports the start of the free vend transaction to the central system.
+func (cp *ChargePoint) sendFreeVendStartTransaction(transaction store.FreeVendTransaction) {
+	var (
+		logInfo = cp.logger.WithField("transactionId", transaction.LocalTransactionId)
+		request = core.NewStartTransactionRequest(transaction.ConnectorId, transaction.IdTag, 0, types.NewDateTime(transaction.Started))
+	)
+	request.ReservationId = transaction.ReservationId
+
+	callback := func(confirmation ocpp.Response, protoError error) {
+		if protoError != nil {
+			logInfo.WithError(protoError).Errorf("Server responded with error when starting a free vend transaction")
+			return
+		}
+
+		cp.confirmFreeVendTransaction(transaction.LocalTransactionId, confirmation.(*core.StartTransactionConfirmation).TransactionId)
+	}
+
+	// The request is queued while offline, charging continues regardless
+	err := util.SendRequest(cp.chargePoint, request, callback)
+	if err != nil {
+		logInfo.WithError(err).Warn("Cannot report the free vend transaction to the central system")
+	}
+}
+
+// confirmFreeVendTransaction stores the transaction id assigned by the central system and sends the StopTransaction
+// request if the transaction was stopped in the meantime.
+func (cp *ChargePoint) confirmFreeVendTransaction(localTransactionId string, transactionId int) {
+	cp.freeVendMu.Lock()
+	transaction, isFound := cp.freeVendTransactions[localTransactionId]
+	if !isFound {
+		cp.freeVendMu.Unlock()
+		return
+	}
+
+	transaction.TransactionId = &transactionId
+	stopRequest := transaction.StopRequest
+	if stopRequest != nil {
+		delete(cp.freeVendTransactions, localTransactionId)
+	}
+	confirmedTransaction := *transaction
+	cp.freeVendMu.Unlock()
+
+	if stopRequest != nil {
+		cp.deletePersistedFreeVendTransaction(localTransactionId)
+	} else {
+		cp.persistFreeVendTransaction(confirmedTransaction)
+	}
+
+	cp.logger.WithField("transactionId", localTransactionId).Infof("Central system assigned the transaction id %d", transactionId)
+
+	if stopRequest != nil {
+		stopRequest.TransactionId = transactionId
+		cp.sendFreeVendStopTransaction(stopRequest)
+	}
+}
+
+// stopFreeVendTransaction stops charging the connector immediately and reports the transaction to the central system
+// once it has confirmed the start of the transaction.
+func (cp *ChargePoint) stopFreeVendTransaction(c connector.Connector, reason core.Reason) error {
+	if !(c.IsCharging() || c.IsPreparing()) {
+		return errors.ErrConnectorNotCharging
+	}
+
+	var (
+		localTransactionId = c.GetTransactionId()
+		request            = core.NewStopTransactionRequest(
+			int(c.CalculateSessionAvgEnergyConsumption()),
+			types.NewDateTime(time.Now()),
+			0,
+		)
+	)
+	request.Reason = reason
+
+	cp.endTransaction(c, localTransactionId, reason)
+
+	cp.freeVendMu.Lock()
+	transaction, isFound := cp.freeVendTransactions[localTransactionId]
+	switch {
+	case !isFound:
+		cp.freeVendMu.Unlock()
+		cp.logger.WithField("transactionId", localTransactionId).Warn("Free vend transaction was not reported to the central system")
+		return nil
+	case transaction.TransactionId == nil:
+		// Sent when the central system confirms the transaction
+		transaction.StopRequest = request
+		stoppedTransaction := *transaction
+		cp.freeVendMu.Unlock()
+
+		cp.persistFreeVendTransaction(stoppedTransaction)
+		return nil
+	}
+
+	delete(cp.freeVendTransactions, localTransactionId)
+	cp.freeVendMu.Unlock()
+
+	cp.deletePersistedFreeVendTransaction(localTransactionId)
+
+	request.TransactionId = *transaction.TransactionId
+	cp.sendFreeVendStopTransaction(request)
+	return nil
+}
+
+func (cp *ChargePoint) sendFreeVendStopTransaction(request *core.StopTransactionRequest) {
+	logInfo := cp.logger.WithField("transactionId", request.TransactionId)
+
+	err := util.SendRequest(cp.chargePoint, request, func(confirmation ocpp.Response, protoError error) {
+		if protoError != nil {
+			logInfo.WithError(protoError).Errorf("Server responded with error for stopping a free vend transaction")
+		}
+	})
+	if err != nil {
+		logInfo.WithError(err).Errorf("Cannot report the end of the free vend transaction to the central system")
+	}
+}
+
+// persistFreeVendTransaction stores the free vend transaction, so it is reported after a restart.
+func (cp *ChargePoint) persistFreeVendTransaction(transaction store.FreeVendTransaction) {
+	if util.IsNilInterfaceOrPointer(cp.freeVendRepository) {
+		return
+	}
+
+	err := cp.freeVendRepository.SaveFreeVendTransaction(transaction)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Unable to persist the free vend transaction %s", transaction.LocalTransactionId)
+	}
+}
+
+// deletePersistedFreeVendTransaction removes the free vend transaction once it is reported.
+func (cp *ChargePoint) deletePersistedFreeVendTransaction(localTransactionId string) {
+	if util.IsNilInterfaceOrPointer(cp.freeVendRepository) {
+		return
+	}
+
+	err := cp.freeVendRepository.DeleteFreeVendTransaction(localTransactionId)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Unable to remove the free vend transaction %s", localTransactionId)
+	}
+}
+
+// restoreFreeVendTransactions restores the free vend transactions from before the restart. The requests queued while
+// offline are lost at the restart, so the transactions not confirmed by the central system are reported again.
+func (cp *ChargePoint) restoreFreeVendTransactions() {
+	if util.IsNilInterfaceOrPointer(cp.freeVendRepository) {
+		return
+	}
+
+	transactions, err := cp.freeVendRepository.GetFreeVendTransactions()
+	if err != nil {
+		cp.logger.WithError(err).Error("Unable to restore the free vend transactions")
+		return
+	}
+
+	for i := range transactions {
+		transaction := transactions[i]
+
+		cp.freeVendMu.Lock()
+		_, isFound := cp.freeVendTransactions[transaction.LocalTransactionId]
+		if !isFound {
+			if cp.freeVendTransactions == nil {
+				cp.freeVendTransactions = map[string]*store.FreeVendTransaction{}
+			}
+			cp.freeVendTransactions[transaction.LocalTransactionId] = &transaction
+		}
+		cp.freeVendMu.Unlock()
+
+		if isFound || transaction.TransactionId != nil {
+			continue
+		}
+
+		cp.logger.WithField("transactionId", transaction.LocalTransactionId).Info("Reporting the restored free vend transaction")
+		cp.sendFreeVendStartTransaction(transaction)
+	}
+}
+
+// isFreeVendTransaction checks if the transaction id is a local id of a free vend transaction.
+func (cp *ChargePoint) isFreeVendTransaction(transactionId string) bool {
+	return strings.HasPrefix(transactionId, freeVendTransactionPrefix)
+}
+
+// findConnectorWithTransactionId returns the connector with the transaction. Free vend transactions are also found
+// by the transaction id assigned by the central system.
+func (cp *ChargePoint) findConnectorWithTransactionId(transactionId string) connector.Connector {
+	c := cp.connectorManager.FindConnectorWithTransactionId(transactionId)
+	if !util.IsNilInterfaceOrPointer(c) {
+		return c
+	}
+
+	id, err := strconv.Atoi(transactionId)
+	if err != nil {
+		return nil
+	}
+
+	cp.freeVendMu.Lock()
+	defer cp.freeVendMu.Unlock()
+
+	for localTransactionId, transaction := range cp.freeVendTransactions {
+		if transaction.TransactionId != nil && *transaction.TransactionId == id {
+			return cp.connectorManager.FindConnectorWithTransactionId(localTransactionId)
+		}
+	}
+
+	return nil
+}
+
+// setupFreeVend watches the EV detection and button inputs of the free vend connectors.
+func (cp *ChargePoint) setupFreeVend() {
+	cp.closeFreeVendInputs()
+
+	for _, connectorSettings := range cp.connectorSettings {
+		freeVend := connectorSettings.FreeVend
+		if !freeVend.Enabled {
+			continue
+		}
+
+		c := cp.connectorManager.FindConnector(connectorSettings.EvseId, connectorSettings.ConnectorId)
+		if util.IsNilInterfaceOrPointer(c) {
+			continue
+		}
+
+		if input := hardware.NewInput(freeVend.EvDetectPin, freeVend.InverseLogic, func(isActive bool) {
+			cp.onFreeVendEvDetected(c, isActive)
+		}); input != nil {
+			cp.freeVendInputs = append(cp.freeVendInputs, input)
+		}
+
+		if input := hardware.NewInput(freeVend.ButtonPin, freeVend.InverseLogic, func(isActive bool) {
+			cp.onFreeVendButton(c, isActive)
+		}); input != nil {
+			cp.freeVendInputs = append(cp.freeVendInputs, input)
+		}
+	}
+}
+
+func (cp *ChargePoint) closeFreeVendInputs() {
+	for _, input := range cp.freeVendInputs {
+		input.Close()
+	}
+
+	cp.freeVendInputs = nil
+}
+
+// onFreeVendEvDetected starts a free vend transaction when the EV is connected and stops it when the EV is disconnected.
+func (cp *ChargePoint) onFreeVendEvDetected(c connector.Connector, isConnected bool) {
+	var err error
+
+	if isConnected {
+		err = cp.StartFreeVend(c.GetConnectorId())
+	} else if cp.isFreeVendTransaction(c.GetTransactionId()) {
+		err = cp.stopChargingConnector(c, core.ReasonEVDisconnected)
+	}
+
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Free vend failed on connector %d", c.GetConnectorId())
+	}
+}
+
+// onFreeVendButton starts a free vend transaction or stops the transaction on the connector when the button is pressed.
+func (cp *ChargePoint) onFreeVendButton(c connector.Connector, isPressed bool) {
+	if !isPressed {
+		return
+	}
+
+	var err error
+	if c.IsCharging() || c.IsPreparing() {
+		err = cp.stopChargingConnector(c, core.ReasonLocal)
+	} else {
+		err = cp.StartFreeVend(c.GetConnectorId())
+	}
+
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Free vend failed on connector %d", c.GetConnectorId())
+	}
+}
@@ -0,0 +1,229 @@
+package v16
+
+import (
+	"github.com/go-co-op/gocron"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	reservationManager "github.com/xBlaz3kx/ChargePi-go/internal/components/reservation-manager"
+	setting "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type freeVendTestSuite struct {
+	suite.Suite
+	cp            *ChargePoint
+	ocppMock      *chargePointMock
+	managerMock   *test.ManagerMock
+	connectorMock *test.ConnectorMock
+}
+
+func (s *freeVendTestSuite) SetupSuite() {
+	setting.SetupOcppConfigurationManager(
+		"../../../configs/configuration.json",
+		configuration.OCPP16,
+		nil,
+		core.ProfileName)
+}
+
+func (s *freeVendTestSuite) SetupTest() {
+	s.ocppMock = new(chargePointMock)
+	s.managerMock = new(test.ManagerMock)
+	s.connectorMock = new(test.ConnectorMock)
+
+	s.connectorMock.On("GetEvseId").Return(1).Maybe()
+	s.connectorMock.On("GetConnectorId").Return(1).Maybe()
+	s.connectorMock.On("GetPowerMeter").Return((*test.PowerMeterMock)(nil)).Maybe()
+	s.connectorMock.On("CalculateSessionAvgEnergyConsumption").Return(0.0).Maybe()
+	s.connectorMock.On("GetMaxChargingTime").Return(180).Maybe()
+
+	s.managerMock.On("FindConnectorById", 1).Return(s.connectorMock).Maybe()
+
+	s.cp = &ChargePoint{
+		chargePoint:          s.ocppMock,
+		availability:         core.AvailabilityTypeOperative,
+		connectorManager:     s.managerMock,
+		scheduler:            gocron.NewScheduler(time.UTC),
+		logger:               log.StandardLogger(),
+		reservations:         reservationManager.NewManager(nil, nil),
+		sessionCosts:         map[string]*sessionCost{},
+		freeVendTransactions: map[string]*store.FreeVendTransaction{},
+		connectorSettings: []*settings.Connector{
+			{EvseId: 1, ConnectorId: 1, FreeVend: settings.FreeVend{Enabled: true}},
+			{EvseId: 1, ConnectorId: 2},
+		},
+	}
+}
+
+func (s *freeVendTestSuite) TestIsFreeVendTag() {
+	otherConnector := new(test.ConnectorMock)
+	otherConnector.On("GetEvseId").Return(1)
+	otherConnector.On("GetConnectorId").Return(2)
+
+	s.Assert().True(s.cp.isFreeVendTag(s.connectorMock, defaultFreeVendIdTag))
+	s.Assert().False(s.cp.isFreeVendTag(s.connectorMock, "exampleTag"))
+	s.Assert().False(s.cp.isFreeVendTag(otherConnector, defaultFreeVendIdTag))
+
+	s.cp.connectorSettings[0].FreeVend.IdTag = "customTag"
+	s.Assert().True(s.cp.isFreeVendTag(s.connectorMock, "customTag"))
+}
+
+func (s *freeVendTestSuite) TestStartFreeVend() {
+	var transactionId string
+
+	s.connectorMock.On("StartCharging", mock.Anything, defaultFreeVendIdTag).Run(func(args mock.Arguments) {
+		transactionId = args.String(0)
+		s.connectorMock.On("GetTransactionId").Return(transactionId)
+	}).Return(nil).Once()
+	s.ocppMock.On("SendRequestAsync", mock.AnythingOfType("*core.StartTransactionRequest")).
+		Return(core.NewStartTransactionConfirmation(types.NewIdTagInfo(types.AuthorizationStatusInvalid), 42), nil, nil).Once()
+
+	// Charging starts without waiting for the central system
+	err := s.cp.startFreeVendTransaction(s.connectorMock, defaultFreeVendIdTag, nil)
+	s.Require().NoError(err)
+	s.Assert().True(s.cp.isFreeVendTransaction(transactionId))
+	s.connectorMock.AssertExpectations(s.T())
+
+	// The transaction id assigned by the central system identifies the connector
+	s.Eventually(func() bool {
+		s.cp.freeVendMu.Lock()
+		defer s.cp.freeVendMu.Unlock()
+		return s.cp.freeVendTransactions[transactionId].TransactionId != nil
+	}, time.Second, 50*time.Millisecond)
+
+	s.managerMock.On("FindConnectorWithTransactionId", "42").Return(nil).Once()
+	s.managerMock.On("FindConnectorWithTransactionId", transactionId).Return(s.connectorMock).Once()
+	s.Assert().Equal(s.connectorMock, s.cp.findConnectorWithTransactionId("42"))
+
+	// Stopping reports the transaction with the id assigned by the central system
+	s.connectorMock.On("IsCharging").Return(true).Once()
+	s.connectorMock.On("StopCharging", core.ReasonLocal).Return(nil).Once()
+	s.ocppMock.On("SendRequestAsync", mock.MatchedBy(func(request *core.StopTransactionRequest) bool {
+		return request.TransactionId == 42 && request.Reason == core.ReasonLocal
+	})).Return(core.NewStopTransactionConfirmation(), nil, nil).Once()
+
+	err = s.cp.stopChargingConnector(s.connectorMock, core.ReasonLocal)
+	s.Assert().NoError(err)
+	s.Assert().Empty(s.cp.freeVendTransactions)
+	s.connectorMock.AssertExpectations(s.T())
+	s.ocppMock.AssertExpectations(s.T())
+}
+
+func (s *freeVendTestSuite) TestStopFreeVendOffline() {
+	transactionId := freeVendTransactionPrefix + "1"
+	s.cp.freeVendTransactions[transactionId] = &store.FreeVendTransaction{LocalTransactionId: transactionId}
+
+	s.connectorMock.On("IsCharging").Return(true).Once()
+	s.connectorMock.On("GetTransactionId").Return(transactionId)
+	s.connectorMock.On("StopCharging", core.ReasonEVDisconnected).Return(nil).Once()
+
+	// Charging stops before the central system confirmed the transaction
+	err := s.cp.stopChargingConnector(s.connectorMock, core.ReasonEVDisconnected)
+	s.Require().NoError(err)
+	s.connectorMock.AssertExpectations(s.T())
+	s.ocppMock.AssertNotCalled(s.T(), "SendRequestAsync", mock.Anything)
+
+	// The StopTransaction is sent when the central system confirms the transaction
+	s.ocppMock.On("SendRequestAsync", mock.MatchedBy(func(request *core.StopTransactionRequest) bool {
+		return request.TransactionId == 43 && request.Reason == core.ReasonEVDisconnected
+	})).Return(core.NewStopTransactionConfirmation(), nil, nil).Once()
+
+	s.cp.confirmFreeVendTransaction(transactionId, 43)
+	s.Assert().Empty(s.cp.freeVendTransactions)
+	s.ocppMock.AssertExpectations(s.T())
+}
+
+func (s *freeVendTestSuite) TestRestoreFreeVendTransactions() {
+	var (
+		confirmedTransactionId = 42
+		started                = time.Now().Add(-time.Hour).Truncate(time.Second)
+		stopRequest            = core.NewStopTransactionRequest(1500, types.NewDateTime(started.Add(time.Minute)), 0)
+	)
+	stopRequest.Reason = core.ReasonEVDisconnected
+
+	tempDir, err := ioutil.TempDir("", "chargepi")
+	s.Require().NoError(err)
+	defer os.RemoveAll(tempDir)
+
+	repository, err := store.Open(filepath.Join(tempDir, "chargepi.db"))
+	s.Require().NoError(err)
+	defer repository.Close()
+
+	// A confirmed transaction and a transaction stopped before the central system confirmed it
+	s.Require().NoError(repository.SaveFreeVendTransaction(store.FreeVendTransaction{
+		LocalTransactionId: "FreeVend1",
+		ConnectorId:        1,
+		IdTag:              defaultFreeVendIdTag,
+		Started:            started,
+		TransactionId:      &confirmedTransactionId,
+	}))
+	s.Require().NoError(repository.SaveFreeVendTransaction(store.FreeVendTransaction{
+		LocalTransactionId: "FreeVend2",
+		ConnectorId:        2,
+		IdTag:              defaultFreeVendIdTag,
+		Started:            started,
+		StopRequest:        stopRequest,
+	}))
+	s.cp.freeVendRepository = repository
+
+	// The start of the unconfirmed transaction is reported again, followed by its end
+	s.ocppMock.On("SendRequestAsync", mock.MatchedBy(func(request *core.StartTransactionRequest) bool {
+		return request.ConnectorId == 2 && request.IdTag == defaultFreeVendIdTag && request.Timestamp.Equal(started)
+	})).Return(core.NewStartTransactionConfirmation(types.NewIdTagInfo(types.AuthorizationStatusAccepted), 43), nil, nil).Once()
+	stopped := make(chan struct{})
+	s.ocppMock.On("SendRequestAsync", mock.MatchedBy(func(request *core.StopTransactionRequest) bool {
+		return request.TransactionId == 43 && request.MeterStop == 1500 && request.Reason == core.ReasonEVDisconnected
+	})).Run(func(args mock.Arguments) {
+		close(stopped)
+	}).Return(core.NewStopTransactionConfirmation(), nil, nil).Once()
+
+	s.cp.restoreFreeVendTransactions()
+
+	// The connector of the confirmed transaction is found by the id assigned by the central system
+	s.managerMock.On("FindConnectorWithTransactionId", "42").Return(nil).Once()
+	s.managerMock.On("FindConnectorWithTransactionId", "FreeVend1").Return(s.connectorMock).Once()
+	s.Assert().Equal(s.connectorMock, s.cp.findConnectorWithTransactionId("42"))
+
+	// The end is reported after the central system confirmed the start
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		s.Fail("The end of the transaction was not reported")
+	}
+
+	s.ocppMock.AssertExpectations(s.T())
+	s.cp.freeVendMu.Lock()
+	s.Assert().NotContains(s.cp.freeVendTransactions, "FreeVend2")
+	s.cp.freeVendMu.Unlock()
+
+	transactions, err := repository.GetFreeVendTransactions()
+	s.Require().NoError(err)
+	s.Require().Len(transactions, 1)
+	s.Assert().EqualValues("FreeVend1", transactions[0].LocalTransactionId)
+}
+
+func (s *freeVendTestSuite) TestStartFreeVendDisabled() {
+	otherConnector := new(test.ConnectorMock)
+	otherConnector.On("GetEvseId").Return(1)
+	otherConnector.On("GetConnectorId").Return(2)
+	s.managerMock.On("FindConnectorById", 2).Return(otherConnector)
+	s.managerMock.On("FindConnectorById", 3).Return(nil)
+
+	s.Assert().ErrorIs(s.cp.StartFreeVend(2), errors.ErrFreeVendDisabled)
+	s.Assert().ErrorIs(s.cp.StartFreeVend(3), errors.ErrConnectorNil)
+}
+
+func TestFreeVend(t *testing.T) {
+	suite.Run(t, new(freeVendTestSuite))
+}
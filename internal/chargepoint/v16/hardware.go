@@ -2,100 +2,412 @@ package v16
 
 import (
 	"context"
-	"fmt"
+	goErrors "errors"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	log "github.com/sirupsen/logrus"
+	authInput "github.com/xBlaz3kx/ChargePi-go/internal/components/auth-input"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display/i18n"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/indicator"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/keypad"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/reader"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// Names of the authorization inputs
+const (
+	tagReaderInput = "tagReader"
+	keypadInput    = "keypad"
+)
+
 func (cp *ChargePoint) sendToLCD(messages ...string) {
-	if util.IsNilInterfaceOrPointer(cp.LCD) || cp.LCD.GetLcdChannel() == nil || !cp.Settings.ChargePoint.Hardware.Lcd.IsEnabled {
+	cp.displayMessage(time.Second*5, messages...)
+}
+
+// displayMessage displays the messages on the LCD for the duration.
+func (cp *ChargePoint) displayMessage(duration time.Duration, messages ...string) {
+	if !cp.isDisplayEnabled() {
 		return
 	}
 
 	cp.logger.Debugf("Sending message(s) to LCD: %v", messages)
-	cp.LCD.GetLcdChannel() <- display.NewMessage(time.Second*5, messages)
+	cp.getDisplay().GetLcdChannel() <- display.NewMessage(duration, messages)
 }
 
-func (cp *ChargePoint) displayLEDStatus(connectorIndex int, status core.ChargePointStatus) {
-	if !cp.Settings.ChargePoint.Hardware.LedIndicator.Enabled || util.IsNilInterfaceOrPointer(cp.Indicator) {
+// getSettings returns the current settings, which can be replaced by a reload.
+func (cp *ChargePoint) getSettings() *settings.Settings {
+	cp.settingsMu.RLock()
+	defer cp.settingsMu.RUnlock()
+	return cp.Settings
+}
+
+// getDisplay returns the current LCD, which can be replaced by a reload.
+func (cp *ChargePoint) getDisplay() display.LCD {
+	cp.settingsMu.RLock()
+	defer cp.settingsMu.RUnlock()
+	return cp.LCD
+}
+
+// displayTranslation displays the translated message on the LCD, unless the translation failed.
+func (cp *ChargePoint) displayTranslation(message []string, err error) {
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Error translating the message")
 		return
 	}
 
-	var color = indicator.Off
-
-	switch status {
-	case core.ChargePointStatusFaulted:
-		color = indicator.Red
-		break
-	case core.ChargePointStatusCharging:
-		color = indicator.Blue
-		break
-	case core.ChargePointStatusReserved:
-		color = indicator.Yellow
-		break
-	case core.ChargePointStatusFinishing:
-		color = indicator.Blue
-		break
-	case core.ChargePointStatusAvailable:
-		color = indicator.Green
-		break
-	case core.ChargePointStatusUnavailable:
-		color = indicator.Orange
-		break
-	default:
+	cp.sendToLCD(message...)
+}
+
+// isDisplayEnabled checks if the LCD is enabled and accepts messages.
+func (cp *ChargePoint) isDisplayEnabled() bool {
+	var (
+		lcd             = cp.getDisplay()
+		currentSettings = cp.getSettings()
+	)
+
+	return !util.IsNilInterfaceOrPointer(lcd) && lcd.GetLcdChannel() != nil &&
+		currentSettings != nil && currentSettings.ChargePoint.Hardware.Lcd.IsEnabled
+}
+
+// setIndicator replaces the indicator and the theme of the animations from the indicator settings.
+func (cp *ChargePoint) setIndicator(ledIndicator indicator.Indicator) {
+	cp.indicatorMu.Lock()
+	defer cp.indicatorMu.Unlock()
+
+	if cp.animator != nil {
+		cp.animator.Stop()
+		cp.animator = nil
+	}
+
+	if !util.IsNilInterfaceOrPointer(cp.Indicator) {
+		cp.Indicator.Cleanup()
+	}
+
+	cp.Indicator = ledIndicator
+	cp.indicatorTheme = indicator.DefaultTheme()
+
+	if util.IsNilInterfaceOrPointer(ledIndicator) {
 		return
 	}
 
-	cp.logger.Debugf("Indicating connector status: %x", color)
+	cp.animator = indicator.NewAnimator(ledIndicator)
 
-	go func() {
-		err := cp.Indicator.DisplayColor(connectorIndex, uint32(color))
+	if cp.indicatorSettings != nil {
+		theme, err := indicator.NewTheme(cp.indicatorSettings.Theme)
 		if err != nil {
-			cp.logger.WithError(err).Errorf("Error indicating status")
+			cp.logger.WithError(err).Warn("Invalid indicator theme, using the default theme")
+			return
 		}
-	}()
+
+		cp.indicatorTheme = theme
+	}
 }
 
-// indicateCard Blinks the LED to indicate that the card was read.
-func (cp *ChargePoint) indicateCard(index int, color uint32) {
-	if !cp.Settings.ChargePoint.Hardware.LedIndicator.Enabled || util.IsNilInterfaceOrPointer(cp.Indicator) {
+// getAnimation returns the animation of the status or event from the theme.
+func (cp *ChargePoint) getAnimation(name string) (*indicator.Animator, indicator.Animation, bool) {
+	cp.indicatorMu.Lock()
+	defer cp.indicatorMu.Unlock()
+
+	if cp.animator == nil {
+		return nil, indicator.Animation{}, false
+	}
+
+	animation, isFound := cp.indicatorTheme[name]
+	return cp.animator, animation, isFound
+}
+
+// displayLEDStatus displays the status of the connector at the index. While the charge point is updating the
+// firmware or is offline, the LEDs of all the connectors display the event instead.
+func (cp *ChargePoint) displayLEDStatus(connectorIndex int, status core.ChargePointStatus) {
+	name := string(status)
+	if cp.isFirmwareUpdating() {
+		name = indicator.EventFirmwareUpdate
+	} else if cp.isChargePointOffline() {
+		name = indicator.EventOffline
+	}
+
+	animator, animation, isFound := cp.getAnimation(name)
+	if !isFound {
 		return
 	}
 
-	cp.logger.Trace("Indicating tag was read")
+	cp.logger.Debugf("Indicating connector status: %s", name)
+	animator.Play(connectorIndex, animation)
+}
 
-	err := cp.Indicator.Blink(index, 3, color)
-	if err != nil {
-		cp.logger.WithError(err).Errorf("Could not indicate card was read")
+// indicateEvent plays the animation of the event once on the LED at the index.
+func (cp *ChargePoint) indicateEvent(index int, event string) {
+	animator, animation, isFound := cp.getAnimation(event)
+	if !isFound {
+		return
+	}
+
+	cp.logger.Tracef("Indicating event: %s", event)
+	animator.Flash(index, animation)
+}
+
+// indicateCard indicates the event of the card on the LED after the connectors' LEDs, if the indicator has one.
+func (cp *ChargePoint) indicateCard(event string) {
+	if util.IsNilInterfaceOrPointer(cp.connectorManager) || cp.indicatorSettings == nil {
+		return
+	}
+
+	numberOfConnectors := len(cp.connectorManager.GetConnectors())
+	if !indicator.HasCardLed(numberOfConnectors, *cp.indicatorSettings) {
+		return
+	}
+
+	cp.indicateEvent(numberOfConnectors, event)
+}
+
+// refreshIndicator displays the current status of all the connectors.
+func (cp *ChargePoint) refreshIndicator() {
+	if util.IsNilInterfaceOrPointer(cp.connectorManager) {
+		return
+	}
+
+	for _, c := range cp.connectorManager.GetConnectors() {
+		status, _ := c.GetStatus()
+		// Connector starts with index 1
+		cp.displayLEDStatus(c.GetConnectorId()-1, status)
 	}
 }
 
-// ListenForTag Listen for an RFID/NFC tag on a separate thread. If a tag is detected, call the HandleChargingRequest.
-// Blink the LED if indication is enabled.
+// setOffline stores whether the central system is reachable and refreshes the indicator when it changes.
+func (cp *ChargePoint) setOffline(isOffline bool) {
+	cp.indicatorMu.Lock()
+	isChanged := cp.isOffline != isOffline
+	cp.isOffline = isOffline
+	cp.indicatorMu.Unlock()
+
+	if isChanged {
+		cp.logger.Infof("Central system reachable: %v", !isOffline)
+		cp.refreshIndicator()
+
+		if isOffline {
+			go cp.displayTranslation(i18n.TranslateOfflineMessage(cp.getDefaultLanguage()))
+		}
+	}
+}
+
+func (cp *ChargePoint) isChargePointOffline() bool {
+	cp.indicatorMu.Lock()
+	defer cp.indicatorMu.Unlock()
+	return cp.isOffline
+}
+
+// ListenForTag Listen for an RFID/NFC tag until the context is cancelled. If a tag is detected, it is handled as an
+// authorization request, which blinks the LED if indication is enabled and calls the HandleChargingRequest.
 func (cp *ChargePoint) ListenForTag(ctx context.Context, tagChannel <-chan string) {
 	if tagChannel == nil {
 		return
 	}
 
 	cp.logger.Info("Started listening for tags from reader")
+	authInput.NewChannelInput(authInput.SourceTag, tagChannel).Listen(ctx, func(request authInput.Request) {
+		err := cp.authInputs.Publish(request)
+		if err != nil {
+			cp.logger.WithError(err).Warn("Invalid tag")
+		}
+	})
+}
+
+// SubmitAuthorization handles the authorization request of an input without hardware, such as a payment backend.
+func (cp *ChargePoint) SubmitAuthorization(request authInput.Request) error {
+	return cp.authInputs.Publish(request)
+}
+
+// handleAuthorizationRequest starts or stops charging with the IdTag of the request. If the request is for a specific
+// connector, the session on the connector is stopped if it belongs to the tag or its group, otherwise charging is
+// started on the connector.
+func (cp *ChargePoint) handleAuthorizationRequest(request authInput.Request) {
+	logInfo := cp.logger.WithFields(log.Fields{
+		"source":      request.Source,
+		"connectorId": request.ConnectorId,
+	})
+	logInfo.Info("Received an authorization request")
+
+	cp.indicateCard(indicator.EventCardRead)
+
+	if request.Language != "" {
+		err := cp.setTagLanguage(request.IdTag, request.Language)
+		if err != nil {
+			logInfo.WithError(err).Warn("Ignoring the preferred language")
+		}
+	}
+
+	lang := cp.getLanguage(request.IdTag)
+
+	switch request.Source {
+	case authInput.SourceTag:
+		go cp.displayTranslation(i18n.TranslateCardReadMessage(lang, request.IdTag))
+	case authInput.SourcePin:
+		go cp.displayTranslation(i18n.TranslatePinEnteredMessage(lang))
+	case authInput.SourcePayment:
+		go cp.displayTranslation(i18n.TranslatePaymentReceivedMessage(lang))
+	}
+
+	err := cp.handleAuthorization(request)
+	if err != nil {
+		logInfo.WithError(err).Error("Cannot handle the authorization request")
 
-Listener:
-	for {
-		select {
-		case tagId := <-tagChannel:
-			go cp.indicateCard(len(cp.connectorManager.GetConnectors()), indicator.White)
-			go cp.sendToLCD("Read tag:", tagId)
-			_, _ = cp.HandleChargingRequest(strings.ToUpper(tagId))
-			break
-		case <-ctx.Done():
-			break Listener
-		default:
-			fmt.Printf("%s: Waiting for a tag \n", time.Now().String())
-			time.Sleep(time.Millisecond * 200)
+		if goErrors.Is(err, errors.ErrTagUnauthorized) {
+			cp.indicateCard(indicator.EventCardRejected)
+			go cp.displayTranslation(i18n.TranslateCardRejectedMessage(lang))
 		}
+
+		return
+	}
+
+	cp.indicateCard(indicator.EventCardAccepted)
+}
+
+// handleAuthorization starts or stops charging on the connector of the request or, if the connector is not
+// specified, on any connector.
+func (cp *ChargePoint) handleAuthorization(request authInput.Request) error {
+	if request.ConnectorId == 0 {
+		_, err := cp.HandleChargingRequest(request.IdTag)
+		return err
+	}
+
+	c := cp.connectorManager.FindConnectorById(request.ConnectorId)
+	if util.IsNilInterfaceOrPointer(c) {
+		return errors.ErrConnectorNil
+	}
+
+	if session := cp.findSessionConnector(request.IdTag); !util.IsNilInterfaceOrPointer(session) && session.GetConnectorId() == c.GetConnectorId() {
+		return cp.stopChargingConnectorByTag(c, request.IdTag, core.ReasonLocal)
+	}
+
+	return cp.startChargingConnector(c, request.IdTag)
+}
+
+// displayPinEntry shows the number of digits of the PIN entered on the keypad.
+func (cp *ChargePoint) displayPinEntry(length int) {
+	cp.displayTranslation(i18n.TranslatePinEntryMessage(cp.getDefaultLanguage(), length))
+}
+
+// GetPaymentUrl returns the url the drivers can pay at for charging on the connector.
+func (cp *ChargePoint) GetPaymentUrl(connectorId int) (string, error) {
+	currentSettings := cp.getSettings()
+	if currentSettings == nil || !currentSettings.ChargePoint.Payment.Enabled || currentSettings.ChargePoint.Payment.Url == "" {
+		return "", errors.ErrPaymentDisabled
+	}
+
+	if util.IsNilInterfaceOrPointer(cp.connectorManager.FindConnectorById(connectorId)) {
+		return "", errors.ErrConnectorNil
+	}
+
+	replacer := strings.NewReplacer(
+		"{chargePointId}", url.PathEscape(currentSettings.ChargePoint.Info.Id),
+		"{connectorId}", strconv.Itoa(connectorId),
+	)
+
+	return replacer.Replace(currentSettings.ChargePoint.Payment.Url), nil
+}
+
+// displayPaymentCode shows the payment url of the connector as a QR code, if the display supports it, or as text.
+func (cp *ChargePoint) displayPaymentCode(connectorId int) {
+	paymentUrl, err := cp.GetPaymentUrl(connectorId)
+	if err != nil || !cp.isDisplayEnabled() {
+		return
+	}
+
+	lang := cp.getDefaultLanguage()
+
+	if qrDisplay, canDisplayQR := cp.getDisplay().(display.QRCodeDisplay); canDisplayQR {
+		caption, err := i18n.TranslateScanToPayCaption(lang, connectorId)
+		if err != nil {
+			cp.logger.WithError(err).Errorf("Error translating the message")
+			return
+		}
+
+		qrDisplay.DisplayQRCode(paymentUrl, caption)
+		return
+	}
+
+	cp.displayTranslation(i18n.TranslateScanToPayMessage(lang, paymentUrl))
+}
+
+// setReader replaces the current reader, if any, and starts listening for tags from the new reader.
+func (cp *ChargePoint) setReader(ctx context.Context, tagReader reader.Reader) {
+	if cp.cancelReader != nil {
+		cp.cancelReader()
+	}
+
+	cp.authInputs.RemoveInput(tagReaderInput)
+
+	if !util.IsNilInterfaceOrPointer(cp.TagReader) {
+		cp.TagReader.Cleanup()
+	}
+
+	cp.TagReader = tagReader
+	cp.cancelReader = nil
+
+	if util.IsNilInterfaceOrPointer(tagReader) {
+		return
+	}
+
+	readerCtx, cancel := context.WithCancel(ctx)
+	cp.cancelReader = cancel
+
+	// Listen for incoming tags
+	go tagReader.ListenForTags(readerCtx)
+	cp.authInputs.AddInput(readerCtx, tagReaderInput, authInput.NewChannelInput(authInput.SourceTag, tagReader.GetTagChannel()))
+}
+
+// setKeypad replaces the current keypad, if any, and starts listening for PINs entered on the new keypad.
+func (cp *ChargePoint) setKeypad(ctx context.Context, k keypad.Keypad) {
+	if cp.cancelKeypad != nil {
+		cp.cancelKeypad()
+	}
+
+	cp.authInputs.RemoveInput(keypadInput)
+
+	if !util.IsNilInterfaceOrPointer(cp.Keypad) {
+		cp.Keypad.Cleanup()
 	}
+
+	cp.Keypad = k
+	cp.cancelKeypad = nil
+
+	if util.IsNilInterfaceOrPointer(k) {
+		return
+	}
+
+	keypadCtx, cancel := context.WithCancel(ctx)
+	cp.cancelKeypad = cancel
+
+	// Listen for the PINs
+	go k.ListenForKeys(keypadCtx)
+	cp.authInputs.AddInput(keypadCtx, keypadInput, authInput.NewPinInput(k.GetKeyChannel(), cp.displayPinEntry))
+}
+
+// setDisplay replaces the current display, if any, and starts listening for messages on the new display.
+func (cp *ChargePoint) setDisplay(ctx context.Context, lcd display.LCD) {
+	if cp.cancelDisplay != nil {
+		cp.cancelDisplay()
+	}
+
+	if !util.IsNilInterfaceOrPointer(cp.LCD) {
+		cp.LCD.Cleanup()
+	}
+
+	cp.LCD = lcd
+	cp.cancelDisplay = nil
+
+	if util.IsNilInterfaceOrPointer(lcd) {
+		return
+	}
+
+	displayCtx, cancel := context.WithCancel(ctx)
+	cp.cancelDisplay = cancel
+
+	go lcd.ListenForMessages(displayCtx)
 }
@@ -5,9 +5,14 @@ import (
 	"errors"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	authInput "github.com/xBlaz3kx/ChargePi-go/internal/components/auth-input"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display/i18n"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/indicator"
+	chargePointErrors "github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 	"github.com/xBlaz3kx/ChargePi-go/test"
 	"testing"
@@ -113,14 +118,8 @@ func (s *hardwareTestSuite) TestDisplayLedStatus() {
 	s.indicatorMock.On("DisplayColor", 1, uint32(indicator.Orange)).Return(nil)
 	s.indicatorMock.On("DisplayColor", 1, uint32(indicator.Off)).Return(errors.New("invalid color")).Once()
 
-	s.cp.Indicator = s.indicatorMock
-	s.cp.Settings = &settings.Settings{ChargePoint: settings.ChargePoint{
-		Hardware: settings.Hardware{
-			LedIndicator: settings.LedIndicator{
-				Enabled: true,
-			},
-		},
-	}}
+	s.cp.indicatorSettings = &settings.LedIndicator{Enabled: true}
+	s.cp.setIndicator(s.indicatorMock)
 
 	// Ok statuses
 	s.cp.displayLEDStatus(1, core.ChargePointStatusCharging)
@@ -137,28 +136,170 @@ func (s *hardwareTestSuite) TestDisplayLedStatus() {
 	s.indicatorMock.AssertNumberOfCalls(s.T(), "DisplayColor", 6)
 }
 
-func (s *hardwareTestSuite) TestIndicateCard() {
-	s.indicatorMock.On("Blink", 1, 3, uint32(indicator.White)).Return(nil)
-	s.indicatorMock.On("Blink", 1, 3, uint32(123)).Return(errors.New("invalid color"))
+func (s *hardwareTestSuite) TestDisplayLedStatusWithTheme() {
+	s.indicatorMock.On("DisplayColor", 0, uint32(0x800000)).Return(nil)
+	s.indicatorMock.On("DisplayColor", 0, uint32(indicator.White)).Return(nil)
+
+	s.cp.indicatorSettings = &settings.LedIndicator{
+		Enabled: true,
+		Theme: map[string]settings.IndicatorAnimation{
+			"Available": {Color: "#ff0000", Brightness: 50},
+		},
+	}
+	s.cp.setIndicator(s.indicatorMock)
+
+	s.cp.displayLEDStatus(0, core.ChargePointStatusAvailable)
+	s.indicatorMock.AssertCalled(s.T(), "DisplayColor", 0, uint32(0x800000))
+
+	// The connectors display the offline animation instead of the status
+	s.cp.setOffline(true)
+	s.indicatorMock.On("DisplayColor", 0, mock.Anything).Return(nil)
+	s.cp.displayLEDStatus(0, core.ChargePointStatusAvailable)
+	s.indicatorMock.AssertNotCalled(s.T(), "DisplayColor", 0, uint32(indicator.Green))
+
+	s.indicatorMock.On("Cleanup").Return()
+	s.cp.setIndicator(nil)
+}
+
+func (s *hardwareTestSuite) TestIndicateEvent() {
+	s.indicatorMock.On("DisplayColor", 1, uint32(indicator.White)).Return(nil)
+	s.indicatorMock.On("DisplayColor", 1, uint32(indicator.Off)).Return(nil)
+
+	s.cp.indicatorSettings = &settings.LedIndicator{
+		Enabled: true,
+		Theme: map[string]settings.IndicatorAnimation{
+			indicator.EventCardRead: {Period: 200},
+		},
+	}
+	s.cp.setIndicator(s.indicatorMock)
+
+	// Ok indication
+	s.cp.indicateEvent(1, indicator.EventCardRead)
+
+	// Unknown event
+	s.cp.indicateEvent(1, "unknownEvent")
+
+	time.Sleep(time.Second)
+
+	s.indicatorMock.AssertCalled(s.T(), "DisplayColor", 1, uint32(indicator.White))
+	// The LED is turned off after blinking
+	s.indicatorMock.AssertCalled(s.T(), "DisplayColor", 1, uint32(indicator.Off))
+}
+
+func (s *hardwareTestSuite) TestGetPaymentUrl() {
+	var (
+		managerMock = new(test.ManagerMock)
+		connector1  = new(test.ConnectorMock)
+	)
+
+	managerMock.On("FindConnectorById", 1).Return(connector1)
+	managerMock.On("FindConnectorById", 2).Return(nil)
+	managerMock.On("GetConnectors").Return([]connector.Connector{})
+	s.cp.connectorManager = managerMock
+
+	// Payment disabled
+	s.cp.Settings = &settings.Settings{}
+	_, err := s.cp.GetPaymentUrl(1)
+	s.Assert().ErrorIs(err, chargePointErrors.ErrPaymentDisabled)
 
-	s.cp.Indicator = s.indicatorMock
 	s.cp.Settings = &settings.Settings{ChargePoint: settings.ChargePoint{
+		Info: settings.Info{Id: "ChargePi"},
+		Payment: settings.Payment{
+			Enabled: true,
+			Url:     "https://pay.example.com/{chargePointId}/{connectorId}",
+		},
+	}}
+
+	paymentUrl, err := s.cp.GetPaymentUrl(1)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("https://pay.example.com/ChargePi/1", paymentUrl)
+
+	// Connector doesn't exist
+	_, err = s.cp.GetPaymentUrl(2)
+	s.Assert().ErrorIs(err, chargePointErrors.ErrConnectorNil)
+}
+
+func (s *hardwareTestSuite) TestDisplayPaymentCode() {
+	var (
+		channel     = make(chan display.LCDMessage, 1)
+		managerMock = new(test.ManagerMock)
+		qrMock      = new(test.QRCodeDisplayMock)
+	)
+
+	managerMock.On("FindConnectorById", 1).Return(new(test.ConnectorMock))
+	s.cp.connectorManager = managerMock
+	s.cp.Settings = &settings.Settings{ChargePoint: settings.ChargePoint{
+		Info: settings.Info{Id: "ChargePi"},
+		Payment: settings.Payment{
+			Enabled: true,
+			Url:     "https://pay.example.com/{chargePointId}/{connectorId}",
+		},
 		Hardware: settings.Hardware{
-			LedIndicator: settings.LedIndicator{
-				Enabled: true,
-			},
+			Lcd: settings.Lcd{IsEnabled: true, Language: "en"},
 		},
 	}}
 
-	// Ok indication
-	s.cp.indicateCard(1, indicator.White)
+	// The display shows the QR code
+	qrMock.On("GetLcdChannel").Return(channel)
+	qrMock.On("DisplayQRCode", "https://pay.example.com/ChargePi/1", "Scan to pay at connector 1").Return().Once()
+	s.cp.LCD = qrMock
+	s.cp.displayPaymentCode(1)
+	qrMock.AssertExpectations(s.T())
+
+	// The LCD shows the url as text
+	s.lcdMock.On("GetLcdChannel").Return(channel)
+	s.cp.LCD = s.lcdMock
+	s.cp.displayPaymentCode(1)
+	s.Assert().EqualValues([]string{"Scan to pay:", "https://pay.exam", "ple.com/ChargePi", "/1"}, (<-channel).Messages)
+}
 
-	// Invalid color
-	s.cp.indicateCard(1, uint32(123))
+func (s *hardwareTestSuite) TestSubmitAuthorization() {
+	received := make(chan authInput.Request, 1)
+	s.cp.authInputs = authInput.NewBus(func(request authInput.Request) {
+		received <- request
+	})
 
-	time.Sleep(time.Second)
+	err := s.cp.SubmitAuthorization(authInput.Request{Source: authInput.SourcePayment, IdTag: " Payment1 ", ConnectorId: 1})
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(authInput.Request{Source: authInput.SourcePayment, IdTag: "Payment1", ConnectorId: 1}, <-received)
+
+	// Invalid requests
+	s.Assert().ErrorIs(s.cp.SubmitAuthorization(authInput.Request{Source: authInput.SourcePayment}), authInput.ErrInvalidRequest)
+	s.Assert().ErrorIs(s.cp.SubmitAuthorization(authInput.Request{Source: authInput.SourcePayment, IdTag: "Payment1", ConnectorId: -1}), authInput.ErrInvalidRequest)
+}
+
+func (s *hardwareTestSuite) TestTagLanguage() {
+	var (
+		channel     = make(chan display.LCDMessage, 2)
+		managerMock = new(test.ManagerMock)
+	)
+
+	managerMock.On("FindConnectorById", 2).Return(nil)
+	managerMock.On("GetConnectors").Return([]connector.Connector{})
+	s.lcdMock.On("GetLcdChannel").Return(channel)
+	s.cp.LCD = s.lcdMock
+	s.cp.connectorManager = managerMock
+	s.cp.authInputs = authInput.NewBus(s.cp.handleAuthorizationRequest)
+	s.cp.Settings = &settings.Settings{ChargePoint: settings.ChargePoint{
+		Hardware: settings.Hardware{
+			Lcd: settings.Lcd{
+				IsEnabled: true,
+				Language:  "en",
+			},
+		},
+	}}
+
+	// The preferred language of the request is stored for the tag
+	err := s.cp.SubmitAuthorization(authInput.Request{Source: authInput.SourceTag, IdTag: "exampleTag", ConnectorId: 2, Language: "de"})
+	s.Require().NoError(err)
+	s.Assert().EqualValues([]string{"Karte gelesen:", "exampleTag"}, (<-channel).Messages)
+	s.Assert().EqualValues("de", s.cp.getLanguage("exampleTag"))
+
+	// Other tags use the language of the LCD
+	s.Assert().EqualValues("en", s.cp.getLanguage("exampleTag2"))
+	s.Assert().EqualValues("en", s.cp.getConnectorLanguage(2))
 
-	s.indicatorMock.AssertNumberOfCalls(s.T(), "Blink", 2)
+	s.Assert().ErrorIs(s.cp.setTagLanguage("exampleTag", "not a language"), i18n.ErrInvalidLanguage)
 }
 
 func TestHardware(t *testing.T) {
@@ -0,0 +1,133 @@
+package v16
+
+import (
+	"encoding/json"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display/i18n"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"golang.org/x/text/language"
+)
+
+type (
+	// LanguagePack is the payload of the SetLanguagePack message. The messages are the translations of the message
+	// IDs; a plural message is an object with the plural forms, e.g. {"one": "...", "other": "..."}.
+	LanguagePack struct {
+		Language string                 `json:"language"`
+		Messages map[string]interface{} `json:"messages"`
+	}
+
+	// TagLanguage is the payload of the SetTagLanguage message. An empty language removes the preference of the tag.
+	TagLanguage struct {
+		IdTag    string `json:"idTag"`
+		Language string `json:"language"`
+	}
+)
+
+// loadTranslations loads the translations from the folder, if it is set.
+func (cp *ChargePoint) loadTranslations(folder string) {
+	if folder == "" {
+		return
+	}
+
+	err := i18n.LoadTranslations(folder)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Cannot load the translations from %s", folder)
+	}
+}
+
+// getDefaultLanguage returns the language of the LCD from the settings.
+func (cp *ChargePoint) getDefaultLanguage() string {
+	currentSettings := cp.getSettings()
+	if currentSettings == nil {
+		return ""
+	}
+
+	return currentSettings.ChargePoint.Hardware.Lcd.Language
+}
+
+// getLanguage returns the preferred language of the tag or the language of the LCD, if the tag has no preference.
+func (cp *ChargePoint) getLanguage(tagId string) string {
+	cp.languageMu.Lock()
+	lang, isFound := cp.tagLanguages[tagId]
+	cp.languageMu.Unlock()
+
+	if isFound && tagId != "" {
+		return lang
+	}
+
+	return cp.getDefaultLanguage()
+}
+
+// getConnectorLanguage returns the language of the session on the connector.
+func (cp *ChargePoint) getConnectorLanguage(connectorId int) string {
+	if util.IsNilInterfaceOrPointer(cp.connectorManager) {
+		return cp.getDefaultLanguage()
+	}
+
+	c := cp.connectorManager.FindConnectorById(connectorId)
+	if util.IsNilInterfaceOrPointer(c) {
+		return cp.getDefaultLanguage()
+	}
+
+	return cp.getLanguage(c.GetTagId())
+}
+
+// setTagLanguage stores the preferred language of the tag. An empty language removes the preference.
+func (cp *ChargePoint) setTagLanguage(tagId, lang string) error {
+	cp.languageMu.Lock()
+	defer cp.languageMu.Unlock()
+
+	if cp.tagLanguages == nil {
+		cp.tagLanguages = map[string]string{}
+	}
+
+	if lang == "" {
+		delete(cp.tagLanguages, tagId)
+		return nil
+	}
+
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return i18n.ErrInvalidLanguage
+	}
+
+	cp.tagLanguages[tagId] = tag.String()
+	return nil
+}
+
+// setLanguagePack adds or replaces the translations of the language pushed by the central system.
+func (cp *ChargePoint) setLanguagePack(request dataTransfer.Request) (interface{}, error) {
+	var pack LanguagePack
+
+	err := request.Decode(&pack)
+	if err != nil {
+		return nil, err
+	}
+
+	if pack.Language == "" || len(pack.Messages) == 0 {
+		return nil, dataTransfer.ErrInvalidPayload
+	}
+
+	content, err := json.Marshal(pack.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, i18n.AddLanguagePack(pack.Language, content)
+}
+
+// setTagLanguageRequest stores the preferred language of the tag pushed by the central system.
+func (cp *ChargePoint) setTagLanguageRequest(request dataTransfer.Request) (interface{}, error) {
+	var tagLanguage TagLanguage
+
+	err := request.Decode(&tagLanguage)
+	if err != nil {
+		return nil, err
+	}
+
+	if tagLanguage.IdTag == "" {
+		return nil, dataTransfer.ErrInvalidPayload
+	}
+
+	return nil, cp.setTagLanguage(tagLanguage.IdTag, tagLanguage.Language)
+}
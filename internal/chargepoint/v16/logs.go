@@ -0,0 +1,160 @@
+package v16
+
+import (
+	"context"
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/diagnostics"
+	"github.com/xBlaz3kx/ChargePi-go/internal/pkg/security"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/logging"
+	"time"
+)
+
+func (cp *ChargePoint) OnGetLog(request *security.GetLogRequest) (*security.GetLogConfirmation, error) {
+	cp.logger.Infof("Received %s for %s with request id %d", request.GetFeatureName(), request.LogType, request.RequestId)
+
+	if !diagnostics.IsLocationSupported(request.Log.RemoteLocation) {
+		return &security.GetLogConfirmation{Status: security.LogStatusRejected}, nil
+	}
+
+	var (
+		files  = cp.getLogFiles(request.LogType)
+		status = security.LogStatusAccepted
+	)
+
+	// There are no logs to upload
+	if len(files) == 0 {
+		return &security.GetLogConfirmation{Status: status}, nil
+	}
+
+	fileName := fmt.Sprintf("%s-%s-%s.log", cp.getSettings().ChargePoint.Info.Id, request.LogType, time.Now().UTC().Format("20060102150405"))
+
+	cp.transferMu.Lock()
+	if cp.cancelLogUpload != nil {
+		// Only one upload can be in progress
+		cp.cancelLogUpload()
+		status = security.LogStatusAcceptedCanceled
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cp.cancelLogUpload = cancel
+	cp.transferMu.Unlock()
+
+	go cp.uploadLogs(ctx, *request, files, fileName)
+	return &security.GetLogConfirmation{Status: status, Filename: fileName}, nil
+}
+
+// getLogFiles returns the files of the log type.
+func (cp *ChargePoint) getLogFiles(logType security.LogType) []string {
+	switch logType {
+	case security.LogTypeSecurity:
+		if cp.securityLogPath == "" {
+			return nil
+		}
+
+		return logging.GetLogFiles(cp.securityLogPath)
+	default:
+		return logging.GetLogFiles(logging.LogFilePath)
+	}
+}
+
+// uploadLogs collects the logs in the requested time window and uploads them to the central system.
+func (cp *ChargePoint) uploadLogs(ctx context.Context, request security.GetLogRequest, files []string, fileName string) {
+	var (
+		requestId              = request.RequestId
+		retries, retryInterval = getRetries(request.Retries, request.RetryInterval)
+		oldest, latest         *time.Time
+		logInfo                = cp.logger.WithField("requestId", requestId)
+	)
+
+	defer cp.finishLogUpload(ctx)
+
+	if request.Log.OldestTimestamp != nil {
+		oldest = &request.Log.OldestTimestamp.Time
+	}
+
+	if request.Log.LatestTimestamp != nil {
+		latest = &request.Log.LatestTimestamp.Time
+	}
+
+	logs, err := diagnostics.CollectLogs(files, oldest, latest)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Unable to collect the logs")
+		cp.setLogStatus(ctx, requestId, security.UploadLogStatusUploadFailure)
+		return
+	}
+
+	cp.setLogStatus(ctx, requestId, security.UploadLogStatusUploading)
+
+	err = diagnostics.Upload(ctx, request.Log.RemoteLocation, fileName, logs, retries, retryInterval)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Unable to upload the logs")
+		cp.setLogStatus(ctx, requestId, security.UploadLogStatusUploadFailure)
+		return
+	}
+
+	cp.setLogStatus(ctx, requestId, security.UploadLogStatusUploaded)
+}
+
+func (cp *ChargePoint) finishLogUpload(ctx context.Context) {
+	cp.transferMu.Lock()
+	defer cp.transferMu.Unlock()
+
+	// Do not reset a newer upload
+	if ctx.Err() == nil {
+		cp.cancelLogUpload()
+		cp.cancelLogUpload = nil
+	}
+}
+
+// setLogStatus stores the status of the upload and notifies the central system, unless the upload was canceled.
+func (cp *ChargePoint) setLogStatus(ctx context.Context, requestId int, status security.UploadLogStatus) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	cp.transferMu.Lock()
+	cp.logStatus = status
+	cp.logRequestId = &requestId
+	cp.transferMu.Unlock()
+
+	cp.sendLogStatusNotification(status, &requestId)
+}
+
+// sendCurrentLogStatus notifies the central system about the status of the upload in progress or Idle, if there is
+// no upload in progress.
+func (cp *ChargePoint) sendCurrentLogStatus() {
+	var (
+		status    = security.UploadLogStatusIdle
+		requestId *int
+	)
+
+	cp.transferMu.Lock()
+	if cp.cancelLogUpload != nil && cp.logStatus != "" {
+		status = cp.logStatus
+		requestId = cp.logRequestId
+	}
+	cp.transferMu.Unlock()
+
+	cp.sendLogStatusNotification(status, requestId)
+}
+
+func (cp *ChargePoint) sendLogStatusNotification(status security.UploadLogStatus, requestId *int) {
+	if cp.securityClient == nil {
+		return
+	}
+
+	logInfo := cp.logger.WithField("status", status)
+	logInfo.Info("Sending log status notification")
+
+	err := cp.securityClient.SendRequestAsync(
+		&security.LogStatusNotificationRequest{Status: status, RequestId: requestId},
+		func(response ocpp.Response, err error) {
+			if err != nil {
+				logInfo.WithError(err).Errorf("Central system responded with an error to the log status")
+			}
+		})
+	if err != nil {
+		logInfo.WithError(err).Errorf("Unable to send the log status")
+	}
+}
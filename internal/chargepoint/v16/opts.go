@@ -3,9 +3,15 @@ package v16
 import (
 	"context"
 	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/certificates"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/indicator"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/keypad"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/reader"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/logging"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
 )
 
@@ -31,11 +37,7 @@ func WithReaderFromSettings(ctx context.Context, readerSettings settings.TagRead
 			return
 		}
 
-		point.TagReader = tagReader
-
-		// Listen for incoming tags
-		go tagReader.ListenForTags(ctx)
-		go point.ListenForTag(ctx, tagReader.GetTagChannel())
+		point.setReader(ctx, tagReader)
 	}
 }
 
@@ -45,11 +47,54 @@ func WithReader(ctx context.Context, tagReader reader.Reader) Options {
 		if util.IsNilInterfaceOrPointer(tagReader) {
 			return
 		}
-		point.TagReader = tagReader
 
-		// Listen for incoming tags
-		go tagReader.ListenForTags(ctx)
-		go point.ListenForTag(ctx, tagReader.GetTagChannel())
+		point.setReader(ctx, tagReader)
+	}
+}
+
+// WithKeypadFromSettings creates a Keypad for entering PINs based on the settings.
+func WithKeypadFromSettings(ctx context.Context, keypadSettings settings.Keypad) Options {
+	return func(point *ChargePoint) {
+		if !keypadSettings.IsEnabled {
+			return
+		}
+
+		k, err := keypad.NewKeypad(keypadSettings)
+		if err != nil {
+			point.logger.WithError(err).Error("Cannot create the keypad")
+			return
+		}
+
+		point.setKeypad(ctx, k)
+	}
+}
+
+// WithKeypad adds the keypad to the charge point and starts listening for PINs.
+func WithKeypad(ctx context.Context, k keypad.Keypad) Options {
+	return func(point *ChargePoint) {
+		if util.IsNilInterfaceOrPointer(k) {
+			return
+		}
+
+		point.setKeypad(ctx, k)
+	}
+}
+
+// WithIndicatorFromSettings creates the LED indicator based on the settings, once the connectors are added.
+func WithIndicatorFromSettings(indicatorSettings settings.LedIndicator) Options {
+	return func(point *ChargePoint) {
+		point.indicatorSettings = &indicatorSettings
+	}
+}
+
+// WithIndicator adds the provided indicator to the ChargePoint. The indicator must have an LED for each connector.
+func WithIndicator(ledIndicator indicator.Indicator) Options {
+	return func(point *ChargePoint) {
+		if util.IsNilInterfaceOrPointer(ledIndicator) {
+			return
+		}
+
+		point.setIndicator(ledIndicator)
 	}
 }
 
@@ -60,13 +105,14 @@ func WithDisplayFromSettings(ctx context.Context, lcdSettings settings.Lcd) Opti
 			return
 		}
 
+		point.loadTranslations(lcdSettings.TranslationsFolder)
+
 		lcd, err := display.NewDisplay(lcdSettings)
 		if err != nil {
 			return
 		}
 
-		point.LCD = lcd
-		go lcd.ListenForMessages(ctx)
+		point.setDisplay(ctx, lcd)
 	}
 }
 
@@ -77,7 +123,83 @@ func WithDisplay(ctx context.Context, display display.LCD) Options {
 			return
 		}
 
-		point.LCD = display
-		go display.ListenForMessages(ctx)
+		point.setDisplay(ctx, display)
+	}
+}
+
+// WithCertificateManager adds the certificate manager used by the security extension.
+func WithCertificateManager(manager *certificates.Manager) Options {
+	return func(point *ChargePoint) {
+		if manager != nil {
+			point.certificateManager = manager
+		}
+	}
+}
+
+// WithDataTransferHandler handles the DataTransfer messages of a vendor. The handler replaces the built-in handler of
+// the same vendor and message id.
+func WithDataTransferHandler(vendorId, messageId string, handler dataTransfer.Handler) Options {
+	return func(point *ChargePoint) {
+		err := point.dataTransfer.Register(vendorId, messageId, handler)
+		if err != nil {
+			point.logger.WithError(err).Warnf("Unable to register the DataTransfer handler of %s", vendorId)
+		}
+	}
+}
+
+// WithSecurityLog writes the security events to a separate log file.
+func WithSecurityLog(path string) Options {
+	return func(point *ChargePoint) {
+		securityLogger, err := logging.NewSecurityLogger(path)
+		if err != nil {
+			point.logger.WithError(err).Warn("Unable to create the security log")
+			return
+		}
+
+		point.securityLogger = securityLogger
+		point.securityLogPath = path
+	}
+}
+
+// WithChargePointState persists the availability of the charge point in the repository.
+func WithChargePointState(repository store.ChargePointRepository) Options {
+	return func(point *ChargePoint) {
+		point.chargePointState = repository
+	}
+}
+
+// WithSessionHistory stores the receipts of the finished sessions in the repository.
+func WithSessionHistory(repository store.SessionRepository) Options {
+	return func(point *ChargePoint) {
+		point.sessionHistory = repository
+	}
+}
+
+// WithReservations persists the reservations in the repository, so they are restored after a restart.
+func WithReservations(repository store.ReservationRepository) Options {
+	return func(point *ChargePoint) {
+		point.reservationRepository = repository
+	}
+}
+
+// WithFreeVendTransactions persists the free vend transactions in the repository, so they are reported to the central
+// system after a restart.
+func WithFreeVendTransactions(repository store.FreeVendRepository) Options {
+	return func(point *ChargePoint) {
+		point.freeVendRepository = repository
+	}
+}
+
+// WithRebootHook reboots the system with the hook at a hard reset. Hard resets are rejected without a hook.
+func WithRebootHook(hook RebootHook) Options {
+	return func(point *ChargePoint) {
+		point.rebootHook = hook
+	}
+}
+
+// WithSoftResetHook restarts the charge point with the hook at a soft reset. Soft resets are rejected without a hook.
+func WithSoftResetHook(hook SoftResetHook) Options {
+	return func(point *ChargePoint) {
+		point.softResetHook = hook
 	}
 }
@@ -0,0 +1,244 @@
+package v16
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/agrison/go-commons-lang/stringUtils"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/indicator"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/keypad"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/reader"
+	settingsManager "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"reflect"
+	"strings"
+)
+
+var ErrConnectorBusy = errors.New("connector is in use")
+
+// ApplySettings applies the changed settings while the charge point is running. The hardware components are replaced
+// if their settings changed. If any of the changes require a restart, the settings are rejected and nothing is applied.
+func (cp *ChargePoint) ApplySettings(ctx context.Context, newSettings *settings.Settings) error {
+	if newSettings == nil {
+		return nil
+	}
+
+	cp.reloadMu.Lock()
+	defer cp.reloadMu.Unlock()
+
+	currentSettings := cp.getSettings()
+
+	err := settingsManager.CheckRestartRequired(currentSettings, newSettings)
+	if err != nil {
+		return err
+	}
+
+	var (
+		currentHardware = currentSettings.ChargePoint.Hardware
+		newHardware     = newSettings.ChargePoint.Hardware
+		lcdChanged      = isLcdChanged(currentHardware.Lcd, newHardware.Lcd)
+		readerChanged   = !reflect.DeepEqual(currentHardware.TagReader, newHardware.TagReader)
+		keypadChanged   = !reflect.DeepEqual(currentHardware.Keypad, newHardware.Keypad)
+		lcd             display.LCD
+		tagReader       reader.Reader
+		k               keypad.Keypad
+	)
+
+	// Create the new components before replacing anything, so the settings can still be rejected
+	if lcdChanged && newHardware.Lcd.IsEnabled {
+		lcd, err = display.NewDisplay(newHardware.Lcd)
+		if err != nil {
+			return fmt.Errorf("cannot create the display: %w", err)
+		}
+	}
+
+	if readerChanged && newHardware.TagReader.IsEnabled {
+		tagReader, err = reader.NewTagReader(newHardware.TagReader)
+		if err != nil {
+			return fmt.Errorf("cannot create the tag reader: %w", err)
+		}
+	}
+
+	if keypadChanged && newHardware.Keypad.IsEnabled {
+		k, err = keypad.NewKeypad(newHardware.Keypad)
+		if err != nil {
+			return fmt.Errorf("cannot create the keypad: %w", err)
+		}
+	}
+
+	if currentHardware.Lcd.TranslationsFolder != newHardware.Lcd.TranslationsFolder {
+		cp.loadTranslations(newHardware.Lcd.TranslationsFolder)
+	}
+
+	// The charge point's goroutines read the settings and the hardware while they are replaced
+	cp.settingsMu.Lock()
+	if lcdChanged {
+		cp.logger.Info("Replacing the display")
+		cp.setDisplay(ctx, lcd)
+	}
+
+	if readerChanged {
+		cp.logger.Info("Replacing the tag reader")
+		cp.setReader(ctx, tagReader)
+	}
+
+	if keypadChanged {
+		cp.logger.Info("Replacing the keypad")
+		cp.setKeypad(ctx, k)
+	}
+
+	cp.Settings = newSettings
+	cp.settingsMu.Unlock()
+
+	if !reflect.DeepEqual(currentHardware.LedIndicator, newHardware.LedIndicator) {
+		cp.logger.Info("Replacing the indicator")
+		ledIndicator := newHardware.LedIndicator
+		cp.indicatorMu.Lock()
+		cp.indicatorSettings = &ledIndicator
+		cp.indicatorMu.Unlock()
+		cp.resetIndicator()
+	}
+
+	return nil
+}
+
+// ApplyConnectors adds, removes or replaces the connectors based on the new connector settings. Connectors in use
+// cannot be removed or replaced; in that case, the settings are rejected and nothing is applied. If a connector cannot
+// be added, the previous connectors are restored.
+func (cp *ChargePoint) ApplyConnectors(connectors []*settings.Connector) error {
+	cp.reloadMu.Lock()
+	defer cp.reloadMu.Unlock()
+
+	err := settingsManager.ValidateConnectors(connectors)
+	if err != nil {
+		return err
+	}
+
+	var (
+		added, removed, changed = settingsManager.DiffConnectors(cp.connectorSettings, connectors)
+		busyConnectors          []string
+		previous                = append([]*settings.Connector{}, removed...)
+		applied                 []*settings.Connector
+		maxChargingTime         = cp.getSettings().ChargePoint.Info.MaxChargingTime
+	)
+
+	if len(added) == 0 && len(removed) == 0 && len(changed) == 0 {
+		return nil
+	}
+
+	// Removed or changed connectors must not be in use
+	for _, c := range append(removed, changed...) {
+		conn := cp.connectorManager.FindConnector(c.EvseId, c.ConnectorId)
+		if !util.IsNilInterfaceOrPointer(conn) && !isConnectorIdle(conn) {
+			busyConnectors = append(busyConnectors, fmt.Sprintf("EVSE %d connector %d", c.EvseId, c.ConnectorId))
+		}
+	}
+
+	if len(busyConnectors) > 0 {
+		return fmt.Errorf("%w: %s", ErrConnectorBusy, strings.Join(busyConnectors, ", "))
+	}
+
+	for _, c := range changed {
+		if current := findConnectorSettings(cp.connectorSettings, c.EvseId, c.ConnectorId); current != nil {
+			previous = append(previous, current)
+		}
+	}
+
+	for _, c := range append(removed, changed...) {
+		cp.logger.Infof("Removing connector %d at EVSE %d", c.ConnectorId, c.EvseId)
+		err = cp.connectorManager.RemoveConnector(c.EvseId, c.ConnectorId)
+		if err != nil {
+			cp.logger.WithError(err).Warn("Connector already removed")
+		}
+	}
+
+	for _, c := range append(added, changed...) {
+		cp.logger.Infof("Adding connector %d at EVSE %d", c.ConnectorId, c.EvseId)
+		err = cp.connectorManager.AddConnectorFromSettings(maxChargingTime, c)
+		if err != nil {
+			cp.restoreConnectors(maxChargingTime, applied, previous)
+			return fmt.Errorf("cannot add connector %d at EVSE %d: %w", c.ConnectorId, c.EvseId, err)
+		}
+
+		applied = append(applied, c)
+	}
+
+	for _, c := range removed {
+		settingsManager.DeleteConnectorState(c.EvseId, c.ConnectorId)
+	}
+
+	// Notify the central system about the new connectors
+	for _, c := range applied {
+		conn := cp.connectorManager.FindConnector(c.EvseId, c.ConnectorId)
+		if !util.IsNilInterfaceOrPointer(conn) {
+			conn.SetStatus(core.ChargePointStatusAvailable, core.NoError)
+		}
+	}
+
+	cp.connectorSettings = connectors
+	cp.setupFreeVend()
+
+	// The indicator length depends on the number of connectors
+	if len(added) != len(removed) && cp.indicatorSettings != nil {
+		cp.resetIndicator()
+	}
+
+	return nil
+}
+
+// restoreConnectors removes the connectors added by a failed ApplyConnectors and adds the removed and replaced
+// connectors back with their previous settings.
+func (cp *ChargePoint) restoreConnectors(maxChargingTime int, applied, previous []*settings.Connector) {
+	cp.logger.Warn("Restoring the previous connectors")
+
+	for _, c := range applied {
+		err := cp.connectorManager.RemoveConnector(c.EvseId, c.ConnectorId)
+		if err != nil {
+			cp.logger.WithError(err).Warnf("Cannot remove connector %d at EVSE %d", c.ConnectorId, c.EvseId)
+		}
+	}
+
+	for _, c := range previous {
+		err := cp.connectorManager.AddConnectorFromSettings(maxChargingTime, c)
+		if err != nil {
+			cp.logger.WithError(err).Errorf("Cannot restore connector %d at EVSE %d", c.ConnectorId, c.EvseId)
+		}
+	}
+}
+
+func findConnectorSettings(connectors []*settings.Connector, evseId, connectorId int) *settings.Connector {
+	for _, c := range connectors {
+		if c.EvseId == evseId && c.ConnectorId == connectorId {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// resetIndicator replaces the indicator with a new one, based on the indicator settings and the number of connectors.
+func (cp *ChargePoint) resetIndicator() {
+	ledIndicator, err := indicator.NewIndicator(len(cp.connectorManager.GetConnectors()), *cp.indicatorSettings)
+	if err != nil && err != indicator.ErrIndicatorDisabled {
+		cp.logger.WithError(err).Error("Cannot create the indicator")
+	}
+
+	cp.setIndicator(ledIndicator)
+	cp.refreshIndicator()
+}
+
+// isLcdChanged checks if the LCD must be replaced. The language and the translations are applied without replacing
+// the LCD.
+func isLcdChanged(current, new settings.Lcd) bool {
+	current.Language, new.Language = "", ""
+	current.TranslationsFolder, new.TranslationsFolder = "", ""
+	return !reflect.DeepEqual(current, new)
+}
+
+func isConnectorIdle(c connector.Connector) bool {
+	return !c.IsCharging() && !c.IsPreparing() && !c.IsReserved() && stringUtils.IsEmpty(c.GetTransactionId())
+}
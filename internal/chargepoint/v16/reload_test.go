@@ -0,0 +1,137 @@
+package v16
+
+import (
+	"context"
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	settingsManager "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	"testing"
+)
+
+type reloadTestSuite struct {
+	suite.Suite
+	cp        *ChargePoint
+	manager   *test.ManagerMock
+	connector *test.ConnectorMock
+}
+
+func (s *reloadTestSuite) SetupTest() {
+	s.manager = new(test.ManagerMock)
+	s.connector = new(test.ConnectorMock)
+
+	s.connector.On("GetConnectorId").Return(1)
+
+	s.cp = &ChargePoint{
+		Settings: &settings.Settings{
+			ChargePoint: settings.ChargePoint{
+				Info: settings.Info{
+					Id:              "ChargePoint",
+					ProtocolVersion: "1.6",
+					ServerUri:       "example.com",
+					MaxChargingTime: 180,
+				},
+			},
+		},
+		connectorSettings: []*settings.Connector{
+			{EvseId: 1, ConnectorId: 1, Type: "Schuko", Relay: settings.Relay{RelayPin: 10}},
+		},
+		connectorManager:  s.manager,
+		logger:            log.StandardLogger(),
+		indicatorSettings: &settings.LedIndicator{},
+	}
+}
+
+func (s *reloadTestSuite) TestApplySettings() {
+	newSettings := *s.cp.Settings
+	newSettings.ChargePoint.Hardware.Lcd.Language = "sl"
+
+	err := s.cp.ApplySettings(context.Background(), &newSettings)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("sl", s.cp.Settings.ChargePoint.Hardware.Lcd.Language)
+
+	// Changing the server requires a restart
+	rejectedSettings := newSettings
+	rejectedSettings.ChargePoint.Info.ServerUri = "example2.com"
+	rejectedSettings.ChargePoint.Hardware.Lcd.Language = "en"
+
+	err = s.cp.ApplySettings(context.Background(), &rejectedSettings)
+	s.Assert().ErrorIs(err, settingsManager.ErrRestartRequired)
+	s.Assert().EqualValues("sl", s.cp.Settings.ChargePoint.Hardware.Lcd.Language)
+}
+
+func (s *reloadTestSuite) TestApplyConnectors() {
+	var (
+		newConnector = &settings.Connector{EvseId: 1, ConnectorId: 2, Type: "Schuko", Relay: settings.Relay{RelayPin: 11}}
+		connectors   = []*settings.Connector{s.cp.connectorSettings[0], newConnector}
+	)
+
+	s.manager.On("AddConnectorFromSettings", newConnector).Return(nil).Once()
+	s.manager.On("FindConnector", 1, 2).Return(s.connector).Once()
+	s.manager.On("GetConnectors").Return([]connector.Connector{s.connector})
+	s.connector.On("SetStatus", core.ChargePointStatusAvailable, core.NoError).Return().Once()
+	s.connector.On("GetStatus").Return("Available", "NoError")
+
+	err := s.cp.ApplyConnectors(connectors)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(connectors, s.cp.connectorSettings)
+
+	// Nothing changed
+	err = s.cp.ApplyConnectors(connectors)
+	s.Assert().NoError(err)
+
+	s.manager.AssertExpectations(s.T())
+	s.connector.AssertExpectations(s.T())
+}
+
+func (s *reloadTestSuite) TestApplyConnectorsBusy() {
+	s.manager.On("FindConnector", 1, 1).Return(s.connector)
+	s.connector.On("IsCharging").Return(true)
+	s.connector.On("IsPreparing").Return(false)
+	s.connector.On("IsReserved").Return(false)
+	s.connector.On("GetTransactionId").Return("1234")
+
+	// Removing a connector in use is rejected
+	err := s.cp.ApplyConnectors([]*settings.Connector{})
+	s.Assert().ErrorIs(err, ErrConnectorBusy)
+	s.Assert().Len(s.cp.connectorSettings, 1)
+	s.manager.AssertNotCalled(s.T(), "RemoveConnector", mock.Anything, mock.Anything)
+}
+
+func (s *reloadTestSuite) TestApplyConnectorsRollback() {
+	var (
+		previousConnector = s.cp.connectorSettings[0]
+		changedConnector  = &settings.Connector{EvseId: 1, ConnectorId: 1, Type: "Schuko", Relay: settings.Relay{RelayPin: 20}}
+		newConnector      = &settings.Connector{EvseId: 1, ConnectorId: 2, Type: "Schuko", Relay: settings.Relay{RelayPin: 11}}
+	)
+
+	s.manager.On("FindConnector", 1, 1).Return(s.connector)
+	s.connector.On("IsCharging").Return(false)
+	s.connector.On("IsPreparing").Return(false)
+	s.connector.On("IsReserved").Return(false)
+	s.connector.On("GetTransactionId").Return("")
+
+	s.manager.On("RemoveConnector", 1, 1).Return(nil).Once()
+	s.manager.On("AddConnectorFromSettings", newConnector).Return(nil).Once()
+	s.manager.On("AddConnectorFromSettings", changedConnector).Return(errors.New("relay pin in use")).Once()
+
+	// The added connector is removed and the changed connector is restored with the previous settings
+	s.manager.On("RemoveConnector", 1, 2).Return(nil).Once()
+	s.manager.On("AddConnectorFromSettings", previousConnector).Return(nil).Once()
+
+	err := s.cp.ApplyConnectors([]*settings.Connector{changedConnector, newConnector})
+	s.Assert().Error(err)
+	s.Assert().EqualValues([]*settings.Connector{previousConnector}, s.cp.connectorSettings)
+
+	s.manager.AssertExpectations(s.T())
+	s.connector.AssertNotCalled(s.T(), "SetStatus", mock.Anything, mock.Anything)
+}
+
+func TestReload(t *testing.T) {
+	suite.Run(t, new(reloadTestSuite))
+}
@@ -1,50 +1,252 @@
 package v16
 
 import (
-	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/reservation"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	connectorManager "github.com/xBlaz3kx/ChargePi-go/internal/components/connector-manager"
+	reservationManager "github.com/xBlaz3kx/ChargePi-go/internal/components/reservation-manager"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"time"
 )
 
 func (cp *ChargePoint) OnReserveNow(request *reservation.ReserveNowRequest) (confirmation *reservation.ReserveNowConfirmation, err error) {
-	cp.logger.Infof("Received %s for %v", request.GetFeatureName(), request.ConnectorId)
-	connector := cp.connectorManager.FindConnector(1, request.ConnectorId)
+	cp.logger.WithFields(log.Fields{
+		"connectorId":   request.ConnectorId,
+		"reservationId": request.ReservationId,
+		"tagId":         request.IdTag,
+	}).Infof("Received request %s", request.GetFeatureName())
 
-	if util.IsNilInterfaceOrPointer(connector) {
-		return reservation.NewReserveNowConfirmation(reservation.ReservationStatusUnavailable), nil
-	} else if !connector.IsAvailable() {
-		return reservation.NewReserveNowConfirmation(reservation.ReservationStatusOccupied), nil
+	return reservation.NewReserveNowConfirmation(cp.reserveConnector(request)), nil
+}
+
+func (cp *ChargePoint) OnCancelReservation(request *reservation.CancelReservationRequest) (confirmation *reservation.CancelReservationConfirmation, err error) {
+	cp.logger.Infof("Received %s for %v", request.GetFeatureName(), request.ReservationId)
+
+	removedReservation, err := cp.reservations.RemoveReservation(request.ReservationId)
+	if err != nil {
+		return reservation.NewCancelReservationConfirmation(reservation.CancelReservationStatusRejected), nil
+	}
+
+	cp.releaseReservedConnector(*removedReservation)
+	return reservation.NewCancelReservationConfirmation(reservation.CancelReservationStatusAccepted), nil
+}
+
+// reserveConnector reserves the connector until the expiry date. Reserving connector 0 reserves any available connector,
+// if ReserveConnectorZeroSupported is enabled. A reservation with the same id is replaced, if the new one is accepted.
+func (cp *ChargePoint) reserveConnector(request *reservation.ReserveNowRequest) reservation.ReservationStatus {
+	if request.ExpiryDate == nil || !request.ExpiryDate.After(time.Now()) {
+		return reservation.ReservationStatusRejected
+	}
+
+	if !cp.isChargePointOperative() {
+		return reservation.ReservationStatusUnavailable
+	}
+
+	previousReservation, _ := cp.reservations.GetReservation(request.ReservationId)
+
+	var c connector.Connector
+	if request.ConnectorId == 0 {
+		isSupported, confErr := ocppManager.GetConfigurationValue(v16.ReserveConnectorZeroSupported.String())
+		if confErr != nil || isSupported != "true" {
+			return reservation.ReservationStatusRejected
+		}
+
+		// The replaced reservation keeps its connector
+		if previousReservation != nil {
+			c = cp.connectorManager.FindConnector(previousReservation.EvseId, previousReservation.ConnectorId)
+		}
+
+		if !isReservedBy(c, previousReservation) {
+			c = cp.connectorManager.FindAvailableConnector()
+		}
+
+		if util.IsNilInterfaceOrPointer(c) {
+			return reservation.ReservationStatusOccupied
+		}
+	} else {
+		c = cp.connectorManager.FindConnectorById(request.ConnectorId)
 	}
 
-	err = connector.ReserveConnector(request.ReservationId, request.IdTag)
+	if util.IsNilInterfaceOrPointer(c) {
+		return reservation.ReservationStatusUnavailable
+	}
+
+	// The connector of the replaced reservation is available for the new one
+	isReplaced := isReservedBy(c, previousReservation)
+	status, _ := c.GetStatus()
+	if isReplaced {
+		status = core.ChargePointStatusAvailable
+	}
+
+	switch {
+	case status == core.ChargePointStatusFaulted:
+		return reservation.ReservationStatusFaulted
+	case status == core.ChargePointStatusUnavailable || !cp.isConnectorOperative(c):
+		return reservation.ReservationStatusUnavailable
+	case status != core.ChargePointStatusAvailable || cp.isEvseInUseExcept(c, previousReservation):
+		return reservation.ReservationStatusOccupied
+	}
+
+	if !isReplaced {
+		err := c.ReserveConnector(request.ReservationId, request.IdTag)
+		if err != nil {
+			return reservation.ReservationStatusRejected
+		}
+	}
+
+	newReservation := store.Reservation{
+		ReservationId: request.ReservationId,
+		EvseId:        c.GetEvseId(),
+		ConnectorId:   c.GetConnectorId(),
+		IdTag:         request.IdTag,
+		ParentIdTag:   request.ParentIdTag,
+		ExpiryDate:    request.ExpiryDate.Time,
+	}
+
+	// Replaces the reservation with the same id
+	err := cp.reservations.AddReservation(newReservation)
 	if err != nil {
-		return reservation.NewReserveNowConfirmation(reservation.ReservationStatusRejected), nil
+		cp.logger.WithError(err).Errorf("Unable to add the reservation")
+		if !isReplaced {
+			_ = c.RemoveReservation()
+		}
+
+		return reservation.ReservationStatusRejected
 	}
 
-	timeFormat := fmt.Sprintf("%d:%d", request.ExpiryDate.Hour(), request.ExpiryDate.Minute())
-	_, schedulerErr := cp.scheduler.Every(1).Day().At(timeFormat).LimitRunsTo(1).Do(connector.RemoveReservation)
-	if schedulerErr != nil {
-		return reservation.NewReserveNowConfirmation(reservation.ReservationStatusRejected), nil
+	if previousReservation != nil && !isReplaced {
+		cp.releaseReservedConnector(*previousReservation)
 	}
 
-	return reservation.NewReserveNowConfirmation(reservation.ReservationStatusAccepted), nil
+	return reservation.ReservationStatusAccepted
 }
 
-func (cp *ChargePoint) OnCancelReservation(request *reservation.CancelReservationRequest) (confirmation *reservation.CancelReservationConfirmation, err error) {
-	cp.logger.Infof("Received %s for %v", request.GetFeatureName(), request.ReservationId)
-	var (
-		connector = cp.connectorManager.FindConnectorWithReservationId(request.ReservationId)
-		status    = reservation.CancelReservationStatusAccepted
-	)
+// isReservedBy checks if the connector is reserved by the reservation.
+func isReservedBy(c connector.Connector, r *store.Reservation) bool {
+	return r != nil && !util.IsNilInterfaceOrPointer(c) &&
+		c.GetEvseId() == r.EvseId && c.GetConnectorId() == r.ConnectorId && c.IsReserved()
+}
 
-	if util.IsNilInterfaceOrPointer(connector) {
-		return reservation.NewCancelReservationConfirmation(reservation.CancelReservationStatusRejected), nil
+// isEvseInUseExcept checks if another connector of the EVSE is in use. The connector of the replaced reservation is not
+// considered in use.
+func (cp *ChargePoint) isEvseInUseExcept(c connector.Connector, replaced *store.Reservation) bool {
+	for _, other := range cp.connectorManager.GetEvseConnectors(c.GetEvseId()) {
+		if other.GetConnectorId() == c.GetConnectorId() || isReservedBy(other, replaced) {
+			continue
+		}
+
+		if connectorManager.IsInUse(other) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// releaseReservedConnector makes the connector of the removed reservation available again.
+func (cp *ChargePoint) releaseReservedConnector(removedReservation store.Reservation) {
+	c := cp.connectorManager.FindConnector(removedReservation.EvseId, removedReservation.ConnectorId)
+	if util.IsNilInterfaceOrPointer(c) || !c.IsReserved() || c.GetReservationId() != removedReservation.ReservationId {
+		return
 	}
 
-	err = connector.RemoveReservation()
+	err := c.RemoveReservation()
 	if err != nil {
-		status = reservation.CancelReservationStatusRejected
+		cp.logger.WithError(err).Errorf("Unable to remove the reservation from connector %d", c.GetConnectorId())
+	}
+}
+
+// onReservationExpired makes the connector available after the reservation expired.
+func (cp *ChargePoint) onReservationExpired(expiredReservation store.Reservation) {
+	cp.releaseReservedConnector(expiredReservation)
+}
+
+// restoreReservations reserves the connectors with the reservations from before the restart. The connectors that were
+// reserved, but their reservation expired or was lost, become available.
+func (cp *ChargePoint) restoreReservations() {
+	for _, restoredReservation := range cp.reservations.Restore() {
+		c := cp.connectorManager.FindConnector(restoredReservation.EvseId, restoredReservation.ConnectorId)
+
+		var err error
+		switch {
+		case util.IsNilInterfaceOrPointer(c):
+			err = errors.ErrConnectorNil
+		case c.IsReserved() && c.GetReservationId() == restoredReservation.ReservationId:
+			// The connector was reserved after connecting to the central system
+			continue
+		default:
+			err = c.ReserveConnector(restoredReservation.ReservationId, restoredReservation.IdTag)
+		}
+
+		if err != nil {
+			cp.logger.WithError(err).Warnf("Unable to restore the reservation %d", restoredReservation.ReservationId)
+			_, _ = cp.reservations.RemoveReservation(restoredReservation.ReservationId)
+		}
+	}
+
+	for _, c := range cp.connectorManager.GetConnectors() {
+		if c.IsReserved() && c.GetReservationId() <= 0 {
+			_ = c.RemoveReservation()
+		}
+	}
+}
+
+// isReservedFor checks if the tag is the reserving tag or has the same parent tag as the reservation.
+func (cp *ChargePoint) isReservedFor(connectorReservation store.Reservation, tagId string) bool {
+	if connectorReservation.IdTag == tagId {
+		return true
+	}
+
+	if connectorReservation.ParentIdTag == "" {
+		return false
+	}
+
+	return reservationManager.IsReservedFor(connectorReservation, tagId, cp.getParentIdTag(tagId))
+}
+
+// findReservedConnector returns the connector reserved for the tag.
+func (cp *ChargePoint) findReservedConnector(tagId string) connector.Connector {
+	for _, connectorReservation := range cp.reservations.GetReservations() {
+		if !cp.isReservedFor(connectorReservation, tagId) {
+			continue
+		}
+
+		c := cp.connectorManager.FindConnector(connectorReservation.EvseId, connectorReservation.ConnectorId)
+		if !util.IsNilInterfaceOrPointer(c) && c.IsReserved() {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// canStartTransaction checks if the connector is available or reserved for the tag.
+func (cp *ChargePoint) canStartTransaction(c connector.Connector, tagId string) bool {
+	_, err := cp.getTransactionReservation(c, tagId)
+	return err == nil
+}
+
+// getTransactionReservation checks if the tag can start a transaction on the connector. If the connector is reserved for
+// the tag, the id of the reservation is returned.
+func (cp *ChargePoint) getTransactionReservation(c connector.Connector, tagId string) (*int, error) {
+	if !c.IsReserved() {
+		if !c.IsAvailable() {
+			return nil, errors.ErrConnectorUnavailable
+		}
+
+		return nil, nil
+	}
+
+	connectorReservation, err := cp.reservations.GetConnectorReservation(c.GetEvseId(), c.GetConnectorId())
+	if err != nil || !cp.isReservedFor(*connectorReservation, tagId) {
+		return nil, errors.ErrConnectorReserved
 	}
 
-	return reservation.NewCancelReservationConfirmation(status), nil
+	return &connectorReservation.ReservationId, nil
 }
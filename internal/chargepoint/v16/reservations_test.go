@@ -2,12 +2,26 @@ package v16
 
 import (
 	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/reservation"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	reservationManager "github.com/xBlaz3kx/ChargePi-go/internal/components/reservation-manager"
+	setting "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
+	chargePointErrors "github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
 	"github.com/xBlaz3kx/ChargePi-go/test"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -23,11 +37,26 @@ type reservationTestSuite struct {
 	cp *ChargePoint
 }
 
+func (s *reservationTestSuite) SetupSuite() {
+	setting.SetupOcppConfigurationManager(
+		"../../../configs/configuration.json",
+		configuration.OCPP16,
+		nil,
+		core.ProfileName,
+		reservation.ProfileName)
+}
+
 func (s *reservationTestSuite) SetupTest() {
 	s.cp = &ChargePoint{
-		logger:    log.StandardLogger(),
-		scheduler: scheduler.GetScheduler(),
+		availability: core.AvailabilityTypeOperative,
+		logger:       log.StandardLogger(),
+		scheduler:    scheduler.GetScheduler(),
 	}
+	s.cp.reservations = reservationManager.NewManager(nil, s.cp.onReservationExpired)
+}
+
+func (s *reservationTestSuite) TearDownTest() {
+	s.cp.reservations.Stop()
 }
 
 func (s *reservationTestSuite) TestReservation() {
@@ -39,33 +68,224 @@ func (s *reservationTestSuite) TestReservation() {
 
 	// Set connector expectations
 	connectorMock.On("ReserveConnector", reservationId, tagId).Return(nil).Once()
-	connectorMock.On("IsAvailable").Return(true).Once()
-	connectorMock.On("RemoveReservation").Return()
+	connectorMock.On("GetStatus").Return(string(core.ChargePointStatusAvailable), string(core.NoError)).Once()
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
 
 	// Set manager expectations
-	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock).Twice()
+	managerMock.On("FindConnectorById", connectorId).Return(connectorMock)
+	managerMock.On("GetEvseConnectors", 1).Return([]connector.Connector{connectorMock})
 	// Connector not found
-	managerMock.On("FindConnector", 1, 2).Return(nil).Once()
+	managerMock.On("FindConnectorById", 2).Return(nil).Once()
 	s.cp.connectorManager = managerMock
 
-	response, err := s.cp.OnReserveNow(reservation.NewReserveNowRequest(connectorId, expiryDate, tagId, reservationId))
+	request := reservation.NewReserveNowRequest(connectorId, expiryDate, tagId, reservationId)
+	request.ParentIdTag = "parentTagId"
+	response, err := s.cp.OnReserveNow(request)
 	s.Assert().NoError(err)
 	s.Assert().NotNil(response)
 	s.Assert().EqualValues(reservation.ReservationStatusAccepted, response.Status)
 
+	connectorReservation, err := s.cp.reservations.GetReservation(reservationId)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(store.Reservation{
+		ReservationId: reservationId,
+		EvseId:        1,
+		ConnectorId:   connectorId,
+		IdTag:         tagId,
+		ParentIdTag:   "parentTagId",
+		ExpiryDate:    expiryDate.Time,
+	}, *connectorReservation)
+
 	// No connector with connectorId
-	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(2, expiryDate, tagId, reservationId))
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(2, expiryDate, tagId, 2))
 	s.Assert().NoError(err)
-	s.Assert().NotNil(response)
 	s.Assert().EqualValues(reservation.ReservationStatusUnavailable, response.Status)
 
+	// The reservation already expired
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(connectorId, types.NewDateTime(time.Now().Add(-time.Minute)), tagId, 2))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusRejected, response.Status)
+
+	// The connector is faulted
+	connectorMock.On("GetStatus").Return(string(core.ChargePointStatusFaulted), string(core.GroundFailure)).Once()
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(connectorId, expiryDate, tagId, 2))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusFaulted, response.Status)
+
+	// The connector is charging
+	connectorMock.On("GetStatus").Return(string(core.ChargePointStatusCharging), string(core.NoError)).Once()
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(connectorId, expiryDate, tagId, 2))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusOccupied, response.Status)
+
 	// Unable to reserve for whatever reason
-	connectorMock.On("IsAvailable").Return(true).Once()
+	connectorMock.On("GetStatus").Return(string(core.ChargePointStatusAvailable), string(core.NoError)).Once()
 	connectorMock.On("ReserveConnector", 2, tagId).Return(errors.New("unable to reserve the connector")).Once()
 	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(connectorId, expiryDate, tagId, 2))
 	s.Assert().NoError(err)
-	s.Assert().NotNil(response)
 	s.Assert().EqualValues(reservation.ReservationStatusRejected, response.Status)
+
+	// The charge point is inoperative
+	s.cp.availability = core.AvailabilityTypeInoperative
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(connectorId, expiryDate, tagId, 2))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusUnavailable, response.Status)
+
+	_, err = s.cp.reservations.GetReservation(2)
+	s.Assert().ErrorIs(err, reservationManager.ErrReservationNotFound)
+}
+
+func (s *reservationTestSuite) TestReplaceReservation() {
+	var (
+		connectorMock        = new(test.ConnectorMock)
+		otherConnectorMock   = new(test.ConnectorMock)
+		faultedConnectorMock = new(test.ConnectorMock)
+		managerMock          = new(test.ManagerMock)
+		expiryDate           = types.NewDateTime(time.Now().Add(time.Minute))
+	)
+
+	connectorMock.On("ReserveConnector", reservationId, tagId).Return(nil).Once()
+	connectorMock.On("GetStatus").Return(string(core.ChargePointStatusAvailable), string(core.NoError)).Once()
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("IsReserved").Return(true)
+	connectorMock.On("GetReservationId").Return(reservationId)
+	connectorMock.On("RemoveReservation").Return(nil).Once()
+
+	otherConnectorMock.On("ReserveConnector", reservationId, "otherTagId").Return(nil).Once()
+	otherConnectorMock.On("GetStatus").Return(string(core.ChargePointStatusAvailable), string(core.NoError)).Once()
+	otherConnectorMock.On("GetEvseId").Return(2)
+	otherConnectorMock.On("GetConnectorId").Return(2)
+
+	managerMock.On("FindConnectorById", connectorId).Return(connectorMock)
+	managerMock.On("FindConnectorById", 2).Return(otherConnectorMock)
+	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock)
+	managerMock.On("GetEvseConnectors", 1).Return([]connector.Connector{connectorMock})
+	managerMock.On("GetEvseConnectors", 2).Return([]connector.Connector{otherConnectorMock})
+	s.cp.connectorManager = managerMock
+
+	faultedConnectorMock.On("GetStatus").Return(string(core.ChargePointStatusFaulted), string(core.GroundFailure)).Once()
+	faultedConnectorMock.On("GetEvseId").Return(3)
+	faultedConnectorMock.On("GetConnectorId").Return(3)
+
+	managerMock.On("FindConnectorById", 3).Return(faultedConnectorMock)
+
+	response, err := s.cp.OnReserveNow(reservation.NewReserveNowRequest(connectorId, expiryDate, tagId, reservationId))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusAccepted, response.Status)
+
+	// The reservation with the same id is updated on the reserved connector
+	connectorMock.On("GetStatus").Return(string(core.ChargePointStatusReserved), string(core.NoError)).Once()
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(connectorId, expiryDate, "newTagId", reservationId))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusAccepted, response.Status)
+	connectorMock.AssertNumberOfCalls(s.T(), "ReserveConnector", 1)
+	connectorMock.AssertNotCalled(s.T(), "RemoveReservation")
+
+	connectorReservation, err := s.cp.reservations.GetReservation(reservationId)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(connectorId, connectorReservation.ConnectorId)
+	s.Assert().EqualValues("newTagId", connectorReservation.IdTag)
+
+	// The rejected reservation does not replace the reservation with the same id
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(3, expiryDate, "otherTagId", reservationId))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusFaulted, response.Status)
+	connectorMock.AssertNotCalled(s.T(), "RemoveReservation")
+
+	connectorReservation, err = s.cp.reservations.GetReservation(reservationId)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(connectorId, connectorReservation.ConnectorId)
+
+	// The reservation with the same id moves to the other connector
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(2, expiryDate, "otherTagId", reservationId))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusAccepted, response.Status)
+	connectorMock.AssertCalled(s.T(), "RemoveReservation")
+
+	connectorReservation, err = s.cp.reservations.GetReservation(reservationId)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(2, connectorReservation.ConnectorId)
+	s.Assert().EqualValues("otherTagId", connectorReservation.IdTag)
+}
+
+func (s *reservationTestSuite) TestReserveConnectorZero() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+		expiryDate    = types.NewDateTime(time.Now().Add(time.Minute))
+	)
+
+	connectorMock.On("ReserveConnector", reservationId, tagId).Return(nil).Once()
+	connectorMock.On("GetStatus").Return(string(core.ChargePointStatusAvailable), string(core.NoError))
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+
+	managerMock.On("FindAvailableConnector").Return(connectorMock).Once()
+	managerMock.On("GetEvseConnectors", 1).Return([]connector.Connector{connectorMock})
+	s.cp.connectorManager = managerMock
+
+	// Reserving connector 0 is not supported
+	s.Require().NoError(ocppManager.UpdateKey(v16.ReserveConnectorZeroSupported.String(), "false"))
+	response, err := s.cp.OnReserveNow(reservation.NewReserveNowRequest(0, expiryDate, tagId, reservationId))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusRejected, response.Status)
+
+	// An available connector is reserved
+	s.Require().NoError(ocppManager.UpdateKey(v16.ReserveConnectorZeroSupported.String(), "true"))
+	defer ocppManager.UpdateKey(v16.ReserveConnectorZeroSupported.String(), "false")
+
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(0, expiryDate, tagId, reservationId))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusAccepted, response.Status)
+	connectorMock.AssertCalled(s.T(), "ReserveConnector", reservationId, tagId)
+
+	connectorReservation, err := s.cp.reservations.GetReservation(reservationId)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(connectorId, connectorReservation.ConnectorId)
+
+	// No connector is available
+	managerMock.On("FindAvailableConnector").Return(nil).Once()
+	response, err = s.cp.OnReserveNow(reservation.NewReserveNowRequest(0, expiryDate, tagId, 2))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservation.ReservationStatusOccupied, response.Status)
+}
+
+func (s *reservationTestSuite) TestReservationExpiry() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+		removed       = make(chan struct{})
+	)
+
+	connectorMock.On("IsReserved").Return(true)
+	connectorMock.On("GetReservationId").Return(reservationId)
+	connectorMock.On("RemoveReservation").Return(nil).Once().Run(func(args mock.Arguments) {
+		close(removed)
+	})
+
+	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock)
+	s.cp.connectorManager = managerMock
+
+	err := s.cp.reservations.AddReservation(store.Reservation{
+		ReservationId: reservationId,
+		EvseId:        1,
+		ConnectorId:   connectorId,
+		IdTag:         tagId,
+		ExpiryDate:    time.Now().Add(100 * time.Millisecond),
+	})
+	s.Require().NoError(err)
+
+	// The connector becomes available at the expiry date
+	select {
+	case <-removed:
+	case <-time.After(time.Second):
+		s.FailNow("the reservation did not expire")
+	}
+
+	_, err = s.cp.reservations.GetReservation(reservationId)
+	s.Assert().ErrorIs(err, reservationManager.ErrReservationNotFound)
 }
 
 func (s *reservationTestSuite) TestCancelReservation() {
@@ -75,32 +295,185 @@ func (s *reservationTestSuite) TestCancelReservation() {
 	)
 
 	// Set connector expectations
+	connectorMock.On("IsReserved").Return(true)
+	connectorMock.On("GetReservationId").Return(reservationId)
 	connectorMock.On("RemoveReservation").Return(nil).Once()
 
 	// Set manager expectations
-	managerMock.On("FindConnectorWithReservationId", reservationId).Return(connectorMock)
+	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock)
 	s.cp.connectorManager = managerMock
 
-	response, err := s.cp.OnCancelReservation(reservation.NewCancelReservationRequest(1))
+	err := s.cp.reservations.AddReservation(store.Reservation{
+		ReservationId: reservationId,
+		EvseId:        1,
+		ConnectorId:   connectorId,
+		IdTag:         tagId,
+		ExpiryDate:    time.Now().Add(time.Minute),
+	})
+	s.Require().NoError(err)
+
+	response, err := s.cp.OnCancelReservation(reservation.NewCancelReservationRequest(reservationId))
 	s.Assert().NoError(err)
-	s.Assert().NotNil(response)
 	s.Assert().EqualValues(reservation.CancelReservationStatusAccepted, response.Status)
+	connectorMock.AssertCalled(s.T(), "RemoveReservation")
 
-	// Something went wrong with the reservation
-	connectorMock.On("RemoveReservation").Return(errors.New("something went wrong")).Once()
+	// The reservation was already cancelled
 	response, err = s.cp.OnCancelReservation(reservation.NewCancelReservationRequest(reservationId))
 	s.Assert().NoError(err)
-	s.Assert().NotNil(response)
 	s.Assert().EqualValues(reservation.CancelReservationStatusRejected, response.Status)
 
-	// No connector with the reservation
-	managerMock.On("FindConnectorWithReservationId", 2).Return(nil)
+	// No reservation with the id
 	response, err = s.cp.OnCancelReservation(reservation.NewCancelReservationRequest(2))
 	s.Assert().NoError(err)
-	s.Assert().NotNil(response)
 	s.Assert().EqualValues(reservation.CancelReservationStatusRejected, response.Status)
 }
 
+func (s *reservationTestSuite) TestTransactionReservation() {
+	var (
+		connectorMock = new(test.ConnectorMock)
+		managerMock   = new(test.ManagerMock)
+		authCache     = auth.NewAuthCache(nil)
+	)
+
+	authCache.SetMaxCachedTags(10)
+	authCache.AddTag("groupTagId", types.NewIdTagInfo(types.AuthorizationStatusAccepted))
+	authCache.AddTag("otherTagId", &types.IdTagInfo{Status: types.AuthorizationStatusAccepted, ParentIdTag: "otherParentTagId"})
+	authCache.AddTag("parentTagMember", &types.IdTagInfo{Status: types.AuthorizationStatusAccepted, ParentIdTag: "parentTagId"})
+	s.cp.authCache = authCache
+
+	connectorMock.On("IsReserved").Return(true)
+	connectorMock.On("GetEvseId").Return(1)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+
+	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock)
+	s.cp.connectorManager = managerMock
+
+	err := s.cp.reservations.AddReservation(store.Reservation{
+		ReservationId: reservationId,
+		EvseId:        1,
+		ConnectorId:   connectorId,
+		IdTag:         tagId,
+		ParentIdTag:   "parentTagId",
+		ExpiryDate:    time.Now().Add(time.Minute),
+	})
+	s.Require().NoError(err)
+
+	// The reserving tag
+	id, err := s.cp.getTransactionReservation(connectorMock, tagId)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservationId, *id)
+	s.Assert().Equal(connectorMock, s.cp.findReservedConnector(tagId))
+
+	// A tag with the same parent tag
+	id, err = s.cp.getTransactionReservation(connectorMock, "parentTagMember")
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(reservationId, *id)
+	s.Assert().Equal(connectorMock, s.cp.findReservedConnector("parentTagMember"))
+
+	// A tag with a different parent tag
+	_, err = s.cp.getTransactionReservation(connectorMock, "otherTagId")
+	s.Assert().ErrorIs(err, chargePointErrors.ErrConnectorReserved)
+	s.Assert().Nil(s.cp.findReservedConnector("otherTagId"))
+
+	// A tag without a parent tag
+	_, err = s.cp.getTransactionReservation(connectorMock, "groupTagId")
+	s.Assert().ErrorIs(err, chargePointErrors.ErrConnectorReserved)
+
+	// A connector that is not reserved
+	availableConnector := new(test.ConnectorMock)
+	availableConnector.On("IsReserved").Return(false)
+	availableConnector.On("IsAvailable").Return(true).Once()
+	id, err = s.cp.getTransactionReservation(availableConnector, "otherTagId")
+	s.Assert().NoError(err)
+	s.Assert().Nil(id)
+
+	availableConnector.On("IsAvailable").Return(false).Once()
+	_, err = s.cp.getTransactionReservation(availableConnector, "otherTagId")
+	s.Assert().ErrorIs(err, chargePointErrors.ErrConnectorUnavailable)
+}
+
+func (s *reservationTestSuite) TestRestoreReservations() {
+	var (
+		connectorMock         = new(test.ConnectorMock)
+		otherConnectorMock    = new(test.ConnectorMock)
+		reservedConnectorMock = new(test.ConnectorMock)
+		managerMock           = new(test.ManagerMock)
+	)
+
+	tempDir, err := ioutil.TempDir("", "chargepi")
+	s.Require().NoError(err)
+	defer os.RemoveAll(tempDir)
+
+	repository, err := store.Open(filepath.Join(tempDir, "chargepi.db"))
+	s.Require().NoError(err)
+	defer repository.Close()
+
+	// Reservations from before the restart
+	s.Require().NoError(repository.SaveReservation(store.Reservation{
+		ReservationId: reservationId,
+		EvseId:        1,
+		ConnectorId:   connectorId,
+		IdTag:         tagId,
+		ExpiryDate:    time.Now().Add(time.Minute),
+	}))
+	s.Require().NoError(repository.SaveReservation(store.Reservation{
+		ReservationId: 2,
+		EvseId:        2,
+		ConnectorId:   2,
+		IdTag:         tagId,
+		ExpiryDate:    time.Now().Add(-time.Minute),
+	}))
+	s.Require().NoError(repository.SaveReservation(store.Reservation{
+		ReservationId: 3,
+		EvseId:        3,
+		ConnectorId:   3,
+		IdTag:         tagId,
+		ExpiryDate:    time.Now().Add(time.Minute),
+	}))
+	s.Require().NoError(repository.SaveReservation(store.Reservation{
+		ReservationId: 4,
+		EvseId:        4,
+		ConnectorId:   4,
+		IdTag:         tagId,
+		ExpiryDate:    time.Now().Add(time.Minute),
+	}))
+
+	s.cp.reservations = reservationManager.NewManager(repository, s.cp.onReservationExpired)
+
+	connectorMock.On("IsReserved").Return(false).Once()
+	connectorMock.On("ReserveConnector", reservationId, tagId).Return(nil).Once()
+	connectorMock.On("IsReserved").Return(true)
+	connectorMock.On("GetReservationId").Return(reservationId)
+
+	// The reservation of the connector expired while the charge point was off
+	otherConnectorMock.On("IsReserved").Return(true)
+	otherConnectorMock.On("GetReservationId").Return(0)
+	otherConnectorMock.On("RemoveReservation").Return(nil).Once()
+
+	// The connector was reserved before the boot notification was accepted
+	reservedConnectorMock.On("IsReserved").Return(true)
+	reservedConnectorMock.On("GetReservationId").Return(4)
+
+	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock)
+	managerMock.On("FindConnector", 3, 3).Return(nil)
+	managerMock.On("FindConnector", 4, 4).Return(reservedConnectorMock)
+	managerMock.On("GetConnectors").Return([]connector.Connector{connectorMock, otherConnectorMock, reservedConnectorMock})
+	s.cp.connectorManager = managerMock
+
+	s.cp.restoreReservations()
+
+	connectorMock.AssertCalled(s.T(), "ReserveConnector", reservationId, tagId)
+	connectorMock.AssertNotCalled(s.T(), "RemoveReservation")
+	otherConnectorMock.AssertCalled(s.T(), "RemoveReservation")
+	reservedConnectorMock.AssertNotCalled(s.T(), "ReserveConnector", 4, tagId)
+
+	reservations, err := repository.GetReservations()
+	s.Require().NoError(err)
+	s.Require().Len(reservations, 2)
+	s.Assert().EqualValues(reservationId, reservations[0].ReservationId)
+	s.Assert().EqualValues(4, reservations[1].ReservationId)
+}
+
 func TestReservation(t *testing.T) {
 	log.SetLevel(log.DebugLevel)
 	suite.Run(t, new(reservationTestSuite))
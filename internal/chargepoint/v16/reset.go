@@ -0,0 +1,53 @@
+package v16
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/xBlaz3kx/ChargePi-go/internal/pkg/security"
+	"time"
+)
+
+// resetDelay is the delay before resetting, so the response is sent first.
+const resetDelay = time.Second * 3
+
+// isFirmwareInstalling checks if a firmware update is being installed.
+func (cp *ChargePoint) isFirmwareInstalling() bool {
+	cp.transferMu.Lock()
+	defer cp.transferMu.Unlock()
+
+	return cp.cancelFirmwareUpdate != nil && cp.firmwareStatus == security.FirmwareStatusInstalling
+}
+
+// canReset checks if the reset can be performed.
+func (cp *ChargePoint) canReset(resetType core.ResetType) bool {
+	if cp.isFirmwareInstalling() {
+		cp.logger.Warn("Rejected the reset, the firmware is being installed")
+		return false
+	}
+
+	switch resetType {
+	case core.ResetTypeHard:
+		return cp.rebootHook != nil
+	case core.ResetTypeSoft:
+		return cp.softResetHook != nil
+	default:
+		return false
+	}
+}
+
+// reset stops the transactions, cleans up the charge point and restarts the charge point or reboots the system.
+func (cp *ChargePoint) reset(resetType core.ResetType) {
+	cp.logger.Infof("Performing a %s reset", resetType)
+
+	switch resetType {
+	case core.ResetTypeHard:
+		cp.CleanUp(core.ReasonHardReset)
+
+		err := cp.rebootHook()
+		if err != nil {
+			cp.logger.WithError(err).Error("Unable to reboot the system")
+		}
+	case core.ResetTypeSoft:
+		cp.CleanUp(core.ReasonSoftReset)
+		cp.softResetHook()
+	}
+}
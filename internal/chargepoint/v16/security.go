@@ -0,0 +1,521 @@
+package v16
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/agrison/go-commons-lang/stringUtils"
+	"github.com/gorilla/websocket"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/remotetrigger"
+	"github.com/lorenzodonini/ocpp-go/ws"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/certificates"
+	"github.com/xBlaz3kx/ChargePi-go/internal/pkg/security"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Security events, which are sent to the central system
+const (
+	SecurityEventStartupOfTheDevice                  = "StartupOfTheDevice"
+	SecurityEventReconfigurationOfSecurityParameters = "ReconfigurationOfSecurityParameters"
+	SecurityEventInvalidChargePointCertificate       = "InvalidChargePointCertificate"
+	SecurityEventInvalidCentralSystemCertificate     = "InvalidCentralSystemCertificate"
+	SecurityEventFailedToAuthenticateAtCentralSystem = "FailedToAuthenticateAtCentralSystem"
+	SecurityEventResetOrReboot                       = "ResetOrReboot"
+)
+
+// Events, which are only written to the security log
+const (
+	securityLogChargePointCertificateRotated = "ChargePointCertificateRotated"
+	securityLogCertificateInstalled          = "CertificateInstalled"
+	securityLogCertificateDeleted            = "CertificateDeleted"
+)
+
+// reconnectDelay is the delay before reconnecting with the new security parameters, so the response is sent first.
+const reconnectDelay = time.Second * 2
+
+// setupSecurity wraps the websocket client, so the messages of the security extension are handled by the charge point.
+// The TLS configuration and the credentials are applied according to the security profile every time the client connects.
+func (cp *ChargePoint) setupSecurity(wsClient *ws.Client) ws.WsClient {
+	cp.wsClient = wsClient
+	cp.wsClient.AddOption(func(dialer *websocket.Dialer) {
+		if tlsConfig := cp.getSecurityTLSConfig(); tlsConfig != nil {
+			dialer.TLSClientConfig = tlsConfig
+		}
+	})
+	cp.applySecurityProfile()
+
+	cp.securityClient = security.NewClient(cp.wsClient)
+	cp.securityClient.SetHandler(cp)
+	return cp.securityClient
+}
+
+// getSecurityProfile returns the security profile from the OCPP configuration. Defaults to 0, which is unsecured.
+func getSecurityProfile() int {
+	value, err := ocppManager.GetConfigurationValue(security.SecurityProfileKey.String())
+	if err != nil {
+		return 0
+	}
+
+	profile, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+
+	return profile
+}
+
+// applySecurityProfile sets the HTTP basic authentication credentials of the security profile.
+func (cp *ChargePoint) applySecurityProfile() {
+	currentSettings := cp.getSettings()
+	if cp.wsClient == nil || currentSettings == nil {
+		return
+	}
+
+	var (
+		info                = currentSettings.ChargePoint.Info
+		authorizationKey, _ = ocppManager.GetConfigurationValue(security.AuthorizationKeyKey.String())
+	)
+
+	switch getSecurityProfile() {
+	case 1, 2:
+		// The username is the charge point id and the password is the authorization key
+		if stringUtils.IsNotEmpty(authorizationKey) {
+			cp.wsClient.SetBasicAuth(info.Id, authorizationKey)
+		}
+	case 3:
+		// The charge point authenticates with the client certificate
+		cp.wsClient.SetHeaderValue("Authorization", "")
+	}
+}
+
+// getSecurityTLSConfig returns the TLS configuration for the security profiles 2 and 3 or nil for the other profiles.
+func (cp *ChargePoint) getSecurityTLSConfig() *tls.Config {
+	profile := getSecurityProfile()
+	if profile < 2 {
+		return nil
+	}
+
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+
+	if cp.certificateManager != nil {
+		if pool := cp.certificateManager.GetCertificatePool(certificates.CentralSystemRootCertificate); pool != nil {
+			// Only the installed root certificates of the central system are trusted
+			rootCAs = pool
+		}
+	}
+
+	tlsSettings := cp.getSettings().ChargePoint.TLS
+	if tlsSettings.IsEnabled && stringUtils.IsNotEmpty(tlsSettings.CACertificatePath) {
+		caCertificate, err := ioutil.ReadFile(tlsSettings.CACertificatePath)
+		if err == nil {
+			rootCAs.AppendCertsFromPEM(caCertificate)
+		}
+	}
+
+	tlsConfig := &tls.Config{
+		RootCAs:    rootCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if profile == 3 {
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cp.getClientCertificate()
+		}
+	}
+
+	return tlsConfig
+}
+
+// getClientCertificate returns the installed charge point certificate or the certificate from the settings.
+func (cp *ChargePoint) getClientCertificate() (*tls.Certificate, error) {
+	if cp.certificateManager != nil {
+		certificate, err := cp.certificateManager.GetChargePointCertificate()
+		if err == nil {
+			return certificate, nil
+		}
+	}
+
+	tlsSettings := cp.getSettings().ChargePoint.TLS
+	if stringUtils.IsAnyEmpty(tlsSettings.ClientCertificatePath, tlsSettings.ClientKeyPath) {
+		return &tls.Certificate{}, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(tlsSettings.ClientCertificatePath, tlsSettings.ClientKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &certificate, nil
+}
+
+// getConnectionUrl returns the url of the central system. The security profiles 2 and 3 require a secure connection.
+func (cp *ChargePoint) getConnectionUrl() string {
+	if getSecurityProfile() >= 2 && strings.HasPrefix(cp.serverUrl, "ws://") {
+		return strings.Replace(cp.serverUrl, "ws://", "wss://", 1)
+	}
+
+	return cp.serverUrl
+}
+
+// changeSecurityConfiguration validates and applies the SecurityProfile and AuthorizationKey keys.
+func (cp *ChargePoint) changeSecurityConfiguration(key, value string) core.ConfigurationStatus {
+	var (
+		currentProfile      = getSecurityProfile()
+		authorizationKey, _ = ocppManager.GetConfigurationValue(security.AuthorizationKeyKey.String())
+	)
+
+	switch key {
+	case security.SecurityProfileKey.String():
+		profile, err := strconv.Atoi(value)
+		if err != nil || profile < 0 || profile > 3 {
+			return core.ConfigurationStatusRejected
+		}
+
+		// The security profile can only be increased
+		if profile <= currentProfile {
+			cp.logger.Warnf("Rejected lowering the security profile from %d to %d", currentProfile, profile)
+			return core.ConfigurationStatusRejected
+		}
+
+		if !cp.isSecurityProfileSupported(profile, authorizationKey) {
+			return core.ConfigurationStatusRejected
+		}
+	case security.AuthorizationKeyKey.String():
+		if len(value) < 16 || len(value) > 40 {
+			return core.ConfigurationStatusRejected
+		}
+	default:
+		return core.ConfigurationStatusNotSupported
+	}
+
+	previousValue, _ := ocppManager.GetConfigurationValue(key)
+
+	err := ocppManager.UpdateKey(key, value)
+	if err != nil {
+		return core.ConfigurationStatusRejected
+	}
+
+	err = ocppManager.UpdateConfigurationFile()
+	if err != nil {
+		return core.ConfigurationStatusRejected
+	}
+
+	// Reconnect with the new security parameters after the response is sent
+	if key == security.SecurityProfileKey.String() || currentProfile == 1 || currentProfile == 2 {
+		time.AfterFunc(reconnectDelay, func() {
+			cp.reconnect(key, previousValue)
+		})
+	}
+
+	return core.ConfigurationStatusAccepted
+}
+
+// isSecurityProfileSupported checks if the charge point has the credentials and certificates needed for the profile.
+func (cp *ChargePoint) isSecurityProfileSupported(profile int, authorizationKey string) bool {
+	if (profile == 1 || profile == 2) && stringUtils.IsEmpty(authorizationKey) {
+		return false
+	}
+
+	if profile >= 2 && (cp.certificateManager == nil ||
+		cp.certificateManager.GetCertificatePool(certificates.CentralSystemRootCertificate) == nil) {
+		return false
+	}
+
+	if profile == 3 {
+		if cp.certificateManager == nil {
+			return false
+		}
+
+		_, err := cp.certificateManager.GetChargePointCertificate()
+		return err == nil
+	}
+
+	return true
+}
+
+// reconnect reconnects to the central system with the new security parameters. If the connection fails,
+// the previous value of the key is restored and the charge point reconnects with the previous parameters.
+func (cp *ChargePoint) reconnect(key, previousValue string) {
+	cp.logger.Infof("Reconnecting to the central system with the new security parameters")
+
+	cp.chargePoint.Stop()
+	cp.applySecurityProfile()
+
+	err := cp.chargePoint.Start(cp.getConnectionUrl())
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Unable to connect with the new security parameters, reverting %s", key)
+
+		_ = ocppManager.UpdateKey(key, previousValue)
+		_ = ocppManager.UpdateConfigurationFile()
+		cp.applySecurityProfile()
+
+		reconnectErr := cp.chargePoint.Start(cp.getConnectionUrl())
+		if reconnectErr != nil {
+			cp.logger.WithError(reconnectErr).Errorf("Unable to reconnect to the central system")
+			return
+		}
+
+		cp.sendSecurityEvent(SecurityEventFailedToAuthenticateAtCentralSystem, err.Error())
+		return
+	}
+
+	cp.sendSecurityEvent(SecurityEventReconfigurationOfSecurityParameters, key)
+}
+
+// sendSecurityEvent notifies the central system about a security event.
+func (cp *ChargePoint) sendSecurityEvent(eventType, techInfo string) {
+	cp.logSecurityEvent(eventType, techInfo)
+
+	if cp.securityClient == nil {
+		return
+	}
+
+	logInfo := cp.logger.WithField("type", eventType)
+	logInfo.Info("Sending a security event")
+
+	if len(techInfo) > 255 {
+		techInfo = techInfo[:255]
+	}
+
+	err := cp.securityClient.SendRequestAsync(
+		security.NewSecurityEventNotificationRequest(eventType, techInfo),
+		func(response ocpp.Response, err error) {
+			if err != nil {
+				logInfo.WithError(err).Errorf("Central system responded with an error to the security event")
+			}
+		})
+	if err != nil {
+		logInfo.WithError(err).Errorf("Unable to send the security event")
+	}
+}
+
+// logSecurityEvent writes the security event to the security log.
+func (cp *ChargePoint) logSecurityEvent(eventType, techInfo string) {
+	if cp.securityLogger == nil {
+		return
+	}
+
+	cp.securityLogger.WithFields(log.Fields{
+		"chargePointId": cp.getSettings().ChargePoint.Info.Id,
+		"type":          eventType,
+		"techInfo":      techInfo,
+	}).Info("Security event")
+}
+
+// signChargePointCertificate generates a new key and sends the certificate signing request to the central system.
+func (cp *ChargePoint) signChargePointCertificate() {
+	if cp.certificateManager == nil {
+		return
+	}
+
+	organization, _ := ocppManager.GetConfigurationValue(security.CpoNameKey.String())
+
+	csr, err := cp.certificateManager.GenerateCSR(cp.getSettings().ChargePoint.Info.Id, organization)
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Unable to generate the certificate signing request")
+		return
+	}
+
+	err = cp.securityClient.SendRequestAsync(&security.SignCertificateRequest{CSR: string(csr)},
+		func(response ocpp.Response, err error) {
+			if err != nil {
+				cp.logger.WithError(err).Errorf("Central system responded with an error to the signing request")
+				return
+			}
+
+			if response.(*security.SignCertificateConfirmation).Status != security.GenericStatusAccepted {
+				cp.logger.Warn("Central system rejected the certificate signing request")
+			}
+		})
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Unable to send the certificate signing request")
+	}
+}
+
+func (cp *ChargePoint) OnExtendedTriggerMessage(request *security.ExtendedTriggerMessageRequest) (*security.ExtendedTriggerMessageConfirmation, error) {
+	cp.logger.Infof("Received %s for %v", request.GetFeatureName(), request.RequestedMessage)
+
+	switch request.RequestedMessage {
+	case security.MessageTriggerSignChargePointCertificate:
+		if cp.certificateManager == nil {
+			return &security.ExtendedTriggerMessageConfirmation{Status: security.TriggerMessageStatusRejected}, nil
+		}
+
+		// Send the signing request after the response
+		time.AfterFunc(time.Second, cp.signChargePointCertificate)
+		return &security.ExtendedTriggerMessageConfirmation{Status: security.TriggerMessageStatusAccepted}, nil
+	case security.MessageTriggerFirmwareStatusNotification:
+		time.AfterFunc(time.Second, cp.sendCurrentFirmwareStatus)
+		return &security.ExtendedTriggerMessageConfirmation{Status: security.TriggerMessageStatusAccepted}, nil
+	case security.MessageTriggerLogStatusNotification:
+		time.AfterFunc(time.Second, cp.sendCurrentLogStatus)
+		return &security.ExtendedTriggerMessageConfirmation{Status: security.TriggerMessageStatusAccepted}, nil
+	default:
+		// The other messages are the same as with the TriggerMessage
+		response, err := cp.OnTriggerMessage(
+			remotetrigger.NewTriggerMessageRequest(remotetrigger.MessageTrigger(request.RequestedMessage)),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return &security.ExtendedTriggerMessageConfirmation{Status: security.TriggerMessageStatus(response.Status)}, nil
+	}
+}
+
+func (cp *ChargePoint) OnCertificateSigned(request *security.CertificateSignedRequest) (*security.CertificateSignedConfirmation, error) {
+	cp.logger.Infof("Received %s", request.GetFeatureName())
+
+	if cp.certificateManager == nil {
+		return &security.CertificateSignedConfirmation{Status: security.GenericStatusRejected}, nil
+	}
+
+	maxChainSize, err := ocppManager.GetConfigurationValue(security.CertificateSignedMaxChainSizeKey.String())
+	if size, convErr := strconv.Atoi(maxChainSize); err == nil && convErr == nil && size > 0 && len(request.CertificateChain) > size {
+		return &security.CertificateSignedConfirmation{Status: security.GenericStatusRejected}, nil
+	}
+
+	err = cp.certificateManager.InstallChargePointCertificate([]byte(request.CertificateChain))
+	if err != nil {
+		cp.logger.WithError(err).Errorf("Rejected the charge point certificate")
+		defer cp.sendSecurityEvent(SecurityEventInvalidChargePointCertificate, err.Error())
+		return &security.CertificateSignedConfirmation{Status: security.GenericStatusRejected}, nil
+	}
+
+	// The new certificate is used when reconnecting with the security profile 3
+	if getSecurityProfile() == 3 {
+		time.AfterFunc(reconnectDelay, func() {
+			cp.chargePoint.Stop()
+			err := cp.chargePoint.Start(cp.getConnectionUrl())
+			if err != nil {
+				cp.logger.WithError(err).Errorf("Unable to reconnect with the new certificate")
+			}
+		})
+	}
+
+	cp.logSecurityEvent(securityLogChargePointCertificateRotated, "")
+	return &security.CertificateSignedConfirmation{Status: security.GenericStatusAccepted}, nil
+}
+
+func (cp *ChargePoint) OnInstallCertificate(request *security.InstallCertificateRequest) (*security.InstallCertificateConfirmation, error) {
+	cp.logger.Infof("Received %s for %s", request.GetFeatureName(), request.CertificateType)
+
+	if cp.certificateManager == nil {
+		return &security.InstallCertificateConfirmation{Status: security.CertificateStatusRejected}, nil
+	}
+
+	err := cp.certificateManager.InstallCertificate(certificates.Use(request.CertificateType), []byte(request.Certificate))
+	switch {
+	case err == nil:
+		cp.logSecurityEvent(securityLogCertificateInstalled, string(request.CertificateType))
+		return &security.InstallCertificateConfirmation{Status: security.CertificateStatusAccepted}, nil
+	case errors.Is(err, certificates.ErrInvalidCertificate):
+		cp.logger.WithError(err).Errorf("Rejected the certificate")
+		if request.CertificateType == security.CertificateUseCentralSystemRoot {
+			defer cp.sendSecurityEvent(SecurityEventInvalidCentralSystemCertificate, err.Error())
+		}
+
+		return &security.InstallCertificateConfirmation{Status: security.CertificateStatusRejected}, nil
+	default:
+		cp.logger.WithError(err).Errorf("Unable to install the certificate")
+		return &security.InstallCertificateConfirmation{Status: security.CertificateStatusFailed}, nil
+	}
+}
+
+func (cp *ChargePoint) OnDeleteCertificate(request *security.DeleteCertificateRequest) (*security.DeleteCertificateConfirmation, error) {
+	cp.logger.Infof("Received %s", request.GetFeatureName())
+
+	if cp.certificateManager == nil {
+		return &security.DeleteCertificateConfirmation{Status: security.DeleteCertificateStatusNotFound}, nil
+	}
+
+	hashData := certificates.HashData{
+		HashAlgorithm:  certificates.HashAlgorithm(request.CertificateHashData.HashAlgorithm),
+		IssuerNameHash: request.CertificateHashData.IssuerNameHash,
+		IssuerKeyHash:  request.CertificateHashData.IssuerKeyHash,
+		SerialNumber:   request.CertificateHashData.SerialNumber,
+	}
+
+	// The last root certificate of the central system cannot be deleted while it is used for the connection
+	if getSecurityProfile() >= 2 && cp.isLastCentralSystemCertificate(hashData) {
+		return &security.DeleteCertificateConfirmation{Status: security.DeleteCertificateStatusFailed}, nil
+	}
+
+	use, err := cp.certificateManager.DeleteCertificate(hashData)
+
+	switch {
+	case err == nil:
+		cp.logger.Infof("Deleted a %s", use)
+		cp.logSecurityEvent(securityLogCertificateDeleted, string(use))
+		return &security.DeleteCertificateConfirmation{Status: security.DeleteCertificateStatusAccepted}, nil
+	case errors.Is(err, certificates.ErrCertificateNotFound):
+		return &security.DeleteCertificateConfirmation{Status: security.DeleteCertificateStatusNotFound}, nil
+	default:
+		cp.logger.WithError(err).Errorf("Unable to delete the certificate")
+		return &security.DeleteCertificateConfirmation{Status: security.DeleteCertificateStatusFailed}, nil
+	}
+}
+
+func (cp *ChargePoint) OnGetInstalledCertificateIds(request *security.GetInstalledCertificateIdsRequest) (*security.GetInstalledCertificateIdsConfirmation, error) {
+	cp.logger.Infof("Received %s for %s", request.GetFeatureName(), request.CertificateType)
+
+	response := &security.GetInstalledCertificateIdsConfirmation{Status: security.GetInstalledCertificateStatusNotFound}
+	if cp.certificateManager == nil {
+		return response, nil
+	}
+
+	installed, err := cp.certificateManager.GetInstalledCertificateIds(certificates.Use(request.CertificateType))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hashData := range installed {
+		response.CertificateHashData = append(response.CertificateHashData, security.CertificateHashData{
+			HashAlgorithm:  security.HashAlgorithm(hashData.HashAlgorithm),
+			IssuerNameHash: hashData.IssuerNameHash,
+			IssuerKeyHash:  hashData.IssuerKeyHash,
+			SerialNumber:   hashData.SerialNumber,
+		})
+	}
+
+	if len(response.CertificateHashData) > 0 {
+		response.Status = security.GetInstalledCertificateStatusAccepted
+	}
+
+	return response, nil
+}
+
+// isLastCentralSystemCertificate checks if the certificate is the only installed root certificate of the central system.
+func (cp *ChargePoint) isLastCentralSystemCertificate(hashData certificates.HashData) bool {
+	installed, err := cp.certificateManager.GetInstalledCertificateIds(certificates.CentralSystemRootCertificate)
+	if err != nil || len(installed) != 1 {
+		return false
+	}
+
+	// The hashes depend on the algorithm, so the certificate is matched by the serial number
+	return strings.EqualFold(installed[0].SerialNumber, hashData.SerialNumber)
+}
+
+// hideAuthorizationKey removes the value of the write-only AuthorizationKey.
+func hideAuthorizationKey(keys []core.ConfigurationKey) []core.ConfigurationKey {
+	result := make([]core.ConfigurationKey, 0, len(keys))
+	for _, key := range keys {
+		if key.Key == security.AuthorizationKeyKey.String() {
+			key.Value = ""
+		}
+
+		result = append(result, key)
+	}
+
+	return result
+}
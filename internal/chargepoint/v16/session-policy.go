@@ -0,0 +1,152 @@
+package v16
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/policy"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"strconv"
+	"time"
+)
+
+// policyCheckInterval is the interval in seconds at which the session policy is evaluated.
+const policyCheckInterval = 10
+
+// newSessionPolicy creates the policy for the session on the connector. The policy of the tag group is selected by the parent id tag.
+func (cp *ChargePoint) newSessionPolicy(c connector.Connector, parentIdTag string) (policy.Policy, error) {
+	var (
+		connectorPolicy settings.SessionPolicy
+		policies        settings.SessionPolicies
+		minPower        float64
+	)
+
+	if connectorSettings := cp.findConnectorSettings(c.GetEvseId(), c.GetConnectorId()); connectorSettings != nil {
+		connectorPolicy = connectorSettings.SessionPolicy
+	}
+
+	if currentSettings := cp.getSettings(); currentSettings != nil {
+		policies = currentSettings.ChargePoint.SessionPolicies
+		minPower = float64(currentSettings.ChargePoint.Hardware.PowerMeters.MinPower)
+	}
+
+	return policy.NewPolicy(policies, connectorPolicy, parentIdTag, c.GetMaxChargingTime(), minPower)
+}
+
+// newSessionEvaluator creates the evaluator of the session policy, which starts with the current meter reading.
+func (cp *ChargePoint) newSessionEvaluator(c connector.Connector, parentIdTag string, started time.Time) (*policy.Evaluator, error) {
+	sessionPolicy, err := cp.newSessionPolicy(c, parentIdTag)
+	if err != nil {
+		return nil, err
+	}
+
+	return policy.NewEvaluator(sessionPolicy, readPolicySample(c, started)), nil
+}
+
+// restoreSessionEvaluator creates the evaluator of the session, which was active before the restart. The evaluator
+// continues with the energy and the meter reading persisted with the session and applies the policy of its tag group.
+func (cp *ChargePoint) restoreSessionEvaluator(c connector.Connector, session settings.Session, started time.Time) (*policy.Evaluator, error) {
+	sessionPolicy, err := cp.newSessionPolicy(c, session.ParentIdTag)
+	if err != nil {
+		return nil, err
+	}
+
+	first := readPolicySample(c, started)
+	if session.MeterReading > 0 {
+		first.Energy = session.MeterReading
+	}
+
+	evaluator := policy.NewEvaluator(sessionPolicy, first)
+	evaluator.SetEnergy(session.Energy)
+	return evaluator, nil
+}
+
+// readPolicySample reads the energy and the power of the connector at the specified time.
+func readPolicySample(c connector.Connector, at time.Time) policy.Sample {
+	sample := readMeter(c)
+	return policy.Sample{Time: at, Energy: sample.Energy, Power: sample.Power}
+}
+
+// startSessionPolicy periodically evaluates the session policy and stops the transaction with the reason of the violated rule.
+// If the power meter is not available, only the duration of the session is limited. The consumed energy is persisted
+// with the session, so the energy limits still apply after a restart.
+func (cp *ChargePoint) startSessionPolicy(c connector.Connector, evaluator *policy.Evaluator) {
+	var (
+		jobTag  = fmt.Sprintf("connector%dPolicy", c.GetConnectorId())
+		logInfo = cp.logger.WithFields(log.Fields{
+			"evseId":      c.GetEvseId(),
+			"connectorId": c.GetConnectorId(),
+		})
+	)
+
+	// Persist the meter reading the session started with, so the energy is known after a restart
+	if !util.IsNilInterfaceOrPointer(c.GetPowerMeter()) {
+		c.SetSessionEnergy(evaluator.GetEnergy(), evaluator.GetMeterReading())
+	}
+
+	evaluate := func() {
+		var (
+			rule       *policy.Rule
+			powerMeter = c.GetPowerMeter()
+		)
+
+		if util.IsNilInterfaceOrPointer(powerMeter) {
+			rule = evaluator.CheckDuration(time.Now())
+		} else {
+			var (
+				energy = evaluator.GetEnergy()
+				sample = readPolicySample(c, time.Now())
+			)
+
+			rule = evaluator.Evaluate(sample)
+			if evaluator.GetEnergy() != energy {
+				c.SetSessionEnergy(evaluator.GetEnergy(), sample.Energy)
+			}
+		}
+
+		if rule == nil {
+			return
+		}
+
+		logInfo.WithField("rule", *rule).Infof("Session policy violated, stopping the transaction")
+
+		err := cp.scheduler.RemoveByTag(jobTag)
+		if err != nil {
+			logInfo.WithError(err).Errorf("Cannot remove session policy schedule")
+		}
+
+		err = cp.stopChargingConnector(c, rule.Reason())
+		if err != nil {
+			logInfo.WithError(err).Errorf("Unable to stop charging")
+		}
+	}
+
+	_, err := cp.scheduler.Every(policyCheckInterval).Seconds().SingletonMode().Tag(jobTag).Do(evaluate)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Cannot schedule session policy")
+	}
+}
+
+// getMaxEnergyOnInvalidId returns the MaxEnergyOnInvalidId in Wh. If the transaction should not be stopped or the key
+// is not set, it returns zero.
+func getMaxEnergyOnInvalidId() float64 {
+	stopTransactionOnInvalidId, err := ocppConfigManager.GetConfigurationValue(v16.StopTransactionOnInvalidId.String())
+	if err == nil && stopTransactionOnInvalidId == "false" {
+		return 0
+	}
+
+	maxEnergyString, err := ocppConfigManager.GetConfigurationValue(v16.MaxEnergyOnInvalidId.String())
+	if err != nil {
+		return 0
+	}
+
+	maxEnergy, err := strconv.ParseFloat(maxEnergyString, 64)
+	if err != nil || maxEnergy < 0 {
+		return 0
+	}
+
+	return maxEnergy
+}
@@ -1,7 +1,6 @@
 package v16
 
 import (
-	"fmt"
 	"github.com/lorenzodonini/ocpp-go/ocpp"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
@@ -13,8 +12,13 @@ import (
 	"time"
 )
 
-// startCharging Start charging on the first available Connector. If there is no available Connector, reject the request.
+// startCharging Start charging on the Connector reserved for the tag or on the first available Connector.
+// If there is no available Connector, reject the request.
 func (cp *ChargePoint) startCharging(tagId string) error {
+	if c := cp.findReservedConnector(tagId); !util.IsNilInterfaceOrPointer(c) {
+		return cp.startChargingConnector(c, tagId)
+	}
+
 	if c := cp.connectorManager.FindAvailableConnector(); !util.IsNilInterfaceOrPointer(c) {
 		return cp.startChargingConnector(c, tagId)
 	}
@@ -24,7 +28,7 @@ func (cp *ChargePoint) startCharging(tagId string) error {
 
 // startChargingConnector Start charging a connector with the specified ID.
 // Send the request to the Central System, turn on and update the status of the Connector,
-// start the timer and sample the PowerMeter, if it's enabled.
+// start the session policy and sample the PowerMeter, if it's enabled.
 func (cp *ChargePoint) startChargingConnector(connector connector.Connector, tagId string) error {
 	if util.IsNilInterfaceOrPointer(connector) {
 		return errors.ErrConnectorNil
@@ -36,24 +40,45 @@ func (cp *ChargePoint) startChargingConnector(connector connector.Connector, tag
 		"tagId":       tagId,
 	})
 
-	if !connector.IsAvailable() {
-		return errors.ErrConnectorUnavailable
+	reservationId, err := cp.getTransactionReservation(connector, tagId)
+	if err != nil {
+		return err
 	}
 
-	if cp.availability != core.AvailabilityTypeOperative {
+	if cp.isEvseInUse(connector) {
+		return errors.ErrEvseInUse
+	}
+
+	if !cp.isChargePointOperative() {
 		return errors.ErrChargePointUnavailable
 	}
 
+	if !cp.isConnectorOperative(connector) {
+		return errors.ErrConnectorUnavailable
+	}
+
+	// Free vend transactions are started without authorization
+	if cp.isFreeVendTag(connector, tagId) {
+		return cp.startFreeVendTransaction(connector, tagId, reservationId)
+	}
+
 	if !cp.isTagAuthorized(tagId) {
 		return errors.ErrTagUnauthorized
 	}
 
+	// The connector policy is checked before the transaction is started, the group policies when the settings are loaded
+	_, err = cp.newSessionPolicy(connector, "")
+	if err != nil {
+		return err
+	}
+
 	request := core.NewStartTransactionRequest(
 		connector.GetConnectorId(),
 		tagId,
 		0,
 		types.NewDateTime(time.Now()),
 	)
+	request.ReservationId = reservationId
 
 	callback := func(confirmation ocpp.Response, protoError error) {
 		if protoError != nil {
@@ -63,29 +88,51 @@ func (cp *ChargePoint) startChargingConnector(connector connector.Connector, tag
 
 		startTransactionConf := confirmation.(*core.StartTransactionConfirmation)
 
-		switch startTransactionConf.IdTagInfo.Status {
-		case types.AuthorizationStatusAccepted, types.AuthorizationStatusConcurrentTx:
-			// Attempt to start charging
-			err := connector.StartCharging(strconv.Itoa(startTransactionConf.TransactionId), tagId)
-			if err != nil {
-				logInfo.WithError(err).Errorf("Unable to start charging connector")
-				return
-			}
+		idTagInfo := startTransactionConf.IdTagInfo
 
-			logInfo.Infof("Started charging connector at %s", time.Now())
+		evaluator, err := cp.newSessionEvaluator(connector, idTagInfo.ParentIdTag, time.Now())
+		if err != nil {
+			logInfo.WithError(err).Errorf("Invalid session policy, stopping the transaction")
+			cp.stopUnstartedTransaction(startTransactionConf.TransactionId)
+			return
+		}
 
-			// Schedule timer to stop the transaction at the time limit
-			_, err = cp.scheduler.Every(connector.GetMaxChargingTime()).Minutes().LimitRunsTo(1).
-				Tag(fmt.Sprintf("connector%dTimer", connector.GetConnectorId())).Do(cp.stopChargingConnector, connector, core.ReasonOther)
-			if err != nil {
-				logInfo.WithError(err).Errorf("Cannot schedule stop charging")
-			}
+		switch idTagInfo.Status {
+		case types.AuthorizationStatusAccepted, types.AuthorizationStatusConcurrentTx:
 			break
 		case types.AuthorizationStatusBlocked, types.AuthorizationStatusInvalid, types.AuthorizationStatusExpired:
 			fallthrough
 		default:
-			logInfo.Errorf("Transaction unauthorized")
+			// The energy is limited only if it can be measured
+			maxEnergy := getMaxEnergyOnInvalidId()
+			if maxEnergy <= 0 || util.IsNilInterfaceOrPointer(connector.GetPowerMeter()) {
+				logInfo.Errorf("Transaction unauthorized")
+				return
+			}
+
+			logInfo.Infof("Transaction unauthorized, limiting the energy to %.0f Wh", maxEnergy)
+			evaluator.LimitEnergyOnInvalidId(maxEnergy)
 		}
+
+		// Attempt to start charging
+		err = connector.StartCharging(strconv.Itoa(startTransactionConf.TransactionId), tagId)
+		if err != nil {
+			logInfo.WithError(err).Errorf("Unable to start charging connector")
+			return
+		}
+
+		// The group of the tag is kept with the session, so the tags of the group can stop it
+		connector.SetSessionParentIdTag(idTagInfo.ParentIdTag)
+		logInfo.Infof("Started charging connector at %s", time.Now())
+
+		// The reservation is used by the transaction
+		if reservationId != nil {
+			_, _ = cp.reservations.RemoveReservation(*reservationId)
+		}
+
+		// Stop the transaction when the session policy is violated
+		cp.startSessionPolicy(connector, evaluator)
+		cp.startCostTracking(connector, tagId, time.Now())
 	}
 
 	return util.SendRequest(cp.chargePoint, request, callback)
@@ -6,24 +6,33 @@ import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	log "github.com/sirupsen/logrus"
-	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	chargePointConnector "github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
 	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
 	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
 	"strconv"
+	"sync"
 	"time"
 )
 
+// cleanUpTimeout is the time the central system has to confirm the transactions stopped at the clean up.
+const cleanUpTimeout = time.Second * 10
+
 // stopChargingConnector Stop charging a connector with the specified ID. Update the status(es), turn off the ConnectorImpl and calculate the energy consumed.
-func (cp *ChargePoint) stopChargingConnector(connector connector.Connector, reason core.Reason) error {
+func (cp *ChargePoint) stopChargingConnector(connector chargePointConnector.Connector, reason core.Reason) error {
+	return cp.stopChargingConnectorByTag(connector, "", reason)
+}
+
+// stopChargingConnectorByTag stops charging the connector like stopChargingConnector. The StopTransaction request
+// contains the tag that stopped the session, if it is not empty.
+func (cp *ChargePoint) stopChargingConnectorByTag(connector chargePointConnector.Connector, tagId string, reason core.Reason) error {
 	if util.IsNilInterfaceOrPointer(connector) {
 		return errors.ErrConnectorNil
 	}
 
 	var (
 		stopTransactionOnEVDisconnect, err = ocppConfigManager.GetConfigurationValue(v16.StopTransactionOnEVSideDisconnect.String())
-		transactionId, convErr             = strconv.Atoi(connector.GetTransactionId())
 		logInfo                            = cp.logger.WithFields(log.Fields{
 			"evseId":      connector.GetEvseId(),
 			"connectorId": connector.GetConnectorId(),
@@ -35,6 +44,12 @@ func (cp *ChargePoint) stopChargingConnector(connector connector.Connector, reas
 		stopTransactionOnEVDisconnect = "true"
 	}
 
+	// Free vend transactions are stopped without waiting for the central system
+	if cp.isFreeVendTransaction(connector.GetTransactionId()) {
+		return cp.stopFreeVendTransaction(connector, reason)
+	}
+
+	transactionId, convErr := strconv.Atoi(connector.GetTransactionId())
 	if convErr != nil {
 		return convErr
 	}
@@ -43,8 +58,9 @@ func (cp *ChargePoint) stopChargingConnector(connector connector.Connector, reas
 		return errors.ErrConnectorNotCharging
 	}
 
+	// The session ends locally without notifying the central system
 	if stopTransactionOnEVDisconnect != "true" && reason == core.ReasonEVDisconnected {
-		return connector.StopCharging(reason)
+		return cp.endTransaction(connector, strconv.Itoa(transactionId), reason)
 	}
 
 	request := core.NewStopTransactionRequest(
@@ -53,6 +69,9 @@ func (cp *ChargePoint) stopChargingConnector(connector connector.Connector, reas
 		transactionId,
 	)
 	request.Reason = reason
+	if tagId != "" {
+		request.IdTag = tagId
+	}
 
 	var callback = func(confirmation ocpp.Response, protoError error) {
 		if protoError != nil {
@@ -61,28 +80,137 @@ func (cp *ChargePoint) stopChargingConnector(connector connector.Connector, reas
 		}
 
 		logInfo.Info("Stopping transaction")
-		err = connector.StopCharging(reason)
-		if err != nil {
-			logInfo.WithError(err).Errorf("Unable to stop charging")
-			return
+		cp.endTransaction(connector, strconv.Itoa(transactionId), reason)
+	}
+
+	return util.SendRequest(cp.chargePoint, request, callback)
+}
+
+// stopUnstartedTransaction stops the transaction, which was accepted by the central system, but could not be started on
+// the connector.
+func (cp *ChargePoint) stopUnstartedTransaction(transactionId int) {
+	request := core.NewStopTransactionRequest(0, types.NewDateTime(time.Now()), transactionId)
+	request.Reason = core.ReasonOther
+
+	callback := func(confirmation ocpp.Response, protoError error) {
+		if protoError != nil {
+			cp.logger.WithError(protoError).Errorf("Server responded with error for stopping a transaction")
+		}
+	}
+
+	err := util.SendRequest(cp.chargePoint, request, callback)
+	util.HandleRequestErr(err, "Cannot stop the transaction")
+}
+
+// stopAllTransactions ends the transactions of all the connectors before the client stops. Each transaction is ended
+// locally first, so the relays are turned off and the sessions are not resumed after a restart, even if the central
+// system does not respond. It waits until the StopTransaction requests are confirmed or the timeout expires.
+func (cp *ChargePoint) stopAllTransactions(reason core.Reason, timeout time.Duration) {
+	var wg sync.WaitGroup
+
+	for _, c := range cp.connectorManager.GetConnectors() {
+		if !(c.IsCharging() || c.IsPreparing()) {
+			continue
 		}
 
-		schedulerErr := cp.scheduler.RemoveByTag(fmt.Sprintf("connector%dSampling", connector.GetConnectorId()))
-		if schedulerErr != nil {
-			logInfo.WithError(err).Errorf("Cannot remove sampling schedule")
+		// Free vend transactions are ended without waiting for the central system
+		if cp.isFreeVendTransaction(c.GetTransactionId()) {
+			err := cp.stopFreeVendTransaction(c, reason)
+			if err != nil {
+				cp.logger.WithError(err).Errorf("Cannot stop the free vend transaction at cleanup")
+			}
+
+			continue
 		}
 
-		schedulerErr = cp.scheduler.RemoveByTag(fmt.Sprintf("connector%dTimer", connector.GetConnectorId()))
-		if schedulerErr != nil {
-			logInfo.WithError(err).Errorf("Cannot remove stop charging schedule")
+		wg.Add(1)
+		err := cp.stopTransactionLocally(c, reason, wg.Done)
+		if err != nil {
+			cp.logger.WithError(err).Errorf("Cannot stop the transaction at cleanup")
+			wg.Done()
 		}
+	}
+
+	confirmed := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(confirmed)
+	}()
 
-		logInfo.Infof("Stopped charging at %s", time.Now())
+	select {
+	case <-confirmed:
+	case <-time.After(timeout):
+		cp.logger.Warn("The central system did not confirm all the stopped transactions")
+	}
+}
+
+// stopTransactionLocally ends the transaction on the connector and sends the StopTransaction request afterwards.
+// The confirmed function is called when the central system responds to the request.
+func (cp *ChargePoint) stopTransactionLocally(connector chargePointConnector.Connector, reason core.Reason, confirmed func()) error {
+	transactionId, err := strconv.Atoi(connector.GetTransactionId())
+	if err != nil {
+		return err
+	}
+
+	logInfo := cp.logger.WithFields(log.Fields{
+		"evseId":        connector.GetEvseId(),
+		"connectorId":   connector.GetConnectorId(),
+		"transactionId": transactionId,
+		"reason":        reason,
+	})
+
+	request := core.NewStopTransactionRequest(
+		int(connector.CalculateSessionAvgEnergyConsumption()),
+		types.NewDateTime(time.Now()),
+		transactionId,
+	)
+	request.Reason = reason
+
+	cp.endTransaction(connector, strconv.Itoa(transactionId), reason)
+
+	callback := func(confirmation ocpp.Response, protoError error) {
+		if protoError != nil {
+			logInfo.WithError(protoError).Errorf("Server responded with error for stopping a transaction")
+		}
+
+		confirmed()
 	}
 
 	return util.SendRequest(cp.chargePoint, request, callback)
 }
 
+// endTransaction stops charging the connector, the sampling, the session policy and the cost tracking of the transaction.
+func (cp *ChargePoint) endTransaction(connector chargePointConnector.Connector, transactionId string, reason core.Reason) error {
+	logInfo := cp.logger.WithFields(log.Fields{
+		"evseId":        connector.GetEvseId(),
+		"connectorId":   connector.GetConnectorId(),
+		"transactionId": transactionId,
+		"reason":        reason,
+	})
+
+	cp.finishCostTracking(connector, transactionId, reason)
+
+	err := connector.StopCharging(reason)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Unable to stop charging")
+		return err
+	}
+
+	schedulerErr := cp.scheduler.RemoveByTag(chargePointConnector.SamplingJobTag(connector.GetEvseId(), connector.GetConnectorId()))
+	if schedulerErr != nil {
+		logInfo.WithError(schedulerErr).Errorf("Cannot remove sampling schedule")
+	}
+
+	schedulerErr = cp.scheduler.RemoveByTag(fmt.Sprintf("connector%dPolicy", connector.GetConnectorId()))
+	if schedulerErr != nil {
+		logInfo.WithError(schedulerErr).Errorf("Cannot remove session policy schedule")
+	}
+
+	logInfo.Infof("Stopped charging at %s", time.Now())
+	cp.applyScheduledAvailability()
+	return nil
+}
+
 // stopChargingConnectorWithTagId Search for a ConnectorImpl that contains the tagId and stop the charging.
 func (cp *ChargePoint) stopChargingConnectorWithTagId(tagId string, reason core.Reason) error {
 	var c = cp.connectorManager.FindConnectorWithTagId(tagId)
@@ -95,7 +223,7 @@ func (cp *ChargePoint) stopChargingConnectorWithTagId(tagId string, reason core.
 
 // stopChargingConnectorWithTransactionId Search for a ConnectorImpl that contains the transactionId and stop the charging.
 func (cp *ChargePoint) stopChargingConnectorWithTransactionId(transactionId string) error {
-	var c = cp.connectorManager.FindConnectorWithTransactionId(transactionId)
+	var c = cp.findConnectorWithTransactionId(transactionId)
 	if !util.IsNilInterfaceOrPointer(c) {
 		return cp.stopChargingConnector(c, core.ReasonRemote)
 	}
@@ -0,0 +1,172 @@
+package v16
+
+import (
+	"github.com/go-co-op/gocron"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	setting "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"testing"
+	"time"
+)
+
+type stopChargingTestSuite struct {
+	suite.Suite
+}
+
+func (s *stopChargingTestSuite) SetupSuite() {
+	setting.SetupOcppConfigurationManager(
+		"../../../configs/configuration.json",
+		configuration.OCPP16,
+		nil,
+		core.ProfileName)
+}
+
+func (s *stopChargingTestSuite) TestStopAllTransactions() {
+	var (
+		ocppMock    = new(chargePointMock)
+		managerMock = new(test.ManagerMock)
+		charging    = new(test.ConnectorMock)
+		idle        = new(test.ConnectorMock)
+		stopped     = make(chan struct{})
+	)
+
+	charging.On("GetEvseId").Return(1).Maybe()
+	charging.On("GetConnectorId").Return(1).Maybe()
+	charging.On("IsCharging").Return(true)
+	charging.On("GetTransactionId").Return("5")
+	charging.On("CalculateSessionAvgEnergyConsumption").Return(1200.0)
+	charging.On("StopCharging", core.ReasonSoftReset).Run(func(args mock.Arguments) {
+		close(stopped)
+	}).Return(nil).Once()
+
+	idle.On("IsCharging").Return(false)
+	idle.On("IsPreparing").Return(false)
+
+	managerMock.On("GetConnectors").Return([]connector.Connector{charging, idle})
+
+	// The transaction is ended before the central system is notified
+	ocppMock.On("SendRequestAsync", mock.MatchedBy(func(request *core.StopTransactionRequest) bool {
+		select {
+		case <-stopped:
+		default:
+			return false
+		}
+
+		return request.TransactionId == 5 && request.MeterStop == 1200 && request.Reason == core.ReasonSoftReset
+	})).Return(core.NewStopTransactionConfirmation(), nil, nil).Once()
+
+	cp := &ChargePoint{
+		chargePoint:      ocppMock,
+		connectorManager: managerMock,
+		scheduler:        gocron.NewScheduler(time.UTC),
+		logger:           log.StandardLogger(),
+	}
+
+	started := time.Now()
+	cp.stopAllTransactions(core.ReasonSoftReset, 5*time.Second)
+
+	// Waited for the confirmation, but not for the timeout
+	s.Assert().Less(int64(time.Since(started)), int64(5*time.Second))
+	charging.AssertExpectations(s.T())
+	ocppMock.AssertExpectations(s.T())
+}
+
+func (s *stopChargingTestSuite) TestStopChargingConnectorByTag() {
+	var (
+		ocppMock = new(chargePointMock)
+		charging = new(test.ConnectorMock)
+		stopped  = make(chan struct{})
+	)
+
+	charging.On("GetEvseId").Return(1).Maybe()
+	charging.On("GetConnectorId").Return(1).Maybe()
+	charging.On("IsCharging").Return(true)
+	charging.On("GetTransactionId").Return("7")
+	charging.On("CalculateSessionAvgEnergyConsumption").Return(800.0)
+	charging.On("StopCharging", core.ReasonLocal).Run(func(args mock.Arguments) {
+		close(stopped)
+	}).Return(nil).Once()
+
+	// The request contains the tag of the group, which stopped the session
+	ocppMock.On("SendRequestAsync", mock.MatchedBy(func(request *core.StopTransactionRequest) bool {
+		return request.TransactionId == 7 && request.IdTag == "fleetTag2" && request.Reason == core.ReasonLocal
+	})).Return(core.NewStopTransactionConfirmation(), nil, nil).Once()
+
+	cp := &ChargePoint{
+		chargePoint: ocppMock,
+		scheduler:   gocron.NewScheduler(time.UTC),
+		logger:      log.StandardLogger(),
+	}
+
+	err := cp.stopChargingConnectorByTag(charging, "fleetTag2", core.ReasonLocal)
+	s.Require().NoError(err)
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		s.Fail("The transaction was not ended")
+	}
+
+	ocppMock.AssertExpectations(s.T())
+}
+
+func (s *stopChargingTestSuite) TestStopUnstartedTransaction() {
+	ocppMock := new(chargePointMock)
+	ocppMock.On("SendRequestAsync", mock.MatchedBy(func(request *core.StopTransactionRequest) bool {
+		return request.TransactionId == 9 && request.MeterStop == 0 && request.Reason == core.ReasonOther
+	})).Return(core.NewStopTransactionConfirmation(), nil, nil).Once()
+
+	cp := &ChargePoint{
+		chargePoint: ocppMock,
+		logger:      log.StandardLogger(),
+	}
+
+	cp.stopUnstartedTransaction(9)
+	ocppMock.AssertExpectations(s.T())
+}
+
+func (s *stopChargingTestSuite) TestStopChargingOnEVDisconnect() {
+	var (
+		ocppMock  = new(chargePointMock)
+		charging  = new(test.ConnectorMock)
+		scheduler = gocron.NewScheduler(time.UTC)
+	)
+
+	s.Require().NoError(ocppManager.UpdateKey(v16.StopTransactionOnEVSideDisconnect.String(), "false"))
+	defer ocppManager.UpdateKey(v16.StopTransactionOnEVSideDisconnect.String(), "true")
+
+	charging.On("GetEvseId").Return(1).Maybe()
+	charging.On("GetConnectorId").Return(1).Maybe()
+	charging.On("IsCharging").Return(true)
+	charging.On("GetTransactionId").Return("11")
+	charging.On("StopCharging", core.ReasonEVDisconnected).Return(nil).Once()
+
+	for _, tag := range []string{connector.SamplingJobTag(1, 1), "connector1Policy", "connector1Cost"} {
+		_, err := scheduler.Every(10).Seconds().Tag(tag).Do(func() {})
+		s.Require().NoError(err)
+	}
+
+	cp := &ChargePoint{
+		chargePoint: ocppMock,
+		scheduler:   scheduler,
+		logger:      log.StandardLogger(),
+	}
+
+	// The session ends without a StopTransaction request and its jobs are removed
+	err := cp.stopChargingConnector(charging, core.ReasonEVDisconnected)
+	s.Require().NoError(err)
+	s.Assert().Empty(scheduler.Jobs())
+	charging.AssertExpectations(s.T())
+	ocppMock.AssertNotCalled(s.T(), "SendRequestAsync", mock.Anything)
+}
+
+func TestStopCharging(t *testing.T) {
+	suite.Run(t, new(stopChargingTestSuite))
+}
@@ -3,6 +3,9 @@ package v16
 import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	chargePointConnector "github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
 	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
 	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
 	"strconv"
@@ -81,6 +84,106 @@ func (cp *ChargePoint) sendAuthorizeRequest(tagId string) (*types.IdTagInfo, err
 	return authInfo.IdTagInfo, err
 }
 
+// getTagInfo returns the information of the tag from the authorization cache or from the central system.
+func (cp *ChargePoint) getTagInfo(tagId string) (*types.IdTagInfo, error) {
+	if !util.IsNilInterfaceOrPointer(cp.authCache) {
+		if tagInfo, isFound := cp.authCache.GetTagInfo(tagId); isFound {
+			return tagInfo, nil
+		}
+	}
+
+	tagInfo, err := cp.sendAuthorizeRequest(tagId)
+	if tagInfo == nil {
+		if err == nil {
+			err = errors.ErrTagUnauthorized
+		}
+
+		return nil, err
+	}
+
+	return tagInfo, nil
+}
+
+// getParentIdTag returns the parent tag (group) of the tag. Tags that are not accepted do not belong to any group.
+func (cp *ChargePoint) getParentIdTag(tagId string) string {
+	tagInfo, err := cp.getTagInfo(tagId)
+	if err != nil {
+		return ""
+	}
+
+	switch tagInfo.Status {
+	case types.AuthorizationStatusAccepted, types.AuthorizationStatusConcurrentTx:
+		return tagInfo.ParentIdTag
+	default:
+		return ""
+	}
+}
+
+// findSessionConnector returns the connector with the session started by the tag. If the tag has no session,
+// it returns the connector with a session started by another tag of the same group.
+func (cp *ChargePoint) findSessionConnector(tagId string) chargePointConnector.Connector {
+	c := cp.connectorManager.FindConnectorWithTagId(tagId)
+	if !util.IsNilInterfaceOrPointer(c) {
+		return c
+	}
+
+	return cp.findGroupSessionConnector(tagId)
+}
+
+// findGroupSessionConnector returns the connector with a session started by another tag of the same group.
+func (cp *ChargePoint) findGroupSessionConnector(tagId string) chargePointConnector.Connector {
+	// Only look up the group of the tag if there are sessions it could stop
+	var sessions []chargePointConnector.Connector
+	for _, c := range cp.connectorManager.GetConnectors() {
+		if (c.IsCharging() || c.IsPreparing()) && c.GetTagId() != "" {
+			sessions = append(sessions, c)
+		}
+	}
+
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	parentIdTag := cp.getParentIdTag(tagId)
+	if parentIdTag == "" {
+		return nil
+	}
+
+	for _, c := range sessions {
+		if cp.getSessionParentIdTag(c) == parentIdTag {
+			cp.logger.Infof("Tag %s belongs to the group %s of the session on connector %d", tagId, parentIdTag, c.GetConnectorId())
+			return c
+		}
+	}
+
+	return nil
+}
+
+// getSessionParentIdTag returns the group of the session on the connector. The group is stored with the session when
+// the transaction starts, otherwise it is looked up in the authorization cache. The central system is not asked,
+// as an Authorize request could stop the session.
+func (cp *ChargePoint) getSessionParentIdTag(c chargePointConnector.Connector) string {
+	if parentIdTag := c.GetParentIdTag(); parentIdTag != "" {
+		return parentIdTag
+	}
+
+	if util.IsNilInterfaceOrPointer(cp.authCache) {
+		return ""
+	}
+
+	tagInfo, isFound := cp.authCache.GetTagInfo(c.GetTagId())
+	if !isFound {
+		return ""
+	}
+
+	switch tagInfo.Status {
+	case types.AuthorizationStatusAccepted, types.AuthorizationStatusConcurrentTx:
+		return tagInfo.ParentIdTag
+	default:
+		return ""
+	}
+}
+
 func (cp *ChargePoint) setMaxCachedTags() {
 	var (
 		maxCachedTagsString, confErr = ocppConfigManager.GetConfigurationValue(v16.LocalAuthListMaxLength.String())
@@ -0,0 +1,129 @@
+package v16
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	chargePointConnector "github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	"testing"
+)
+
+type tagAuthTestSuite struct {
+	suite.Suite
+	cp *ChargePoint
+}
+
+func (s *tagAuthTestSuite) SetupTest() {
+	authCache := auth.NewAuthCache(nil)
+	authCache.SetMaxCachedTags(10)
+	authCache.AddTag("fleetTag1", &types.IdTagInfo{ParentIdTag: "fleet", Status: types.AuthorizationStatusAccepted})
+	authCache.AddTag("fleetTag2", &types.IdTagInfo{ParentIdTag: "fleet", Status: types.AuthorizationStatusAccepted})
+	authCache.AddTag("blockedFleetTag", &types.IdTagInfo{ParentIdTag: "fleet", Status: types.AuthorizationStatusBlocked})
+	authCache.AddTag("otherTag", &types.IdTagInfo{ParentIdTag: "other", Status: types.AuthorizationStatusAccepted})
+
+	s.cp = &ChargePoint{
+		scheduler: scheduler.GetScheduler(),
+		logger:    log.StandardLogger(),
+		authCache: authCache,
+	}
+}
+
+func (s *tagAuthTestSuite) TestGetParentIdTag() {
+	s.Assert().EqualValues("fleet", s.cp.getParentIdTag("fleetTag1"))
+	s.Assert().EqualValues("other", s.cp.getParentIdTag("otherTag"))
+	// Tags that are not accepted do not belong to a group
+	s.Assert().EqualValues("", s.cp.getParentIdTag("blockedFleetTag"))
+}
+
+func (s *tagAuthTestSuite) TestFindSessionConnector() {
+	var (
+		managerMock = new(test.ManagerMock)
+		connector1  = new(test.ConnectorMock)
+		connector2  = new(test.ConnectorMock)
+	)
+
+	connector1.On("GetConnectorId").Return(1).Maybe()
+	connector1.On("IsCharging").Return(false)
+	connector1.On("IsPreparing").Return(false)
+	connector1.On("GetTagId").Return("")
+
+	connector2.On("GetConnectorId").Return(2).Maybe()
+	connector2.On("IsCharging").Return(true)
+	connector2.On("GetTagId").Return("fleetTag1")
+	connector2.On("GetParentIdTag").Return("")
+
+	managerMock.On("FindConnectorWithTagId", "fleetTag1").Return(connector2)
+	managerMock.On("FindConnectorWithTagId", "fleetTag2").Return(nil)
+	managerMock.On("FindConnectorWithTagId", "blockedFleetTag").Return(nil)
+	managerMock.On("FindConnectorWithTagId", "otherTag").Return(nil)
+	managerMock.On("GetConnectors").Return([]chargePointConnector.Connector{connector1, connector2})
+	s.cp.connectorManager = managerMock
+
+	// The tag that started the session
+	s.Assert().Equal(connector2, s.cp.findSessionConnector("fleetTag1"))
+
+	// A tag of the same group
+	s.Assert().Equal(connector2, s.cp.findSessionConnector("fleetTag2"))
+
+	// A blocked tag of the same group
+	s.Assert().Nil(s.cp.findSessionConnector("blockedFleetTag"))
+
+	// A tag of another group
+	s.Assert().Nil(s.cp.findSessionConnector("otherTag"))
+}
+
+func (s *tagAuthTestSuite) TestFindSessionConnectorWithSessionGroup() {
+	var (
+		managerMock = new(test.ManagerMock)
+		connector1  = new(test.ConnectorMock)
+		connector2  = new(test.ConnectorMock)
+	)
+
+	connector1.On("GetConnectorId").Return(1).Maybe()
+	connector1.On("IsCharging").Return(false)
+	connector1.On("IsPreparing").Return(false)
+	connector1.On("GetTagId").Return("")
+
+	// The tag of the session is not cached, but the group is stored with the session
+	connector2.On("GetConnectorId").Return(2).Maybe()
+	connector2.On("IsCharging").Return(true)
+	connector2.On("GetTagId").Return("uncachedFleetTag")
+	connector2.On("GetParentIdTag").Return("fleet")
+
+	managerMock.On("FindConnectorWithTagId", "fleetTag2").Return(nil)
+	managerMock.On("FindConnectorWithTagId", "otherTag").Return(nil)
+	managerMock.On("GetConnectors").Return([]chargePointConnector.Connector{connector1, connector2})
+	s.cp.connectorManager = managerMock
+
+	// A tag of the same group stops the session, even though connector 1 is available
+	s.Assert().Equal(connector2, s.cp.findSessionConnector("fleetTag2"))
+	managerMock.AssertNotCalled(s.T(), "FindAvailableConnector")
+
+	// A tag of another group
+	s.Assert().Nil(s.cp.findSessionConnector("otherTag"))
+}
+
+func (s *tagAuthTestSuite) TestGetTagGroup() {
+	group, err := s.cp.GetTagGroup("fleetTag2")
+	s.Require().NoError(err)
+	s.Assert().EqualValues("fleetTag2", group.IdTag)
+	s.Assert().EqualValues("fleet", group.ParentIdTag)
+	s.Assert().EqualValues([]string{"blockedFleetTag", "fleetTag1", "fleetTag2"}, group.Members)
+
+	group, err = s.cp.GetTagGroup("otherTag")
+	s.Require().NoError(err)
+	s.Assert().EqualValues([]string{"otherTag"}, group.Members)
+
+	// Authorization cache disabled
+	s.cp.authCache = nil
+	_, err = s.cp.GetTagGroup("fleetTag1")
+	s.Assert().ErrorIs(err, errors.ErrAuthCacheDisabled)
+}
+
+func TestTagAuth(t *testing.T) {
+	suite.Run(t, new(tagAuthTestSuite))
+}
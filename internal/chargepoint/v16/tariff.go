@@ -0,0 +1,235 @@
+package v16
+
+import (
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp2.0/tariffcost"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	dataTransfer "github.com/xBlaz3kx/ChargePi-go/internal/components/data-transfer"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display/i18n"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/tariff"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"time"
+)
+
+// costUpdateInterval is the interval in seconds at which the running cost is updated and displayed.
+const costUpdateInterval = 30
+
+// sessionCost is the running cost of a transaction.
+type sessionCost struct {
+	cost        *tariff.Session
+	tagId       string
+	evseId      int
+	connectorId int
+}
+
+// getTariff returns the tariff pushed by the central system or the tariff from the settings.
+// If neither is valid, the sessions are free.
+func (cp *ChargePoint) getTariff() *tariff.Tariff {
+	cp.tariffMu.Lock()
+	centralSystemTariff := cp.centralSystemTariff
+	cp.tariffMu.Unlock()
+
+	if centralSystemTariff != nil {
+		return centralSystemTariff
+	}
+
+	currentSettings := cp.getSettings()
+	if currentSettings == nil {
+		return nil
+	}
+
+	settingsTariff, err := tariff.NewTariff(currentSettings.ChargePoint.Tariff)
+	if err != nil {
+		cp.logger.WithError(err).Warn("Invalid tariff in the settings, the sessions are free")
+		return nil
+	}
+
+	return settingsTariff
+}
+
+// readMeter reads the energy and the power of the connector. Only the time is set if the connector has no power meter.
+func readMeter(c connector.Connector) tariff.Sample {
+	sample := tariff.Sample{Time: time.Now()}
+
+	if powerMeter := c.GetPowerMeter(); !util.IsNilInterfaceOrPointer(powerMeter) {
+		sample.Energy = powerMeter.GetEnergy()
+		sample.Power = powerMeter.GetPower()
+	}
+
+	return sample
+}
+
+// startCostTracking periodically calculates the running cost of the transaction on the connector from the meter
+// readings and displays it on the LCD, unless the session is free.
+func (cp *ChargePoint) startCostTracking(c connector.Connector, tagId string, started time.Time) {
+	first := readMeter(c)
+	first.Time = started
+	cp.trackCost(c, tagId, tariff.NewSession(cp.getTariff(), cp.getMinPower(), first))
+}
+
+// restoreCostTracking continues calculating the cost of the session, which was active before the restart. The cost
+// continues from the energy and the meter reading persisted with the session.
+func (cp *ChargePoint) restoreCostTracking(c connector.Connector, session settings.Session, started time.Time) {
+	first := readMeter(c)
+	first.Time = started
+	if session.MeterReading > 0 {
+		first.Energy = session.MeterReading
+	}
+
+	cost := tariff.NewSession(cp.getTariff(), cp.getMinPower(), first)
+	cost.SetEnergy(session.Energy)
+	cp.trackCost(c, session.TagId, cost)
+}
+
+// getMinPower returns the minimum power in W, below which the EV is not charging.
+func (cp *ChargePoint) getMinPower() float64 {
+	currentSettings := cp.getSettings()
+	if currentSettings == nil {
+		return 0
+	}
+
+	return float64(currentSettings.ChargePoint.Hardware.PowerMeters.MinPower)
+}
+
+// trackCost periodically updates the cost of the transaction on the connector and displays it on the LCD.
+func (cp *ChargePoint) trackCost(c connector.Connector, tagId string, cost *tariff.Session) {
+	var (
+		transactionId = c.GetTransactionId()
+		logInfo       = cp.logger.WithFields(log.Fields{
+			"evseId":        c.GetEvseId(),
+			"connectorId":   c.GetConnectorId(),
+			"transactionId": transactionId,
+		})
+	)
+
+	cp.tariffMu.Lock()
+	if cp.sessionCosts == nil {
+		cp.sessionCosts = map[string]*sessionCost{}
+	}
+
+	cp.sessionCosts[transactionId] = &sessionCost{
+		cost:        cost,
+		tagId:       tagId,
+		evseId:      c.GetEvseId(),
+		connectorId: c.GetConnectorId(),
+	}
+	cp.tariffMu.Unlock()
+
+	update := func() {
+		cost.Update(readMeter(c))
+
+		if cost.GetTariff().IsFree() {
+			return
+		}
+
+		cp.displayTranslation(i18n.TranslateRunningCostMessage(cp.getLanguage(tagId), cost.GetCost().Total,
+			cost.GetTariff().GetCurrency(), cost.GetEnergy()))
+	}
+
+	_, err := cp.scheduler.Every(costUpdateInterval).Seconds().SingletonMode().
+		Tag(fmt.Sprintf("connector%dCost", c.GetConnectorId())).Do(update)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Cannot schedule the cost updates")
+	}
+}
+
+// finishCostTracking stops updating the running cost of the transaction and creates its receipt. The receipt is
+// added to the session history and displayed on the LCD.
+func (cp *ChargePoint) finishCostTracking(c connector.Connector, transactionId string, reason core.Reason) *session.Receipt {
+	err := cp.scheduler.RemoveByTag(fmt.Sprintf("connector%dCost", c.GetConnectorId()))
+	if err != nil {
+		cp.logger.WithError(err).Debug("Cannot remove the cost update schedule")
+	}
+
+	cp.tariffMu.Lock()
+	transactionCost, isFound := cp.sessionCosts[transactionId]
+	delete(cp.sessionCosts, transactionId)
+	cp.tariffMu.Unlock()
+
+	if !isFound {
+		return nil
+	}
+
+	transactionCost.cost.Update(readMeter(c))
+
+	receipt := transactionCost.cost.Receipt()
+	receipt.TransactionId = transactionId
+	receipt.TagId = transactionCost.tagId
+	receipt.EvseId = transactionCost.evseId
+	receipt.ConnectorId = transactionCost.connectorId
+	receipt.StopReason = string(reason)
+
+	cp.logger.WithFields(log.Fields{
+		"transactionId": transactionId,
+		"energy":        receipt.Energy,
+		"total":         receipt.Cost.Total,
+		"currency":      receipt.Currency,
+	}).Info("Session finished")
+
+	if !util.IsNilInterfaceOrPointer(cp.sessionHistory) {
+		err = cp.sessionHistory.SaveReceipt(receipt)
+		if err != nil {
+			cp.logger.WithError(err).Error("Unable to add the session to the session history")
+		}
+	}
+
+	cp.displayTranslation(i18n.TranslateSessionSummaryMessage(cp.getLanguage(receipt.TagId), receipt.Cost.Total,
+		receipt.Currency, receipt.Energy, receipt.Duration))
+	return &receipt
+}
+
+// setTariff replaces the tariff with the tariff pushed by the central system. The tariff applies to the transactions
+// started afterwards.
+func (cp *ChargePoint) setTariff(request dataTransfer.Request) (interface{}, error) {
+	var tariffSettings settings.Tariff
+
+	err := request.Decode(&tariffSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	newTariff, err := tariff.NewTariff(tariffSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	cp.tariffMu.Lock()
+	cp.centralSystemTariff = newTariff
+	cp.tariffMu.Unlock()
+
+	return nil, nil
+}
+
+// costUpdated replaces the running cost of the transaction with the total cost calculated by the central system.
+// The payload is the OCPP 2.0.1 CostUpdated request.
+func (cp *ChargePoint) costUpdated(request dataTransfer.Request) (interface{}, error) {
+	var costUpdate tariffcost.CostUpdatedRequest
+
+	err := request.Decode(&costUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	if costUpdate.TransactionID == "" || costUpdate.TotalCost < 0 {
+		return nil, dataTransfer.ErrInvalidPayload
+	}
+
+	cp.tariffMu.Lock()
+	transactionCost, isFound := cp.sessionCosts[costUpdate.TransactionID]
+	cp.tariffMu.Unlock()
+
+	if !isFound {
+		return nil, errors.ErrNoConnectorWithTransaction
+	}
+
+	cost := transactionCost.cost
+	cost.SetTotalCost(costUpdate.TotalCost)
+	cp.displayTranslation(i18n.TranslateRunningCostMessage(cp.getLanguage(transactionCost.tagId), cost.GetCost().Total,
+		cost.GetTariff().GetCurrency(), cost.GetEnergy()))
+	return nil, nil
+}
@@ -0,0 +1,158 @@
+package v16
+
+import (
+	"github.com/go-co-op/gocron"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/errors"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type tariffTestSuite struct {
+	suite.Suite
+	cp             *ChargePoint
+	connectorMock  *test.ConnectorMock
+	powerMeterMock *test.PowerMeterMock
+	store          *store.Store
+	tempDir        string
+}
+
+func (s *tariffTestSuite) SetupTest() {
+	tempDir, err := ioutil.TempDir("", "chargepi")
+	s.Require().NoError(err)
+	s.tempDir = tempDir
+
+	s.store, err = store.Open(filepath.Join(tempDir, "chargepi.db"))
+	s.Require().NoError(err)
+
+	managerMock := new(test.ManagerMock)
+	managerMock.On("SetNotificationChannel", mock.Anything).Return()
+	managerMock.On("SetMeterValuesChannel", mock.Anything).Return()
+
+	s.powerMeterMock = new(test.PowerMeterMock)
+	s.connectorMock = new(test.ConnectorMock)
+	s.connectorMock.On("GetEvseId").Return(1)
+	s.connectorMock.On("GetConnectorId").Return(1)
+	s.connectorMock.On("GetTransactionId").Return("1234")
+	s.connectorMock.On("GetPowerMeter").Return(s.powerMeterMock)
+
+	s.cp = NewChargePoint(managerMock, gocron.NewScheduler(time.UTC), nil,
+		WithLogger(log.StandardLogger()),
+		WithSessionHistory(s.store),
+	)
+	s.cp.Settings = &settings.Settings{}
+	s.cp.Settings.ChargePoint.Tariff = settings.Tariff{Currency: "EUR", EnergyPrice: 0.3, SessionFee: 1}
+}
+
+func (s *tariffTestSuite) TearDownTest() {
+	_ = s.store.Close()
+	_ = os.RemoveAll(s.tempDir)
+}
+
+func (s *tariffTestSuite) TestReceipt() {
+	s.powerMeterMock.On("GetEnergy").Return(1000.0).Once()
+	s.powerMeterMock.On("GetEnergy").Return(6000.0)
+	s.powerMeterMock.On("GetPower").Return(11000.0)
+
+	s.cp.startCostTracking(s.connectorMock, "exampleTag", time.Now().Add(-time.Hour))
+	receipt := s.cp.finishCostTracking(s.connectorMock, "1234", core.ReasonLocal)
+	s.Require().NotNil(receipt)
+
+	s.Assert().EqualValues("1234", receipt.TransactionId)
+	s.Assert().EqualValues("exampleTag", receipt.TagId)
+	s.Assert().EqualValues(1, receipt.EvseId)
+	s.Assert().EqualValues(1, receipt.ConnectorId)
+	s.Assert().EqualValues(core.ReasonLocal, receipt.StopReason)
+	s.Assert().EqualValues(5000, receipt.Energy)
+	s.Assert().InDelta(3600, receipt.Duration, 1)
+	s.Assert().EqualValues("EUR", receipt.Currency)
+	s.Assert().EqualValues(session.Cost{SessionFee: 1, Energy: 1.5, Total: 2.5}, receipt.Cost)
+
+	// The receipt is in the session history
+	storedReceipt, err := s.cp.GetReceipt("1234")
+	s.Require().NoError(err)
+	s.Assert().EqualValues(receipt.Cost, storedReceipt.Cost)
+
+	history, err := s.cp.GetSessionHistory()
+	s.Require().NoError(err)
+	s.Assert().Len(history, 1)
+
+	// The transaction is not tracked anymore
+	s.Assert().Nil(s.cp.finishCostTracking(s.connectorMock, "1234", core.ReasonLocal))
+}
+
+func (s *tariffTestSuite) TestRestoreCostTracking() {
+	// The meter was reset during the restart
+	s.powerMeterMock.On("GetEnergy").Return(500.0)
+	s.powerMeterMock.On("GetPower").Return(11000.0)
+
+	s.cp.restoreCostTracking(s.connectorMock, settings.Session{
+		TagId:        "exampleTag",
+		Energy:       4000,
+		MeterReading: 9000,
+	}, time.Now().Add(-time.Hour))
+
+	receipt := s.cp.finishCostTracking(s.connectorMock, "1234", core.ReasonLocal)
+	s.Require().NotNil(receipt)
+	s.Assert().EqualValues("exampleTag", receipt.TagId)
+	s.Assert().EqualValues(4500, receipt.Energy)
+	s.Assert().InDelta(3600, receipt.Duration, 1)
+	s.Assert().EqualValues(session.Cost{SessionFee: 1, Energy: 1.35, Total: 2.35}, receipt.Cost)
+}
+
+func (s *tariffTestSuite) TestSetTariff() {
+	response, err := s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageSetTariff, `{"currency":"USD","timePrice":0.1}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, response.Status)
+	s.Assert().EqualValues("USD", s.cp.getTariff().GetCurrency())
+
+	response, err = s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageSetTariff, `{"energyPrice":-1}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusRejected, response.Status)
+	s.Assert().EqualValues("USD", s.cp.getTariff().GetCurrency())
+}
+
+func (s *tariffTestSuite) TestCostUpdated() {
+	s.powerMeterMock.On("GetEnergy").Return(0.0)
+	s.powerMeterMock.On("GetPower").Return(0.0)
+
+	// No transaction
+	response, err := s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageCostUpdated, `{"totalCost":5,"transactionId":"1234"}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusRejected, response.Status)
+
+	s.cp.startCostTracking(s.connectorMock, "exampleTag", time.Now())
+
+	response, err = s.cp.OnDataTransfer(newDataTransferRequest(VendorId, MessageCostUpdated, `{"totalCost":5,"transactionId":"1234"}`))
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, response.Status)
+
+	receipt := s.cp.finishCostTracking(s.connectorMock, "1234", core.ReasonRemote)
+	s.Require().NotNil(receipt)
+	s.Assert().True(receipt.IsCostFromCentralSystem)
+	s.Assert().EqualValues(5, receipt.Cost.Total)
+}
+
+func (s *tariffTestSuite) TestSessionHistoryDisabled() {
+	s.cp.sessionHistory = nil
+
+	_, err := s.cp.GetSessionHistory()
+	s.Assert().ErrorIs(err, errors.ErrSessionHistoryDisabled)
+
+	_, err = s.cp.GetReceipt("1234")
+	s.Assert().ErrorIs(err, errors.ErrSessionHistoryDisabled)
+}
+
+func TestTariff(t *testing.T) {
+	suite.Run(t, new(tariffTestSuite))
+}
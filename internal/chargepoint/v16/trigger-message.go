@@ -1,11 +1,16 @@
 package v16
 
 import (
+	"fmt"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/firmware"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/remotetrigger"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	"github.com/reactivex/rxgo/v2"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"time"
 )
 
 func (cp *ChargePoint) OnTriggerMessage(request *remotetrigger.TriggerMessageRequest) (confirmation *remotetrigger.TriggerMessageConfirmation, err error) {
@@ -33,12 +38,45 @@ func (cp *ChargePoint) OnTriggerMessage(request *remotetrigger.TriggerMessageReq
 		status = remotetrigger.TriggerMessageStatusAccepted
 		break
 	case core.MeterValuesFeatureName:
-		status = remotetrigger.TriggerMessageStatusNotImplemented
+		connectors := getMeteredConnectors(cp.connectorManager.GetConnectors())
+
+		// Connector 0 represents the main meter of the charge point
+		if request.ConnectorId != nil && *request.ConnectorId == 0 {
+			if len(connectors) == 0 {
+				break
+			}
+
+			defer func() {
+				go cp.sampleMainMeter(connectors, types.ReadingContextTrigger)
+			}()
+
+			status = remotetrigger.TriggerMessageStatusAccepted
+			break
+		}
+
+		if request.ConnectorId != nil {
+			c := cp.connectorManager.FindConnectorById(*request.ConnectorId)
+			connectors = getMeteredConnectors([]connector.Connector{c})
+		}
+
+		// Only the connectors with a power meter can be sampled
+		if len(connectors) == 0 {
+			break
+		}
+
+		// Sample the connectors after the response
+		defer func() {
+			go cp.sampleConnectors(connectors, types.ReadingContextTrigger)
+		}()
+
+		status = remotetrigger.TriggerMessageStatusAccepted
 		break
 	case core.StatusNotificationFeatureName:
 		if request.ConnectorId == nil {
-			// Send the status of all connectors after the response
+			// Send the status of the charge point and all connectors after the response
 			defer func() {
+				cp.notifyChargePointStatus(cp.getAvailability())
+
 				for _, c := range cp.connectorManager.GetConnectors() {
 					if cp.connectorChannel != nil {
 						cp.connectorChannel <- rxgo.Of(c)
@@ -50,9 +88,15 @@ func (cp *ChargePoint) OnTriggerMessage(request *remotetrigger.TriggerMessageReq
 			break
 		}
 
-		connectorID := *request.ConnectorId
-		c := cp.connectorManager.FindConnector(1, connectorID)
-		if c != nil {
+		// Connector 0 represents the charge point
+		if *request.ConnectorId == 0 {
+			defer cp.notifyChargePointStatus(cp.getAvailability())
+			status = remotetrigger.TriggerMessageStatusAccepted
+			break
+		}
+
+		c := cp.connectorManager.FindConnectorById(*request.ConnectorId)
+		if !util.IsNilInterfaceOrPointer(c) {
 			defer func(c connector.Connector) {
 				cp.notifyConnectorStatus(c)
 			}(c)
@@ -66,3 +110,65 @@ func (cp *ChargePoint) OnTriggerMessage(request *remotetrigger.TriggerMessageReq
 
 	return remotetrigger.NewTriggerMessageConfirmation(status), nil
 }
+
+// sampleConnectors samples the measurands from the MeterValuesSampledData configuration key on the connectors.
+func (cp *ChargePoint) sampleConnectors(connectors []connector.Connector, readingContext types.ReadingContext) {
+	measurands := util.GetTypesToSample()
+
+	for _, c := range connectors {
+		c.SamplePowerMeter(measurands, readingContext)
+	}
+}
+
+// sampleMainMeter sends the meter values of the main meter of the charge point as connector 0. The main meter is the
+// sum of the power meters of the connectors, except the voltage, which is the average voltage of the connectors.
+func (cp *ChargePoint) sampleMainMeter(connectors []connector.Connector, readingContext types.ReadingContext) {
+	var samples []types.SampledValue
+
+	for _, measurand := range util.GetTypesToSample() {
+		var (
+			value       float64
+			isSupported bool
+		)
+
+		for _, c := range connectors {
+			var connectorValue float64
+			connectorValue, isSupported = connector.ReadMeasurand(c.GetPowerMeter(), measurand)
+			value += connectorValue
+		}
+
+		if !isSupported {
+			continue
+		}
+
+		if measurand == types.MeasurandVoltage {
+			value /= float64(len(connectors))
+		}
+
+		samples = append(samples, types.SampledValue{
+			Value:     fmt.Sprintf("%.3f", value),
+			Context:   readingContext,
+			Measurand: measurand,
+		})
+	}
+
+	if len(samples) == 0 || cp.meterValuesChannel == nil {
+		return
+	}
+
+	cp.meterValuesChannel <- models.NewMeterValueNotification(0, 0, nil,
+		types.MeterValue{Timestamp: types.NewDateTime(time.Now()), SampledValue: samples})
+}
+
+// getMeteredConnectors returns the connectors with a power meter.
+func getMeteredConnectors(connectors []connector.Connector) []connector.Connector {
+	var metered []connector.Connector
+
+	for _, c := range connectors {
+		if !util.IsNilInterfaceOrPointer(c) && !util.IsNilInterfaceOrPointer(c.GetPowerMeter()) {
+			metered = append(metered, c)
+		}
+	}
+
+	return metered
+}
@@ -3,12 +3,17 @@ package v16
 import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/remotetrigger"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	"github.com/reactivex/rxgo/v2"
 	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	setting "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/scheduler"
 	"github.com/xBlaz3kx/ChargePi-go/test"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
 	"testing"
 	"time"
 )
@@ -18,6 +23,16 @@ type triggerMessageTestSuite struct {
 	cp *ChargePoint
 }
 
+func (s *triggerMessageTestSuite) SetupSuite() {
+	// The sampled measurands are read from the OCPP configuration
+	setting.SetupOcppConfigurationManager(
+		"../../../configs/configuration.json",
+		configuration.OCPP16,
+		nil,
+		core.ProfileName,
+		remotetrigger.ProfileName)
+}
+
 func (s *triggerMessageTestSuite) SetupTest() {
 	s.cp = &ChargePoint{
 		logger:    log.StandardLogger(),
@@ -30,11 +45,16 @@ func (s *triggerMessageTestSuite) TestTriggerMessage() {
 	var (
 		connectorMock = new(test.ConnectorMock)
 		managerMock   = new(test.ManagerMock)
+		meterMock     = new(test.PowerMeterMock)
 		connectorChan = make(chan rxgo.Item)
 	)
 	// Set manager expectations
-	managerMock.On("FindConnector", 1, connectorId).Return(connectorMock).Once()
-	managerMock.On("GetConnectors").Return([]connector.Connector{connectorMock}).Once()
+	managerMock.On("GetConnectors").Return([]connector.Connector{connectorMock})
+	managerMock.On("FindConnectorById", connectorId).Return(connectorMock)
+	managerMock.On("FindConnectorById", 9).Return(nil)
+	connectorMock.On("GetConnectorId").Return(connectorId)
+	connectorMock.On("SamplePowerMeter", mock.Anything, types.ReadingContextTrigger).Return()
+	connectorMock.On("GetPowerMeter").Return(meterMock)
 
 	s.cp.connectorManager = managerMock
 	s.cp.connectorChannel = connectorChan
@@ -54,10 +74,53 @@ func (s *triggerMessageTestSuite) TestTriggerMessage() {
 	s.Assert().NotNil(response)
 	s.Assert().EqualValues(remotetrigger.TriggerMessageStatusNotImplemented, response.Status)
 
+	// Sample all connectors
 	response, err = s.cp.OnTriggerMessage(remotetrigger.NewTriggerMessageRequest(core.MeterValuesFeatureName))
 	s.Assert().NoError(err)
 	s.Assert().NotNil(response)
-	s.Assert().EqualValues(remotetrigger.TriggerMessageStatusNotImplemented, response.Status)
+	s.Assert().EqualValues(remotetrigger.TriggerMessageStatusAccepted, response.Status)
+
+	// Sample a single connector
+	var (
+		request            = remotetrigger.NewTriggerMessageRequest(core.MeterValuesFeatureName)
+		triggerConnectorId = connectorId
+	)
+	request.ConnectorId = &triggerConnectorId
+	response, err = s.cp.OnTriggerMessage(request)
+	s.Assert().NoError(err)
+	s.Assert().NotNil(response)
+	s.Assert().EqualValues(remotetrigger.TriggerMessageStatusAccepted, response.Status)
+
+	time.Sleep(time.Millisecond * 100)
+	connectorMock.AssertNumberOfCalls(s.T(), "SamplePowerMeter", 2)
+
+	// Unknown connector
+	unknownConnectorId := 9
+	request.ConnectorId = &unknownConnectorId
+	response, err = s.cp.OnTriggerMessage(request)
+	s.Assert().NoError(err)
+	s.Assert().NotNil(response)
+	s.Assert().EqualValues(remotetrigger.TriggerMessageStatusRejected, response.Status)
+
+	// A connector without a power meter cannot be sampled
+	var (
+		unmeteredConnectorId = 2
+		unmeteredConnector   = new(test.ConnectorMock)
+	)
+	unmeteredConnector.On("GetPowerMeter").Return((*test.PowerMeterMock)(nil))
+	managerMock.On("FindConnectorById", unmeteredConnectorId).Return(unmeteredConnector)
+
+	request.ConnectorId = &unmeteredConnectorId
+	response, err = s.cp.OnTriggerMessage(request)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(remotetrigger.TriggerMessageStatusRejected, response.Status)
+
+	request = remotetrigger.NewTriggerMessageRequest(core.StatusNotificationFeatureName)
+	request.ConnectorId = &unknownConnectorId
+	response, err = s.cp.OnTriggerMessage(request)
+	s.Assert().NoError(err)
+	s.Assert().NotNil(response)
+	s.Assert().EqualValues(remotetrigger.TriggerMessageStatusRejected, response.Status)
 
 	response, err = s.cp.OnTriggerMessage(remotetrigger.NewTriggerMessageRequest(core.HeartbeatFeatureName))
 	s.Assert().NoError(err)
@@ -86,6 +149,15 @@ func (s *triggerMessageTestSuite) TestTriggerMessage() {
 	time.Sleep(time.Second * 3)
 	s.Assert().EqualValues(1, numMessages)
 
+	// Get status of the charge point
+	chargePointConnectorId := 0
+	request = remotetrigger.NewTriggerMessageRequest(core.StatusNotificationFeatureName)
+	request.ConnectorId = &chargePointConnectorId
+	response, err = s.cp.OnTriggerMessage(request)
+	s.Assert().NoError(err)
+	s.Assert().NotNil(response)
+	s.Assert().EqualValues(remotetrigger.TriggerMessageStatusAccepted, response.Status)
+
 	// Get status of a single connector
 	/*request := remotetrigger.NewTriggerMessageRequest(core.StatusNotificationFeatureName)
 	response, err = s.cp.OnTriggerMessage(request)
@@ -94,6 +166,55 @@ func (s *triggerMessageTestSuite) TestTriggerMessage() {
 	s.Assert().EqualValues(remotetrigger.TriggerMessageStatusAccepted, response.Status)*/
 }
 
+func (s *triggerMessageTestSuite) TestTriggerMainMeterValues() {
+	var (
+		managerMock     = new(test.ManagerMock)
+		meterMock       = new(test.PowerMeterMock)
+		connectorMock   = new(test.ConnectorMock)
+		meterValuesChan = make(chan models.MeterValueNotification, 1)
+		request         = remotetrigger.NewTriggerMessageRequest(core.MeterValuesFeatureName)
+		mainConnectorId = 0
+	)
+
+	meterMock.On("GetPower").Return(3680.0)
+	connectorMock.On("GetPowerMeter").Return(meterMock)
+	managerMock.On("GetConnectors").Return([]connector.Connector{connectorMock, connectorMock})
+
+	s.cp.connectorManager = managerMock
+	s.cp.meterValuesChannel = meterValuesChan
+
+	// The main meter is the sum of the connectors' meters
+	request.ConnectorId = &mainConnectorId
+	response, err := s.cp.OnTriggerMessage(request)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(remotetrigger.TriggerMessageStatusAccepted, response.Status)
+
+	select {
+	case notification := <-meterValuesChan:
+		s.Assert().EqualValues(0, notification.ConnectorId)
+		s.Require().Len(notification.MeterValues, 1)
+
+		// Only the Power.Active.Import is sampled by the configuration
+		samples := notification.MeterValues[0].SampledValue
+		s.Require().Len(samples, 1)
+		s.Assert().EqualValues(types.MeasurandPowerActiveImport, samples[0].Measurand)
+		s.Assert().EqualValues(types.ReadingContextTrigger, samples[0].Context)
+		s.Assert().EqualValues("7360.000", samples[0].Value)
+	case <-time.After(time.Second):
+		s.FailNow("The meter values were not sent")
+	}
+
+	// No connector has a power meter
+	unmeteredConnector := new(test.ConnectorMock)
+	unmeteredConnector.On("GetPowerMeter").Return((*test.PowerMeterMock)(nil))
+	s.cp.connectorManager = new(test.ManagerMock)
+	s.cp.connectorManager.(*test.ManagerMock).On("GetConnectors").Return([]connector.Connector{unmeteredConnector})
+
+	response, err = s.cp.OnTriggerMessage(request)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(remotetrigger.TriggerMessageStatusRejected, response.Status)
+}
+
 func TestTriggerMessage(t *testing.T) {
 	suite.Run(t, new(triggerMessageTestSuite))
 }
@@ -0,0 +1,138 @@
+package authInput
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+)
+
+// Sources of the authorization requests
+const (
+	SourceTag     = Source("Tag")
+	SourcePin     = Source("Pin")
+	SourcePayment = Source("Payment")
+)
+
+var ErrInvalidRequest = errors.New("invalid authorization request")
+
+type (
+	// Source is the kind of the input the authorization request came from.
+	Source string
+
+	// Request is a request to start or stop charging with the IdTag. If the ConnectorId is 0, the charge point
+	// chooses the connector.
+	Request struct {
+		Source      Source `json:"source"`
+		IdTag       string `json:"idTag"`
+		ConnectorId int    `json:"connectorId,omitempty"`
+		// Language preferred by the driver for the messages on the LCD, if known
+		Language string `json:"language,omitempty"`
+	}
+
+	// Handler handles the authorization requests published to the Bus.
+	Handler func(request Request)
+
+	// Input produces authorization requests, e.g. from an RFID reader or a keypad. Listen should block until the
+	// context is cancelled.
+	Input interface {
+		Listen(ctx context.Context, publish func(request Request))
+		Cleanup()
+	}
+
+	// Bus collects the authorization requests of all the inputs and passes them to the handler.
+	Bus struct {
+		handler Handler
+		mu      sync.Mutex
+		inputs  map[string]*registeredInput
+	}
+
+	registeredInput struct {
+		input  Input
+		cancel context.CancelFunc
+	}
+)
+
+// NewBus creates a Bus that passes the requests to the handler.
+func NewBus(handler Handler) *Bus {
+	return &Bus{
+		handler: handler,
+		inputs:  map[string]*registeredInput{},
+	}
+}
+
+// AddInput starts listening to the input. An input with the same name is replaced.
+func (b *Bus) AddInput(ctx context.Context, name string, input Input) {
+	b.RemoveInput(name)
+
+	if input == nil {
+		return
+	}
+
+	inputCtx, cancel := context.WithCancel(ctx)
+
+	b.mu.Lock()
+	b.inputs[name] = &registeredInput{input: input, cancel: cancel}
+	b.mu.Unlock()
+
+	log.Infof("Listening for authorization requests from %s", name)
+	go input.Listen(inputCtx, func(request Request) {
+		err := b.Publish(request)
+		if err != nil {
+			log.WithError(err).Warnf("Invalid authorization request from %s", name)
+		}
+	})
+}
+
+// RemoveInput stops listening to the input and cleans it up.
+func (b *Bus) RemoveInput(name string) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	registered, isFound := b.inputs[name]
+	delete(b.inputs, name)
+	b.mu.Unlock()
+
+	if !isFound {
+		return
+	}
+
+	registered.cancel()
+	registered.input.Cleanup()
+}
+
+// Publish validates the request and passes it to the handler. Inputs without hardware, such as a payment
+// backend, publish the requests directly.
+func (b *Bus) Publish(request Request) error {
+	request.IdTag = strings.TrimSpace(request.IdTag)
+	if request.IdTag == "" || len(request.IdTag) > 20 || request.ConnectorId < 0 {
+		return ErrInvalidRequest
+	}
+
+	if b.handler != nil {
+		b.handler(request)
+	}
+
+	return nil
+}
+
+// Close removes all the inputs.
+func (b *Bus) Close() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	names := make([]string, 0, len(b.inputs))
+	for name := range b.inputs {
+		names = append(names, name)
+	}
+	b.mu.Unlock()
+
+	for _, name := range names {
+		b.RemoveInput(name)
+	}
+}
@@ -0,0 +1,66 @@
+package authInput
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+	"time"
+)
+
+type busTestSuite struct {
+	suite.Suite
+	bus      *Bus
+	requests chan Request
+}
+
+func (s *busTestSuite) SetupTest() {
+	s.requests = make(chan Request, 5)
+	s.bus = NewBus(func(request Request) {
+		s.requests <- request
+	})
+}
+
+func (s *busTestSuite) TearDownTest() {
+	s.bus.Close()
+}
+
+func (s *busTestSuite) TestPublish() {
+	err := s.bus.Publish(Request{Source: SourcePayment, IdTag: " payment123 ", ConnectorId: 2})
+	s.Require().NoError(err)
+	s.Assert().Equal(Request{Source: SourcePayment, IdTag: "payment123", ConnectorId: 2}, <-s.requests)
+
+	s.Assert().ErrorIs(s.bus.Publish(Request{Source: SourcePayment}), ErrInvalidRequest)
+	s.Assert().ErrorIs(s.bus.Publish(Request{Source: SourcePayment, IdTag: strings.Repeat("1", 21)}), ErrInvalidRequest)
+	s.Assert().ErrorIs(s.bus.Publish(Request{Source: SourcePayment, IdTag: "123", ConnectorId: -1}), ErrInvalidRequest)
+	s.Assert().Empty(s.requests)
+}
+
+func (s *busTestSuite) TestChannelInput() {
+	tags := make(chan string, 1)
+	s.bus.AddInput(context.Background(), "reader", NewChannelInput(SourceTag, tags))
+
+	tags <- "abc123"
+
+	select {
+	case request := <-s.requests:
+		s.Assert().Equal(Request{Source: SourceTag, IdTag: "ABC123"}, request)
+	case <-time.After(time.Second):
+		s.Fail("request not published")
+	}
+
+	// The removed input is not listened to anymore
+	s.bus.RemoveInput("reader")
+	time.Sleep(50 * time.Millisecond)
+	tags <- "abc123"
+
+	select {
+	case <-s.requests:
+		s.Fail("request published after the input was removed")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestBus(t *testing.T) {
+	suite.Run(t, new(busTestSuite))
+}
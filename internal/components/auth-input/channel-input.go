@@ -0,0 +1,35 @@
+package authInput
+
+import (
+	"context"
+	"strings"
+)
+
+// channelInput publishes the ids received on the channel, e.g. the tags read by an RFID/NFC reader.
+type channelInput struct {
+	source  Source
+	channel <-chan string
+}
+
+// NewChannelInput creates an Input that publishes every id received on the channel as an upper-case IdTag.
+func NewChannelInput(source Source, channel <-chan string) Input {
+	return &channelInput{source: source, channel: channel}
+}
+
+func (i *channelInput) Listen(ctx context.Context, publish func(request Request)) {
+	for {
+		select {
+		case id, isOpen := <-i.channel:
+			// The producer was cleaned up
+			if !isOpen {
+				return
+			}
+
+			publish(Request{Source: i.source, IdTag: strings.ToUpper(id)})
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (i *channelInput) Cleanup() {}
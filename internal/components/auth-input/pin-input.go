@@ -0,0 +1,99 @@
+package authInput
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+const (
+	// pinTimeout clears the entry if no key is pressed.
+	pinTimeout   = 30 * time.Second
+	maxPinLength = 20
+)
+
+// pinInput collects the digits pressed on a keypad into a PIN. The '*' key clears the entry and the '#' key submits it.
+// The keys 'A' to 'D' select the connectors 1 to 4, otherwise the charge point chooses the connector.
+type pinInput struct {
+	keys      <-chan rune
+	onChange  func(length int)
+	pin       strings.Builder
+	connector int
+}
+
+// NewPinInput creates an Input that publishes the PINs entered on the keypad. The onChange callback receives the
+// number of digits entered, so the entry can be shown (masked) on the display.
+func NewPinInput(keys <-chan rune, onChange func(length int)) Input {
+	return &pinInput{keys: keys, onChange: onChange}
+}
+
+func (i *pinInput) Listen(ctx context.Context, publish func(request Request)) {
+	timeout := time.NewTimer(pinTimeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case key, isOpen := <-i.keys:
+			if !isOpen {
+				return
+			}
+
+			if !timeout.Stop() {
+				select {
+				case <-timeout.C:
+				default:
+				}
+			}
+			timeout.Reset(pinTimeout)
+
+			i.handleKey(key, publish)
+		case <-timeout.C:
+			i.reset()
+			timeout.Reset(pinTimeout)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (i *pinInput) handleKey(key rune, publish func(request Request)) {
+	switch {
+	case key >= '0' && key <= '9':
+		if i.pin.Len() >= maxPinLength {
+			return
+		}
+
+		i.pin.WriteRune(key)
+		i.changed()
+	case key >= 'A' && key <= 'D':
+		i.connector = int(key-'A') + 1
+	case key == '*':
+		i.reset()
+	case key == '#':
+		if i.pin.Len() == 0 {
+			return
+		}
+
+		request := Request{Source: SourcePin, IdTag: i.pin.String(), ConnectorId: i.connector}
+		i.reset()
+		publish(request)
+	}
+}
+
+func (i *pinInput) reset() {
+	if i.pin.Len() == 0 && i.connector == 0 {
+		return
+	}
+
+	i.pin.Reset()
+	i.connector = 0
+	i.changed()
+}
+
+func (i *pinInput) changed() {
+	if i.onChange != nil {
+		i.onChange(i.pin.Len())
+	}
+}
+
+func (i *pinInput) Cleanup() {}
@@ -0,0 +1,91 @@
+package authInput
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type pinInputTestSuite struct {
+	suite.Suite
+	keys     chan rune
+	requests chan Request
+	lengths  chan int
+	cancel   context.CancelFunc
+}
+
+func (s *pinInputTestSuite) SetupTest() {
+	var ctx context.Context
+
+	s.keys = make(chan rune, 10)
+	s.requests = make(chan Request, 5)
+	s.lengths = make(chan int, 50)
+	ctx, s.cancel = context.WithCancel(context.Background())
+
+	input := NewPinInput(s.keys, func(length int) {
+		s.lengths <- length
+	})
+
+	go input.Listen(ctx, func(request Request) {
+		s.requests <- request
+	})
+}
+
+func (s *pinInputTestSuite) TearDownTest() {
+	s.cancel()
+}
+
+func (s *pinInputTestSuite) pressKeys(keys string) {
+	for _, key := range keys {
+		s.keys <- key
+	}
+}
+
+func (s *pinInputTestSuite) expectRequest(expected Request) {
+	select {
+	case request := <-s.requests:
+		s.Assert().Equal(expected, request)
+	case <-time.After(time.Second):
+		s.Fail("request not published")
+	}
+}
+
+func (s *pinInputTestSuite) TestEnterPin() {
+	s.pressKeys("1234#")
+	s.expectRequest(Request{Source: SourcePin, IdTag: "1234"})
+
+	// Select the connector
+	s.pressKeys("B5678#")
+	s.expectRequest(Request{Source: SourcePin, IdTag: "5678", ConnectorId: 2})
+}
+
+func (s *pinInputTestSuite) TestClearPin() {
+	s.pressKeys("12*34#")
+	s.expectRequest(Request{Source: SourcePin, IdTag: "34"})
+
+	// Empty PINs are not submitted
+	s.pressKeys("#*#")
+	select {
+	case <-s.requests:
+		s.Fail("empty PIN submitted")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func (s *pinInputTestSuite) TestPinLength() {
+	s.pressKeys("12")
+
+	s.Assert().Eventually(func() bool {
+		return len(s.lengths) == 2
+	}, time.Second, 10*time.Millisecond)
+	s.Assert().EqualValues(1, <-s.lengths)
+	s.Assert().EqualValues(2, <-s.lengths)
+
+	s.pressKeys("*")
+	s.Assert().EqualValues(0, <-s.lengths)
+}
+
+func TestPinInput(t *testing.T) {
+	suite.Run(t, new(pinInputTestSuite))
+}
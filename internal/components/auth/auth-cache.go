@@ -2,13 +2,12 @@ package auth
 
 import (
 	"fmt"
-	"github.com/kkyr/fig"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	goCache "github.com/patrickmn/go-cache"
 	log "github.com/sirupsen/logrus"
-	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
-	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
-	"path/filepath"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"sort"
 	"strings"
 	"time"
 )
@@ -20,50 +19,65 @@ const (
 
 type (
 	Cache struct {
-		cache    *goCache.Cache
-		filePath string
+		cache      *goCache.Cache
+		repository store.AuthRepository
+	}
+
+	// TagGroup is the group (parentIdTag) of a tag with the cached tags belonging to the same group.
+	TagGroup struct {
+		IdTag       string   `json:"idTag"`
+		ParentIdTag string   `json:"parentIdTag,omitempty"`
+		Members     []string `json:"members"`
 	}
 )
 
-func NewAuthCache(filePath string) *Cache {
+// NewAuthCache creates an authorization cache, which persists the tags in the repository. If the repository is nil,
+// the tags are only kept in memory.
+func NewAuthCache(repository store.AuthRepository) *Cache {
 	cache := goCache.New(time.Minute*10, time.Minute*10)
 	// Defaults
 	cache.Set(VersionKey, 1, goCache.NoExpiration)
 	cache.Set(MaxTagsKey, 0, goCache.NoExpiration)
 
 	return &Cache{
-		cache:    cache,
-		filePath: filePath,
+		cache:      cache,
+		repository: repository,
 	}
 }
 
-// LoadAuthFile loads tags from the cache file
-func (c *Cache) LoadAuthFile() {
-	var (
-		auth settingsData.AuthorizationFile
-		err  error
-	)
+// LoadTags loads the tags from the repository
+func (c *Cache) LoadTags() {
+	if util.IsNilInterfaceOrPointer(c.repository) {
+		return
+	}
 
-	err = fig.Load(&auth,
-		fig.File(filepath.Base(c.filePath)),
-		fig.Dirs(filepath.Dir(c.filePath)))
-	if err != nil {
-		//todo temporary fix - tags with ExpiryDate won't unmarshall successfully
-		log.WithError(err).Errorf("Unable to load authorization file")
+	info, err := c.repository.GetAuthInfo()
+	switch err {
+	case nil:
+		c.cache.Set(VersionKey, info.Version, goCache.NoExpiration)
+		c.cache.Set(MaxTagsKey, info.MaxCachedTags, goCache.NoExpiration)
+	case store.ErrNotFound:
+	default:
+		log.WithError(err).Errorf("Unable to load authorization info")
 	}
 
-	c.cache.Set(VersionKey, auth.Version, goCache.NoExpiration)
-	c.cache.Set(MaxTagsKey, auth.MaxCachedTags, goCache.NoExpiration)
-	loadTags(c.cache, auth.Tags)
+	tags, err := c.repository.GetTags()
+	if err != nil {
+		log.WithError(err).Errorf("Unable to load tags")
+		return
+	}
 
-	log.Infof("Read auth file version %d with Tags %s", auth.Version, auth.Tags)
+	loadTags(c.cache, tags)
+	log.Infof("Loaded %d tags", len(tags))
 }
 
-// AddTag Add a tag to the global authorization cache.
+// AddTag Add a tag to the global authorization cache. If the tag is already cached, its information (e.g. the status
+// or the parent tag) is updated.
 func (c *Cache) AddTag(tagId string, tagInfo *types.IdTagInfo) {
 	var (
 		maxTags        int
 		expirationTime = time.Minute * 10
+		key            = fmt.Sprintf("AuthTag%s", tagId)
 	)
 
 	cacheMaxTags, isFound := c.cache.Get(MaxTagsKey)
@@ -72,7 +86,8 @@ func (c *Cache) AddTag(tagId string, tagInfo *types.IdTagInfo) {
 	}
 	maxTags = cacheMaxTags.(int)
 
-	if c.cache.ItemCount() >= maxTags+2 {
+	_, isCached := c.cache.Get(key)
+	if !isCached && c.cache.ItemCount() >= maxTags+2 {
 		return
 	}
 
@@ -80,16 +95,26 @@ func (c *Cache) AddTag(tagId string, tagInfo *types.IdTagInfo) {
 		expirationTime = tagInfo.ExpiryDate.Sub(time.Now())
 	}
 
-	// Add a tag if it doesn't exist in the cache already
-	err := c.cache.Add(fmt.Sprintf("AuthTag%s", tagId), *tagInfo, expirationTime)
-	if err != nil {
-		log.WithError(err).Errorf("Error adding tag to cache")
+	c.cache.Set(key, *tagInfo, expirationTime)
+
+	if !util.IsNilInterfaceOrPointer(c.repository) {
+		err := c.repository.SaveTag(tagId, *tagInfo)
+		if err != nil {
+			log.WithError(err).Errorf("Error persisting tag")
+		}
 	}
 }
 
 // RemoveTag Remove a tag from the global authorization cache.
 func (c *Cache) RemoveTag(tagId string) {
 	c.cache.Delete(fmt.Sprintf("AuthTag%s", tagId))
+
+	if !util.IsNilInterfaceOrPointer(c.repository) {
+		err := c.repository.DeleteTag(tagId)
+		if err != nil {
+			log.WithError(err).Errorf("Error removing persisted tag")
+		}
+	}
 }
 
 // RemoveCachedTags Remove all Tags from the global authorization cache.
@@ -113,6 +138,13 @@ func (c *Cache) RemoveCachedTags() {
 	// Reset the version and max tags
 	c.cache.Set(VersionKey, version, goCache.NoExpiration)
 	c.cache.Set(MaxTagsKey, maxCachedTags, goCache.NoExpiration)
+
+	if !util.IsNilInterfaceOrPointer(c.repository) {
+		err := c.repository.ReplaceTags(map[string]types.IdTagInfo{})
+		if err != nil {
+			log.WithError(err).Errorf("Error removing persisted tags")
+		}
+	}
 }
 
 // SetMaxCachedTags Set the maximum number of Tags allowed in the global authorization cache.
@@ -123,10 +155,15 @@ func (c *Cache) SetMaxCachedTags(number int) {
 	}
 }
 
+// DumpTags persists the version, the max cached tags and all the valid tags in the repository.
 func (c *Cache) DumpTags() {
-	log.Debug("Writing tags to file..")
+	if util.IsNilInterfaceOrPointer(c.repository) {
+		return
+	}
+
+	log.Debug("Persisting tags..")
 	var (
-		authTags                  []types.IdTagInfo
+		authTags                  = map[string]types.IdTagInfo{}
 		version, isVersionFound   = c.cache.Get(VersionKey)
 		maxCachedTags, isMaxFound = c.cache.Get(MaxTagsKey)
 	)
@@ -140,18 +177,22 @@ func (c *Cache) DumpTags() {
 	}
 
 	for key, item := range c.cache.Items() {
-		if strings.Contains(key, "AuthTag") && !item.Expired() {
-			authTags = append(authTags, item.Object.(types.IdTagInfo))
+		if strings.HasPrefix(key, "AuthTag") && !item.Expired() {
+			authTags[strings.TrimPrefix(key, "AuthTag")] = item.Object.(types.IdTagInfo)
 		}
 	}
 
-	err := settings.WriteToFile(c.filePath, settingsData.AuthorizationFile{
+	err := c.repository.SetAuthInfo(store.AuthInfo{
 		Version:       version.(int),
 		MaxCachedTags: maxCachedTags.(int),
-		Tags:          authTags,
 	})
 	if err != nil {
-		log.WithError(err).Errorf("Error updating auth cache file")
+		log.WithError(err).Errorf("Error persisting authorization info")
+	}
+
+	err = c.repository.ReplaceTags(authTags)
+	if err != nil {
+		log.WithError(err).Errorf("Error persisting tags")
 	}
 }
 
@@ -183,17 +224,52 @@ func (c *Cache) IsTagAuthorized(tagId string) bool {
 	return false
 }
 
-// loadTags loads the tags into the cache
-func loadTags(cache *goCache.Cache, tags []types.IdTagInfo) {
-	if tags != nil {
-		for _, tag := range tags {
-			log.Tracef("Adding tag: %v", tag)
-			if tag.ExpiryDate != nil {
-				cache.Set(fmt.Sprintf("AuthTag%s", tag.ParentIdTag), tag, tag.ExpiryDate.Sub(time.Now()))
-				continue
-			}
+// GetTagInfo returns the information about the tag from the global authorization cache.
+func (c *Cache) GetTagInfo(tagId string) (*types.IdTagInfo, bool) {
+	tagObject, isFound := c.cache.Get(fmt.Sprintf("AuthTag%s", tagId))
+	if !isFound {
+		return nil, false
+	}
+
+	tagInfo := tagObject.(types.IdTagInfo)
+	return &tagInfo, true
+}
 
-			cache.SetDefault(fmt.Sprintf("AuthTag%s", tag.ParentIdTag), tag)
+// GetGroupMembers returns the cached tags with the parent tag, ordered by the tag id.
+func (c *Cache) GetGroupMembers(parentIdTag string) []string {
+	members := []string{}
+	if parentIdTag == "" {
+		return members
+	}
+
+	for key, item := range c.cache.Items() {
+		if !strings.HasPrefix(key, "AuthTag") || item.Expired() {
+			continue
 		}
+
+		tagInfo := item.Object.(types.IdTagInfo)
+		if tagInfo.ExpiryDate != nil && tagInfo.ExpiryDate.Before(time.Now()) {
+			continue
+		}
+
+		if tagInfo.ParentIdTag == parentIdTag {
+			members = append(members, strings.TrimPrefix(key, "AuthTag"))
+		}
+	}
+
+	sort.Strings(members)
+	return members
+}
+
+// loadTags loads the tags into the cache
+func loadTags(cache *goCache.Cache, tags map[string]types.IdTagInfo) {
+	for tagId, tag := range tags {
+		log.Tracef("Adding tag: %v", tag)
+		if tag.ExpiryDate != nil {
+			cache.Set(fmt.Sprintf("AuthTag%s", tagId), tag, tag.ExpiryDate.Sub(time.Now()))
+			continue
+		}
+
+		cache.SetDefault(fmt.Sprintf("AuthTag%s", tagId), tag)
 	}
 }
@@ -17,7 +17,7 @@ type AuthCacheTestSuite struct {
 }
 
 func (s *AuthCacheTestSuite) SetupTest() {
-	s.authCache = NewAuthCache("./auth.json")
+	s.authCache = NewAuthCache(nil)
 	s.tag = &types.IdTagInfo{
 		ParentIdTag: "123",
 		ExpiryDate:  types.NewDateTime(time.Now().Add(10 * time.Minute)),
@@ -53,6 +53,10 @@ func (s *AuthCacheTestSuite) TestAddTag() {
 	// Test cached tag limit
 	s.authCache.AddTag(overLimitTag.ParentIdTag, &overLimitTag)
 	s.Require().False(s.authCache.IsTagAuthorized(overLimitTag.ParentIdTag))
+
+	// The cached tag is updated
+	s.authCache.AddTag(s.tag.ParentIdTag, s.blockedTag)
+	s.Require().False(s.authCache.IsTagAuthorized(s.tag.ParentIdTag))
 }
 
 func (s *AuthCacheTestSuite) TestIsTagAuthorized() {
@@ -67,6 +71,36 @@ func (s *AuthCacheTestSuite) TestIsTagAuthorized() {
 	s.Require().False(s.authCache.IsTagAuthorized(s.expiredTag.ParentIdTag))
 }
 
+func (s *AuthCacheTestSuite) TestGetTagInfo() {
+	s.authCache.SetMaxCachedTags(5)
+	s.authCache.AddTag(s.tag.ParentIdTag, s.tag)
+
+	tagInfo, isFound := s.authCache.GetTagInfo(s.tag.ParentIdTag)
+	s.Require().True(isFound)
+	s.Assert().EqualValues(s.tag.ParentIdTag, tagInfo.ParentIdTag)
+	s.Assert().EqualValues(s.tag.Status, tagInfo.Status)
+
+	_, isFound = s.authCache.GetTagInfo("unknownTag")
+	s.Assert().False(isFound)
+}
+
+func (s *AuthCacheTestSuite) TestGetGroupMembers() {
+	s.authCache.SetMaxCachedTags(5)
+	s.authCache.AddTag("tag2", s.tag)
+	s.authCache.AddTag("tag1", s.tag)
+	s.authCache.AddTag("blockedTag", s.blockedTag)
+	s.authCache.AddTag("expiredTag", &types.IdTagInfo{
+		ParentIdTag: s.tag.ParentIdTag,
+		ExpiryDate:  types.NewDateTime(time.Now().Add(-time.Minute)),
+		Status:      types.AuthorizationStatusAccepted,
+	})
+
+	s.Assert().EqualValues([]string{"tag1", "tag2"}, s.authCache.GetGroupMembers(s.tag.ParentIdTag))
+	s.Assert().EqualValues([]string{"blockedTag"}, s.authCache.GetGroupMembers(s.blockedTag.ParentIdTag))
+	s.Assert().Empty(s.authCache.GetGroupMembers("unknownGroup"))
+	s.Assert().Empty(s.authCache.GetGroupMembers(""))
+}
+
 func (s *AuthCacheTestSuite) TestRemoveCachedTags() {
 	s.authCache.SetMaxCachedTags(5)
 
@@ -0,0 +1,462 @@
+package certificates
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"hash"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Types of the installed CA certificates
+const (
+	CentralSystemRootCertificate = Use("CentralSystemRootCertificate")
+	ManufacturerRootCertificate  = Use("ManufacturerRootCertificate")
+)
+
+// Supported hash algorithms of the certificate hash data
+const (
+	SHA256 = HashAlgorithm("SHA256")
+	SHA384 = HashAlgorithm("SHA384")
+	SHA512 = HashAlgorithm("SHA512")
+)
+
+const (
+	chargePointKeyFile         = "charge-point.key"
+	chargePointCertificateFile = "charge-point.crt"
+	pendingKeyFile             = "charge-point.key.pending"
+	pendingCsrFile             = "charge-point.csr.pending"
+	caFolder                   = "ca"
+
+	// The keys are only readable by the owner
+	privateFileMode   = 0600
+	privateFolderMode = 0700
+)
+
+var (
+	ErrNoPendingRequest         = errors.New("no pending certificate signing request")
+	ErrInvalidCertificate       = errors.New("invalid certificate")
+	ErrCertificateMismatch      = errors.New("certificate does not match the signing request")
+	ErrCertificateStoreFull     = errors.New("certificate store is full")
+	ErrCertificateNotFound      = errors.New("certificate not found")
+	ErrNoChargePointCertificate = errors.New("no charge point certificate installed")
+)
+
+type (
+	Use           string
+	HashAlgorithm string
+
+	// HashData identifies a certificate, as defined by the OCPP security extension.
+	HashData struct {
+		HashAlgorithm  HashAlgorithm
+		IssuerNameHash string
+		IssuerKeyHash  string
+		SerialNumber   string
+	}
+
+	// Manager stores the charge point key and certificate and the installed CA certificates in a folder.
+	Manager struct {
+		mu              sync.Mutex
+		folder          string
+		maxCertificates int
+	}
+
+	subjectPublicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+)
+
+// NewManager creates the certificate folders with restrictive permissions. If maxCertificates is zero, the number of
+// installed CA certificates is not limited.
+func NewManager(folder string, maxCertificates int) (*Manager, error) {
+	for _, use := range []Use{CentralSystemRootCertificate, ManufacturerRootCertificate} {
+		err := os.MkdirAll(filepath.Join(folder, caFolder, string(use)), privateFolderMode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Manager{
+		folder:          folder,
+		maxCertificates: maxCertificates,
+	}, nil
+}
+
+// SetMaxCertificates limits the number of the installed CA certificates.
+func (m *Manager) SetMaxCertificates(maxCertificates int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxCertificates = maxCertificates
+}
+
+// GenerateCSR generates a new key pair and returns a PEM encoded certificate signing request. The key is used after
+// the signed certificate is installed.
+func (m *Manager) GenerateCSR(commonName, organization string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := pkix.Name{CommonName: commonName}
+	if organization != "" {
+		subject.Organization = []string{organization}
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: subject}, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyBytes, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		keyPem = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+		csrPem = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})
+	)
+
+	err = m.writeFile(pendingKeyFile, keyPem)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.writeFile(pendingCsrFile, csrPem)
+	if err != nil {
+		return nil, err
+	}
+
+	return csrPem, nil
+}
+
+// InstallChargePointCertificate verifies the signed certificate chain against the pending signing request and
+// replaces the charge point certificate and key.
+func (m *Manager) InstallChargePointCertificate(chainPem []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	csrPem, err := ioutil.ReadFile(filepath.Join(m.folder, pendingCsrFile))
+	if err != nil {
+		return ErrNoPendingRequest
+	}
+
+	csrBlock, _ := pem.Decode(csrPem)
+	if csrBlock == nil {
+		return ErrNoPendingRequest
+	}
+
+	csr, err := x509.ParseCertificateRequest(csrBlock.Bytes)
+	if err != nil {
+		return ErrNoPendingRequest
+	}
+
+	chain, err := parseCertificates(chainPem)
+	if err != nil {
+		return err
+	}
+
+	var (
+		leaf = chain[0]
+		now  = time.Now()
+	)
+
+	if now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return fmt.Errorf("%w: certificate is not valid at %s", ErrInvalidCertificate, now.Format(time.RFC3339))
+	}
+
+	if leaf.Subject.CommonName != csr.Subject.CommonName || !publicKeysEqual(leaf.PublicKey, csr.PublicKey) {
+		return ErrCertificateMismatch
+	}
+
+	// Verify the chain if the root certificate of the central system is installed
+	if roots := m.certificatePool(CentralSystemRootCertificate); roots != nil {
+		intermediates := x509.NewCertPool()
+		for _, certificate := range chain[1:] {
+			intermediates.AddCert(certificate)
+		}
+
+		_, err = leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+		}
+	}
+
+	keyPem, err := ioutil.ReadFile(filepath.Join(m.folder, pendingKeyFile))
+	if err != nil {
+		return ErrNoPendingRequest
+	}
+
+	err = m.writeFile(chargePointCertificateFile, chainPem)
+	if err != nil {
+		return err
+	}
+
+	err = m.writeFile(chargePointKeyFile, keyPem)
+	if err != nil {
+		return err
+	}
+
+	_ = os.Remove(filepath.Join(m.folder, pendingKeyFile))
+	_ = os.Remove(filepath.Join(m.folder, pendingCsrFile))
+	return nil
+}
+
+// GetChargePointCertificate returns the installed charge point certificate.
+func (m *Manager) GetChargePointCertificate() (*tls.Certificate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	certificate, err := tls.LoadX509KeyPair(
+		filepath.Join(m.folder, chargePointCertificateFile),
+		filepath.Join(m.folder, chargePointKeyFile),
+	)
+	if err != nil {
+		return nil, ErrNoChargePointCertificate
+	}
+
+	return &certificate, nil
+}
+
+// InstallCertificate installs a CA certificate.
+func (m *Manager) InstallCertificate(use Use, certificatePem []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	certificates, err := parseCertificates(certificatePem)
+	if err != nil {
+		return err
+	}
+
+	var (
+		certificate = certificates[0]
+		now         = time.Now()
+	)
+
+	if !certificate.IsCA || now.Before(certificate.NotBefore) || now.After(certificate.NotAfter) {
+		return fmt.Errorf("%w: not a valid CA certificate", ErrInvalidCertificate)
+	}
+
+	fileName := m.caFilePath(use, certificate)
+	if _, err = os.Stat(fileName); err == nil {
+		// Already installed
+		return nil
+	}
+
+	if m.maxCertificates > 0 && len(m.loadCertificates(CentralSystemRootCertificate))+len(m.loadCertificates(ManufacturerRootCertificate)) >= m.maxCertificates {
+		return ErrCertificateStoreFull
+	}
+
+	return ioutil.WriteFile(fileName, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}), privateFileMode)
+}
+
+// DeleteCertificate deletes the CA certificate with the hash data.
+func (m *Manager) DeleteCertificate(hashData HashData) (Use, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, use := range []Use{CentralSystemRootCertificate, ManufacturerRootCertificate} {
+		certificates := m.loadCertificates(use)
+		for _, certificate := range certificates {
+			certificateHashData, err := getHashData(certificate, certificates, hashData.HashAlgorithm)
+			if err != nil {
+				return "", err
+			}
+
+			if certificateHashData.equal(hashData) {
+				return use, os.Remove(m.caFilePath(use, certificate))
+			}
+		}
+	}
+
+	return "", ErrCertificateNotFound
+}
+
+// GetInstalledCertificateIds returns the SHA256 hash data of the installed CA certificates of the type.
+func (m *Manager) GetInstalledCertificateIds(use Use) ([]HashData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		certificates = m.loadCertificates(use)
+		hashData     []HashData
+	)
+
+	for _, certificate := range certificates {
+		certificateHashData, err := getHashData(certificate, certificates, SHA256)
+		if err != nil {
+			return nil, err
+		}
+
+		hashData = append(hashData, *certificateHashData)
+	}
+
+	return hashData, nil
+}
+
+// GetCertificatePool returns the installed CA certificates of the type or nil if none are installed.
+func (m *Manager) GetCertificatePool(use Use) *x509.CertPool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.certificatePool(use)
+}
+
+func (m *Manager) certificatePool(use Use) *x509.CertPool {
+	certificates := m.loadCertificates(use)
+	if len(certificates) == 0 {
+		return nil
+	}
+
+	pool := x509.NewCertPool()
+	for _, certificate := range certificates {
+		pool.AddCert(certificate)
+	}
+
+	return pool
+}
+
+func (m *Manager) loadCertificates(use Use) []*x509.Certificate {
+	var (
+		certificates []*x509.Certificate
+		folder       = filepath.Join(m.folder, caFolder, string(use))
+	)
+
+	files, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return nil
+	}
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".pem") {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(folder, file.Name()))
+		if err != nil {
+			log.WithError(err).Warnf("Unable to read the certificate %s", file.Name())
+			continue
+		}
+
+		parsed, err := parseCertificates(content)
+		if err != nil {
+			log.WithError(err).Warnf("Unable to parse the certificate %s", file.Name())
+			continue
+		}
+
+		certificates = append(certificates, parsed[0])
+	}
+
+	return certificates
+}
+
+func (m *Manager) caFilePath(use Use, certificate *x509.Certificate) string {
+	fingerprint := sha256.Sum256(certificate.Raw)
+	return filepath.Join(m.folder, caFolder, string(use), hex.EncodeToString(fingerprint[:])+".pem")
+}
+
+func (m *Manager) writeFile(fileName string, content []byte) error {
+	return ioutil.WriteFile(filepath.Join(m.folder, fileName), content, privateFileMode)
+}
+
+func (h HashData) equal(other HashData) bool {
+	return h.HashAlgorithm == other.HashAlgorithm &&
+		strings.EqualFold(h.IssuerNameHash, other.IssuerNameHash) &&
+		strings.EqualFold(h.IssuerKeyHash, other.IssuerKeyHash) &&
+		strings.EqualFold(strings.TrimLeft(h.SerialNumber, "0"), strings.TrimLeft(other.SerialNumber, "0"))
+}
+
+// getHashData calculates the hash data of the certificate. The issuer key is taken from the certificate itself if it
+// is self-signed, otherwise from the issuer among the candidates.
+func getHashData(certificate *x509.Certificate, candidates []*x509.Certificate, algorithm HashAlgorithm) (*HashData, error) {
+	var newHash func() hash.Hash
+	switch algorithm {
+	case SHA256:
+		newHash = sha256.New
+	case SHA384:
+		newHash = sha512.New384
+	case SHA512:
+		newHash = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %s", algorithm)
+	}
+
+	issuer := certificate
+	for _, candidate := range candidates {
+		if candidate != certificate && certificate.CheckSignatureFrom(candidate) == nil {
+			issuer = candidate
+			break
+		}
+	}
+
+	var publicKeyInfo subjectPublicKeyInfo
+	_, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &publicKeyInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	nameHash := newHash()
+	nameHash.Write(certificate.RawIssuer)
+
+	keyHash := newHash()
+	keyHash.Write(publicKeyInfo.PublicKey.Bytes)
+
+	return &HashData{
+		HashAlgorithm:  algorithm,
+		IssuerNameHash: hex.EncodeToString(nameHash.Sum(nil)),
+		IssuerKeyHash:  hex.EncodeToString(keyHash.Sum(nil)),
+		SerialNumber:   certificate.SerialNumber.Text(16),
+	}, nil
+}
+
+func parseCertificates(content []byte) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+
+	for block, rest := pem.Decode(content); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+		}
+
+		certificates = append(certificates, certificate)
+	}
+
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("%w: no PEM encoded certificates found", ErrInvalidCertificate)
+	}
+
+	return certificates, nil
+}
+
+func publicKeysEqual(a, b interface{}) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
@@ -0,0 +1,200 @@
+package certificates
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/suite"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type (
+	certificateAuthority struct {
+		certificate *x509.Certificate
+		key         *ecdsa.PrivateKey
+		pem         []byte
+	}
+
+	certificatesTestSuite struct {
+		suite.Suite
+		folder  string
+		manager *Manager
+	}
+)
+
+func newCertificateAuthority(serialNumber int64) (*certificateAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serialNumber),
+		Subject:               pkix.Name{CommonName: "Central System Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, err := x509.ParseCertificate(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &certificateAuthority{
+		certificate: certificate,
+		key:         key,
+		pem:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: raw}),
+	}, nil
+}
+
+// sign signs the certificate signing request with the certificate authority.
+func (ca *certificateAuthority) sign(csrPem []byte) ([]byte, error) {
+	block, _ := pem.Decode(csrPem)
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(100),
+		Subject:      csr.Subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, csr.PublicKey, ca.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: raw}), nil
+}
+
+func (s *certificatesTestSuite) SetupTest() {
+	var err error
+	s.folder = s.T().TempDir()
+
+	s.manager, err = NewManager(s.folder, 2)
+	s.Require().NoError(err)
+}
+
+func (s *certificatesTestSuite) TestChargePointCertificate() {
+	ca, err := newCertificateAuthority(1)
+	s.Require().NoError(err)
+	s.Require().NoError(s.manager.InstallCertificate(CentralSystemRootCertificate, ca.pem))
+
+	// No signing request was made
+	_, err = s.manager.GetChargePointCertificate()
+	s.Assert().ErrorIs(err, ErrNoChargePointCertificate)
+	s.Assert().ErrorIs(s.manager.InstallChargePointCertificate(ca.pem), ErrNoPendingRequest)
+
+	csr, err := s.manager.GenerateCSR("ChargePi", "ChargePi CPO")
+	s.Require().NoError(err)
+
+	info, err := os.Stat(filepath.Join(s.folder, pendingKeyFile))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(privateFileMode, info.Mode().Perm())
+
+	// A certificate for a different key is rejected
+	otherManager, err := NewManager(s.T().TempDir(), 0)
+	s.Require().NoError(err)
+	otherCsr, err := otherManager.GenerateCSR("ChargePi", "")
+	s.Require().NoError(err)
+	otherCertificate, err := ca.sign(otherCsr)
+	s.Require().NoError(err)
+	s.Assert().ErrorIs(s.manager.InstallChargePointCertificate(otherCertificate), ErrCertificateMismatch)
+
+	certificate, err := ca.sign(csr)
+	s.Require().NoError(err)
+	s.Require().NoError(s.manager.InstallChargePointCertificate(certificate))
+
+	tlsCertificate, err := s.manager.GetChargePointCertificate()
+	s.Require().NoError(err)
+	s.Assert().NotNil(tlsCertificate)
+
+	info, err = os.Stat(filepath.Join(s.folder, chargePointKeyFile))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(privateFileMode, info.Mode().Perm())
+
+	// The pending request is removed after the certificate is installed
+	s.Assert().ErrorIs(s.manager.InstallChargePointCertificate(certificate), ErrNoPendingRequest)
+}
+
+func (s *certificatesTestSuite) TestUntrustedChargePointCertificate() {
+	trusted, err := newCertificateAuthority(1)
+	s.Require().NoError(err)
+	untrusted, err := newCertificateAuthority(2)
+	s.Require().NoError(err)
+	s.Require().NoError(s.manager.InstallCertificate(CentralSystemRootCertificate, trusted.pem))
+
+	csr, err := s.manager.GenerateCSR("ChargePi", "")
+	s.Require().NoError(err)
+
+	certificate, err := untrusted.sign(csr)
+	s.Require().NoError(err)
+	s.Assert().ErrorIs(s.manager.InstallChargePointCertificate(certificate), ErrInvalidCertificate)
+}
+
+func (s *certificatesTestSuite) TestCACertificates() {
+	s.Assert().Nil(s.manager.GetCertificatePool(CentralSystemRootCertificate))
+
+	centralSystemRoot, err := newCertificateAuthority(1)
+	s.Require().NoError(err)
+	manufacturerRoot, err := newCertificateAuthority(2)
+	s.Require().NoError(err)
+	thirdRoot, err := newCertificateAuthority(3)
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.manager.InstallCertificate(CentralSystemRootCertificate, centralSystemRoot.pem))
+	s.Require().NoError(s.manager.InstallCertificate(ManufacturerRootCertificate, manufacturerRoot.pem))
+	s.Assert().NotNil(s.manager.GetCertificatePool(CentralSystemRootCertificate))
+
+	// The store is limited to two certificates
+	s.Assert().ErrorIs(s.manager.InstallCertificate(CentralSystemRootCertificate, thirdRoot.pem), ErrCertificateStoreFull)
+
+	// Only CA certificates can be installed
+	s.Assert().ErrorIs(s.manager.InstallCertificate(CentralSystemRootCertificate, []byte("invalid")), ErrInvalidCertificate)
+
+	installed, err := s.manager.GetInstalledCertificateIds(CentralSystemRootCertificate)
+	s.Require().NoError(err)
+	s.Require().Len(installed, 1)
+	s.Assert().EqualValues(SHA256, installed[0].HashAlgorithm)
+	s.Assert().EqualValues("1", installed[0].SerialNumber)
+
+	info, err := os.Stat(filepath.Join(s.folder, caFolder, string(CentralSystemRootCertificate)))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(privateFolderMode, info.Mode().Perm())
+
+	use, err := s.manager.DeleteCertificate(installed[0])
+	s.Require().NoError(err)
+	s.Assert().EqualValues(CentralSystemRootCertificate, use)
+
+	_, err = s.manager.DeleteCertificate(installed[0])
+	s.Assert().ErrorIs(err, ErrCertificateNotFound)
+
+	installed, err = s.manager.GetInstalledCertificateIds(CentralSystemRootCertificate)
+	s.Require().NoError(err)
+	s.Assert().Empty(installed)
+}
+
+func TestCertificates(t *testing.T) {
+	suite.Run(t, new(certificatesTestSuite))
+}
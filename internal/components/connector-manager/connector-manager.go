@@ -13,6 +13,7 @@ import (
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"sort"
 	"sync"
 )
 
@@ -27,9 +28,13 @@ var (
 )
 
 type (
+	// Manager contains the EVSEs and their connectors. Each connector has a connector id, which is unique across the
+	// EVSEs and is used as the connector id in OCPP 1.6. Only one connector of an EVSE can be used at a time.
 	Manager interface {
 		GetConnectors() []connector.Connector
+		GetEvseConnectors(evseId int) []connector.Connector
 		FindConnector(evseId, connectorID int) connector.Connector
+		FindConnectorById(connectorId int) connector.Connector
 		FindAvailableConnector() connector.Connector
 		FindConnectorWithTagId(tagId string) connector.Connector
 		FindConnectorWithTransactionId(transactionId string) connector.Connector
@@ -38,6 +43,7 @@ type (
 		StopChargingConnector(tagId, transactionId string, reason core.Reason) error
 		StopAllConnectors(reason core.Reason) error
 		AddConnector(c connector.Connector) error
+		RemoveConnector(evseId, connectorID int) error
 		AddConnectorFromSettings(maxChargingTime int, c *settings.Connector) error
 		AddConnectorsFromConfiguration(maxChargingTime int, c []*settings.Connector) error
 		RestoreConnectorStatus(*settings.Connector) error
@@ -74,6 +80,7 @@ func NewManager(notificationChannel chan rxgo.Item) Manager {
 	}
 }
 
+// GetConnectors returns all the connectors, ordered by the connector id.
 func (m *managerImpl) GetConnectors() []connector.Connector {
 	var connectors []connector.Connector
 
@@ -85,9 +92,54 @@ func (m *managerImpl) GetConnectors() []connector.Connector {
 		return true
 	})
 
+	sort.Slice(connectors, func(i, j int) bool {
+		return connectors[i].GetConnectorId() < connectors[j].GetConnectorId()
+	})
+
+	return connectors
+}
+
+// GetEvseConnectors returns the connectors of the EVSE, ordered by the connector id.
+func (m *managerImpl) GetEvseConnectors(evseId int) []connector.Connector {
+	var connectors []connector.Connector
+
+	for _, c := range m.GetConnectors() {
+		if c.GetEvseId() == evseId {
+			connectors = append(connectors, c)
+		}
+	}
+
 	return connectors
 }
 
+// IsInUse checks if the connector is in a session, preparing or reserved, so the other connectors of its EVSE cannot be used.
+func IsInUse(c connector.Connector) bool {
+	status, _ := c.GetStatus()
+
+	switch status {
+	case core.ChargePointStatusPreparing,
+		core.ChargePointStatusCharging,
+		core.ChargePointStatusSuspendedEV,
+		core.ChargePointStatusSuspendedEVSE,
+		core.ChargePointStatusFinishing,
+		core.ChargePointStatusReserved:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsEvseInUse checks if any connector of the EVSE, except the connector with the connectorId, is in use.
+func IsEvseInUse(m Manager, evseId, connectorId int) bool {
+	for _, c := range m.GetEvseConnectors(evseId) {
+		if c.GetConnectorId() != connectorId && IsInUse(c) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (m *managerImpl) SetNotificationChannel(notificationChannel chan rxgo.Item) {
 	if notificationChannel != nil {
 		m.notificationChannel = notificationChannel
@@ -113,21 +165,26 @@ func (m *managerImpl) FindConnector(evseId, connectorID int) connector.Connector
 	return nil
 }
 
-func (m *managerImpl) FindAvailableConnector() connector.Connector {
-	var availableConnector connector.Connector
-
-	m.connectors.Range(func(key, value interface{}) bool {
-		c, canCast := value.(connector.Connector)
-		// todo check if there is no connector with the same EVSE charging
-		if canCast && c.IsAvailable() {
-			availableConnector = c
-			return false
+// FindConnectorById finds the connector by its OCPP 1.6 connector id, regardless of the EVSE.
+func (m *managerImpl) FindConnectorById(connectorId int) connector.Connector {
+	for _, c := range m.GetConnectors() {
+		if c.GetConnectorId() == connectorId {
+			return c
 		}
+	}
 
-		return true
-	})
+	return nil
+}
+
+// FindAvailableConnector returns the available connector with the lowest connector id, whose EVSE is not in use.
+func (m *managerImpl) FindAvailableConnector() connector.Connector {
+	for _, c := range m.GetConnectors() {
+		if c.IsAvailable() && !IsEvseInUse(m, c.GetEvseId(), c.GetConnectorId()) {
+			return c
+		}
+	}
 
-	return availableConnector
+	return nil
 }
 
 func (m *managerImpl) FindConnectorWithTagId(tagId string) connector.Connector {
@@ -226,6 +283,11 @@ func (m *managerImpl) AddConnector(c connector.Connector) error {
 		key = fmt.Sprintf("Evse%dConnector%d", c.GetEvseId(), c.GetConnectorId())
 	)
 
+	// The connector id must be unique across the EVSEs
+	if existing := m.FindConnectorById(c.GetConnectorId()); existing != nil && existing.GetEvseId() != c.GetEvseId() {
+		return ErrConnectorAlreadyExists
+	}
+
 	logInfo.Debugf("Adding a connector to manager")
 	c.SetNotificationChannel(m.notificationChannel)
 	c.SetMeterValuesChannel(m.meterValuesChannel)
@@ -239,6 +301,21 @@ func (m *managerImpl) AddConnector(c connector.Connector) error {
 	return nil
 }
 
+func (m *managerImpl) RemoveConnector(evseId, connectorID int) error {
+	key := fmt.Sprintf("Evse%dConnector%d", evseId, connectorID)
+
+	_, isLoaded := m.connectors.LoadAndDelete(key)
+	if !isLoaded {
+		return ErrConnectorNotFound
+	}
+
+	log.WithFields(log.Fields{
+		"evseId":      evseId,
+		"connectorId": connectorID,
+	}).Debugf("Removed a connector from manager")
+	return nil
+}
+
 func (m *managerImpl) AddConnectorFromSettings(maxChargingTime int, c *settings.Connector) error {
 	if util.IsNilInterfaceOrPointer(c) {
 		return ErrConnectorNil
@@ -347,6 +424,8 @@ func (m *managerImpl) RestoreConnectorStatus(c *settings.Connector) error {
 	case core.ChargePointStatusFaulted:
 		conn.SetStatus(core.ChargePointStatusFaulted, core.InternalError)
 		return nil
+	case core.ChargePointStatusUnavailable:
+		return nil
 	default:
 		return ErrConnectorStatusInvalid
 	}
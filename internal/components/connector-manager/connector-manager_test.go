@@ -5,6 +5,7 @@ import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
 	settingsModel "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 	"github.com/xBlaz3kx/ChargePi-go/test"
@@ -35,7 +36,7 @@ func CreateNewConnectorMock(evseId, connectorId int, session session.Session) *t
 	connector1.On("GetConnectorId").Return(connectorId)
 	connector1.On("GetEvseId").Return(evseId)
 	connector1.On("CalculateSessionAvgEnergyConsumption").Return(30.0)
-	connector1.On("GetStatus").Return(core.ChargePointStatusAvailable, core.NoError)
+	connector1.On("GetStatus").Return(string(core.ChargePointStatusAvailable), string(core.NoError))
 	connector1.On("IsAvailable").Return(true)
 	connector1.On("IsPreparing").Return(false)
 	connector1.On("IsCharging").Return(false)
@@ -112,6 +113,20 @@ func (suite *connectorManagerTestSuite) TestAddConnector() {
 	suite.Require().Contains(suite.connectorManager.GetConnectors(), suite.connector2)
 }
 
+func (suite *connectorManagerTestSuite) TestRemoveConnector() {
+	err := suite.connectorManager.RemoveConnector(1, 1)
+	suite.Require().NoError(err)
+	suite.Require().Nil(suite.connectorManager.FindConnector(1, 1))
+
+	// Connector was already removed
+	err = suite.connectorManager.RemoveConnector(1, 1)
+	suite.Require().ErrorIs(err, ErrConnectorNotFound)
+
+	// Connector can be added again
+	err = suite.connectorManager.AddConnector(suite.connector1)
+	suite.Require().NoError(err)
+}
+
 func (suite *connectorManagerTestSuite) TestStartChargingConnector() {
 	tagId := "exampleTag"
 	transactionId := "exampleTransactionId123"
@@ -195,6 +210,39 @@ func (suite *connectorManagerTestSuite) TestFindConnectorWithId() {
 	suite.Require().Nil(c)
 }
 
+func (suite *connectorManagerTestSuite) TestEvseTopology() {
+	var (
+		charging  = new(test.ConnectorMock)
+		otherEvse = CreateNewConnectorMock(2, 4, suite.chSession)
+		duplicate = CreateNewConnectorMock(2, 1, suite.chSession)
+	)
+
+	charging.On("GetEvseId").Return(1)
+	charging.On("GetConnectorId").Return(2)
+	charging.On("GetStatus").Return(string(core.ChargePointStatusCharging), string(core.NoError))
+	charging.On("IsAvailable").Return(false)
+	charging.On("SetNotificationChannel", mock.Anything).Return()
+	charging.On("SetMeterValuesChannel", mock.Anything).Return()
+
+	suite.Require().NoError(suite.connectorManager.AddConnector(otherEvse))
+	suite.Require().NoError(suite.connectorManager.AddConnector(charging))
+
+	// The connector id is unique across the EVSEs
+	suite.Require().ErrorIs(suite.connectorManager.AddConnector(duplicate), ErrConnectorAlreadyExists)
+
+	// Connectors are ordered by the connector id
+	suite.Require().Equal([]connector.Connector{suite.connector1, charging, otherEvse}, suite.connectorManager.GetConnectors())
+	suite.Require().Equal([]connector.Connector{suite.connector1, charging}, suite.connectorManager.GetEvseConnectors(1))
+
+	suite.Require().Equal(otherEvse, suite.connectorManager.FindConnectorById(4))
+	suite.Require().Nil(suite.connectorManager.FindConnectorById(0))
+
+	// The other connector of EVSE 1 is charging
+	suite.Require().True(IsEvseInUse(suite.connectorManager, 1, 1))
+	suite.Require().False(IsEvseInUse(suite.connectorManager, 1, 2))
+	suite.Require().Equal(otherEvse, suite.connectorManager.FindAvailableConnector())
+}
+
 func (suite *connectorManagerTestSuite) TestFindConnectorWithTagId() {
 	tagId := "exampleTag"
 
@@ -229,7 +277,7 @@ func (suite *connectorManagerTestSuite) TestStopAllConnectors() {
 		newConn    = new(test.ConnectorMock)
 	)
 
-	newConn.On("GetConnectorId").Return(1)
+	newConn.On("GetConnectorId").Return(4)
 	newConn.On("GetEvseId").Return(4)
 	newConn.On("SetNotificationChannel", mock.Anything).Return()
 	newConn.On("SetMeterValuesChannel", mock.Anything).Return()
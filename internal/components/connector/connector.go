@@ -17,6 +17,7 @@ import (
 	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
 	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
 	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -29,6 +30,8 @@ var (
 	ErrRelayPointerNil          = errors.New("relay pointer cannot be nil")
 	ErrSessionTimeLimitExceeded = errors.New("session time limit exceeded")
 	ErrNotCharging              = errors.New("connector not charging")
+	ErrInvalidCurrentLimit      = errors.New("invalid current limit")
+	ErrCurrentLimitNotSupported = errors.New("connector does not support current limiting")
 )
 
 type (
@@ -59,11 +62,12 @@ type (
 		RemoveReservation() error
 		GetReservationId() int
 		GetTagId() string
+		GetParentIdTag() string
 		GetTransactionId() string
 		GetConnectorId() int
 		GetEvseId() int
 		CalculateSessionAvgEnergyConsumption() float64
-		SamplePowerMeter(measurands []types.Measurand)
+		SamplePowerMeter(measurands []types.Measurand, readingContext types.ReadingContext)
 		SetStatus(status core.ChargePointStatus, errCode core.ChargePointErrorCode)
 		GetStatus() (core.ChargePointStatus, core.ChargePointErrorCode)
 		IsAvailable() bool
@@ -73,6 +77,9 @@ type (
 		IsUnavailable() bool
 		GetPowerMeter() powerMeter.PowerMeter
 		GetMaxChargingTime() int
+		SetCurrentLimit(limit float64) error
+		SetSessionEnergy(energy, meterReading float64)
+		SetSessionParentIdTag(parentIdTag string)
 	}
 )
 
@@ -131,10 +138,12 @@ func (connector *connectorImpl) StartCharging(transactionId string, tagId string
 	})
 	logInfo.Debugf("Trying to start charging on connector")
 
-	if !(connector.IsAvailable() || connector.IsPreparing()) {
+	// The reservation is consumed by the transaction
+	if !(connector.IsAvailable() || connector.IsPreparing() || connector.IsReserved()) {
 		return ErrInvalidConnectorStatus
 	}
 
+	connector.reservationId = -1
 	connector.SetStatus(core.ChargePointStatusPreparing, core.NoError)
 	sessionErr := connector.session.StartSession(transactionId, tagId)
 	if sessionErr != nil {
@@ -144,16 +153,7 @@ func (connector *connectorImpl) StartCharging(transactionId string, tagId string
 	connector.relay.Enable()
 	connector.SetStatus(core.ChargePointStatusCharging, core.NoError)
 
-	settings.UpdateConnectorSessionInfo(
-		connector.EvseId,
-		connector.ConnectorId,
-		&settingsModel.Session{
-			IsActive:      connector.session.IsActive,
-			TagId:         connector.session.TagId,
-			TransactionId: connector.session.TransactionId,
-			Started:       connector.session.Started,
-			Consumption:   connector.session.Consumption,
-		})
+	connector.updateSessionInfo()
 
 	if connector.PowerMeterEnabled && connector.GetPowerMeter() != nil {
 		sampleError := connector.preparePowerMeterAtConnector()
@@ -197,8 +197,11 @@ func (connector *connectorImpl) ResumeCharging(session session.Session) (err err
 		}
 
 		connector.relay.Enable()
+		connector.session.ParentIdTag = session.ParentIdTag
 		connector.session.Started = session.Started
 		connector.session.Consumption = append(connector.session.Consumption, session.Consumption...)
+		connector.session.Energy = session.Energy
+		connector.session.MeterReading = session.MeterReading
 		return nil, chargingTimeElapsed
 	}
 
@@ -218,16 +221,7 @@ func (connector *connectorImpl) StopCharging(reason core.Reason) error {
 		connector.session.EndSession()
 		connector.relay.Disable()
 
-		settings.UpdateConnectorSessionInfo(
-			connector.EvseId,
-			connector.ConnectorId,
-			&settingsModel.Session{
-				IsActive:      connector.session.IsActive,
-				TagId:         connector.session.TagId,
-				TransactionId: connector.session.TransactionId,
-				Started:       connector.session.Started,
-				Consumption:   connector.session.Consumption,
-			})
+		connector.updateSessionInfo()
 
 		switch reason {
 		case core.ReasonEVDisconnected:
@@ -246,9 +240,49 @@ func (connector *connectorImpl) StopCharging(reason core.Reason) error {
 	return ErrNotCharging
 }
 
+// SetSessionEnergy persists the energy consumed by the active session until the meter reading, so the energy is not
+// lost if the charge point restarts.
+func (connector *connectorImpl) SetSessionEnergy(energy, meterReading float64) {
+	if !connector.session.IsActive {
+		return
+	}
+
+	connector.session.Energy = energy
+	connector.session.MeterReading = meterReading
+	connector.updateSessionInfo()
+}
+
+// SetSessionParentIdTag persists the group of the tag, which started the active session.
+func (connector *connectorImpl) SetSessionParentIdTag(parentIdTag string) {
+	if !connector.session.IsActive {
+		return
+	}
+
+	connector.session.ParentIdTag = parentIdTag
+	connector.updateSessionInfo()
+}
+
+// updateSessionInfo persists the session of the connector.
+func (connector *connectorImpl) updateSessionInfo() {
+	settings.UpdateConnectorSessionInfo(
+		connector.EvseId,
+		connector.ConnectorId,
+		&settingsModel.Session{
+			IsActive:      connector.session.IsActive,
+			TagId:         connector.session.TagId,
+			ParentIdTag:   connector.session.ParentIdTag,
+			TransactionId: connector.session.TransactionId,
+			Started:       connector.session.Started,
+			Consumption:   connector.session.Consumption,
+			Energy:        connector.session.Energy,
+			MeterReading:  connector.session.MeterReading,
+		})
+}
+
 // SamplePowerMeter Get a sample from the power meter. The measurands argument takes the list of all the types of the measurands to sample.
+// The samples are marked with the reading context and the transaction id is attached if the session is active.
 // It will add all the samples to the connector's Session if it is active.
-func (connector *connectorImpl) SamplePowerMeter(measurands []types.Measurand) {
+func (connector *connectorImpl) SamplePowerMeter(measurands []types.Measurand, readingContext types.ReadingContext) {
 	logInfo := log.WithFields(log.Fields{
 		"evseId":      connector.EvseId,
 		"connectorId": connector.ConnectorId,
@@ -260,36 +294,36 @@ func (connector *connectorImpl) SamplePowerMeter(measurands []types.Measurand) {
 
 	logInfo.Debugf("Sampling connector %v", measurands)
 	var (
-		meterValues []types.MeterValue
-		samples     []types.SampledValue
-		value       = 0.0
+		meterValues   []types.MeterValue
+		samples       []types.SampledValue
+		transactionId *int
 	)
 
 	for _, measurand := range measurands {
+		value, isSupported := ReadMeasurand(connector.powerMeter, measurand)
+		if !isSupported {
+			logInfo.Debugf("Measurand %s not supported", measurand)
+			continue
+		}
 
-		switch measurand {
-		case types.MeasurandEnergyActiveImportInterval, types.MeasurandEnergyActiveImportRegister,
-			types.MeasurandEnergyActiveExportInterval, types.MeasurandEnergyActiveExportRegister:
-			value = connector.powerMeter.GetEnergy()
-			break
-		case types.MeasurandCurrentImport, types.MeasurandCurrentExport:
-			value = connector.powerMeter.GetCurrent()
-			break
-		case types.MeasurandPowerActiveImport, types.MeasurandPowerActiveExport:
-			value = connector.powerMeter.GetPower()
-			break
-		case types.MeasurandVoltage:
-			value = connector.powerMeter.GetVoltage()
-			break
+		sample := types.SampledValue{
+			Value:     fmt.Sprintf("%.3f", value),
+			Context:   readingContext,
+			Measurand: measurand,
 		}
 
-		if value != 0.0 {
-			sample := types.SampledValue{
-				Value:     fmt.Sprintf("%.3f", value),
-				Measurand: measurand,
-			}
+		samples = append(samples, sample)
+		meterValues = append(meterValues, types.MeterValue{SampledValue: []types.SampledValue{sample}, Timestamp: types.NewDateTime(time.Now())})
+	}
+
+	if len(meterValues) == 0 {
+		return
+	}
 
-			meterValues = append(meterValues, types.MeterValue{SampledValue: []types.SampledValue{sample}, Timestamp: types.NewDateTime(time.Now())})
+	if connector.session.IsActive {
+		id, err := strconv.Atoi(connector.session.TransactionId)
+		if err == nil {
+			transactionId = &id
 		}
 	}
 
@@ -297,7 +331,7 @@ func (connector *connectorImpl) SamplePowerMeter(measurands []types.Measurand) {
 		connector.meterValuesChannel <- models.NewMeterValueNotification(
 			connector.EvseId,
 			connector.ConnectorId,
-			nil,
+			transactionId,
 			meterValues...,
 		)
 	}
@@ -305,13 +339,35 @@ func (connector *connectorImpl) SamplePowerMeter(measurands []types.Measurand) {
 	connector.session.AddSampledValue(samples)
 }
 
+// ReadMeasurand reads the measurand from the power meter. It returns false if the measurand is not supported.
+func ReadMeasurand(meter powerMeter.PowerMeter, measurand types.Measurand) (float64, bool) {
+	switch measurand {
+	case types.MeasurandEnergyActiveImportInterval, types.MeasurandEnergyActiveImportRegister,
+		types.MeasurandEnergyActiveExportInterval, types.MeasurandEnergyActiveExportRegister:
+		return meter.GetEnergy(), true
+	case types.MeasurandCurrentImport, types.MeasurandCurrentExport:
+		return meter.GetCurrent(), true
+	case types.MeasurandPowerActiveImport, types.MeasurandPowerActiveExport:
+		return meter.GetPower(), true
+	case types.MeasurandVoltage:
+		return meter.GetVoltage(), true
+	default:
+		return 0, false
+	}
+}
+
+// SamplingJobTag returns the tag of the scheduled sampling job of the connector.
+func SamplingJobTag(evseId, connectorId int) string {
+	return fmt.Sprintf("Evse%dConnector%dSampling", evseId, connectorId)
+}
+
 // preparePowerMeterAtConnector
 func (connector *connectorImpl) preparePowerMeterAtConnector() error {
 	var (
 		measurands          = util.GetTypesToSample()
 		sampleTime          = "10s"
 		sampleInterval, err = ocppConfigManager.GetConfigurationValue(v16.MeterValueSampleInterval.String())
-		jobTag              = fmt.Sprintf("Evse%dConnector%dSampling", connector.EvseId, connector.ConnectorId)
+		jobTag              = SamplingJobTag(connector.EvseId, connector.ConnectorId)
 	)
 	if err != nil {
 		sampleInterval = "10"
@@ -321,7 +377,7 @@ func (connector *connectorImpl) preparePowerMeterAtConnector() error {
 	// Schedule the sampling
 	_, err = scheduler.GetScheduler().Every(sampleTime).
 		Tag(jobTag).
-		Do(connector.SamplePowerMeter, measurands)
+		Do(connector.SamplePowerMeter, measurands, types.ReadingContextSamplePeriodic)
 
 	return err
 }
@@ -378,6 +434,11 @@ func (connector *connectorImpl) GetTagId() string {
 	return connector.session.TagId
 }
 
+// GetParentIdTag returns the group of the tag, which started the session.
+func (connector *connectorImpl) GetParentIdTag() string {
+	return connector.session.ParentIdTag
+}
+
 func (connector *connectorImpl) ReserveConnector(reservationId int, tagId string) error {
 	logInfo := log.WithFields(log.Fields{
 		"evseId":      connector.EvseId,
@@ -390,7 +451,9 @@ func (connector *connectorImpl) ReserveConnector(reservationId int, tagId string
 		return ErrInvalidReservationId
 	}
 
-	if !connector.IsAvailable() {
+	// After a restart, the status of a reserved connector is restored without the reservation
+	isRestoredReservation := connector.IsReserved() && connector.reservationId <= 0
+	if !connector.IsAvailable() && !isRestoredReservation {
 		return ErrInvalidConnectorStatus
 	}
 
@@ -430,7 +493,12 @@ func (connector *connectorImpl) CalculateSessionAvgEnergyConsumption() float64 {
 	return connector.session.CalculateEnergyConsumptionWithAvgPower()
 }
 
+// GetPowerMeter returns the power meter of the connector or nil if the power meter is disabled.
 func (connector *connectorImpl) GetPowerMeter() powerMeter.PowerMeter {
+	if !connector.PowerMeterEnabled {
+		return nil
+	}
+
 	return connector.powerMeter
 }
 
@@ -438,6 +506,25 @@ func (connector *connectorImpl) GetMaxChargingTime() int {
 	return connector.MaxChargingTime
 }
 
+// SetCurrentLimit limits the current per phase in A offered to the vehicle, if the hardware of the connector supports it.
+func (connector *connectorImpl) SetCurrentLimit(limit float64) error {
+	if limit < 0 {
+		return ErrInvalidCurrentLimit
+	}
+
+	limiter, isLimiter := connector.relay.(hardware.CurrentLimiter)
+	if !isLimiter {
+		return ErrCurrentLimitNotSupported
+	}
+
+	log.WithFields(log.Fields{
+		"evseId":      connector.EvseId,
+		"connectorId": connector.ConnectorId,
+		"limit":       limit,
+	}).Info("Limiting the current")
+	return limiter.SetCurrentLimit(limit)
+}
+
 func (connector *connectorImpl) GetStatus() (core.ChargePointStatus, core.ChargePointErrorCode) {
 	return connector.ConnectorStatus, connector.ErrorCode
 }
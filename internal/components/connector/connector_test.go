@@ -26,6 +26,11 @@ type (
 	RelayMock struct {
 		mock.Mock
 	}
+
+	LimiterRelayMock struct {
+		RelayMock
+	}
+
 	ConnectorTestSuite struct {
 		suite.Suite
 		connector      *connectorImpl
@@ -81,6 +86,10 @@ func (r *RelayMock) Disable() {
 	r.Called()
 }
 
+func (r *LimiterRelayMock) SetCurrentLimit(limit float64) error {
+	return r.Called(limit).Error(0)
+}
+
 /*---------------------- Test suite ----------------------*/
 
 func NewConnectorTestSuite() *ConnectorTestSuite {
@@ -233,13 +242,25 @@ func (s *ConnectorTestSuite) TestResumeCharging() {
 	)
 
 	// Ok case
+	validSession.Energy = 1500
+	validSession.MeterReading = 20000
+	validSession.ParentIdTag = "fleet"
 	s.connector.SetStatus(core.ChargePointStatusCharging, core.NoError)
 	err, timeElapsed := s.connector.ResumeCharging(validSession)
 	s.Require().NoError(err)
 	s.Require().InDelta(0, timeElapsed, 1)
+	s.Assert().EqualValues(1500, s.connector.session.Energy)
+	s.Assert().EqualValues(20000, s.connector.session.MeterReading)
+	s.Assert().EqualValues("fleet", s.connector.GetParentIdTag())
+
+	// The energy is updated while the session is active
+	s.connector.SetSessionEnergy(1800, 20300)
+	s.Assert().EqualValues(1800, s.connector.session.Energy)
+	s.Assert().EqualValues(20300, s.connector.session.MeterReading)
 
 	err = s.connector.StopCharging(core.ReasonLocal)
 	s.Require().NoError(err)
+	s.Assert().EqualValues("", s.connector.GetParentIdTag())
 
 	// Invalid session
 	s.connector.SetStatus(core.ChargePointStatusCharging, core.NoError)
@@ -282,6 +303,27 @@ func (s *ConnectorTestSuite) TestReserveConnector() {
 	s.connector.SetStatus(core.ChargePointStatusCharging, core.NoError)
 	err = s.connector.ReserveConnector(2, "")
 	s.Require().Error(err)
+
+	// The status was restored after a restart without the reservation
+	s.connector.SetStatus(core.ChargePointStatusReserved, core.NoError)
+	err = s.connector.ReserveConnector(3, "exampleTag")
+	s.Require().NoError(err)
+	s.Assert().EqualValues(3, s.connector.GetReservationId())
+}
+
+func (s *ConnectorTestSuite) TestStartChargingReserved() {
+	err := s.connector.ReserveConnector(1, "exampleTag")
+	s.Require().NoError(err)
+
+	// The transaction uses the reservation
+	err = s.connector.StartCharging("1234", "exampleTag")
+	s.Require().NoError(err)
+	s.Assert().True(s.connector.IsCharging())
+	s.Assert().False(s.connector.IsReserved())
+	s.Assert().LessOrEqual(s.connector.GetReservationId(), 0)
+
+	err = s.connector.StopCharging(core.ReasonLocal)
+	s.Require().NoError(err)
 }
 
 func (s *ConnectorTestSuite) TestRemoveReservation() {
@@ -298,6 +340,22 @@ func (s *ConnectorTestSuite) TestRemoveReservation() {
 	s.Require().Error(err)
 }
 
+func (s *ConnectorTestSuite) TestSetCurrentLimit() {
+	// The relay cannot limit the current
+	s.Assert().ErrorIs(s.connector.SetCurrentLimit(16), ErrCurrentLimitNotSupported)
+
+	relay := new(LimiterRelayMock)
+	relay.On("Disable").Return()
+	relay.On("SetCurrentLimit", 10.0).Return(nil).Once()
+
+	c, err := NewConnector(1, 2, "Schuko", relay, s.powerMeterMock, false, 15)
+	s.Require().NoError(err)
+
+	s.Assert().NoError(c.SetCurrentLimit(10))
+	s.Assert().ErrorIs(c.SetCurrentLimit(-1), ErrInvalidCurrentLimit)
+	relay.AssertExpectations(s.T())
+}
+
 func (s *ConnectorTestSuite) TestSamplePowerMeter() {
 	s.powerMeterMock = new(PowerMeterMock)
 
@@ -318,8 +376,10 @@ func (s *ConnectorTestSuite) TestSamplePowerMeter() {
 			case notif := <-meterValueChan:
 				s.Assert().EqualValues(s.connector.GetConnectorId(), notif.ConnectorId)
 				s.Assert().EqualValues(s.connector.GetEvseId(), notif.EvseId)
+				s.Assert().Nil(notif.TransactionId)
 
 				s.Assert().Len(notif.MeterValues, 3)
+				s.Assert().EqualValues(types.ReadingContextSamplePeriodic, notif.MeterValues[0].SampledValue[0].Context)
 				s.Assert().EqualValues("1.000", notif.MeterValues[0].SampledValue[0].Value)
 				s.Assert().EqualValues(types.MeasurandVoltage, notif.MeterValues[0].SampledValue[0].Measurand)
 
@@ -337,11 +397,35 @@ func (s *ConnectorTestSuite) TestSamplePowerMeter() {
 	s.connector.SetMeterValuesChannel(meterValueChan)
 	s.connector.PowerMeterEnabled = true
 	s.connector.powerMeter = s.powerMeterMock
-	s.connector.SamplePowerMeter([]types.Measurand{types.MeasurandVoltage, types.MeasurandCurrentImport, types.MeasurandEnergyActiveImportInterval})
+	s.connector.SamplePowerMeter([]types.Measurand{types.MeasurandVoltage, types.MeasurandCurrentImport, types.MeasurandEnergyActiveImportInterval}, types.ReadingContextSamplePeriodic)
 
 	time.Sleep(time.Second)
 
-	s.connector.SamplePowerMeter([]types.Measurand{types.MeasurandVoltage, types.MeasurandCurrentImport, types.MeasurandEnergyActiveImportInterval})
+	s.connector.SamplePowerMeter([]types.Measurand{types.MeasurandVoltage, types.MeasurandCurrentImport, types.MeasurandEnergyActiveImportInterval}, types.ReadingContextSamplePeriodic)
+}
+
+func (s *ConnectorTestSuite) TestSamplePowerMeterWithTransaction() {
+	s.powerMeterMock = new(PowerMeterMock)
+	s.powerMeterMock.On("GetEnergy").Return(0.0)
+	s.powerMeterMock.On("GetPower").Return(0.0)
+
+	meterValueChan := make(chan models.MeterValueNotification, 1)
+	s.connector.SetMeterValuesChannel(meterValueChan)
+	s.connector.PowerMeterEnabled = true
+	s.connector.powerMeter = s.powerMeterMock
+	s.Require().NoError(s.connector.session.StartSession("12", "exampleTag"))
+
+	// Unsupported measurands are skipped, zero values are reported
+	s.connector.SamplePowerMeter([]types.Measurand{types.MeasurandEnergyActiveImportRegister, types.MeasueandSoC, types.MeasurandPowerActiveImport},
+		types.ReadingContextTrigger)
+
+	notification := <-meterValueChan
+	s.Require().NotNil(notification.TransactionId)
+	s.Assert().EqualValues(12, *notification.TransactionId)
+	s.Require().Len(notification.MeterValues, 2)
+	s.Assert().EqualValues("0.000", notification.MeterValues[0].SampledValue[0].Value)
+	s.Assert().EqualValues(types.ReadingContextTrigger, notification.MeterValues[0].SampledValue[0].Context)
+	s.Assert().EqualValues(types.MeasurandPowerActiveImport, notification.MeterValues[1].SampledValue[0].Measurand)
 }
 
 func TestConnector(t *testing.T) {
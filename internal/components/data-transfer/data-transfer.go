@@ -0,0 +1,152 @@
+package dataTransfer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"sync"
+)
+
+// AnyMessage registers a handler for all the messages of the vendor, which have no handler of their own.
+const AnyMessage = "*"
+
+var (
+	ErrInvalidPayload = errors.New("invalid payload")
+	ErrInvalidHandler = errors.New("handler must not be nil")
+)
+
+type (
+	// Request is a DataTransfer request received from the central system.
+	Request struct {
+		VendorId  string
+		MessageId string
+		Data      interface{}
+	}
+
+	// Handler handles the DataTransfer requests of a vendor and message id. The returned data is sent back in the
+	// response. If the handler returns an error, the request is rejected.
+	Handler func(request Request) (interface{}, error)
+
+	// Registry contains the DataTransfer handlers, keyed by the vendor and the message id.
+	Registry struct {
+		mu       sync.RWMutex
+		handlers map[string]map[string]Handler
+	}
+)
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		handlers: map[string]map[string]Handler{},
+	}
+}
+
+// Register adds the handler of the message of the vendor. An existing handler is replaced.
+// Use AnyMessage to handle all the messages of the vendor.
+func (r *Registry) Register(vendorId, messageId string, handler Handler) error {
+	if handler == nil {
+		return ErrInvalidHandler
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, isFound := r.handlers[vendorId]; !isFound {
+		r.handlers[vendorId] = map[string]Handler{}
+	}
+
+	r.handlers[vendorId][messageId] = handler
+	return nil
+}
+
+// Unregister removes the handler of the message of the vendor.
+func (r *Registry) Unregister(vendorId, messageId string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.handlers[vendorId], messageId)
+	if len(r.handlers[vendorId]) == 0 {
+		delete(r.handlers, vendorId)
+	}
+}
+
+// Handle finds the handler of the request and returns the confirmation for the central system.
+func (r *Registry) Handle(request *core.DataTransferRequest) *core.DataTransferConfirmation {
+	messageId := request.MessageId
+
+	r.mu.RLock()
+	vendorHandlers, isVendorFound := r.handlers[request.VendorId]
+	handler, isFound := vendorHandlers[messageId]
+	if !isFound {
+		handler, isFound = vendorHandlers[AnyMessage]
+	}
+	r.mu.RUnlock()
+
+	switch {
+	case !isVendorFound:
+		return core.NewDataTransferConfirmation(core.DataTransferStatusUnknownVendorId)
+	case !isFound:
+		return core.NewDataTransferConfirmation(core.DataTransferStatusUnknownMessageId)
+	}
+
+	data, err := handler(Request{
+		VendorId:  request.VendorId,
+		MessageId: messageId,
+		Data:      request.Data,
+	})
+	if err != nil {
+		return core.NewDataTransferConfirmation(core.DataTransferStatusRejected)
+	}
+
+	confirmation := core.NewDataTransferConfirmation(core.DataTransferStatusAccepted)
+	if data != nil {
+		confirmation.Data, err = Encode(data)
+		if err != nil {
+			return core.NewDataTransferConfirmation(core.DataTransferStatusRejected)
+		}
+	}
+
+	return confirmation
+}
+
+// Decode decodes the data of the request into the payload. The data may be a JSON string, as the OCPP 1.6
+// specification defines the data as a string, or a JSON object.
+func (r Request) Decode(payload interface{}) error {
+	var raw []byte
+
+	switch data := r.Data.(type) {
+	case nil:
+		return fmt.Errorf("%w: no data", ErrInvalidPayload)
+	case string:
+		raw = []byte(data)
+	default:
+		var err error
+		raw, err = json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+	}
+
+	err := json.Unmarshal(raw, payload)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	return nil
+}
+
+// Encode encodes the data as a string for a DataTransfer request or response. Strings are sent as they are and
+// everything else is encoded as JSON.
+func Encode(data interface{}) (string, error) {
+	if text, isString := data.(string); isString {
+		return text, nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	return string(raw), nil
+}
@@ -0,0 +1,132 @@
+package dataTransfer
+
+import (
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+const vendorId = "exampleVendor"
+
+type (
+	examplePayload struct {
+		Text  string `json:"text"`
+		Value int    `json:"value"`
+	}
+
+	DataTransferTestSuite struct {
+		suite.Suite
+		registry *Registry
+	}
+)
+
+func newRequest(vendorId, messageId string, data interface{}) *core.DataTransferRequest {
+	request := core.NewDataTransferRequest(vendorId)
+	request.MessageId = messageId
+	request.Data = data
+	return request
+}
+
+func (s *DataTransferTestSuite) SetupTest() {
+	s.registry = NewRegistry()
+}
+
+func (s *DataTransferTestSuite) TestRegister() {
+	err := s.registry.Register(vendorId, "exampleMessage", nil)
+	s.Assert().ErrorIs(err, ErrInvalidHandler)
+
+	err = s.registry.Register(vendorId, "exampleMessage", func(request Request) (interface{}, error) {
+		return nil, nil
+	})
+	s.Require().NoError(err)
+
+	confirmation := s.registry.Handle(newRequest(vendorId, "exampleMessage", nil))
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, confirmation.Status)
+	s.Assert().Nil(confirmation.Data)
+
+	confirmation = s.registry.Handle(newRequest(vendorId, "unknownMessage", nil))
+	s.Assert().EqualValues(core.DataTransferStatusUnknownMessageId, confirmation.Status)
+
+	confirmation = s.registry.Handle(newRequest("unknownVendor", "exampleMessage", nil))
+	s.Assert().EqualValues(core.DataTransferStatusUnknownVendorId, confirmation.Status)
+
+	s.registry.Unregister(vendorId, "exampleMessage")
+	confirmation = s.registry.Handle(newRequest(vendorId, "exampleMessage", nil))
+	s.Assert().EqualValues(core.DataTransferStatusUnknownVendorId, confirmation.Status)
+}
+
+func (s *DataTransferTestSuite) TestAnyMessage() {
+	var handledMessageId string
+
+	err := s.registry.Register(vendorId, AnyMessage, func(request Request) (interface{}, error) {
+		handledMessageId = request.MessageId
+		return "ok", nil
+	})
+	s.Require().NoError(err)
+
+	confirmation := s.registry.Handle(newRequest(vendorId, "anyMessage", nil))
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, confirmation.Status)
+	s.Assert().EqualValues("ok", confirmation.Data)
+	s.Assert().EqualValues("anyMessage", handledMessageId)
+
+	// Requests without a message id are handled as well
+	confirmation = s.registry.Handle(newRequest(vendorId, "", nil))
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, confirmation.Status)
+}
+
+func (s *DataTransferTestSuite) TestTypedPayload() {
+	err := s.registry.Register(vendorId, "exampleMessage", func(request Request) (interface{}, error) {
+		var payload examplePayload
+
+		err := request.Decode(&payload)
+		if err != nil {
+			return nil, err
+		}
+
+		payload.Value++
+		return payload, nil
+	})
+	s.Require().NoError(err)
+
+	// The data is a JSON string
+	confirmation := s.registry.Handle(newRequest(vendorId, "exampleMessage", `{"text":"example","value":1}`))
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, confirmation.Status)
+	s.Assert().EqualValues(`{"text":"example","value":2}`, confirmation.Data)
+
+	// The data is a JSON object
+	confirmation = s.registry.Handle(newRequest(vendorId, "exampleMessage", map[string]interface{}{"text": "example", "value": 2}))
+	s.Assert().EqualValues(core.DataTransferStatusAccepted, confirmation.Status)
+	s.Assert().EqualValues(`{"text":"example","value":3}`, confirmation.Data)
+
+	// Invalid payloads are rejected
+	confirmation = s.registry.Handle(newRequest(vendorId, "exampleMessage", "notJson"))
+	s.Assert().EqualValues(core.DataTransferStatusRejected, confirmation.Status)
+
+	confirmation = s.registry.Handle(newRequest(vendorId, "exampleMessage", nil))
+	s.Assert().EqualValues(core.DataTransferStatusRejected, confirmation.Status)
+}
+
+func (s *DataTransferTestSuite) TestHandlerError() {
+	err := s.registry.Register(vendorId, "exampleMessage", func(request Request) (interface{}, error) {
+		return nil, errors.New("example error")
+	})
+	s.Require().NoError(err)
+
+	confirmation := s.registry.Handle(newRequest(vendorId, "exampleMessage", nil))
+	s.Assert().EqualValues(core.DataTransferStatusRejected, confirmation.Status)
+}
+
+func (s *DataTransferTestSuite) TestEncode() {
+	data, err := Encode("example")
+	s.Assert().NoError(err)
+	s.Assert().EqualValues("example", data)
+
+	data, err = Encode(examplePayload{Text: "example", Value: 1})
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(`{"text":"example","value":1}`, data)
+}
+
+func TestDataTransfer(t *testing.T) {
+	suite.Run(t, new(DataTransferTestSuite))
+}
@@ -0,0 +1,142 @@
+package diagnostics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+var (
+	ErrUnsupportedLocation = errors.New("unsupported upload location")
+	ErrUploadFailed        = errors.New("log upload failed")
+)
+
+// logEntry is the part of a JSON log entry used for filtering.
+type logEntry struct {
+	Time time.Time `json:"time"`
+}
+
+// IsLocationSupported checks if the logs can be uploaded to the location.
+func IsLocationSupported(location string) bool {
+	locationUrl, err := url.Parse(location)
+	if err != nil {
+		return false
+	}
+
+	return locationUrl.Scheme == "http" || locationUrl.Scheme == "https"
+}
+
+// CollectLogs reads the JSON log entries from the files, which were logged between the oldest and latest timestamp.
+// The timestamps are optional. Lines without a timestamp are always included.
+func CollectLogs(files []string, oldest, latest *time.Time) ([]byte, error) {
+	var logs bytes.Buffer
+
+	for _, fileName := range files {
+		file, err := os.Open(fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+		for scanner.Scan() {
+			var (
+				line  = scanner.Bytes()
+				entry logEntry
+			)
+
+			if json.Unmarshal(line, &entry) == nil && !entry.Time.IsZero() {
+				if (oldest != nil && entry.Time.Before(*oldest)) || (latest != nil && entry.Time.After(*latest)) {
+					continue
+				}
+			}
+
+			logs.Write(line)
+			logs.WriteByte('\n')
+		}
+
+		err = scanner.Err()
+		_ = file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return logs.Bytes(), nil
+}
+
+// Upload uploads the logs to the location as a multipart form file. The upload is attempted retries+1 times,
+// with the retryInterval between the attempts.
+func Upload(ctx context.Context, location, fileName string, logs []byte, retries int, retryInterval time.Duration) error {
+	if !IsLocationSupported(location) {
+		return ErrUnsupportedLocation
+	}
+
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(retryInterval):
+			}
+		}
+
+		err = upload(ctx, location, fileName, logs)
+		if err == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %v", ErrUploadFailed, err)
+}
+
+func upload(ctx context.Context, location, fileName string, logs []byte) error {
+	var (
+		body   bytes.Buffer
+		writer = multipart.NewWriter(&body)
+	)
+
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		return err
+	}
+
+	_, err = part.Write(logs)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, location, &body)
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", response.Status)
+	}
+
+	return nil
+}
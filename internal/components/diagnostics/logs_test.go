@@ -0,0 +1,91 @@
+package diagnostics
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type logsTestSuite struct {
+	suite.Suite
+	files []string
+}
+
+func (s *logsTestSuite) SetupTest() {
+	var (
+		folder = s.T().TempDir()
+		first  = filepath.Join(folder, "chargepi.log.202201010000")
+		second = filepath.Join(folder, "chargepi.log.202201080000")
+	)
+
+	s.Require().NoError(ioutil.WriteFile(first,
+		[]byte(`{"level":"info","msg":"first","time":"2022-01-01T10:00:00Z"}`+"\n"+
+			`{"level":"info","msg":"second","time":"2022-01-02T10:00:00Z"}`+"\n"), 0600))
+	s.Require().NoError(ioutil.WriteFile(second,
+		[]byte(`{"level":"info","msg":"third","time":"2022-01-08T10:00:00Z"}`+"\n"+"not a json line\n"), 0600))
+
+	s.files = []string{first, second}
+}
+
+func (s *logsTestSuite) TestCollectLogs() {
+	logs, err := CollectLogs(s.files, nil, nil)
+	s.Require().NoError(err)
+	s.Assert().Contains(string(logs), "first")
+	s.Assert().Contains(string(logs), "third")
+
+	var (
+		oldest = time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)
+		latest = time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)
+	)
+
+	logs, err = CollectLogs(s.files, &oldest, &latest)
+	s.Require().NoError(err)
+	s.Assert().NotContains(string(logs), "first")
+	s.Assert().Contains(string(logs), "second")
+	s.Assert().NotContains(string(logs), "third")
+	// Lines without a timestamp are not filtered
+	s.Assert().Contains(string(logs), "not a json line")
+
+	_, err = CollectLogs([]string{"missing.log"}, nil, nil)
+	s.Assert().Error(err)
+}
+
+func (s *logsTestSuite) TestUpload() {
+	var (
+		requests int
+		uploaded []byte
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		file, header, err := r.FormFile("file")
+		s.Require().NoError(err)
+		s.Assert().EqualValues("logs.log", header.Filename)
+
+		uploaded, err = ioutil.ReadAll(file)
+		s.Require().NoError(err)
+	}))
+	defer server.Close()
+
+	err := Upload(context.Background(), server.URL, "logs.log", []byte("logs"), 1, time.Millisecond)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(2, requests)
+	s.Assert().EqualValues("logs", uploaded)
+
+	err = Upload(context.Background(), "ftp://example.com", "logs.log", []byte("logs"), 0, 0)
+	s.Assert().ErrorIs(err, ErrUnsupportedLocation)
+}
+
+func TestLogs(t *testing.T) {
+	suite.Run(t, new(logsTestSuite))
+}
@@ -0,0 +1,228 @@
+package firmware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnsupportedLocation   = errors.New("unsupported firmware location")
+	ErrInvalidCertificate    = errors.New("invalid signing certificate")
+	ErrInvalidSignature      = errors.New("invalid firmware signature")
+	ErrNoInstallCommand      = errors.New("install command not configured")
+	ErrDownloadFailed        = errors.New("firmware download failed")
+	ErrUnsupportedSigningKey = errors.New("unsupported signing key")
+)
+
+// Manager downloads, verifies and installs the firmware.
+type Manager struct {
+	downloadFolder string
+	installCommand []string
+	client         *http.Client
+}
+
+// NewManager creates a firmware manager. The install command is executed with the path of the firmware
+// as the last argument.
+func NewManager(downloadFolder, installCommand string) *Manager {
+	return &Manager{
+		downloadFolder: downloadFolder,
+		installCommand: strings.Fields(installCommand),
+		client:         &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+// CanInstall checks if the firmware can be installed.
+func (m *Manager) CanInstall() bool {
+	return len(m.installCommand) > 0
+}
+
+// IsLocationSupported checks if the firmware can be downloaded from the location.
+func IsLocationSupported(location string) bool {
+	locationUrl, err := url.Parse(location)
+	if err != nil {
+		return false
+	}
+
+	return locationUrl.Scheme == "http" || locationUrl.Scheme == "https"
+}
+
+// VerifyCertificate verifies the PEM encoded signing certificate against the root certificates.
+func VerifyCertificate(signingCertificate string, roots *x509.CertPool) (*x509.Certificate, error) {
+	if roots == nil {
+		return nil, fmt.Errorf("%w: no root certificates installed", ErrInvalidCertificate)
+	}
+
+	var (
+		rest          = []byte(signingCertificate)
+		chain         []*x509.Certificate
+		intermediates = x509.NewCertPool()
+	)
+
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+		}
+
+		chain = append(chain, certificate)
+	}
+
+	if len(chain) == 0 {
+		return nil, ErrInvalidCertificate
+	}
+
+	for _, certificate := range chain[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+	}
+
+	return chain[0], nil
+}
+
+// VerifySignature verifies the base64 encoded SHA256 signature of the firmware file with the signing certificate.
+func VerifySignature(firmwarePath string, certificate *x509.Certificate, signature string) error {
+	decodedSignature, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	file, err := os.Open(firmwarePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return err
+	}
+
+	digest := hash.Sum(nil)
+
+	switch publicKey := certificate.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(publicKey, digest, decodedSignature) {
+			return ErrInvalidSignature
+		}
+	case *rsa.PublicKey:
+		err = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest, decodedSignature)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+	default:
+		return ErrUnsupportedSigningKey
+	}
+
+	return nil
+}
+
+// Download downloads the firmware to the download folder and returns the path of the file. The download is
+// attempted retries+1 times, with the retryInterval between the attempts.
+func (m *Manager) Download(ctx context.Context, location string, retries int, retryInterval time.Duration) (string, error) {
+	if !IsLocationSupported(location) {
+		return "", ErrUnsupportedLocation
+	}
+
+	err := os.MkdirAll(m.downloadFolder, 0700)
+	if err != nil {
+		return "", err
+	}
+
+	locationUrl, _ := url.Parse(location)
+	fileName := path.Base(locationUrl.Path)
+	if fileName == "." || fileName == "/" {
+		fileName = "firmware"
+	}
+
+	filePath := filepath.Join(m.downloadFolder, fileName)
+
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(retryInterval):
+			}
+		}
+
+		err = m.download(ctx, location, filePath)
+		if err == nil {
+			return filePath, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %v", ErrDownloadFailed, err)
+}
+
+func (m *Manager) download(ctx context.Context, location, filePath string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := m.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", response.Status)
+	}
+
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, response.Body)
+	return err
+}
+
+// Install runs the install command with the firmware file.
+func (m *Manager) Install(ctx context.Context, firmwarePath string) error {
+	if !m.CanInstall() {
+		return ErrNoInstallCommand
+	}
+
+	args := append(append([]string{}, m.installCommand[1:]...), firmwarePath)
+	output, err := exec.CommandContext(ctx, m.installCommand[0], args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
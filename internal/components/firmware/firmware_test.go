@@ -0,0 +1,160 @@
+package firmware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/stretchr/testify/suite"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type firmwareTestSuite struct {
+	suite.Suite
+	rootPool       *x509.CertPool
+	signingKey     *ecdsa.PrivateKey
+	signingCertPem string
+	firmware       []byte
+	server         *httptest.Server
+	requests       int
+}
+
+func createCertificate(template, parent *x509.Certificate, publicKey *ecdsa.PublicKey, signer *ecdsa.PrivateKey) (*x509.Certificate, error) {
+	raw, err := x509.CreateCertificate(rand.Reader, template, parent, publicKey, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(raw)
+}
+
+func (s *firmwareTestSuite) SetupSuite() {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Manufacturer Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	root, err := createCertificate(rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	s.Require().NoError(err)
+
+	s.signingKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+
+	signingCertificate, err := createCertificate(&x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Firmware Signing"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}, root, &s.signingKey.PublicKey, rootKey)
+	s.Require().NoError(err)
+
+	s.rootPool = x509.NewCertPool()
+	s.rootPool.AddCert(root)
+	s.signingCertPem = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: signingCertificate.Raw}))
+	s.firmware = []byte("firmware image")
+}
+
+func (s *firmwareTestSuite) SetupTest() {
+	s.requests = 0
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests++
+		if r.URL.Path != "/firmware.bin" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write(s.firmware)
+	}))
+}
+
+func (s *firmwareTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *firmwareTestSuite) sign(content []byte) string {
+	digest := sha256.Sum256(content)
+	signature, err := ecdsa.SignASN1(rand.Reader, s.signingKey, digest[:])
+	s.Require().NoError(err)
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func (s *firmwareTestSuite) TestVerifyCertificate() {
+	certificate, err := VerifyCertificate(s.signingCertPem, s.rootPool)
+	s.Require().NoError(err)
+	s.Assert().EqualValues("Firmware Signing", certificate.Subject.CommonName)
+
+	_, err = VerifyCertificate(s.signingCertPem, nil)
+	s.Assert().ErrorIs(err, ErrInvalidCertificate)
+
+	_, err = VerifyCertificate(s.signingCertPem, x509.NewCertPool())
+	s.Assert().ErrorIs(err, ErrInvalidCertificate)
+
+	_, err = VerifyCertificate("invalid", s.rootPool)
+	s.Assert().ErrorIs(err, ErrInvalidCertificate)
+}
+
+func (s *firmwareTestSuite) TestDownloadAndVerify() {
+	manager := NewManager(s.T().TempDir(), "true")
+
+	firmwarePath, err := manager.Download(context.Background(), s.server.URL+"/firmware.bin", 0, 0)
+	s.Require().NoError(err)
+
+	content, err := ioutil.ReadFile(firmwarePath)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(s.firmware, content)
+
+	certificate, err := VerifyCertificate(s.signingCertPem, s.rootPool)
+	s.Require().NoError(err)
+
+	s.Assert().NoError(VerifySignature(firmwarePath, certificate, s.sign(s.firmware)))
+	s.Assert().ErrorIs(VerifySignature(firmwarePath, certificate, s.sign([]byte("tampered"))), ErrInvalidSignature)
+	s.Assert().ErrorIs(VerifySignature(firmwarePath, certificate, "not base64"), ErrInvalidSignature)
+
+	s.Assert().NoError(manager.Install(context.Background(), firmwarePath))
+}
+
+func (s *firmwareTestSuite) TestDownloadRetries() {
+	manager := NewManager(s.T().TempDir(), "")
+
+	_, err := manager.Download(context.Background(), s.server.URL+"/missing.bin", 2, time.Millisecond)
+	s.Assert().ErrorIs(err, ErrDownloadFailed)
+	s.Assert().EqualValues(3, s.requests)
+
+	_, err = manager.Download(context.Background(), "ftp://example.com/firmware.bin", 0, 0)
+	s.Assert().ErrorIs(err, ErrUnsupportedLocation)
+}
+
+func (s *firmwareTestSuite) TestInstall() {
+	manager := NewManager(s.T().TempDir(), "")
+	s.Assert().False(manager.CanInstall())
+	s.Assert().ErrorIs(manager.Install(context.Background(), "firmware.bin"), ErrNoInstallCommand)
+
+	manager = NewManager(s.T().TempDir(), "false")
+	s.Assert().True(manager.CanInstall())
+	s.Assert().Error(manager.Install(context.Background(), filepath.Join(s.T().TempDir(), "firmware.bin")))
+}
+
+func TestFirmware(t *testing.T) {
+	suite.Run(t, new(firmwareTestSuite))
+}
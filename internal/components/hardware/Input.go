@@ -0,0 +1,75 @@
+package hardware
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/warthog618/gpiod"
+	"time"
+)
+
+// debouncePeriod is the time in which the changes of the input after a change are ignored.
+const debouncePeriod = 50 * time.Millisecond
+
+type (
+	InputImpl struct {
+		InputPin     int
+		InverseLogic bool
+		pin          *gpiod.Line
+		lastEvent    time.Duration
+	}
+
+	Input interface {
+		Close()
+	}
+)
+
+// NewInput creates a new InputImpl that watches the GPIO pin and calls the handler with the state of the input when it changes.
+func NewInput(inputPin int, inverseLogic bool, handler func(isActive bool)) *InputImpl {
+	if inputPin <= 0 || handler == nil {
+		return nil
+	}
+
+	log.Debugf("Creating new input at pin %d", inputPin)
+	input := InputImpl{
+		InputPin:     inputPin,
+		InverseLogic: inverseLogic,
+	}
+
+	err := input.initPin(handler)
+	if err != nil {
+		log.WithError(err).Errorf("Cannot watch the input at pin %d", inputPin)
+		return nil
+	}
+
+	return &input
+}
+
+func (i *InputImpl) initPin(handler func(isActive bool)) error {
+	// Refer to gpiod docs
+	c, err := gpiod.NewChip("gpiochip0")
+	if err != nil {
+		return err
+	}
+
+	// The requested line stays valid after the chip is closed
+	defer c.Close()
+
+	i.pin, err = c.RequestLine(i.InputPin, gpiod.AsInput, gpiod.WithBothEdges, gpiod.WithEventHandler(func(event gpiod.LineEvent) {
+		if i.lastEvent != 0 && event.Timestamp-i.lastEvent < debouncePeriod {
+			return
+		}
+
+		i.lastEvent = event.Timestamp
+
+		isActive := event.Type == gpiod.LineEventRisingEdge
+		if i.InverseLogic {
+			isActive = !isActive
+		}
+
+		handler(isActive)
+	}))
+	return err
+}
+
+func (i *InputImpl) Close() {
+	_ = i.pin.Close()
+}
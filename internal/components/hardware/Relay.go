@@ -17,6 +17,12 @@ type (
 		Enable()
 		Disable()
 	}
+
+	// CurrentLimiter is implemented by the connector hardware, which is able to limit the current offered to the
+	// vehicle, e.g. with the control pilot.
+	CurrentLimiter interface {
+		SetCurrentLimit(limit float64) error
+	}
 )
 
 // NewRelay creates a new RelayImpl struct that will communicate with the GPIO pin specified.
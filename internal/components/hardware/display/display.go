@@ -33,6 +33,11 @@ type (
 		Clear()
 		GetLcdChannel() chan<- LCDMessage
 	}
+
+	// QRCodeDisplay is implemented by the displays that can show a QR code, e.g. the payment url of a connector.
+	QRCodeDisplay interface {
+		DisplayQRCode(content string, caption string)
+	}
 )
 
 // NewMessage creates a new message for the LCD.
@@ -1,11 +1,17 @@
 package i18n
 
 import (
+	"bytes"
+	"embed"
+	"errors"
 	"fmt"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
 	"gopkg.in/yaml.v3"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,58 +19,38 @@ import (
 )
 
 var (
-	bundle             *i18n.Bundle
-	defaultMessages    map[string]i18n.Message
-	matcher            language.Matcher
-	supportedLanguages = []language.Tag{
-		language.English, // The first language is used as fallback.
-	}
+	ErrMessageNotFound   = errors.New("default message not found")
+	ErrInvalidFileName   = errors.New("invalid file name")
+	ErrInvalidLanguage   = errors.New("invalid language")
+	ErrEmptyLanguagePack = errors.New("language pack has no messages")
+)
+
+// translationFiles are the bundled translations.
+//
+//go:embed translations/*.yaml
+var translationFiles embed.FS
+
+var (
+	mu              sync.RWMutex
+	bundle          *i18n.Bundle
+	defaultMessages map[string]i18n.Message
+	matcher         language.Matcher
 )
 
 func init() {
 	once := sync.Once{}
 	once.Do(func() {
-
 		defaultMessages = make(map[string]i18n.Message)
 
+		// The default language is used as fallback.
 		bundle = i18n.NewBundle(language.English)
 		bundle.RegisterUnmarshalFunc("yaml", yaml.Unmarshal)
 
-		loadTranslations()
-
-		// Default messages
-		addDefaultMessage(i18n.Message{
-			ID:    "ConnectorTemplate",
-			Other: "Connector {{.Id}}",
-		})
-		addDefaultMessage(i18n.Message{
-			ID:    "ConnectorAvailable",
-			Other: "available.",
-		})
-		addDefaultMessage(i18n.Message{
-			ID:    "ConnectorFinishing",
-			Other: "Stopped charging",
-		})
-		addDefaultMessage(i18n.Message{
-			ID:    "ConnectorCharging",
-			Other: "Started charging",
-		})
-		addDefaultMessage(i18n.Message{
-			ID:    "ConnectorStopTemplate",
-			Other: "at {{.Id}}.",
-		})
-		addDefaultMessage(i18n.Message{
-			ID:    "ConnectorFaulted",
-			Other: "has faulted.",
-		})
-		addDefaultMessage(i18n.Message{
-			ID:    "WelcomeMessage",
-			Other: "Welcome to",
-		})
-		addDefaultMessage(i18n.Message{
-			ID:    "WelcomeMessage2",
-			Other: "ChargePi!",
-		})
+		for _, defaultMessage := range catalog {
+			addDefaultMessage(defaultMessage)
+		}
+
+		loadBundledTranslations()
 	})
 }
 
@@ -73,75 +59,155 @@ func addDefaultMessage(message i18n.Message) {
 	defaultMessages[message.ID] = message
 }
 
-// loadTranslations loads all available translations from the translations folder into the bundle.
-func loadTranslations() {
+// loadBundledTranslations loads the translations embedded in the binary into the bundle.
+func loadBundledTranslations() {
 	log.Info("Loading translations..")
 
-	err := filepath.Walk("./hardware/display/i18n/translations", func(path string, info os.FileInfo, err error) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	err := fs.WalkDir(translationFiles, "translations", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		content, err := translationFiles.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		_, err = bundle.ParseMessageFileBytes(content, path)
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Errorf("Error loading the bundled translations")
+	}
+
+	// Create a matcher based on the loaded translations.
+	matcher = language.NewMatcher(bundle.LanguageTags())
+}
+
+// LoadTranslations loads the active.*.yaml and active.*.json translations from the folder. The translations replace
+// the bundled translations of the same messages.
+func LoadTranslations(folder string) error {
+	log.Infof("Loading translations from %s", folder)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	err := filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
 		// Load all active.*.yaml translations into the bundle
-		if info != nil && !info.IsDir() && strings.Contains(info.Name(), "active.") {
+		if !info.IsDir() && strings.HasPrefix(info.Name(), "active.") {
 			return loadTranslation(path, info)
 		}
 
 		return nil
 	})
 
-	if err != nil {
-		log.Errorf("Error loading translations: %v", err)
-	}
-
-	// Create a matcher based on imported translation files.
-	matcher = language.NewMatcher(supportedLanguages)
+	matcher = language.NewMatcher(bundle.LanguageTags())
+	return err
 }
 
 func loadTranslation(path string, info os.FileInfo) error {
 	// active.en.yaml -> en
 	strs := strings.Split(info.Name(), ".")
-	if len(strs) < 2 {
-		return fmt.Errorf("invalid file name")
+	if len(strs) < 3 {
+		return ErrInvalidFileName
 	}
 
-	// The language is second to last
-	lang := strs[len(strs)-2]
-	log.Debugf("loading translation: %s", lang)
-
-	err := addLanguageSupport(lang)
-	if err != nil {
-		return err
-	}
+	log.Debugf("loading translation: %s", strs[len(strs)-2])
 
 	// Load the translation file
-	_, err = bundle.LoadMessageFile(path)
+	_, err := bundle.LoadMessageFile(path)
 	return err
 }
 
-func addLanguageSupport(lang string) error {
+// AddLanguagePack adds or replaces the translations of the language, e.g. pushed by the central system.
+// The content is a YAML or JSON map of message IDs and translations.
+func AddLanguagePack(lang string, content []byte) error {
 	tag, err := language.Parse(lang)
+	if err != nil {
+		return ErrInvalidLanguage
+	}
+
+	format := "yaml"
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		format = "json"
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	messageFile, err := bundle.ParseMessageFileBytes(content, fmt.Sprintf("active.%s.%s", tag, format))
 	if err != nil {
 		return err
 	}
 
-	supportedLanguages = append(supportedLanguages, tag)
+	if len(messageFile.Messages) == 0 {
+		return ErrEmptyLanguagePack
+	}
+
+	matcher = language.NewMatcher(bundle.LanguageTags())
 	return nil
 }
 
+// SupportedLanguages returns the languages with translations.
+func SupportedLanguages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var languages []string
+	for _, tag := range bundle.LanguageTags() {
+		languages = append(languages, tag.String())
+	}
+
+	return languages
+}
+
 // Localize translates the message based on the language of the chat.
 func Localize(lang string, messageId string, data map[string]interface{}, plural interface{}) (string, error) {
-	tag, _ := language.MatchStrings(matcher, lang)
-	locale := i18n.NewLocalizer(bundle, tag.String())
 	defaultMessage, ok := defaultMessages[messageId]
 	if !ok {
-		return "", fmt.Errorf("default message not found")
+		return "", ErrMessageNotFound
 	}
 
+	mu.RLock()
+	defer mu.RUnlock()
+
+	tag, _ := language.MatchStrings(matcher, lang)
+	locale := i18n.NewLocalizer(bundle, tag.String())
+
 	msg, err := locale.Localize(&i18n.LocalizeConfig{
 		DefaultMessage: &defaultMessage,
 		TemplateData:   data,
 		PluralCount:    plural,
 	})
-	if err != nil {
+
+	// Messages missing in the translation fall back to the default language
+	var notFoundErr *i18n.MessageNotFoundErr
+	if err != nil && !(errors.As(err, &notFoundErr) && msg != "") {
 		return "", err
 	}
 
 	return msg, nil
 }
+
+// FormatNumber formats the number with the decimal separator of the language, without grouping the digits.
+func FormatNumber(lang string, value float64, decimals int) string {
+	printer := message.NewPrinter(language.Make(lang))
+	return printer.Sprint(number.Decimal(value, number.NoSeparator(), number.MinFractionDigits(decimals), number.MaxFractionDigits(decimals)))
+}
+
+// FormatEnergy formats the energy in Wh as kWh, e.g. "12,35 kWh".
+func FormatEnergy(lang string, energy float64) string {
+	return fmt.Sprintf("%s kWh", FormatNumber(lang, energy/1000, 2))
+}
+
+// FormatCost formats the amount with the currency, e.g. "3,20 EUR".
+func FormatCost(lang string, amount float64, currency string) string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s", FormatNumber(lang, amount, 2), currency))
+}
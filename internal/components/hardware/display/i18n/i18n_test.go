@@ -12,7 +12,110 @@ type I18NTestSuite struct {
 func (suite *I18NTestSuite) SetupTest() {
 }
 
-func (suite *I18NTestSuite) Test() {
+func (suite *I18NTestSuite) TestBundledTranslations() {
+	suite.ElementsMatch([]string{"en", "sl", "de", "fr", "es"}, SupportedLanguages()[:5])
+
+	expected := map[string][]string{
+		"en": {"Connector 1", "available."},
+		"sl": {"Vticnica 1", "je na voljo."},
+		"de": {"Ladepunkt 1", "ist frei."},
+		"fr": {"Prise 1", "disponible."},
+		"es": {"Conector 1", "disponible."},
+		// Falls back to English
+		"ja": {"Connector 1", "available."},
+	}
+
+	for lang, message := range expected {
+		translated, err := TranslateConnectorAvailableMessage(lang, 1)
+		suite.NoError(err)
+		suite.EqualValues(message, translated, lang)
+	}
+
+	// Every message of the catalog is translated
+	for _, lang := range []string{"sl", "de", "fr", "es"} {
+		for _, message := range catalog {
+			var plural interface{}
+			if message.One != "" {
+				plural = 2
+			}
+
+			translated, err := Localize(lang, message.ID, map[string]interface{}{"Id": 1, "Count": 2, "Cost": "1"}, plural)
+			suite.NoError(err)
+
+			english, err := Localize("en", message.ID, map[string]interface{}{"Id": 1, "Count": 2, "Cost": "1"}, plural)
+			suite.NoError(err)
+
+			// Same words in some languages
+			switch message.ID {
+			case "WelcomeMessage2", "TotalCost", "Minutes":
+			default:
+				suite.NotEqualValues(english, translated, "%s %s", lang, message.ID)
+			}
+		}
+	}
+}
+
+func (suite *I18NTestSuite) TestPluralization() {
+	expected := map[int]string{
+		1: "1 minuta",
+		2: "2 minuti",
+		3: "3 minute",
+		5: "5 minut",
+	}
+
+	for minutes, duration := range expected {
+		summary, err := TranslateSessionSummaryMessage("sl", 1.5, "EUR", 3250, minutes*60)
+		suite.NoError(err)
+		suite.EqualValues([]string{"Skupaj: 1,50 EUR", "Energija: 3,25 kWh", "Trajanje:", duration}, summary)
+	}
+
+	summary, err := TranslateSessionSummaryMessage("en", 1.5, "EUR", 3250, 60)
+	suite.NoError(err)
+	suite.EqualValues([]string{"Total: 1.50 EUR", "Energy: 3.25 kWh", "Duration:", "1 minute"}, summary)
+}
+
+func (suite *I18NTestSuite) TestFormat() {
+	suite.EqualValues("1234.50", FormatNumber("en", 1234.5, 2))
+	suite.EqualValues("1234,50", FormatNumber("de", 1234.5, 2))
+	suite.EqualValues("12,35 kWh", FormatEnergy("fr", 12345))
+	suite.EqualValues("0,30 EUR", FormatCost("es", 0.3, "EUR"))
+	suite.EqualValues("0.30", FormatCost("en", 0.3, ""))
+}
+
+func (suite *I18NTestSuite) TestScanToPayMessage() {
+	lines, err := TranslateScanToPayMessage("en", "https://pay.example.com/ChargePi/1")
+	suite.Require().NoError(err)
+	suite.EqualValues([]string{"Scan to pay:", "https://pay.exam", "ple.com/ChargePi", "/1"}, lines)
+
+	caption, err := TranslateScanToPayCaption("de", 2)
+	suite.Require().NoError(err)
+	suite.EqualValues("Zum Bezahlen an Ladepunkt 2 scannen", caption)
+}
+
+func (suite *I18NTestSuite) TestAddLanguagePack() {
+	err := AddLanguagePack("it", []byte("ConnectorTemplate: Connettore {{.Id}}\nConnectorAvailable: disponibile.\n"))
+	suite.NoError(err)
+
+	translated, err := TranslateConnectorAvailableMessage("it", 2)
+	suite.NoError(err)
+	suite.EqualValues([]string{"Connettore 2", "disponibile."}, translated)
+
+	// The messages missing in the pack are in English
+	translated, err = TranslateConnectorFaultedMessage("it", 2)
+	suite.NoError(err)
+	suite.EqualValues([]string{"Connettore 2", "has faulted."}, translated)
+
+	// JSON pack replaces the translation
+	err = AddLanguagePack("it", []byte(`{"ConnectorAvailable": "libero."}`))
+	suite.NoError(err)
+
+	translated, err = TranslateConnectorAvailableMessage("it", 2)
+	suite.NoError(err)
+	suite.EqualValues([]string{"Connettore 2", "libero."}, translated)
+
+	suite.ErrorIs(AddLanguagePack("not a language", []byte("ConnectorAvailable: x")), ErrInvalidLanguage)
+	suite.ErrorIs(AddLanguagePack("nl", []byte("{}")), ErrEmptyLanguagePack)
+	suite.Error(AddLanguagePack("nl", []byte("ConnectorAvailable: [")))
 }
 
 func TestI18N(t *testing.T) {
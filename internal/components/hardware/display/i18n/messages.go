@@ -1,63 +1,185 @@
 package i18n
 
-func TranslateConnectorAvailableMessage(lang string, connectorId int) ([]string, error) {
-	data := make(map[string]interface{})
-	data["Id"] = connectorId
+import (
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"strings"
+)
 
-	firstPart, err := Localize(lang, "ConnectorTemplate", data, nil)
-	secondPart, err := Localize(lang, "ConnectorAvailable", nil, nil)
-	if err != nil {
-		return nil, err
+// lineLength is the number of characters in a line of the 16x2 LCD.
+const lineLength = 16
+
+// catalog contains the default (English) messages of all the user-facing events.
+var catalog = []i18n.Message{
+	// Connector statuses
+	{ID: "ConnectorTemplate", Other: "Connector {{.Id}}"},
+	{ID: "ConnectorAvailable", Other: "available."},
+	{ID: "ConnectorPreparing", Other: "is preparing."},
+	{ID: "ConnectorFinishing", Other: "Stopped charging"},
+	{ID: "ConnectorCharging", Other: "Started charging"},
+	{ID: "ConnectorStopTemplate", Other: "at {{.Id}}."},
+	{ID: "ConnectorSuspended", Other: "is suspended."},
+	{ID: "ConnectorReserved", Other: "is reserved."},
+	{ID: "ConnectorFaulted", Other: "has faulted."},
+	{ID: "ConnectorUnavailable", Other: "is unavailable."},
+	// Charge point
+	{ID: "WelcomeMessage", Other: "Welcome to"},
+	{ID: "WelcomeMessage2", Other: "ChargePi!"},
+	{ID: "OfflineMessage", Other: "Central system"},
+	{ID: "OfflineMessage2", Other: "is offline."},
+	{ID: "FirmwareUpdateMessage", Other: "Updating"},
+	{ID: "FirmwareUpdateMessage2", Other: "the firmware."},
+	// Authorization
+	{ID: "CardRead", Other: "Read tag:"},
+	{ID: "CardRejected", Other: "Card rejected."},
+	{ID: "PinEntry", Other: "Enter PIN:"},
+	{ID: "PinEntered", Other: "PIN entered."},
+	{ID: "PaymentReceived", Other: "Payment received"},
+	{ID: "ScanToPay", Other: "Scan to pay:"},
+	{ID: "ScanToPayConnector", Other: "Scan to pay at connector {{.Id}}"},
+	// Sessions
+	{ID: "RunningCost", Other: "Cost: {{.Cost}}"},
+	{ID: "TotalCost", Other: "Total: {{.Cost}}"},
+	{ID: "Energy", Other: "Energy: {{.Energy}}"},
+	{ID: "Duration", Other: "Duration:"},
+	{ID: "Minutes", One: "{{.Count}} minute", Other: "{{.Count}} minutes"},
+}
+
+// localizeLines translates the messages, each into its own line.
+func localizeLines(lang string, data map[string]interface{}, messageIds ...string) ([]string, error) {
+	var lines []string
+
+	for _, messageId := range messageIds {
+		line, err := Localize(lang, messageId, data, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, line)
 	}
 
-	return []string{firstPart, secondPart}, nil
+	return lines, nil
+}
+
+func TranslateConnectorAvailableMessage(lang string, connectorId int) ([]string, error) {
+	return localizeLines(lang, map[string]interface{}{"Id": connectorId}, "ConnectorTemplate", "ConnectorAvailable")
+}
+
+func TranslateConnectorPreparingMessage(lang string, connectorId int) ([]string, error) {
+	return localizeLines(lang, map[string]interface{}{"Id": connectorId}, "ConnectorTemplate", "ConnectorPreparing")
 }
 
 func TranslateConnectorFinishingMessage(lang string, connectorId int) ([]string, error) {
-	data := make(map[string]interface{})
-	data["Id"] = connectorId
+	return localizeLines(lang, map[string]interface{}{"Id": connectorId}, "ConnectorFinishing", "ConnectorStopTemplate")
+}
+
+func TranslateConnectorSuspendedMessage(lang string, connectorId int) ([]string, error) {
+	return localizeLines(lang, map[string]interface{}{"Id": connectorId}, "ConnectorTemplate", "ConnectorSuspended")
+}
+
+func TranslateConnectorReservedMessage(lang string, connectorId int) ([]string, error) {
+	return localizeLines(lang, map[string]interface{}{"Id": connectorId}, "ConnectorTemplate", "ConnectorReserved")
+}
+
+func TranslateConnectorFaultedMessage(lang string, connectorId int) ([]string, error) {
+	return localizeLines(lang, map[string]interface{}{"Id": connectorId}, "ConnectorTemplate", "ConnectorFaulted")
+}
+
+func TranslateConnectorUnavailableMessage(lang string, connectorId int) ([]string, error) {
+	return localizeLines(lang, map[string]interface{}{"Id": connectorId}, "ConnectorTemplate", "ConnectorUnavailable")
+}
+
+func TranslateConnectorChargingMessage(lang string, connectorId int) ([]string, error) {
+	return localizeLines(lang, map[string]interface{}{"Id": connectorId}, "ConnectorCharging", "ConnectorStopTemplate")
+}
+
+func TranslateWelcomeMessage(lang string) ([]string, error) {
+	return localizeLines(lang, nil, "WelcomeMessage", "WelcomeMessage2")
+}
 
-	firstPart, err := Localize(lang, "ConnectorFinishing", nil, nil)
-	secondPart, err := Localize(lang, "ConnectorStopTemplate", data, nil)
+func TranslateOfflineMessage(lang string) ([]string, error) {
+	return localizeLines(lang, nil, "OfflineMessage", "OfflineMessage2")
+}
+
+func TranslateFirmwareUpdateMessage(lang string) ([]string, error) {
+	return localizeLines(lang, nil, "FirmwareUpdateMessage", "FirmwareUpdateMessage2")
+}
+
+func TranslateCardReadMessage(lang string, tagId string) ([]string, error) {
+	lines, err := localizeLines(lang, nil, "CardRead")
 	if err != nil {
 		return nil, err
 	}
 
-	return []string{firstPart, secondPart}, nil
+	return append(lines, tagId), nil
 }
 
-func TranslateConnectorFaultedMessage(lang string, connectorId int) ([]string, error) {
-	data := make(map[string]interface{})
-	data["Id"] = connectorId
+func TranslateCardRejectedMessage(lang string) ([]string, error) {
+	return localizeLines(lang, nil, "CardRejected")
+}
 
-	firstPart, err := Localize(lang, "ConnectorTemplate", data, nil)
-	secondPart, err := Localize(lang, "ConnectorFaulted", nil, nil)
+// TranslatePinEntryMessage shows an asterisk for each digit of the PIN entered.
+func TranslatePinEntryMessage(lang string, length int) ([]string, error) {
+	lines, err := localizeLines(lang, nil, "PinEntry")
 	if err != nil {
 		return nil, err
 	}
 
-	return []string{firstPart, secondPart}, nil
+	return append(lines, strings.Repeat("*", length)), nil
 }
 
-func TranslateConnectorChargingMessage(lang string, connectorId int) ([]string, error) {
-	data := make(map[string]interface{})
-	data["Id"] = connectorId
+func TranslatePinEnteredMessage(lang string) ([]string, error) {
+	return localizeLines(lang, nil, "PinEntered")
+}
 
-	firstPart, err := Localize(lang, "ConnectorCharging", nil, nil)
-	secondPart, err := Localize(lang, "ConnectorStopTemplate", data, nil)
+func TranslatePaymentReceivedMessage(lang string) ([]string, error) {
+	return localizeLines(lang, nil, "PaymentReceived")
+}
+
+// TranslateScanToPayMessage shows the payment url as text, for the displays that cannot show a QR code.
+func TranslateScanToPayMessage(lang string, paymentUrl string) ([]string, error) {
+	lines, err := localizeLines(lang, nil, "ScanToPay")
 	if err != nil {
 		return nil, err
 	}
 
-	return []string{firstPart, secondPart}, nil
+	// The url is split into lines that fit the LCD, which shows them in pairs
+	for len(paymentUrl) > lineLength {
+		lines = append(lines, paymentUrl[:lineLength])
+		paymentUrl = paymentUrl[lineLength:]
+	}
+
+	return append(lines, paymentUrl), nil
 }
 
-func TranslateWelcomeMessage(lang string) ([]string, error) {
-	firstPart, err := Localize(lang, "WelcomeMessage", nil, nil)
-	secondPart, err := Localize(lang, "WelcomeMessage2", nil, nil)
+// TranslateScanToPayCaption returns the caption of the payment QR code of the connector.
+func TranslateScanToPayCaption(lang string, connectorId int) (string, error) {
+	return Localize(lang, "ScanToPayConnector", map[string]interface{}{"Id": connectorId}, nil)
+}
+
+// TranslateRunningCostMessage shows the cost and the energy (in Wh) of the session in progress.
+func TranslateRunningCostMessage(lang string, cost float64, currency string, energy float64) ([]string, error) {
+	return localizeLines(lang, map[string]interface{}{
+		"Cost":   FormatCost(lang, cost, currency),
+		"Energy": FormatEnergy(lang, energy),
+	}, "RunningCost", "Energy")
+}
+
+// TranslateSessionSummaryMessage shows the total cost, the energy (in Wh) and the duration (in seconds) of the
+// finished session.
+func TranslateSessionSummaryMessage(lang string, cost float64, currency string, energy float64, duration int) ([]string, error) {
+	lines, err := localizeLines(lang, map[string]interface{}{
+		"Cost":   FormatCost(lang, cost, currency),
+		"Energy": FormatEnergy(lang, energy),
+	}, "TotalCost", "Energy", "Duration")
+	if err != nil {
+		return nil, err
+	}
+
+	minutes := duration / 60
+	durationLine, err := Localize(lang, "Minutes", map[string]interface{}{"Count": minutes}, minutes)
 	if err != nil {
 		return nil, err
 	}
 
-	return []string{firstPart, secondPart}, nil
+	return append(lines, durationLine), nil
 }
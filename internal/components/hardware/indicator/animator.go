@@ -0,0 +1,149 @@
+package indicator
+
+import (
+	"context"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+const frameInterval = time.Millisecond * 50
+
+type (
+	// Animator plays the animations on the LEDs of an Indicator without blocking. Each LED index plays one animation
+	// at a time; a finite animation returns to the last animation played until it was replaced.
+	Animator struct {
+		indicator Indicator
+		mu        sync.Mutex
+		renderMu  sync.Mutex
+		base      map[int]Animation
+		cancel    map[int]context.CancelFunc
+	}
+)
+
+// NewAnimator creates an Animator for the indicator.
+func NewAnimator(indicator Indicator) *Animator {
+	return &Animator{
+		indicator: indicator,
+		base:      map[int]Animation{},
+		cancel:    map[int]context.CancelFunc{},
+	}
+}
+
+// Play replaces the animation of the LED at the index. The animation is played until it is replaced.
+func (a *Animator) Play(index int, animation Animation) {
+	animation.Repeat = 0
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.base[index] = animation
+	a.start(index, animation)
+}
+
+// Flash plays the animation on the LED at the index for the number of periods of the animation (at least once)
+// and continues with the animation that was played before.
+func (a *Animator) Flash(index int, animation Animation) {
+	if animation.Repeat <= 0 {
+		animation.Repeat = 1
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.start(index, animation)
+}
+
+// Stop stops all the animations.
+func (a *Animator) Stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for index, cancel := range a.cancel {
+		cancel()
+		delete(a.cancel, index)
+	}
+}
+
+// start replaces the animation at the index. The first frame is rendered immediately, so the animations are
+// rendered in the order they were started. Must be called with the lock held.
+func (a *Animator) start(index int, animation Animation) {
+	if cancel, isFound := a.cancel[index]; isFound {
+		cancel()
+		delete(a.cancel, index)
+	}
+
+	a.render(index, animation.colorAt(index, 0))
+
+	// A solid color does not change until it is replaced
+	if animation.Pattern == PatternSolid && animation.Repeat == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel[index] = cancel
+
+	go a.animate(ctx, index, animation)
+}
+
+// animate renders the frames of the animation until it is done or replaced.
+func (a *Animator) animate(ctx context.Context, index int, animation Animation) {
+	var (
+		startedAt = time.Now()
+		ticker    = time.NewTicker(frameInterval)
+		lastColor = animation.colorAt(index, 0)
+	)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			elapsed := time.Since(startedAt)
+			if animation.isDone(elapsed) {
+				a.restore(ctx, index)
+				return
+			}
+
+			color := animation.colorAt(index, elapsed)
+			if color != lastColor {
+				a.render(index, color)
+				lastColor = color
+			}
+		}
+	}
+}
+
+// restore continues with the animation played before the finished animation, unless it was replaced in the meantime.
+func (a *Animator) restore(ctx context.Context, index int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	if cancel, isFound := a.cancel[index]; isFound {
+		cancel()
+		delete(a.cancel, index)
+	}
+
+	base, isFound := a.base[index]
+	if !isFound {
+		a.render(index, Off)
+		return
+	}
+
+	a.start(index, base)
+}
+
+func (a *Animator) render(index int, color uint32) {
+	a.renderMu.Lock()
+	defer a.renderMu.Unlock()
+
+	err := a.indicator.DisplayColor(index, color)
+	if err != nil {
+		log.WithError(err).Errorf("Error displaying the color on the indicator")
+	}
+}
@@ -0,0 +1,92 @@
+package indicator
+
+import (
+	"github.com/warthog618/gpiod"
+	"time"
+)
+
+// threshold is the minimal channel value of a color that turns on a single-color LED
+const threshold = 0x40
+
+// GPIOLeds is an indicator with a single-color LED on a GPIO pin for each index. Any color brighter than
+// the threshold turns the LED on, so dimmed frames of the animations turn it off.
+type GPIOLeds struct {
+	inverseLogic bool
+	lines        []*gpiod.Line
+}
+
+// NewGPIOLeds requests the pins of the LEDs as outputs and turns the LEDs off.
+func NewGPIOLeds(pins []int, inverseLogic bool) (*GPIOLeds, error) {
+	if len(pins) == 0 {
+		return nil, ErrInvalidNumberOfLeds
+	}
+
+	for _, pin := range pins {
+		if pin <= 0 {
+			return nil, ErrInvalidPin
+		}
+	}
+
+	chip, err := gpiod.NewChip("gpiochip0")
+	if err != nil {
+		return nil, err
+	}
+	defer chip.Close()
+
+	leds := &GPIOLeds{inverseLogic: inverseLogic}
+	for _, pin := range pins {
+		line, err := chip.RequestLine(pin, gpiod.AsOutput(leds.value(false)))
+		if err != nil {
+			leds.Cleanup()
+			return nil, err
+		}
+
+		leds.lines = append(leds.lines, line)
+	}
+
+	return leds, nil
+}
+
+// DisplayColor turns the LED at the index on, if the color is bright enough, or off.
+func (g *GPIOLeds) DisplayColor(index int, colorHex uint32) error {
+	if index < 0 || index >= len(g.lines) {
+		return ErrInvalidIndex
+	}
+
+	return g.lines[index].SetValue(g.value(isLit(colorHex)))
+}
+
+// Blink the LED at index a certain number of times. Same as with the LED strip, the LED stays on after blinking
+// if the number of times is odd.
+func (g *GPIOLeds) Blink(index int, times int, colorHex uint32) error {
+	if index < 0 || index >= len(g.lines) {
+		return ErrInvalidIndex
+	}
+
+	for i := 0; i < times; i++ {
+		_ = g.lines[index].SetValue(g.value(i%2 != 0 && isLit(colorHex)))
+		time.Sleep(time.Millisecond * sleepTime)
+	}
+
+	return nil
+}
+
+// Cleanup turns the LEDs off and releases the pins.
+func (g *GPIOLeds) Cleanup() {
+	for _, line := range g.lines {
+		_ = line.SetValue(g.value(false))
+		_ = line.Close()
+	}
+}
+
+func (g *GPIOLeds) value(isOn bool) int {
+	if isOn != g.inverseLogic {
+		return 1
+	}
+
+	return 0
+}
+
+func isLit(colorHex uint32) bool {
+	return (colorHex>>16)&0xff >= threshold || (colorHex>>8)&0xff >= threshold || colorHex&0xff >= threshold
+}
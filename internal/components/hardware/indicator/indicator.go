@@ -3,7 +3,7 @@ package indicator
 import (
 	"errors"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 )
 
 // color constants
@@ -20,12 +20,16 @@ const (
 // Supported types
 const (
 	TypeWS281x = "WS281x"
+	TypeGPIO   = "GPIO"
 )
 
 var (
-	ErrInvalidIndex        = errors.New("invalid index")
-	ErrInvalidPin          = errors.New("invalid data pin #")
-	ErrInvalidNumberOfLeds = errors.New("number of leds must be greater than zero")
+	ErrIndicatorDisabled    = errors.New("indicator disabled")
+	ErrIndicatorUnsupported = errors.New("indicator type not supported")
+	ErrInvalidIndex         = errors.New("invalid index")
+	ErrInvalidPin           = errors.New("invalid data pin #")
+	ErrInvalidNumberOfLeds  = errors.New("number of leds must be greater than zero")
+	ErrTooManyStrips        = errors.New("at most two led strips are supported")
 )
 
 type (
@@ -37,34 +41,60 @@ type (
 	}
 )
 
-// NewIndicator constructs the Indicator based on the type provided by the settings file.
-func NewIndicator(stripLength int) Indicator {
-	var (
-		indicatorEnabled = viper.GetBool("chargepoint.hardware.ledIndicator.enabled")
-		indicatorType    = viper.GetString("chargepoint.hardware.ledIndicator.type")
-		indicateCardRead = viper.GetBool("chargepoint.hardware.ledIndicator.indicateCardRead")
-		indicatorDataPin = viper.GetInt("chargepoint.hardware.ledIndicator.dataPin")
-	)
+// NewIndicator constructs the Indicator based on the type provided by the settings. A single LED strip has an LED for
+// each connector and an additional LED for the card events, if enabled.
+func NewIndicator(numberOfConnectors int, indicatorSettings settings.LedIndicator) (Indicator, error) {
+	if !indicatorSettings.Enabled {
+		return nil, ErrIndicatorDisabled
+	}
+
+	log.Infof("Preparing Indicator from config: %s", indicatorSettings.Type)
+
+	switch indicatorSettings.Type {
+	case TypeWS281x:
+		if len(indicatorSettings.Strips) > 0 {
+			strips, err := NewStrips(indicatorSettings.Strips, indicatorSettings.Invert)
+			if err != nil {
+				return nil, err
+			}
+
+			return strips, nil
+		}
 
-	if indicatorEnabled {
-		if indicateCardRead {
+		stripLength := numberOfConnectors
+		if indicatorSettings.IndicateCardRead {
 			stripLength++
 		}
 
-		log.Infof("Preparing Indicator from config: %s", indicatorType)
-		switch indicatorType {
-		case TypeWS281x:
-			ledStrip, ledError := NewWS281xStrip(stripLength, indicatorDataPin)
-			if ledError != nil {
-				log.WithError(ledError).Errorf("Error creating indicator")
-				return nil
-			}
+		ledStrip, err := NewWS281xStrip(stripLength, indicatorSettings.DataPin, indicatorSettings.Invert)
+		if err != nil {
+			return nil, err
+		}
 
-			return ledStrip
-		default:
-			return nil
+		return ledStrip, nil
+	case TypeGPIO:
+		leds, err := NewGPIOLeds(indicatorSettings.Pins, indicatorSettings.Invert)
+		if err != nil {
+			return nil, err
 		}
+
+		return leds, nil
+	default:
+		return nil, ErrIndicatorUnsupported
 	}
+}
 
-	return nil
+// HasCardLed checks if the indicator has an LED for the card events after the LEDs of the connectors. A single LED strip
+// is extended by the card LED, the strips and the GPIO LEDs must be configured with an extra index.
+func HasCardLed(numberOfConnectors int, indicatorSettings settings.LedIndicator) bool {
+	switch {
+	case !indicatorSettings.Enabled || !indicatorSettings.IndicateCardRead:
+		return false
+	case indicatorSettings.Type == TypeWS281x && len(indicatorSettings.Strips) > 0:
+		return len(indicatorSettings.Strips) > numberOfConnectors
+	case indicatorSettings.Type == TypeGPIO:
+		return len(indicatorSettings.Pins) > numberOfConnectors
+	default:
+		return true
+	}
 }
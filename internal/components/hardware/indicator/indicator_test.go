@@ -0,0 +1,70 @@
+package indicator
+
+import (
+	"github.com/rpi-ws281x/rpi-ws281x-go"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"testing"
+)
+
+type indicatorTestSuite struct {
+	suite.Suite
+}
+
+func (s *indicatorTestSuite) TestNewIndicator() {
+	_, err := NewIndicator(2, settings.LedIndicator{Enabled: false, Type: TypeWS281x, DataPin: 18})
+	s.Assert().ErrorIs(err, ErrIndicatorDisabled)
+
+	_, err = NewIndicator(2, settings.LedIndicator{Enabled: true, Type: "APA102"})
+	s.Assert().ErrorIs(err, ErrIndicatorUnsupported)
+
+	_, err = NewIndicator(2, settings.LedIndicator{Enabled: true, Type: TypeWS281x})
+	s.Assert().ErrorIs(err, ErrInvalidPin)
+
+	_, err = NewIndicator(2, settings.LedIndicator{Enabled: true, Type: TypeWS281x, Strips: []settings.LedStrip{{DataPin: 18}}})
+	s.Assert().ErrorIs(err, ErrInvalidNumberOfLeds)
+
+	_, err = NewIndicator(3, settings.LedIndicator{Enabled: true, Type: TypeWS281x, Strips: []settings.LedStrip{
+		{DataPin: 18, Length: 12}, {DataPin: 13, Length: 12}, {DataPin: 21, Length: 12},
+	}})
+	s.Assert().ErrorIs(err, ErrTooManyStrips)
+
+	_, err = NewIndicator(2, settings.LedIndicator{Enabled: true, Type: TypeGPIO})
+	s.Assert().ErrorIs(err, ErrInvalidNumberOfLeds)
+
+	_, err = NewIndicator(2, settings.LedIndicator{Enabled: true, Type: TypeGPIO, Pins: []int{17, 0}})
+	s.Assert().ErrorIs(err, ErrInvalidPin)
+}
+
+func (s *indicatorTestSuite) TestHasCardLed() {
+	indicatorSettings := settings.LedIndicator{Enabled: true, Type: TypeWS281x, DataPin: 18}
+	s.Assert().False(HasCardLed(2, indicatorSettings))
+
+	// The single strip is extended by the card LED
+	indicatorSettings.IndicateCardRead = true
+	s.Assert().True(HasCardLed(2, indicatorSettings))
+
+	// The strips have no extra strip for the card
+	indicatorSettings.Strips = []settings.LedStrip{{DataPin: 18, Length: 12}, {DataPin: 13, Length: 12}}
+	s.Assert().False(HasCardLed(2, indicatorSettings))
+	s.Assert().True(HasCardLed(1, indicatorSettings))
+
+	indicatorSettings = settings.LedIndicator{Enabled: true, Type: TypeGPIO, IndicateCardRead: true, Pins: []int{17, 27}}
+	s.Assert().False(HasCardLed(2, indicatorSettings))
+	s.Assert().True(HasCardLed(1, indicatorSettings))
+}
+
+func (s *indicatorTestSuite) TestWS281xDefaultOptions() {
+	defaultChannel := ws2811.DefaultOptions.Channels[0]
+
+	strip, err := NewWS281xStrip(3, 18, true)
+	s.Require().NoError(err)
+	defer strip.Cleanup()
+
+	// The shared default options are not changed
+	s.Assert().EqualValues(defaultChannel, ws2811.DefaultOptions.Channels[0])
+}
+
+func TestIndicator(t *testing.T) {
+	suite.Run(t, new(indicatorTestSuite))
+}
@@ -0,0 +1,105 @@
+package indicator
+
+import (
+	"github.com/rpi-ws281x/rpi-ws281x-go"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"time"
+)
+
+// Strips is an indicator with a separate WS281x LED strip for each index, e.g. a strip or a ring around each
+// connector. All the LEDs of a strip display the same color.
+type Strips struct {
+	numberOfStrips int
+	ws2811         *ws2811.WS2811
+}
+
+// NewStrips creates and initializes the LED strips. The strips are driven by the PWM channels of a single driver, so
+// at most two strips are supported.
+func NewStrips(stripSettings []settings.LedStrip, invert bool) (*Strips, error) {
+	switch {
+	case len(stripSettings) == 0:
+		return nil, ErrInvalidNumberOfLeds
+	case len(stripSettings) > ws2811.RpiPwmChannels:
+		return nil, ErrTooManyStrips
+	}
+
+	opt := ws2811.DefaultOptions
+	opt.Frequency = freq
+	// The default channel options are shared, so the channels are copied
+	opt.Channels = make([]ws2811.ChannelOption, len(stripSettings))
+
+	for i, strip := range stripSettings {
+		if strip.Length <= 0 {
+			return nil, ErrInvalidNumberOfLeds
+		}
+
+		if strip.DataPin <= 0 {
+			return nil, ErrInvalidPin
+		}
+
+		opt.Channels[i] = ws2811.DefaultOptions.Channels[0]
+		opt.Channels[i].Brightness = brightness
+		opt.Channels[i].LedCount = strip.Length
+		opt.Channels[i].GpioPin = strip.DataPin
+		opt.Channels[i].Invert = invert
+	}
+
+	ledStrips, err := ws2811.MakeWS2811(&opt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ledStrips.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Strips{numberOfStrips: len(stripSettings), ws2811: ledStrips}, nil
+}
+
+// DisplayColor changes the color of the strip at the index.
+func (s *Strips) DisplayColor(index int, colorHex uint32) error {
+	if index < 0 || index >= s.numberOfStrips {
+		return ErrInvalidIndex
+	}
+
+	return s.fill(index, colorHex)
+}
+
+// Blink the strip at the index a certain number of times with the specified color, same as a single LED.
+func (s *Strips) Blink(index int, times int, colorHex uint32) error {
+	if index < 0 || index >= s.numberOfStrips {
+		return ErrInvalidIndex
+	}
+
+	for i := 0; i < times; i++ {
+		if i%2 == 0 {
+			_ = s.fill(index, Off)
+		} else {
+			_ = s.fill(index, colorHex)
+		}
+
+		time.Sleep(time.Millisecond * sleepTime)
+	}
+
+	return nil
+}
+
+// Cleanup turns off and releases all the strips.
+func (s *Strips) Cleanup() {
+	for i := 0; i < s.numberOfStrips; i++ {
+		_ = s.fill(i, Off)
+	}
+
+	s.ws2811.Fini()
+}
+
+// fill changes the color of all the LEDs of the strip.
+func (s *Strips) fill(index int, colorHex uint32) error {
+	leds := s.ws2811.Leds(index)
+	for i := range leds {
+		leds[i] = colorHex
+	}
+
+	return s.ws2811.Render()
+}
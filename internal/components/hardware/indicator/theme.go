@@ -0,0 +1,192 @@
+package indicator
+
+import (
+	"errors"
+	"fmt"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported patterns
+const (
+	PatternSolid   = "solid"
+	PatternBlink   = "blink"
+	PatternBreathe = "breathe"
+	PatternChase   = "chase"
+)
+
+// Events indicated besides the connector statuses
+const (
+	EventCardRead       = "CardRead"
+	EventCardAccepted   = "CardAccepted"
+	EventCardRejected   = "CardRejected"
+	EventOffline        = "Offline"
+	EventFirmwareUpdate = "FirmwareUpdate"
+)
+
+const defaultPeriod = time.Second
+
+var (
+	ErrInvalidColor   = errors.New("invalid color")
+	ErrInvalidPattern = errors.New("invalid pattern")
+)
+
+type (
+	// Animation describes how an LED displays a status or an event.
+	Animation struct {
+		Color   uint32
+		Pattern string
+		// Brightness in percent
+		Brightness int
+		Period     time.Duration
+		// Repeat is the number of periods the animation is played for; zero plays it until it is replaced.
+		Repeat int
+	}
+
+	// Theme maps the connector statuses and the events to animations.
+	Theme map[string]Animation
+)
+
+// DefaultTheme returns the animations used when the theme does not override them.
+func DefaultTheme() Theme {
+	return Theme{
+		"Available":         {Color: Green, Pattern: PatternSolid},
+		"Charging":          {Color: Blue, Pattern: PatternSolid},
+		"Finishing":         {Color: Blue, Pattern: PatternSolid},
+		"Reserved":          {Color: Yellow, Pattern: PatternSolid},
+		"Unavailable":       {Color: Orange, Pattern: PatternSolid},
+		"Faulted":           {Color: Red, Pattern: PatternSolid},
+		EventCardRead:       {Color: White, Pattern: PatternBlink, Repeat: 2},
+		EventCardAccepted:   {Color: Green, Pattern: PatternBlink, Repeat: 2},
+		EventCardRejected:   {Color: Red, Pattern: PatternBlink, Repeat: 3},
+		EventOffline:        {Color: Orange, Pattern: PatternBreathe, Period: time.Second * 3},
+		EventFirmwareUpdate: {Color: White, Pattern: PatternChase, Period: time.Millisecond * 1500},
+	}
+}
+
+// NewTheme overrides the default theme with the animations from the settings.
+func NewTheme(animations map[string]settings.IndicatorAnimation) (Theme, error) {
+	theme := DefaultTheme()
+
+	for name, animationSettings := range animations {
+		animation, err := newAnimation(animationSettings, theme[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		theme[name] = animation
+	}
+
+	return theme, nil
+}
+
+// newAnimation overrides the default animation with the non-zero settings.
+func newAnimation(animationSettings settings.IndicatorAnimation, animation Animation) (Animation, error) {
+	if animationSettings.Color != "" {
+		color, err := ParseColor(animationSettings.Color)
+		if err != nil {
+			return animation, err
+		}
+
+		animation.Color = color
+	}
+
+	switch animationSettings.Pattern {
+	case "":
+		if animation.Pattern == "" {
+			animation.Pattern = PatternSolid
+		}
+	case PatternSolid, PatternBlink, PatternBreathe, PatternChase:
+		animation.Pattern = animationSettings.Pattern
+	default:
+		return animation, ErrInvalidPattern
+	}
+
+	if animationSettings.Brightness > 0 {
+		animation.Brightness = animationSettings.Brightness
+	}
+
+	if animationSettings.Period > 0 {
+		animation.Period = time.Duration(animationSettings.Period) * time.Millisecond
+	}
+
+	return animation, nil
+}
+
+// ParseColor parses a hex color in the "#ff7b00" or "0xff7b00" format.
+func ParseColor(color string) (uint32, error) {
+	color = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(color), "#"), "0x")
+
+	value, err := strconv.ParseUint(color, 16, 32)
+	if err != nil || value > White {
+		return 0, ErrInvalidColor
+	}
+
+	return uint32(value), nil
+}
+
+// getPeriod returns the period of the animation or the default period.
+func (a Animation) getPeriod() time.Duration {
+	if a.Period <= 0 {
+		return defaultPeriod
+	}
+
+	return a.Period
+}
+
+// isDone checks if the animation was played for the number of periods.
+func (a Animation) isDone(elapsed time.Duration) bool {
+	return a.Repeat > 0 && elapsed >= a.getPeriod()*time.Duration(a.Repeat)
+}
+
+// colorAt returns the color of the LED at the index after the animation has been played for the elapsed time.
+// The chase pattern is shifted by the index, so the LEDs running it light up one after another.
+func (a Animation) colorAt(index int, elapsed time.Duration) uint32 {
+	var (
+		period   = a.getPeriod()
+		position = float64(elapsed%period) / float64(period)
+		level    = 1.0
+	)
+
+	switch a.Pattern {
+	case PatternBlink:
+		if position >= 0.5 {
+			level = 0
+		}
+	case PatternBreathe:
+		level = (1 - math.Cos(2*math.Pi*position)) / 2
+	case PatternChase:
+		phase := float64(index%3) / 3
+		if position < phase || position >= phase+1.0/3 {
+			level = 0
+		}
+	}
+
+	return scaleColor(a.Color, level*float64(a.getBrightness())/100)
+}
+
+func (a Animation) getBrightness() int {
+	if a.Brightness <= 0 || a.Brightness > 100 {
+		return 100
+	}
+
+	return a.Brightness
+}
+
+// scaleColor scales each channel of the color by the level between 0 and 1.
+func scaleColor(color uint32, level float64) uint32 {
+	if level >= 1 {
+		return color
+	}
+
+	var scaled uint32
+	for shift := uint(0); shift < 24; shift += 8 {
+		channel := float64((color >> shift) & 0xff)
+		scaled |= uint32(math.Round(channel*level)) << shift
+	}
+
+	return scaled
+}
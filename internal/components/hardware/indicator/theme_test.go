@@ -0,0 +1,137 @@
+package indicator
+
+import (
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type (
+	themeTestSuite struct {
+		suite.Suite
+	}
+
+	fakeIndicator struct {
+		mu     sync.Mutex
+		colors map[int][]uint32
+	}
+)
+
+func (f *fakeIndicator) DisplayColor(index int, colorHex uint32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.colors[index] = append(f.colors[index], colorHex)
+	return nil
+}
+
+func (f *fakeIndicator) Blink(index int, times int, colorHex uint32) error {
+	return nil
+}
+
+func (f *fakeIndicator) Cleanup() {
+}
+
+func (f *fakeIndicator) lastColor(index int) uint32 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.colors[index][len(f.colors[index])-1]
+}
+
+func (f *fakeIndicator) colorsOf(index int) []uint32 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]uint32{}, f.colors[index]...)
+}
+
+func (s *themeTestSuite) TestParseColor() {
+	color, err := ParseColor("#FF7B00")
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(Orange, color)
+
+	color, err = ParseColor("0x0000ff")
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(Blue, color)
+
+	_, err = ParseColor("green")
+	s.Assert().ErrorIs(err, ErrInvalidColor)
+
+	_, err = ParseColor("#1000000")
+	s.Assert().ErrorIs(err, ErrInvalidColor)
+}
+
+func (s *themeTestSuite) TestNewTheme() {
+	theme, err := NewTheme(map[string]settings.IndicatorAnimation{
+		"Charging":        {Pattern: PatternBreathe, Period: 2000},
+		"SuspendedEV":     {Color: "#00ffff"},
+		EventCardRejected: {Color: "#ff00ff", Brightness: 50},
+	})
+	s.Require().NoError(err)
+
+	// Overridden
+	s.Assert().EqualValues(Animation{Color: Blue, Pattern: PatternBreathe, Period: time.Second * 2}, theme["Charging"])
+	s.Assert().EqualValues(Animation{Color: 0x00ffff, Pattern: PatternSolid}, theme["SuspendedEV"])
+	s.Assert().EqualValues(Animation{Color: 0xff00ff, Pattern: PatternBlink, Brightness: 50, Repeat: 3}, theme[EventCardRejected])
+	// Default
+	s.Assert().EqualValues(DefaultTheme()["Available"], theme["Available"])
+
+	_, err = NewTheme(map[string]settings.IndicatorAnimation{"Available": {Pattern: "rainbow"}})
+	s.Assert().ErrorIs(err, ErrInvalidPattern)
+
+	_, err = NewTheme(map[string]settings.IndicatorAnimation{"Available": {Color: "green"}})
+	s.Assert().ErrorIs(err, ErrInvalidColor)
+}
+
+func (s *themeTestSuite) TestColorAt() {
+	var (
+		blink   = Animation{Color: White, Pattern: PatternBlink, Period: time.Second}
+		breathe = Animation{Color: White, Pattern: PatternBreathe, Period: time.Second}
+		chase   = Animation{Color: Red, Pattern: PatternChase, Period: time.Millisecond * 300}
+		dimmed  = Animation{Color: White, Pattern: PatternSolid, Brightness: 50}
+	)
+
+	s.Assert().EqualValues(White, blink.colorAt(0, 0))
+	s.Assert().EqualValues(Off, blink.colorAt(0, time.Millisecond*600))
+
+	s.Assert().EqualValues(Off, breathe.colorAt(0, 0))
+	s.Assert().EqualValues(White, breathe.colorAt(0, time.Millisecond*500))
+
+	// The LEDs light up one after another
+	s.Assert().EqualValues(Red, chase.colorAt(0, 0))
+	s.Assert().EqualValues(Off, chase.colorAt(1, 0))
+	s.Assert().EqualValues(Red, chase.colorAt(1, time.Millisecond*150))
+
+	s.Assert().EqualValues(0x808080, dimmed.colorAt(0, 0))
+}
+
+func (s *themeTestSuite) TestAnimator() {
+	var (
+		leds     = &fakeIndicator{colors: map[int][]uint32{}}
+		animator = NewAnimator(leds)
+	)
+
+	animator.Play(0, Animation{Color: Green, Pattern: PatternSolid})
+	s.Assert().EqualValues(Green, leds.lastColor(0))
+
+	// A finite animation returns to the played animation
+	animator.Flash(0, Animation{Color: Red, Pattern: PatternBlink, Period: time.Millisecond * 200, Repeat: 2})
+	s.Assert().EqualValues(Red, leds.lastColor(0))
+
+	time.Sleep(time.Millisecond * 600)
+	s.Assert().EqualValues(Green, leds.lastColor(0))
+	s.Assert().Contains(leds.colorsOf(0), uint32(Off))
+
+	// A LED without an animation is turned off after the finite animation
+	animator.Flash(1, Animation{Color: White, Pattern: PatternSolid, Period: time.Millisecond * 100})
+	s.Assert().EqualValues(White, leds.lastColor(1))
+
+	time.Sleep(time.Millisecond * 300)
+	s.Assert().EqualValues(Off, leds.lastColor(1))
+
+	animator.Stop()
+}
+
+func TestTheme(t *testing.T) {
+	suite.Run(t, new(themeTestSuite))
+}
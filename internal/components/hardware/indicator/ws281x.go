@@ -14,12 +14,13 @@ const (
 type WS281x struct {
 	numberOfLEDs int
 	dataPin      int
+	invert       bool
 	ws2811       *ws2811.WS2811
 }
 
 // NewWS281xStrip create a new LED strip object with the specified number of LEDs and the data pin.
 // When created, it will also be initialized.
-func NewWS281xStrip(numberOfLEDs int, dataPin int) (*WS281x, error) {
+func NewWS281xStrip(numberOfLEDs int, dataPin int, invert bool) (*WS281x, error) {
 	if numberOfLEDs <= 0 {
 		return nil, ErrInvalidNumberOfLeds
 	}
@@ -28,7 +29,7 @@ func NewWS281xStrip(numberOfLEDs int, dataPin int) (*WS281x, error) {
 		return nil, ErrInvalidPin
 	}
 
-	ledStrip := &WS281x{dataPin: dataPin, numberOfLEDs: numberOfLEDs, ws2811: nil}
+	ledStrip := &WS281x{dataPin: dataPin, numberOfLEDs: numberOfLEDs, invert: invert, ws2811: nil}
 	err := ledStrip.init()
 	if err != nil {
 		return nil, err
@@ -40,9 +41,12 @@ func NewWS281xStrip(numberOfLEDs int, dataPin int) (*WS281x, error) {
 // init initialize the LED strip.
 func (ws *WS281x) init() error {
 	opt := ws2811.DefaultOptions
+	// The default channel options are shared, so the channels are copied
+	opt.Channels = append([]ws2811.ChannelOption{}, ws2811.DefaultOptions.Channels...)
 	opt.Channels[0].Brightness = brightness
 	opt.Channels[0].LedCount = ws.numberOfLEDs
 	opt.Channels[0].GpioPin = ws.dataPin
+	opt.Channels[0].Invert = ws.invert
 	opt.Frequency = freq
 
 	ledStrip, err := ws2811.MakeWS2811(&opt)
@@ -57,7 +61,7 @@ func (ws *WS281x) init() error {
 // DisplayColor change the color of the LED at specified index to the specified color.
 // The index must be greater than 0 and less than the length of the LED strip.
 func (ws *WS281x) DisplayColor(index int, colorHex uint32) error {
-	if index < 0 || index > len(ws.ws2811.Leds(0)) {
+	if index < 0 || index >= len(ws.ws2811.Leds(0)) {
 		return ErrInvalidIndex
 	}
 
@@ -68,7 +72,7 @@ func (ws *WS281x) DisplayColor(index int, colorHex uint32) error {
 // Blink the LED at index a certain number of times with the specified color. If the number of times the LED is supposed to blink is even, it will stay turned off after the blinking,
 // otherwise it will stay on after the blinking.
 func (ws *WS281x) Blink(index int, times int, colorHex uint32) error {
-	if index < 0 || index > len(ws.ws2811.Leds(0)) {
+	if index < 0 || index >= len(ws.ws2811.Leds(0)) {
 		return ErrInvalidIndex
 	}
 
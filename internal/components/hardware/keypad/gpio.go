@@ -0,0 +1,68 @@
+package keypad
+
+import (
+	"github.com/warthog618/gpiod"
+)
+
+// gpioMatrix is a keypad connected directly to the GPIO pins. The selected column is driven low and the pressed
+// rows are pulled low.
+type gpioMatrix struct {
+	rows    *gpiod.Lines
+	columns *gpiod.Lines
+	values  []int
+}
+
+func newGpioMatrix(rowPins, columnPins []int) (*gpioMatrix, error) {
+	// Refer to gpiod docs
+	c, err := gpiod.NewChip("gpiochip0")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.RequestLines(rowPins, gpiod.AsInput, gpiod.WithPullUp)
+	if err != nil {
+		return nil, err
+	}
+
+	idle := make([]int, len(columnPins))
+	for i := range idle {
+		idle[i] = 1
+	}
+
+	columns, err := c.RequestLines(columnPins, gpiod.AsOutput(idle...))
+	if err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+
+	return &gpioMatrix{rows: rows, columns: columns, values: idle}, nil
+}
+
+func (m *gpioMatrix) selectColumn(column int) error {
+	for i := range m.values {
+		m.values[i] = 1
+	}
+
+	m.values[column] = 0
+	return m.columns.SetValues(m.values)
+}
+
+func (m *gpioMatrix) readRows() ([]bool, error) {
+	values := make([]int, len(m.rows.Offsets()))
+	err := m.rows.Values(values)
+	if err != nil {
+		return nil, err
+	}
+
+	pressed := make([]bool, len(values))
+	for i, value := range values {
+		pressed[i] = value == 0
+	}
+
+	return pressed, nil
+}
+
+func (m *gpioMatrix) close() {
+	_ = m.rows.Close()
+	_ = m.columns.Close()
+}
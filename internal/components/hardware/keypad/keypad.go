@@ -0,0 +1,162 @@
+package keypad
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"time"
+)
+
+// Supported keypads
+const (
+	DriverGPIO    = "gpio"
+	DriverPCF8574 = "pcf8574"
+)
+
+const (
+	defaultLayout = "123A456B789C*0#D"
+	scanInterval  = 20 * time.Millisecond
+)
+
+var (
+	ErrKeypadUnsupported = errors.New("keypad type unsupported")
+	ErrKeypadDisabled    = errors.New("keypad disabled")
+	ErrInvalidLayout     = errors.New("the keypad layout does not match the rows and columns")
+)
+
+type (
+	// Keypad is an abstraction for a keypad, which sends the pressed keys to the key channel.
+	Keypad interface {
+		ListenForKeys(ctx context.Context)
+		Cleanup()
+		GetKeyChannel() <-chan rune
+	}
+
+	// matrix drives a column of the keypad and reads which rows are pressed.
+	matrix interface {
+		selectColumn(column int) error
+		readRows() ([]bool, error)
+		close()
+	}
+
+	// MatrixKeypad scans a matrix keypad column by column.
+	MatrixKeypad struct {
+		matrix     matrix
+		layout     []rune
+		rows       int
+		columns    int
+		keyChannel chan rune
+		pressed    map[rune]bool
+	}
+)
+
+// NewKeypad creates an instance of the Keypad interface based on the provided configuration.
+func NewKeypad(keypadSettings settings.Keypad) (Keypad, error) {
+	if !keypadSettings.IsEnabled {
+		return nil, ErrKeypadDisabled
+	}
+
+	log.Infof("Preparing keypad from config: %s", keypadSettings.Driver)
+
+	var (
+		m       matrix
+		err     error
+		rows    = len(keypadSettings.RowPins)
+		columns = len(keypadSettings.ColumnPins)
+	)
+
+	switch keypadSettings.Driver {
+	case DriverGPIO:
+		m, err = newGpioMatrix(keypadSettings.RowPins, keypadSettings.ColumnPins)
+	case DriverPCF8574:
+		rows, columns = 4, 4
+		m, err = newPcf8574Matrix(keypadSettings.I2CAddress, keypadSettings.I2CBus)
+	default:
+		return nil, ErrKeypadUnsupported
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	keypad, err := newMatrixKeypad(m, rows, columns, keypadSettings.Layout)
+	if err != nil {
+		m.close()
+		return nil, err
+	}
+
+	return keypad, nil
+}
+
+func newMatrixKeypad(m matrix, rows, columns int, layout string) (*MatrixKeypad, error) {
+	if layout == "" {
+		layout = defaultLayout
+	}
+
+	keys := []rune(layout)
+	if rows <= 0 || columns <= 0 || len(keys) != rows*columns {
+		return nil, ErrInvalidLayout
+	}
+
+	return &MatrixKeypad{
+		matrix:     m,
+		layout:     keys,
+		rows:       rows,
+		columns:    columns,
+		keyChannel: make(chan rune, 10),
+		pressed:    map[rune]bool{},
+	}, nil
+}
+
+// ListenForKeys scans the keypad until the context is cancelled. A key is sent once when it is pressed.
+func (k *MatrixKeypad) ListenForKeys(ctx context.Context) {
+	ticker := time.NewTicker(scanInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			k.scan()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// scan reads the state of every key and sends the keys that were pressed since the previous scan.
+func (k *MatrixKeypad) scan() {
+	for column := 0; column < k.columns; column++ {
+		err := k.matrix.selectColumn(column)
+		if err != nil {
+			log.WithError(err).Debug("Cannot select the keypad column")
+			return
+		}
+
+		rows, err := k.matrix.readRows()
+		if err != nil {
+			log.WithError(err).Debug("Cannot read the keypad rows")
+			return
+		}
+
+		for row := 0; row < k.rows && row < len(rows); row++ {
+			key := k.layout[row*k.columns+column]
+			if rows[row] && !k.pressed[key] {
+				select {
+				case k.keyChannel <- key:
+				default:
+				}
+			}
+
+			k.pressed[key] = rows[row]
+		}
+	}
+}
+
+func (k *MatrixKeypad) GetKeyChannel() <-chan rune {
+	return k.keyChannel
+}
+
+func (k *MatrixKeypad) Cleanup() {
+	k.matrix.close()
+}
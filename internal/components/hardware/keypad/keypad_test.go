@@ -0,0 +1,95 @@
+package keypad
+
+import (
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"testing"
+)
+
+// fakeMatrix reports the keys in pressed as pressed.
+type fakeMatrix struct {
+	pressed map[[2]int]bool
+	column  int
+	closed  bool
+}
+
+func (m *fakeMatrix) selectColumn(column int) error {
+	m.column = column
+	return nil
+}
+
+func (m *fakeMatrix) readRows() ([]bool, error) {
+	rows := make([]bool, 4)
+	for row := range rows {
+		rows[row] = m.pressed[[2]int{row, m.column}]
+	}
+
+	return rows, nil
+}
+
+func (m *fakeMatrix) close() {
+	m.closed = true
+}
+
+type keypadTestSuite struct {
+	suite.Suite
+	matrix *fakeMatrix
+	keypad *MatrixKeypad
+}
+
+func (s *keypadTestSuite) SetupTest() {
+	var err error
+
+	s.matrix = &fakeMatrix{pressed: map[[2]int]bool{}}
+	s.keypad, err = newMatrixKeypad(s.matrix, 4, 4, "")
+	s.Require().NoError(err)
+}
+
+func (s *keypadTestSuite) TestScan() {
+	// Key 5
+	s.matrix.pressed[[2]int{1, 1}] = true
+	s.keypad.scan()
+	s.Assert().EqualValues('5', <-s.keypad.GetKeyChannel())
+
+	// A held key is sent only once
+	s.keypad.scan()
+	s.Assert().Empty(s.keypad.GetKeyChannel())
+
+	// Release and press the key #
+	s.matrix.pressed = map[[2]int]bool{{3, 2}: true}
+	s.keypad.scan()
+	s.Assert().EqualValues('#', <-s.keypad.GetKeyChannel())
+
+	s.matrix.pressed = map[[2]int]bool{}
+	s.keypad.scan()
+	s.matrix.pressed[[2]int{1, 1}] = true
+	s.keypad.scan()
+	s.Assert().EqualValues('5', <-s.keypad.GetKeyChannel())
+
+	s.keypad.Cleanup()
+	s.Assert().True(s.matrix.closed)
+}
+
+func (s *keypadTestSuite) TestLayout() {
+	_, err := newMatrixKeypad(s.matrix, 4, 3, "")
+	s.Assert().ErrorIs(err, ErrInvalidLayout)
+
+	keypad, err := newMatrixKeypad(s.matrix, 4, 3, "123456789*0#")
+	s.Require().NoError(err)
+
+	s.matrix.pressed[[2]int{3, 0}] = true
+	keypad.scan()
+	s.Assert().EqualValues('*', <-keypad.GetKeyChannel())
+}
+
+func (s *keypadTestSuite) TestNewKeypad() {
+	_, err := NewKeypad(settings.Keypad{IsEnabled: false})
+	s.Assert().ErrorIs(err, ErrKeypadDisabled)
+
+	_, err = NewKeypad(settings.Keypad{IsEnabled: true, Driver: "unknown"})
+	s.Assert().ErrorIs(err, ErrKeypadUnsupported)
+}
+
+func TestKeypad(t *testing.T) {
+	suite.Run(t, new(keypadTestSuite))
+}
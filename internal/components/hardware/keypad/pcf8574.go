@@ -0,0 +1,53 @@
+package keypad
+
+import (
+	"github.com/d2r2/go-i2c"
+	"strconv"
+)
+
+const defaultPcf8574Address = 0x20
+
+// pcf8574Matrix is a 4x4 keypad connected to a PCF8574 I2C expander, with the rows on P0-P3 and the columns on P4-P7.
+// The selected column is driven low and the pressed rows read low.
+type pcf8574Matrix struct {
+	i2c *i2c.I2C
+}
+
+func newPcf8574Matrix(address string, bus int) (*pcf8574Matrix, error) {
+	i2cAddress, err := strconv.ParseUint(address, 0, 8)
+	if address == "" || err != nil {
+		i2cAddress = defaultPcf8574Address
+	}
+
+	i2cDev, err := i2c.NewI2C(uint8(i2cAddress), bus)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pcf8574Matrix{i2c: i2cDev}, nil
+}
+
+func (m *pcf8574Matrix) selectColumn(column int) error {
+	// The rows are kept high, so they can be read
+	_, err := m.i2c.WriteBytes([]byte{0xFF &^ (1 << (4 + column))})
+	return err
+}
+
+func (m *pcf8574Matrix) readRows() ([]bool, error) {
+	buffer := make([]byte, 1)
+	_, err := m.i2c.ReadBytes(buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	pressed := make([]bool, 4)
+	for row := range pressed {
+		pressed[row] = buffer[0]&(1<<row) == 0
+	}
+
+	return pressed, nil
+}
+
+func (m *pcf8574Matrix) close() {
+	_ = m.i2c.Close()
+}
@@ -0,0 +1,162 @@
+package policy
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Sample is a meter reading of the connector.
+	Sample struct {
+		Time time.Time
+		// Energy is the register of the meter in Wh
+		Energy float64
+		// Power in W
+		Power float64
+	}
+
+	// Evaluator checks the session against its policy. The energy is calculated from the energy register of the meter.
+	Evaluator struct {
+		mu      sync.Mutex
+		policy  Policy
+		started time.Time
+		// energy in Wh consumed since the session started
+		energy     float64
+		lastSample Sample
+		hasCharged bool
+		// belowMinPowerSince is set when the power drops below the minimum power after the EV has charged
+		belowMinPowerSince *time.Time
+	}
+)
+
+// NewEvaluator creates an evaluator for the session, which started with the first meter reading.
+func NewEvaluator(policy Policy, first Sample) *Evaluator {
+	return &Evaluator{
+		policy:     policy,
+		started:    first.Time,
+		lastSample: first,
+	}
+}
+
+// SetEnergy sets the energy in Wh the session consumed before the first meter reading, e.g. before a restart.
+func (e *Evaluator) SetEnergy(energy float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.energy = energy
+}
+
+// GetPolicy returns the policy of the session.
+func (e *Evaluator) GetPolicy() Policy {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.policy
+}
+
+// GetEnergy returns the energy in Wh consumed since the session started.
+func (e *Evaluator) GetEnergy() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.energy
+}
+
+// GetMeterReading returns the energy register in Wh of the last meter reading.
+func (e *Evaluator) GetMeterReading() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lastSample.Energy
+}
+
+// LimitEnergyOnInvalidId limits the energy of the session after the id tag was invalidated.
+func (e *Evaluator) LimitEnergyOnInvalidId(maxEnergy float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.policy.MaxEnergyOnInvalidId = maxEnergy
+}
+
+// CheckDuration returns the violated rule if the session exceeded the maximum duration.
+func (e *Evaluator) CheckDuration(now time.Time) *Rule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.checkDuration(now)
+}
+
+// Evaluate adds the sample to the session and returns the violated rule or nil if the session can continue.
+func (e *Evaluator) Evaluate(sample Sample) *Rule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if sample.Time.After(e.lastSample.Time) {
+		energy := sample.Energy - e.lastSample.Energy
+
+		// The meter was reset and started counting from zero
+		if energy < 0 {
+			energy = sample.Energy
+		}
+
+		e.energy += energy
+		e.lastSample = sample
+	}
+
+	if rule := e.checkDuration(sample.Time); rule != nil {
+		return rule
+	}
+
+	if e.policy.MaxEnergyOnInvalidId > 0 && e.energy >= e.policy.MaxEnergyOnInvalidId {
+		return newRule(RuleMaxEnergyOnInvalidId)
+	}
+
+	if e.policy.MaxEnergy > 0 && e.energy >= e.policy.MaxEnergy {
+		return newRule(RuleMaxEnergy)
+	}
+
+	return e.checkFull(sample)
+}
+
+func (e *Evaluator) checkDuration(now time.Time) *Rule {
+	if e.policy.MaxDuration > 0 && now.Sub(e.started) >= e.policy.MaxDuration {
+		return newRule(RuleMaxDuration)
+	}
+
+	return nil
+}
+
+// checkFull detects if the EV is full. The EV is full when the power stays below the minimum power for the
+// StopAfterFull duration after the EV has charged. After that, the EV can stay connected for the idle grace period.
+func (e *Evaluator) checkFull(sample Sample) *Rule {
+	if e.policy.StopAfterFull <= 0 && e.policy.IdleGracePeriod <= 0 {
+		return nil
+	}
+
+	if sample.Power >= e.policy.MinPower {
+		e.hasCharged = true
+		e.belowMinPowerSince = nil
+		return nil
+	}
+
+	if !e.hasCharged {
+		return nil
+	}
+
+	if e.belowMinPowerSince == nil {
+		e.belowMinPowerSince = &sample.Time
+	}
+
+	belowMinPower := sample.Time.Sub(*e.belowMinPowerSince)
+	if belowMinPower < e.policy.StopAfterFull {
+		return nil
+	}
+
+	if e.policy.IdleGracePeriod <= 0 {
+		return newRule(RuleStopAfterFull)
+	}
+
+	if belowMinPower >= e.policy.StopAfterFull+e.policy.IdleGracePeriod {
+		return newRule(RuleIdleGracePeriod)
+	}
+
+	return nil
+}
+
+func newRule(rule Rule) *Rule {
+	return &rule
+}
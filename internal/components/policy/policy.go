@@ -0,0 +1,128 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"time"
+)
+
+// Rules which can stop a charging session
+const (
+	RuleMaxEnergy            = Rule("MaxEnergy")
+	RuleMaxDuration          = Rule("MaxDuration")
+	RuleStopAfterFull        = Rule("StopAfterFull")
+	RuleIdleGracePeriod      = Rule("IdleGracePeriod")
+	RuleMaxEnergyOnInvalidId = Rule("MaxEnergyOnInvalidId")
+)
+
+var ErrMaxDurationExceeded = errors.New("max duration exceeds the max charging time")
+
+type (
+	Rule string
+
+	// Policy contains the limits of a single charging session. Zero values disable the limit.
+	Policy struct {
+		// MaxEnergy in Wh
+		MaxEnergy   float64
+		MaxDuration time.Duration
+		// StopAfterFull is the duration the power must be below MinPower to consider the EV full
+		StopAfterFull time.Duration
+		// IdleGracePeriod is the duration the EV can stay connected after it is full
+		IdleGracePeriod time.Duration
+		// MaxEnergyOnInvalidId in Wh limits the session of an id tag, which was invalidated by the central system
+		MaxEnergyOnInvalidId float64
+		// MinPower in W
+		MinPower float64
+	}
+)
+
+// Reason returns the reason for the StopTransaction request when the rule is violated.
+func (r Rule) Reason() core.Reason {
+	switch r {
+	case RuleMaxEnergyOnInvalidId:
+		return core.ReasonDeAuthorized
+	case RuleStopAfterFull, RuleIdleGracePeriod:
+		// The EV finished charging, which is a regular termination of the transaction
+		return core.ReasonLocal
+	default:
+		return core.ReasonOther
+	}
+}
+
+// NewPolicy creates a policy for the session. The connector policy overrides the default policy and the policy of the
+// tag group overrides both. The maximum duration defaults to the max charging time of the connector and cannot exceed it.
+func NewPolicy(policies settings.SessionPolicies, connectorPolicy settings.SessionPolicy, parentIdTag string,
+	maxChargingTime int, minPower float64) (Policy, error) {
+	policy := merge(policies.Default, connectorPolicy)
+
+	if groupPolicy, isFound := policies.Groups[parentIdTag]; parentIdTag != "" && isFound {
+		policy = merge(policy, groupPolicy)
+	}
+
+	err := validate(policy, maxChargingTime)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	if policy.MaxDuration <= 0 {
+		policy.MaxDuration = maxChargingTime
+	}
+
+	return Policy{
+		MaxEnergy:       policy.MaxEnergy * 1000,
+		MaxDuration:     time.Duration(policy.MaxDuration) * time.Minute,
+		StopAfterFull:   time.Duration(policy.StopAfterFull) * time.Minute,
+		IdleGracePeriod: time.Duration(policy.IdleGracePeriod) * time.Minute,
+		MinPower:        minPower,
+	}, nil
+}
+
+// ValidatePolicies checks the default policy and the policies of the tag groups against the max charging time, so an
+// invalid policy is rejected when the settings are loaded instead of when a session starts.
+func ValidatePolicies(policies settings.SessionPolicies, maxChargingTime int) error {
+	err := validate(policies.Default, maxChargingTime)
+	if err != nil {
+		return err
+	}
+
+	for parentIdTag, groupPolicy := range policies.Groups {
+		err = validate(groupPolicy, maxChargingTime)
+		if err != nil {
+			return fmt.Errorf("group %s: %w", parentIdTag, err)
+		}
+	}
+
+	return nil
+}
+
+// validate checks that the max duration of the policy does not exceed the max charging time.
+func validate(policy settings.SessionPolicy, maxChargingTime int) error {
+	if maxChargingTime > 0 && policy.MaxDuration > maxChargingTime {
+		return fmt.Errorf("%w: %d > %d minutes", ErrMaxDurationExceeded, policy.MaxDuration, maxChargingTime)
+	}
+
+	return nil
+}
+
+// merge overrides the limits of the base policy with the non-zero limits of the override.
+func merge(base, override settings.SessionPolicy) settings.SessionPolicy {
+	if override.MaxEnergy > 0 {
+		base.MaxEnergy = override.MaxEnergy
+	}
+
+	if override.MaxDuration > 0 {
+		base.MaxDuration = override.MaxDuration
+	}
+
+	if override.StopAfterFull > 0 {
+		base.StopAfterFull = override.StopAfterFull
+	}
+
+	if override.IdleGracePeriod > 0 {
+		base.IdleGracePeriod = override.IdleGracePeriod
+	}
+
+	return base
+}
@@ -0,0 +1,158 @@
+package policy
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"testing"
+	"time"
+)
+
+type PolicyTestSuite struct {
+	suite.Suite
+	started time.Time
+}
+
+func (s *PolicyTestSuite) SetupTest() {
+	s.started = time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+}
+
+func (s *PolicyTestSuite) TestNewPolicy() {
+	policies := settings.SessionPolicies{
+		Default: settings.SessionPolicy{MaxEnergy: 10, StopAfterFull: 5},
+		Groups: map[string]settings.SessionPolicy{
+			"fleet": {MaxEnergy: 50, IdleGracePeriod: 15},
+		},
+	}
+
+	// The connector overrides the default policy
+	policy, err := NewPolicy(policies, settings.SessionPolicy{MaxDuration: 60}, "", 180, 20)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(Policy{
+		MaxEnergy:     10000,
+		MaxDuration:   time.Hour,
+		StopAfterFull: 5 * time.Minute,
+		MinPower:      20,
+	}, policy)
+
+	// The tag group overrides the connector policy
+	policy, err = NewPolicy(policies, settings.SessionPolicy{MaxEnergy: 20}, "fleet", 180, 20)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(50000, policy.MaxEnergy)
+	s.Assert().EqualValues(15*time.Minute, policy.IdleGracePeriod)
+	s.Assert().EqualValues(180*time.Minute, policy.MaxDuration)
+
+	// Unknown groups are ignored
+	policy, err = NewPolicy(policies, settings.SessionPolicy{}, "unknown", 180, 20)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(10000, policy.MaxEnergy)
+
+	// The duration cannot exceed the max charging time
+	_, err = NewPolicy(policies, settings.SessionPolicy{MaxDuration: 240}, "", 180, 20)
+	s.Assert().ErrorIs(err, ErrMaxDurationExceeded)
+}
+
+func (s *PolicyTestSuite) TestValidatePolicies() {
+	policies := settings.SessionPolicies{
+		Default: settings.SessionPolicy{MaxDuration: 120},
+		Groups: map[string]settings.SessionPolicy{
+			"fleet": {MaxDuration: 180},
+		},
+	}
+	s.Assert().NoError(ValidatePolicies(policies, 180))
+
+	// The policy of a group exceeds the max charging time
+	s.Assert().ErrorIs(ValidatePolicies(policies, 150), ErrMaxDurationExceeded)
+
+	// The default policy exceeds the max charging time
+	s.Assert().ErrorIs(ValidatePolicies(policies, 60), ErrMaxDurationExceeded)
+}
+
+func (s *PolicyTestSuite) TestMaxDuration() {
+	evaluator := NewEvaluator(Policy{MaxDuration: time.Hour}, Sample{Time: s.started})
+
+	s.Assert().Nil(evaluator.CheckDuration(s.started.Add(59 * time.Minute)))
+
+	rule := evaluator.CheckDuration(s.started.Add(time.Hour))
+	s.Require().NotNil(rule)
+	s.Assert().EqualValues(RuleMaxDuration, *rule)
+	s.Assert().EqualValues(core.ReasonOther, rule.Reason())
+}
+
+func (s *PolicyTestSuite) TestMaxEnergy() {
+	evaluator := NewEvaluator(Policy{MaxEnergy: 1000}, Sample{Time: s.started, Energy: 10000})
+
+	s.Assert().Nil(evaluator.Evaluate(Sample{Time: s.started.Add(15 * time.Minute), Energy: 10500, Power: 2000}))
+	s.Assert().InDelta(500, evaluator.GetEnergy(), 0.001)
+
+	rule := evaluator.Evaluate(Sample{Time: s.started.Add(30 * time.Minute), Energy: 11000, Power: 2000})
+	s.Require().NotNil(rule)
+	s.Assert().EqualValues(RuleMaxEnergy, *rule)
+	s.Assert().EqualValues(core.ReasonOther, rule.Reason())
+}
+
+func (s *PolicyTestSuite) TestMaxEnergyOnInvalidId() {
+	evaluator := NewEvaluator(Policy{MaxEnergy: 10000}, Sample{Time: s.started})
+	evaluator.LimitEnergyOnInvalidId(500)
+
+	s.Assert().Nil(evaluator.Evaluate(Sample{Time: s.started.Add(5 * time.Minute), Energy: 200, Power: 2000}))
+
+	rule := evaluator.Evaluate(Sample{Time: s.started.Add(15 * time.Minute), Energy: 500, Power: 2000})
+	s.Require().NotNil(rule)
+	s.Assert().EqualValues(RuleMaxEnergyOnInvalidId, *rule)
+	s.Assert().EqualValues(core.ReasonDeAuthorized, rule.Reason())
+}
+
+func (s *PolicyTestSuite) TestStopAfterFull() {
+	evaluator := NewEvaluator(Policy{StopAfterFull: 5 * time.Minute, MinPower: 20}, Sample{Time: s.started})
+
+	// The EV did not start charging yet
+	s.Assert().Nil(evaluator.Evaluate(Sample{Time: s.started, Power: 0}))
+	s.Assert().Nil(evaluator.Evaluate(Sample{Time: s.started.Add(10 * time.Minute), Power: 0}))
+
+	s.Assert().Nil(evaluator.Evaluate(Sample{Time: s.started.Add(11 * time.Minute), Power: 3000}))
+	s.Assert().Nil(evaluator.Evaluate(Sample{Time: s.started.Add(20 * time.Minute), Power: 10}))
+	s.Assert().Nil(evaluator.Evaluate(Sample{Time: s.started.Add(24 * time.Minute), Power: 10}))
+
+	rule := evaluator.Evaluate(Sample{Time: s.started.Add(25 * time.Minute), Power: 10})
+	s.Require().NotNil(rule)
+	s.Assert().EqualValues(RuleStopAfterFull, *rule)
+	s.Assert().EqualValues(core.ReasonLocal, rule.Reason())
+}
+
+func (s *PolicyTestSuite) TestIdleGracePeriod() {
+	evaluator := NewEvaluator(Policy{StopAfterFull: 5 * time.Minute, IdleGracePeriod: 10 * time.Minute, MinPower: 20}, Sample{Time: s.started})
+
+	s.Assert().Nil(evaluator.Evaluate(Sample{Time: s.started, Power: 3000}))
+	s.Assert().Nil(evaluator.Evaluate(Sample{Time: s.started.Add(10 * time.Minute), Power: 0}))
+	// The EV is full, but the grace period has not passed yet
+	s.Assert().Nil(evaluator.Evaluate(Sample{Time: s.started.Add(20 * time.Minute), Power: 0}))
+
+	// The EV resumed charging during the grace period
+	s.Assert().Nil(evaluator.Evaluate(Sample{Time: s.started.Add(21 * time.Minute), Power: 1000}))
+	s.Assert().Nil(evaluator.Evaluate(Sample{Time: s.started.Add(22 * time.Minute), Power: 0}))
+	s.Assert().Nil(evaluator.Evaluate(Sample{Time: s.started.Add(36 * time.Minute), Power: 0}))
+
+	rule := evaluator.Evaluate(Sample{Time: s.started.Add(37 * time.Minute), Power: 0})
+	s.Require().NotNil(rule)
+	s.Assert().EqualValues(RuleIdleGracePeriod, *rule)
+	s.Assert().EqualValues(core.ReasonLocal, rule.Reason())
+}
+
+func (s *PolicyTestSuite) TestRestoredEnergy() {
+	// The session consumed 800 Wh before the restart and the meter was reset
+	evaluator := NewEvaluator(Policy{MaxEnergy: 1000}, Sample{Time: s.started, Energy: 5000})
+	evaluator.SetEnergy(800)
+
+	s.Assert().Nil(evaluator.Evaluate(Sample{Time: s.started.Add(5 * time.Minute), Energy: 100, Power: 2000}))
+	s.Assert().InDelta(900, evaluator.GetEnergy(), 0.001)
+	s.Assert().EqualValues(100, evaluator.GetMeterReading())
+
+	rule := evaluator.Evaluate(Sample{Time: s.started.Add(10 * time.Minute), Energy: 200, Power: 2000})
+	s.Require().NotNil(rule)
+	s.Assert().EqualValues(RuleMaxEnergy, *rule)
+}
+
+func TestPolicy(t *testing.T) {
+	suite.Run(t, new(PolicyTestSuite))
+}
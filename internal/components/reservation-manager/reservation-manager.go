@@ -0,0 +1,230 @@
+package reservationManager
+
+import (
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrReservationExpired  = errors.New("reservation expired")
+	ErrInvalidReservation  = errors.New("invalid reservation")
+)
+
+type (
+	// ExpiryHandler is called when the reservation expires.
+	ExpiryHandler func(reservation store.Reservation)
+
+	// Manager keeps the reservations of the connectors, removes them when they expire and persists them in the
+	// repository, so they are restored after a restart.
+	Manager struct {
+		mu           sync.Mutex
+		reservations map[int]*reservationEntry
+		repository   store.ReservationRepository
+		onExpiry     ExpiryHandler
+	}
+
+	reservationEntry struct {
+		reservation store.Reservation
+		timer       *time.Timer
+	}
+)
+
+// NewManager creates a reservation manager. If the repository is nil, the reservations are only kept in memory.
+func NewManager(repository store.ReservationRepository, onExpiry ExpiryHandler) *Manager {
+	return &Manager{
+		reservations: map[int]*reservationEntry{},
+		repository:   repository,
+		onExpiry:     onExpiry,
+	}
+}
+
+// IsReservedFor checks if the tag can use the reservation. The tag can use the reservation if it is the reserving tag
+// or if both tags belong to the same parent tag.
+func IsReservedFor(reservation store.Reservation, idTag, parentIdTag string) bool {
+	if reservation.IdTag == idTag {
+		return true
+	}
+
+	return reservation.ParentIdTag != "" && reservation.ParentIdTag == parentIdTag
+}
+
+// AddReservation adds the reservation or replaces the reservation with the same id and schedules its expiry.
+func (m *Manager) AddReservation(reservation store.Reservation) error {
+	if reservation.ReservationId <= 0 || reservation.IdTag == "" {
+		return ErrInvalidReservation
+	}
+
+	if !reservation.ExpiryDate.After(time.Now()) {
+		return ErrReservationExpired
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, isFound := m.reservations[reservation.ReservationId]; isFound {
+		entry.timer.Stop()
+	}
+
+	m.schedule(reservation)
+	m.persist(reservation)
+	return nil
+}
+
+// RemoveReservation removes the reservation and returns it.
+func (m *Manager) RemoveReservation(reservationId int) (*store.Reservation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, isFound := m.reservations[reservationId]
+	if !isFound {
+		return nil, ErrReservationNotFound
+	}
+
+	entry.timer.Stop()
+	m.remove(reservationId)
+	return &entry.reservation, nil
+}
+
+// GetReservation returns the reservation with the id.
+func (m *Manager) GetReservation(reservationId int) (*store.Reservation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, isFound := m.reservations[reservationId]
+	if !isFound {
+		return nil, ErrReservationNotFound
+	}
+
+	reservation := entry.reservation
+	return &reservation, nil
+}
+
+// GetConnectorReservation returns the reservation of the connector.
+func (m *Manager) GetConnectorReservation(evseId, connectorId int) (*store.Reservation, error) {
+	for _, reservation := range m.GetReservations() {
+		if reservation.EvseId == evseId && reservation.ConnectorId == connectorId {
+			return &reservation, nil
+		}
+	}
+
+	return nil, ErrReservationNotFound
+}
+
+// GetReservations returns the reservations ordered by the reservation id.
+func (m *Manager) GetReservations() []store.Reservation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reservations := []store.Reservation{}
+	for _, entry := range m.reservations {
+		reservations = append(reservations, entry.reservation)
+	}
+
+	sort.Slice(reservations, func(i, j int) bool {
+		return reservations[i].ReservationId < reservations[j].ReservationId
+	})
+
+	return reservations
+}
+
+// Restore loads the reservations from the repository and schedules their expiry. The reservations that expired while
+// the charge point was off are removed. Returns the restored reservations.
+func (m *Manager) Restore() []store.Reservation {
+	if util.IsNilInterfaceOrPointer(m.repository) {
+		return m.GetReservations()
+	}
+
+	reservations, err := m.repository.GetReservations()
+	if err != nil {
+		log.WithError(err).Error("Unable to restore the reservations")
+		return m.GetReservations()
+	}
+
+	m.mu.Lock()
+	for _, reservation := range reservations {
+		if entry, isFound := m.reservations[reservation.ReservationId]; isFound {
+			entry.timer.Stop()
+		}
+
+		if !reservation.ExpiryDate.After(time.Now()) {
+			log.WithField("reservationId", reservation.ReservationId).Info("Reservation expired while offline")
+			m.remove(reservation.ReservationId)
+			continue
+		}
+
+		m.schedule(reservation)
+	}
+	m.mu.Unlock()
+
+	return m.GetReservations()
+}
+
+// Stop stops the expiry timers without removing the reservations, so they can be restored.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, entry := range m.reservations {
+		entry.timer.Stop()
+	}
+}
+
+// schedule adds the reservation and removes it at the expiry date.
+func (m *Manager) schedule(reservation store.Reservation) {
+	reservationId := reservation.ReservationId
+
+	entry := &reservationEntry{reservation: reservation}
+	entry.timer = time.AfterFunc(time.Until(reservation.ExpiryDate), func() {
+		m.expire(reservationId, entry)
+	})
+
+	m.reservations[reservationId] = entry
+}
+
+// expire removes the expired reservation, unless it was replaced, and notifies the expiry handler.
+func (m *Manager) expire(reservationId int, expiredEntry *reservationEntry) {
+	m.mu.Lock()
+	entry, isFound := m.reservations[reservationId]
+	if !isFound || entry != expiredEntry {
+		m.mu.Unlock()
+		return
+	}
+
+	log.WithField("reservationId", reservationId).Info("Reservation expired")
+	m.remove(reservationId)
+	m.mu.Unlock()
+
+	if m.onExpiry != nil {
+		m.onExpiry(entry.reservation)
+	}
+}
+
+func (m *Manager) remove(reservationId int) {
+	delete(m.reservations, reservationId)
+
+	if util.IsNilInterfaceOrPointer(m.repository) {
+		return
+	}
+
+	err := m.repository.DeleteReservation(reservationId)
+	if err != nil {
+		log.WithError(err).Errorf("Unable to delete the reservation %d", reservationId)
+	}
+}
+
+func (m *Manager) persist(reservation store.Reservation) {
+	if util.IsNilInterfaceOrPointer(m.repository) {
+		return
+	}
+
+	err := m.repository.SaveReservation(reservation)
+	if err != nil {
+		log.WithError(err).Errorf("Unable to persist the reservation %d", reservation.ReservationId)
+	}
+}
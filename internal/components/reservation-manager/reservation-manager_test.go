@@ -0,0 +1,182 @@
+package reservationManager
+
+import (
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type ReservationManagerTestSuite struct {
+	suite.Suite
+	store   *store.Store
+	tempDir string
+	expired chan store.Reservation
+	manager *Manager
+}
+
+func newReservation(reservationId int, expiresIn time.Duration) store.Reservation {
+	return store.Reservation{
+		ReservationId: reservationId,
+		EvseId:        1,
+		ConnectorId:   reservationId,
+		IdTag:         "exampleTag",
+		ParentIdTag:   "exampleParentTag",
+		ExpiryDate:    time.Now().Add(expiresIn),
+	}
+}
+
+func (s *ReservationManagerTestSuite) SetupTest() {
+	tempDir, err := ioutil.TempDir("", "chargepi")
+	s.Require().NoError(err)
+	s.tempDir = tempDir
+
+	s.store, err = store.Open(filepath.Join(tempDir, "chargepi.db"))
+	s.Require().NoError(err)
+
+	s.expired = make(chan store.Reservation, 5)
+	s.manager = NewManager(s.store, func(reservation store.Reservation) {
+		s.expired <- reservation
+	})
+}
+
+func (s *ReservationManagerTestSuite) TearDownTest() {
+	s.manager.Stop()
+	_ = s.store.Close()
+	_ = os.RemoveAll(s.tempDir)
+}
+
+func (s *ReservationManagerTestSuite) TestAddReservation() {
+	reservation := newReservation(1, time.Minute)
+
+	err := s.manager.AddReservation(reservation)
+	s.Require().NoError(err)
+
+	addedReservation, err := s.manager.GetReservation(1)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(reservation, *addedReservation)
+
+	connectorReservation, err := s.manager.GetConnectorReservation(1, 1)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(reservation, *connectorReservation)
+
+	// The reservation is persisted
+	reservations, err := s.store.GetReservations()
+	s.Require().NoError(err)
+	s.Assert().Len(reservations, 1)
+
+	// Invalid reservations
+	err = s.manager.AddReservation(newReservation(0, time.Minute))
+	s.Assert().ErrorIs(err, ErrInvalidReservation)
+
+	err = s.manager.AddReservation(newReservation(2, -time.Minute))
+	s.Assert().ErrorIs(err, ErrReservationExpired)
+
+	_, err = s.manager.GetConnectorReservation(1, 2)
+	s.Assert().ErrorIs(err, ErrReservationNotFound)
+}
+
+func (s *ReservationManagerTestSuite) TestReplaceReservation() {
+	err := s.manager.AddReservation(newReservation(1, 100*time.Millisecond))
+	s.Require().NoError(err)
+
+	// The reservation with the same id is replaced and its expiry rescheduled
+	replacement := newReservation(1, time.Minute)
+	replacement.ConnectorId = 2
+	err = s.manager.AddReservation(replacement)
+	s.Require().NoError(err)
+
+	s.Assert().Len(s.manager.GetReservations(), 1)
+
+	connectorReservation, err := s.manager.GetConnectorReservation(1, 2)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(replacement, *connectorReservation)
+
+	select {
+	case <-s.expired:
+		s.Fail("the replaced reservation expired")
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func (s *ReservationManagerTestSuite) TestRemoveReservation() {
+	reservation := newReservation(1, time.Minute)
+	err := s.manager.AddReservation(reservation)
+	s.Require().NoError(err)
+
+	removedReservation, err := s.manager.RemoveReservation(1)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(reservation, *removedReservation)
+
+	_, err = s.manager.RemoveReservation(1)
+	s.Assert().ErrorIs(err, ErrReservationNotFound)
+
+	reservations, err := s.store.GetReservations()
+	s.Require().NoError(err)
+	s.Assert().Empty(reservations)
+}
+
+func (s *ReservationManagerTestSuite) TestExpiry() {
+	reservation := newReservation(1, 100*time.Millisecond)
+	err := s.manager.AddReservation(reservation)
+	s.Require().NoError(err)
+
+	select {
+	case expiredReservation := <-s.expired:
+		s.Assert().EqualValues(reservation, expiredReservation)
+		s.Assert().False(time.Now().Before(reservation.ExpiryDate))
+	case <-time.After(time.Second):
+		s.FailNow("the reservation did not expire")
+	}
+
+	_, err = s.manager.GetReservation(1)
+	s.Assert().ErrorIs(err, ErrReservationNotFound)
+
+	reservations, err := s.store.GetReservations()
+	s.Require().NoError(err)
+	s.Assert().Empty(reservations)
+}
+
+func (s *ReservationManagerTestSuite) TestRestore() {
+	s.Require().NoError(s.store.SaveReservation(newReservation(1, time.Minute)))
+	s.Require().NoError(s.store.SaveReservation(newReservation(2, -time.Minute)))
+	s.Require().NoError(s.store.SaveReservation(newReservation(3, 100*time.Millisecond)))
+
+	// The reservation that expired while offline is removed
+	reservations := s.manager.Restore()
+	s.Require().Len(reservations, 2)
+	s.Assert().EqualValues(1, reservations[0].ReservationId)
+	s.Assert().EqualValues(3, reservations[1].ReservationId)
+
+	// The restored reservations expire
+	select {
+	case expiredReservation := <-s.expired:
+		s.Assert().EqualValues(3, expiredReservation.ReservationId)
+	case <-time.After(time.Second):
+		s.FailNow("the restored reservation did not expire")
+	}
+
+	storedReservations, err := s.store.GetReservations()
+	s.Require().NoError(err)
+	s.Require().Len(storedReservations, 1)
+	s.Assert().EqualValues(1, storedReservations[0].ReservationId)
+}
+
+func (s *ReservationManagerTestSuite) TestIsReservedFor() {
+	reservation := newReservation(1, time.Minute)
+
+	s.Assert().True(IsReservedFor(reservation, "exampleTag", ""))
+	s.Assert().True(IsReservedFor(reservation, "otherTag", "exampleParentTag"))
+	s.Assert().False(IsReservedFor(reservation, "otherTag", "otherParentTag"))
+	s.Assert().False(IsReservedFor(reservation, "otherTag", ""))
+
+	reservation.ParentIdTag = ""
+	s.Assert().False(IsReservedFor(reservation, "otherTag", ""))
+}
+
+func TestReservationManager(t *testing.T) {
+	suite.Run(t, new(ReservationManagerTestSuite))
+}
@@ -0,0 +1,130 @@
+package settings
+
+import (
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const maskedValue = "********"
+
+// NewDefaultSettings creates starter settings for a charge point with the hardware disabled.
+func NewDefaultSettings(chargePointId, serverUri, protocolVersion string) *settings.Settings {
+	return &settings.Settings{
+		ChargePoint: settings.ChargePoint{
+			Info: settings.Info{
+				Id:              chargePointId,
+				ProtocolVersion: protocolVersion,
+				ServerUri:       serverUri,
+				MaxChargingTime: 180,
+				OCPPInfo: settings.OCPPInfo{
+					Vendor: "xBlaz3kx",
+					Model:  "ChargePi",
+				},
+			},
+			Logging: settings.Logging{
+				Type:   []string{"console"},
+				Format: "syslog",
+			},
+			Hardware: settings.Hardware{
+				Lcd: settings.Lcd{
+					Language: "en",
+				},
+			},
+		},
+		Mqtt: settings.Mqtt{
+			TopicPrefix: "chargepi",
+			HomeAssistant: settings.HomeAssistant{
+				DiscoveryPrefix: "homeassistant",
+			},
+		},
+	}
+}
+
+// NewDefaultConnector creates a starter Schuko connector with the power meter disabled.
+func NewDefaultConnector(evseId, connectorId, relayPin int) *settings.Connector {
+	return &settings.Connector{
+		EvseId:      evseId,
+		ConnectorId: connectorId,
+		Type:        "Schuko",
+		Status:      string(core.ChargePointStatusAvailable),
+		Relay: settings.Relay{
+			RelayPin: relayPin,
+		},
+		PowerMeter: settings.PowerMeter{
+			Type:                 "CS5460A",
+			ShuntOffset:          0.01,
+			VoltageDividerOffset: 1333,
+		},
+	}
+}
+
+// GenerateFiles validates the settings and connectors and writes them to the output folder. The settings are
+// written in the specified format, the connectors are written to the connectors subfolder as JSON files.
+func GenerateFiles(outputFolder, format string, conf *settings.Settings, connectors []*settings.Connector, overwrite bool) ([]string, error) {
+	err := ValidateSettings(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ValidateConnectors(connectors)
+	if err != nil {
+		return nil, err
+	}
+
+	connectorsFolder := filepath.Join(outputFolder, "connectors")
+	err = os.MkdirAll(connectorsFolder, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]interface{}{
+		filepath.Join(outputFolder, fmt.Sprintf("settings.%s", format)): conf,
+	}
+
+	for _, connector := range connectors {
+		fileName := fmt.Sprintf("connector-%d.json", connector.ConnectorId)
+		if connector.EvseId != 1 {
+			fileName = fmt.Sprintf("evse-%d-connector-%d.json", connector.EvseId, connector.ConnectorId)
+		}
+
+		files[filepath.Join(connectorsFolder, fileName)] = connector
+	}
+
+	var writtenFiles []string
+	for filePath := range files {
+		if _, err = os.Stat(filePath); err == nil && !overwrite {
+			return nil, fmt.Errorf("%w: %s", os.ErrExist, filePath)
+		}
+	}
+
+	for filePath, structure := range files {
+		err = WriteToFile(filePath, structure)
+		if err != nil {
+			return writtenFiles, err
+		}
+
+		writtenFiles = append(writtenFiles, filePath)
+	}
+
+	sort.Strings(writtenFiles)
+	return writtenFiles, nil
+}
+
+// MaskSecrets returns a copy of the settings with the passwords replaced.
+func MaskSecrets(conf *settings.Settings) *settings.Settings {
+	masked := *conf
+
+	if masked.ChargePoint.Info.BasicAuthPassword != "" {
+		masked.ChargePoint.Info.BasicAuthPassword = maskedValue
+	}
+
+	if masked.Mqtt.Password != "" {
+		masked.Mqtt.Password = maskedValue
+	}
+
+	return &masked
+}
@@ -0,0 +1,145 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrRestartRequired      = errors.New("changes require a restart")
+	ErrDuplicateConnector   = errors.New("duplicate connector")
+	ErrConnectorNumbering   = errors.New("connector ids must start with 1 and increment by one")
+	ErrMissingMandatoryKeys = errors.New("missing mandatory keys")
+)
+
+// CheckRestartRequired compares the current and the new settings and returns ErrRestartRequired with the list of
+// changed settings, if any of the settings cannot be applied while the charge point is running.
+func CheckRestartRequired(current, new *settings.Settings) error {
+	var (
+		changed        []string
+		currentInfo    = current.ChargePoint.Info
+		newInfo        = new.ChargePoint.Info
+		restartOnlyFor = map[string][2]interface{}{
+			"chargePoint.info.id":              {currentInfo.Id, newInfo.Id},
+			"chargePoint.info.protocolVersion": {currentInfo.ProtocolVersion, newInfo.ProtocolVersion},
+			"chargePoint.info.serverUri":       {currentInfo.ServerUri, newInfo.ServerUri},
+			"chargePoint.info.basicAuthUser":   {currentInfo.BasicAuthUsername, newInfo.BasicAuthUsername},
+			"chargePoint.info.basicAuthPass":   {currentInfo.BasicAuthPassword, newInfo.BasicAuthPassword},
+			"chargePoint.info.ocpp":            {currentInfo.OCPPInfo, newInfo.OCPPInfo},
+			"chargePoint.tls":                  {current.ChargePoint.TLS, new.ChargePoint.TLS},
+			"api":                              {current.Api, new.Api},
+			"mqtt":                             {current.Mqtt, new.Mqtt},
+		}
+	)
+
+	for setting, values := range restartOnlyFor {
+		if !reflect.DeepEqual(values[0], values[1]) {
+			changed = append(changed, setting)
+		}
+	}
+
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		return fmt.Errorf("%w: %s", ErrRestartRequired, strings.Join(changed, ", "))
+	}
+
+	return nil
+}
+
+// ValidateConnectors validates each connector's settings and checks that the EVSE and connector ids are unique.
+// The connector ids are the OCPP 1.6 connector ids, so they must be unique across the EVSEs, start with 1 and
+// increment by one.
+func ValidateConnectors(connectors []*settings.Connector) error {
+	var (
+		validate     = validator.New()
+		ids          = map[string]bool{}
+		connectorIds = map[int]bool{}
+	)
+
+	for _, c := range connectors {
+		err := validate.Struct(c)
+		if err != nil {
+			return err
+		}
+
+		key := connectorKey(c)
+		if ids[key] || connectorIds[c.ConnectorId] {
+			return fmt.Errorf("%w: EVSE %d connector %d", ErrDuplicateConnector, c.EvseId, c.ConnectorId)
+		}
+
+		ids[key] = true
+		connectorIds[c.ConnectorId] = true
+	}
+
+	for i := 1; i <= len(connectorIds); i++ {
+		if !connectorIds[i] {
+			return fmt.Errorf("%w: connector %d is missing", ErrConnectorNumbering, i)
+		}
+	}
+
+	return nil
+}
+
+// DiffConnectors compares the current and the new connector settings. The status and session are ignored, since they
+// are updated by the connectors themselves.
+func DiffConnectors(current, new []*settings.Connector) (added, removed, changed []*settings.Connector) {
+	var (
+		currentConnectors = map[string]*settings.Connector{}
+		newConnectors     = map[string]*settings.Connector{}
+	)
+
+	for _, c := range current {
+		currentConnectors[connectorKey(c)] = c
+	}
+
+	for _, c := range new {
+		key := connectorKey(c)
+		newConnectors[key] = c
+
+		currentConnector, isFound := currentConnectors[key]
+		switch {
+		case !isFound:
+			added = append(added, c)
+		case !isSameConnector(currentConnector, c):
+			changed = append(changed, c)
+		}
+	}
+
+	for _, c := range current {
+		if _, isFound := newConnectors[connectorKey(c)]; !isFound {
+			removed = append(removed, c)
+		}
+	}
+
+	return added, removed, changed
+}
+
+// ReloadOcppConfiguration checks the OCPP configuration file and reloads it. The configuration is validated beforehand,
+// since the configuration manager exits if it cannot read the file.
+func ReloadOcppConfiguration(filePath string, version configuration.ProtocolVersion) error {
+	err := ValidateOcppConfiguration(filePath, version)
+	if err != nil {
+		return err
+	}
+
+	return ocppConfigManager.LoadConfiguration()
+}
+
+func connectorKey(c *settings.Connector) string {
+	return fmt.Sprintf("connectorEvse%dId%d", c.EvseId, c.ConnectorId)
+}
+
+func isSameConnector(current, new *settings.Connector) bool {
+	currentCopy, newCopy := *current, *new
+	currentCopy.Status, newCopy.Status = "", ""
+	currentCopy.Session, newCopy.Session = settings.Session{}, settings.Session{}
+
+	return reflect.DeepEqual(currentCopy, newCopy)
+}
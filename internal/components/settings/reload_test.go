@@ -0,0 +1,182 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type (
+	reloadHandlerMock struct {
+		mock.Mock
+	}
+
+	ReloadTestSuite struct {
+		suite.Suite
+		settings   *settingsData.Settings
+		connectors []*settingsData.Connector
+		tempDir    string
+	}
+)
+
+func (r *reloadHandlerMock) ApplySettings(ctx context.Context, settings *settingsData.Settings) error {
+	return r.Called(settings).Error(0)
+}
+
+func (r *reloadHandlerMock) ApplyConnectors(connectors []*settingsData.Connector) error {
+	return r.Called(connectors).Error(0)
+}
+
+func (s *ReloadTestSuite) SetupTest() {
+	s.settings = &settingsData.Settings{
+		ChargePoint: settingsData.ChargePoint{
+			Info: settingsData.Info{
+				Id:              "ChargePoint",
+				ProtocolVersion: "1.6",
+				ServerUri:       "example.com",
+			},
+		},
+	}
+
+	s.connectors = []*settingsData.Connector{
+		{EvseId: 1, ConnectorId: 1, Type: "Schuko", Status: "Available", Relay: settingsData.Relay{RelayPin: 10}},
+		{EvseId: 1, ConnectorId: 2, Type: "Schuko", Status: "Available", Relay: settingsData.Relay{RelayPin: 11}},
+	}
+
+	tempDir, err := ioutil.TempDir("", "chargepi")
+	s.Require().NoError(err)
+	s.tempDir = tempDir
+}
+
+func (s *ReloadTestSuite) TearDownTest() {
+	_ = os.RemoveAll(s.tempDir)
+}
+
+func (s *ReloadTestSuite) TestCheckRestartRequired() {
+	newSettings := *s.settings
+
+	// Only hardware and logging changed
+	newSettings.ChargePoint.Hardware.Lcd.Language = "sl"
+	newSettings.ChargePoint.Logging.Type = []string{"file"}
+	s.Assert().NoError(CheckRestartRequired(s.settings, &newSettings))
+
+	// Changing the connection settings requires a restart
+	newSettings.ChargePoint.Info.ServerUri = "example2.com"
+	newSettings.ChargePoint.TLS.IsEnabled = true
+	err := CheckRestartRequired(s.settings, &newSettings)
+	s.Assert().ErrorIs(err, ErrRestartRequired)
+	s.Assert().Contains(err.Error(), "chargePoint.info.serverUri, chargePoint.tls")
+}
+
+func (s *ReloadTestSuite) TestValidateConnectors() {
+	s.Assert().NoError(ValidateConnectors(s.connectors))
+
+	duplicate := *s.connectors[0]
+	s.Assert().ErrorIs(ValidateConnectors(append(s.connectors, &duplicate)), ErrDuplicateConnector)
+
+	invalid := settingsData.Connector{EvseId: 1, ConnectorId: 3}
+	s.Assert().Error(ValidateConnectors(append(s.connectors, &invalid)))
+
+	// The connector ids are unique across the EVSEs
+	otherEvse := *s.connectors[1]
+	otherEvse.EvseId = 2
+	s.Assert().ErrorIs(ValidateConnectors([]*settingsData.Connector{s.connectors[0], s.connectors[1], &otherEvse}), ErrDuplicateConnector)
+
+	otherEvse.ConnectorId = 3
+	s.Assert().NoError(ValidateConnectors(append(s.connectors, &otherEvse)))
+
+	// The connector ids must not skip a number
+	otherEvse.ConnectorId = 4
+	s.Assert().ErrorIs(ValidateConnectors(append(s.connectors, &otherEvse)), ErrConnectorNumbering)
+}
+
+func (s *ReloadTestSuite) TestDiffConnectors() {
+	var (
+		statusChanged = *s.connectors[0]
+		relayChanged  = *s.connectors[1]
+		newConnector  = &settingsData.Connector{EvseId: 2, ConnectorId: 1, Type: "Schuko", Relay: settingsData.Relay{RelayPin: 12}}
+	)
+
+	// Status and session changes are ignored
+	statusChanged.Status = "Charging"
+	statusChanged.Session.TransactionId = "1234"
+	added, removed, changed := DiffConnectors(s.connectors, []*settingsData.Connector{&statusChanged, s.connectors[1]})
+	s.Assert().Empty(added)
+	s.Assert().Empty(removed)
+	s.Assert().Empty(changed)
+
+	relayChanged.Relay.RelayPin = 20
+	added, removed, changed = DiffConnectors(s.connectors, []*settingsData.Connector{&relayChanged, newConnector})
+	s.Assert().EqualValues([]*settingsData.Connector{newConnector}, added)
+	s.Assert().EqualValues([]*settingsData.Connector{s.connectors[0]}, removed)
+	s.Assert().EqualValues([]*settingsData.Connector{&relayChanged}, changed)
+}
+
+func (s *ReloadTestSuite) TestReloadOcppConfiguration() {
+	filePath := filepath.Join(s.tempDir, "configuration.json")
+
+	// Invalid file
+	s.Require().NoError(ioutil.WriteFile(filePath, []byte("{\"version\": 1, \"keys\": ["), 0644))
+	s.Assert().Error(ReloadOcppConfiguration(filePath, configuration.OCPP16))
+
+	// Missing mandatory keys
+	s.Require().NoError(ioutil.WriteFile(filePath, []byte("{\"version\": 1, \"keys\": []}"), 0644))
+	s.Assert().ErrorIs(ReloadOcppConfiguration(filePath, configuration.OCPP16), ErrMissingMandatoryKeys)
+
+	// File doesn't exist
+	s.Assert().Error(ReloadOcppConfiguration(filepath.Join(s.tempDir, "missing.json"), configuration.OCPP16))
+}
+
+func (s *ReloadTestSuite) TestWatchConnectors() {
+	var (
+		handler          = new(reloadHandlerMock)
+		settingsFilePath = filepath.Join(s.tempDir, "settings.yaml")
+		connectorsFolder = filepath.Join(s.tempDir, "connectors")
+		ocppConfigPath   = filepath.Join(s.tempDir, "configuration.json")
+		ctx, cancel      = context.WithCancel(context.Background())
+	)
+	defer cancel()
+
+	s.Require().NoError(os.Mkdir(connectorsFolder, 0755))
+
+	watcher, err := NewWatcher(settingsFilePath, connectorsFolder, ocppConfigPath, configuration.OCPP16, handler, nil)
+	s.Require().NoError(err)
+	go watcher.Run(ctx)
+
+	applied := make(chan []*settingsData.Connector, 1)
+	handler.On("ApplyConnectors", mock.Anything).Return(nil).Once().Run(func(args mock.Arguments) {
+		applied <- args.Get(0).([]*settingsData.Connector)
+	})
+
+	connector, err := json.Marshal(s.connectors[0])
+	s.Require().NoError(err)
+	s.Require().NoError(ioutil.WriteFile(filepath.Join(connectorsFolder, "connector-1.json"), connector, 0644))
+
+	var connectors []*settingsData.Connector
+	select {
+	case connectors = <-applied:
+	case <-time.After(time.Second * 3):
+		s.FailNow("The connectors were not applied")
+	}
+
+	s.Require().Len(connectors, 1)
+	s.Assert().EqualValues(1, connectors[0].ConnectorId)
+
+	// Unrelated files are ignored
+	s.Require().NoError(ioutil.WriteFile(filepath.Join(s.tempDir, "unrelated.json"), []byte("{}"), 0644))
+	time.Sleep(reloadDelay * 2)
+	handler.AssertNumberOfCalls(s.T(), "ApplyConnectors", 1)
+	handler.AssertNotCalled(s.T(), "ApplySettings", mock.Anything)
+}
+
+func TestReload(t *testing.T) {
+	suite.Run(t, new(ReloadTestSuite))
+}
@@ -1,21 +1,19 @@
 package settings
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/agrison/go-commons-lang/stringUtils"
-	"github.com/go-playground/validator"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/util"
 	ocppConfigManager "github.com/xBlaz3kx/ocppManager-go"
 	"github.com/xBlaz3kx/ocppManager-go/configuration"
 	"os"
 	"path/filepath"
 	"strings"
-	"sync"
 )
 
 const (
@@ -32,19 +30,39 @@ const (
 	ApiEnabled      = "api.enabled"
 	ApiAddress      = "api.address"
 	ApiPort         = "api.port"
+	MqttTopicPrefix = "mqtt.topicPrefix"
+	MqttHaPrefix    = "mqtt.homeAssistant.discoveryPrefix"
+	MinPower        = "chargepoint.hardware.powerMeters.minPower"
+	FirmwareFolder  = "chargepoint.firmware.downloadFolder"
+	RebootCommand   = "chargepoint.reset.rebootCommand"
 )
 
 var (
-	ConnectorSettings = sync.Map{}
+	ErrNoConnectorRepository = errors.New("connector repository not set")
+
+	connectorRepository store.ConnectorRepository
 )
 
 func InitSettings(settingsFilePath string) {
-	readConfiguration(viper.GetViper(), "settings", "yaml", settingsFilePath)
+	err := ReadSettings(settingsFilePath)
+	if err != nil {
+		log.WithError(err).Fatalf("Cannot parse config file")
+	}
+}
+
+// ReadSettings reads the settings file into viper and sets up the environment variables and defaults.
+func ReadSettings(settingsFilePath string) error {
+	err := readConfiguration(viper.GetViper(), "settings", "yaml", settingsFilePath)
+	if err != nil {
+		return err
+	}
+
 	setupEnv()
 	setDefaults()
+	return nil
 }
 
-func readConfiguration(viper *viper.Viper, fileName, extension, filePath string) {
+func readConfiguration(viper *viper.Viper, fileName, extension, filePath string) error {
 	if stringUtils.IsNotEmpty(filePath) {
 		viper.SetConfigFile(filePath)
 	} else {
@@ -57,10 +75,11 @@ func readConfiguration(viper *viper.Viper, fileName, extension, filePath string)
 
 	err := viper.ReadInConfig()
 	if err != nil {
-		log.WithError(err).Fatalf("Cannot parse config file")
+		return err
 	}
 
 	log.Debugf("Using configuration file: %s", viper.ConfigFileUsed())
+	return nil
 }
 
 func setupEnv() {
@@ -75,11 +94,27 @@ func setDefaults() {
 	viper.SetDefault(MaxChargingTime, 180)
 	viper.SetDefault(ProtocolVersion, "1.6")
 	viper.SetDefault(LoggingFormat, "gelf")
+	viper.SetDefault(MqttTopicPrefix, "chargepi")
+	viper.SetDefault(MqttHaPrefix, "homeassistant")
+	viper.SetDefault(MinPower, 20)
+	viper.SetDefault(FirmwareFolder, "/tmp/chargepi/firmware")
+	viper.SetDefault(RebootCommand, "sudo reboot")
 }
 
-func SetupOcppConfigurationManager(filePath string, version configuration.ProtocolVersion, supportedProfiles ...string) {
+// SetupOcppConfigurationManager configures and loads the OCPP configuration. If the repository is set, the configuration
+// is persisted in the repository instead of the configuration file.
+func SetupOcppConfigurationManager(
+	filePath string,
+	version configuration.ProtocolVersion,
+	repository store.OcppConfigurationRepository,
+	supportedProfiles ...string,
+) {
 	fileName := strings.TrimSuffix(filePath, filepath.Ext(filePath))
 
+	if !util.IsNilInterfaceOrPointer(repository) {
+		ocppConfigManager.SetManager(store.NewOcppConfigurationManager(ocppConfigManager.GetManager(), repository, filePath))
+	}
+
 	ocppConfigManager.SetFileFormat(JSON)
 	ocppConfigManager.SetVersion(version)
 	ocppConfigManager.SetFileName(filepath.Base(fileName))
@@ -96,19 +131,30 @@ func SetupOcppConfigurationManager(filePath string, version configuration.Protoc
 // GetSettings gets settings from cache or reads the settings file if the cached settings are not found.
 func GetSettings() *settings.Settings {
 	log.Debug("Fetching settings..")
+
+	conf, err := LoadSettings()
+	if err != nil {
+		log.WithError(err).Fatalf("Invalid settings")
+	}
+
+	return conf
+}
+
+// LoadSettings unmarshalls and validates the settings currently loaded in viper.
+func LoadSettings() (*settings.Settings, error) {
 	var conf settings.Settings
 
 	err := viper.Unmarshal(&conf)
 	if err != nil {
-		log.WithError(err).Fatalf("Couldn't load settings")
+		return nil, err
 	}
 
-	validationErr := validator.New().Struct(conf)
-	if validationErr != nil {
-		log.WithError(validationErr).Fatalf("Invalid settings")
+	err = ValidateSettings(&conf)
+	if err != nil {
+		return nil, err
 	}
 
-	return &conf
+	return &conf, nil
 }
 
 // loadConnectorFromPath loads a connector from file
@@ -119,30 +165,44 @@ func loadConnectorFromPath(name, path string) (*settings.Connector, error) {
 		connector    settings.Connector
 	)
 
-	readConfiguration(connectorCfg, name, "json", path)
+	err := readConfiguration(connectorCfg, name, "json", path)
+	if err != nil {
+		log.WithError(err).Errorf("Cannot read connector file")
+		return nil, err
+	}
 
-	err := connectorCfg.Unmarshal(&connector)
+	err = connectorCfg.Unmarshal(&connector)
 	if err != nil {
 		log.WithError(err).Errorf("Cannot read connector file")
 		return nil, err
 	}
 
 	log.Debugf("Read connector from %s", path)
-	cachePathKey := fmt.Sprintf("connectorEvse%dId%d", connector.EvseId, connector.ConnectorId)
-	ConnectorSettings.Store(cachePathKey, connectorCfg)
-
 	return &connector, nil
 }
 
-// GetConnectors Scan the connectors folder, read all the connectors' settings and cache the settings.
+// GetConnectors Scan the connectors folder and read all the connectors' settings.
 func GetConnectors(connectorsFolderPath string) []*settings.Connector {
-	var (
-		connectors []*settings.Connector
-	)
-
 	log.Debug("Fetching connectors..")
 
+	connectors, err := LoadConnectors(connectorsFolderPath)
+	if err != nil {
+		log.WithError(err).Errorf("Error reading connectors")
+	}
+
+	return connectors
+}
+
+// LoadConnectors reads all the connectors' settings from the folder.
+// Returns an error if any of the connector files cannot be read.
+func LoadConnectors(connectorsFolderPath string) ([]*settings.Connector, error) {
+	var connectors []*settings.Connector
+
 	err := filepath.Walk(connectorsFolderPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
 		// Skip directories
 		if info.IsDir() {
 			return nil
@@ -159,97 +219,77 @@ func GetConnectors(connectorsFolderPath string) []*settings.Connector {
 		return nil
 	})
 
-	if err != nil {
-		log.WithError(err).Errorf("Error reading connectors")
-	}
-
-	return connectors
+	return connectors, err
 }
 
-// UpdateConnectorStatus update the Connector's status in the connector configuration file
-func UpdateConnectorStatus(evseId, connectorId int, status core.ChargePointStatus) {
-	var (
-		cachePathKey = fmt.Sprintf("connectorEvse%dId%d", evseId, connectorId)
-		connector    settings.Connector
-		err          error
-		logInfo      = log.WithFields(log.Fields{
-			"evseId":      evseId,
-			"connectorId": connectorId,
-			"status":      status,
-		})
-	)
+// SetConnectorRepository sets the repository, where the connectors' status and sessions are persisted.
+func SetConnectorRepository(repository store.ConnectorRepository) {
+	connectorRepository = repository
+}
 
-	viperCfg, isFound := ConnectorSettings.Load(cachePathKey)
-	if !isFound {
-		logInfo.WithError(err).Errorf("Error updating connector status")
-		return
+// GetConnectorState returns the persisted status and session of the connector.
+func GetConnectorState(evseId, connectorId int) (*store.ConnectorState, error) {
+	if util.IsNilInterfaceOrPointer(connectorRepository) {
+		return nil, ErrNoConnectorRepository
 	}
 
-	cfg := viperCfg.(*viper.Viper)
+	return connectorRepository.GetConnectorState(evseId, connectorId)
+}
 
-	err = cfg.Unmarshal(&connector)
-	if err != nil {
-		logInfo.WithError(err).Errorf("Error updating connector status")
+// DeleteConnectorState removes the persisted status and session of the connector.
+func DeleteConnectorState(evseId, connectorId int) {
+	if util.IsNilInterfaceOrPointer(connectorRepository) {
 		return
 	}
 
-	// Replace the connector status
-	cfg.Set("status", status)
-
-	err = cfg.WriteConfig()
+	err := connectorRepository.DeleteConnectorState(evseId, connectorId)
 	if err != nil {
-		logInfo.WithError(err).Errorf("Error updating connector status")
-		return
+		log.WithError(err).WithFields(log.Fields{
+			"evseId":      evseId,
+			"connectorId": connectorId,
+		}).Errorf("Error deleting connector state")
 	}
-
-	logInfo.Debugf("Updated status at connector %d", connectorId)
 }
 
-// UpdateConnectorSessionInfo update the Connector's Session object in the connector configuration file
-func UpdateConnectorSessionInfo(evseId, connectorId int, session *settings.Session) {
-	var (
-		cachePathKey = fmt.Sprintf("connectorEvse%dId%d", evseId, connectorId)
-		connector    *settings.Connector
-		err          error
-		logInfo      = log.WithFields(log.Fields{
-			"evseId":      evseId,
-			"connectorId": connectorId,
-			"session":     session,
-		})
-	)
+// UpdateConnectorStatus persists the Connector's status
+func UpdateConnectorStatus(evseId, connectorId int, status core.ChargePointStatus) {
+	logInfo := log.WithFields(log.Fields{
+		"evseId":      evseId,
+		"connectorId": connectorId,
+		"status":      status,
+	})
 
-	logInfo.Debugf("Updating session info")
-	viperCfg, isFound := ConnectorSettings.Load(cachePathKey)
-	if !isFound {
-		logInfo.WithError(err).Errorf("Error updating connector status")
+	if util.IsNilInterfaceOrPointer(connectorRepository) {
+		logInfo.WithError(ErrNoConnectorRepository).Errorf("Error updating connector status")
 		return
 	}
 
-	cfg := viperCfg.(*viper.Viper)
-
-	err = cfg.Unmarshal(&connector)
+	err := connectorRepository.UpdateConnectorStatus(evseId, connectorId, status)
 	if err != nil {
 		logInfo.WithError(err).Errorf("Error updating connector status")
 		return
 	}
 
-	connector.Session = *session
+	logInfo.Debugf("Updated status at connector %d", connectorId)
+}
 
-	marshal, err := json.Marshal(connector)
-	if err != nil {
-		logInfo.WithError(err).Errorf("Error updating connector status")
-		return
-	}
+// UpdateConnectorSessionInfo persists the Connector's Session object
+func UpdateConnectorSessionInfo(evseId, connectorId int, session *settings.Session) {
+	logInfo := log.WithFields(log.Fields{
+		"evseId":      evseId,
+		"connectorId": connectorId,
+		"session":     session,
+	})
 
-	err = cfg.ReadConfig(bytes.NewReader(marshal))
-	if err != nil {
-		logInfo.WithError(err).Errorf("Error updating connector status")
+	logInfo.Debugf("Updating session info")
+	if util.IsNilInterfaceOrPointer(connectorRepository) {
+		logInfo.WithError(ErrNoConnectorRepository).Errorf("Error updating connector session")
 		return
 	}
 
-	err = cfg.WriteConfig()
+	err := connectorRepository.UpdateConnectorSession(evseId, connectorId, *session)
 	if err != nil {
-		logInfo.WithError(err).Errorf("Error updating connector status")
+		logInfo.WithError(err).Errorf("Error updating connector session")
 		return
 	}
 
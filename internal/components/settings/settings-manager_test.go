@@ -1,17 +1,14 @@
 package settings
 
 import (
-	"github.com/kkyr/fig"
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
 	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
-	"os/exec"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
-	"time"
-)
-
-const (
-	fileName = "connector-1.json"
 )
 
 type SettingsManagerTestSuite struct {
@@ -20,6 +17,8 @@ type SettingsManagerTestSuite struct {
 	session    settingsData.Session
 	relay      settingsData.Relay
 	powerMeter settingsData.PowerMeter
+	store      *store.Store
+	tempDir    string
 }
 
 func (s *SettingsManagerTestSuite) SetupTest() {
@@ -46,6 +45,14 @@ func (s *SettingsManagerTestSuite) SetupTest() {
 		VoltageDividerOffset: 0,
 	}
 
+	tempDir, err := ioutil.TempDir("", "chargepi")
+	s.Require().NoError(err)
+	s.tempDir = tempDir
+
+	s.store, err = store.Open(filepath.Join(tempDir, "chargepi.db"))
+	s.Require().NoError(err)
+	SetConnectorRepository(s.store)
+
 	s.connector = settingsData.Connector{
 		EvseId:      1,
 		ConnectorId: 1,
@@ -58,55 +65,38 @@ func (s *SettingsManagerTestSuite) SetupTest() {
 }
 
 func (s *SettingsManagerTestSuite) TestUpdateSessionInfo() {
-	var (
-		connectorFromFile settingsData.Connector
-		newSession        = settingsData.Session{
-			IsActive:      true,
-			TransactionId: "Transaction1234",
-			TagId:         "Tag1234",
-			Started:       "",
-			Consumption:   nil,
-		}
-	)
+	newSession := settingsData.Session{
+		IsActive:      true,
+		TransactionId: "Transaction1234",
+		TagId:         "Tag1234",
+		Started:       "",
+		Consumption:   nil,
+	}
 
 	UpdateConnectorSessionInfo(s.connector.EvseId, s.connector.ConnectorId, &newSession)
 
-	err := fig.Load(&connectorFromFile, fig.File(fileName))
-	s.Require().FileExists("./" + fileName)
-	s.Require().NoError(err)
-	s.Require().EqualValues(newSession, connectorFromFile.Session)
-
-	// Clean up
-	cmd := exec.Command("rm", fileName)
-	err = cmd.Run()
+	state, err := GetConnectorState(s.connector.EvseId, s.connector.ConnectorId)
 	s.Require().NoError(err)
+	s.Require().EqualValues(newSession, state.Session)
 }
 
 func (s *SettingsManagerTestSuite) TestUpdateConnectorStatus() {
-	if testing.Short() {
-		return
-	}
-
-	cmd := exec.Command("touch", fileName)
-	err := cmd.Run()
-	s.Require().NoError(err)
-
-	var connectorFromFile settingsData.Connector
-
 	UpdateConnectorStatus(s.connector.EvseId, s.connector.ConnectorId, core.ChargePointStatusCharging)
 
-	time.Sleep(time.Second)
-
-	err = fig.Load(&connectorFromFile, fig.File(fileName))
-	s.Require().FileExists("./" + fileName)
+	state, err := GetConnectorState(s.connector.EvseId, s.connector.ConnectorId)
 	s.Require().NoError(err)
+	s.Require().EqualValues(core.ChargePointStatusCharging, state.Status)
 
-	s.Require().EqualValues(core.ChargePointStatusCharging, connectorFromFile.Status)
+	DeleteConnectorState(s.connector.EvseId, s.connector.ConnectorId)
 
-	// Clean up
-	cmd = exec.Command("rm", fileName)
-	err = cmd.Run()
-	s.Require().NoError(err)
+	_, err = GetConnectorState(s.connector.EvseId, s.connector.ConnectorId)
+	s.Require().ErrorIs(err, store.ErrNotFound)
+}
+
+func (s *SettingsManagerTestSuite) TearDownTest() {
+	SetConnectorRepository(nil)
+	_ = s.store.Close()
+	_ = os.RemoveAll(s.tempDir)
 }
 
 func TestSettingsManager(t *testing.T) {
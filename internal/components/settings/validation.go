@@ -0,0 +1,281 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/policy"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/tariff"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/internal/pkg/security"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	"github.com/xBlaz3kx/ocppManager-go/v16"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidServerUri            = errors.New("invalid server uri")
+	ErrUnsupportedProtocolVersion  = errors.New("unsupported protocol version")
+	ErrInvalidConfigurationValue   = errors.New("invalid configuration value")
+	ErrDuplicateConfigurationKey   = errors.New("duplicate configuration key")
+	ErrConfigurationValueTooLong   = errors.New("configuration value too long")
+	ErrEmptyConfigurationKey       = errors.New("empty configuration key")
+	ErrUnsupportedFeatureProfile   = errors.New("unsupported feature profile")
+	ErrInvalidMeasurandInConfigKey = errors.New("invalid measurand")
+)
+
+type ocppValueType int
+
+const (
+	boolValue = ocppValueType(iota)
+	intValue
+	positiveIntValue
+	csvValue
+	measurandListValue
+	profileListValue
+)
+
+// ocppKeySchema describes the value types of the OCPP 1.6 configuration keys.
+var ocppKeySchema = map[configuration.Key]ocppValueType{
+	v16.AllowOfflineTxForUnknownId:              boolValue,
+	v16.AuthorizationCacheEnabled:               boolValue,
+	v16.AuthorizeRemoteTxRequests:               boolValue,
+	v16.BlinkRepeat:                             intValue,
+	v16.ClockAlignedDataInterval:                intValue,
+	v16.ConnectionTimeOut:                       intValue,
+	v16.GetConfigurationMaxKeys:                 intValue,
+	v16.HeartbeatInterval:                       positiveIntValue,
+	v16.LightIntensity:                          intValue,
+	v16.LocalAuthorizeOffline:                   boolValue,
+	v16.LocalPreAuthorize:                       boolValue,
+	v16.MaxEnergyOnInvalidId:                    intValue,
+	v16.MeterValuesAlignedData:                  measurandListValue,
+	v16.MeterValuesAlignedDataMaxLength:         intValue,
+	v16.MeterValuesSampledData:                  measurandListValue,
+	v16.MeterValuesSampledDataMaxLength:         intValue,
+	v16.MeterValueSampleInterval:                intValue,
+	v16.MinimumStatusDuration:                   intValue,
+	v16.NumberOfConnectors:                      intValue,
+	v16.ResetRetries:                            intValue,
+	v16.ConnectorPhaseRotation:                  csvValue,
+	v16.ConnectorPhaseRotationMaxLength:         intValue,
+	v16.StopTransactionOnEVSideDisconnect:       boolValue,
+	v16.StopTransactionOnInvalidId:              boolValue,
+	v16.StopTxnAlignedData:                      measurandListValue,
+	v16.StopTxnAlignedDataMaxLength:             intValue,
+	v16.StopTxnSampledData:                      measurandListValue,
+	v16.StopTxnSampledDataMaxLength:             intValue,
+	v16.SupportedFeatureProfiles:                profileListValue,
+	v16.SupportedFeatureProfilesMaxLength:       intValue,
+	v16.TransactionMessageAttempts:              intValue,
+	v16.TransactionMessageRetryInterval:         intValue,
+	v16.UnlockConnectorOnEVSideDisconnect:       boolValue,
+	v16.WebSocketPingInterval:                   intValue,
+	v16.LocalAuthListEnabled:                    boolValue,
+	v16.LocalAuthListMaxLength:                  intValue,
+	v16.SendLocalListMaxLength:                  intValue,
+	v16.ReserveConnectorZeroSupported:           boolValue,
+	v16.ChargeProfileMaxStackLevel:              intValue,
+	v16.ChargingScheduleAllowedChargingRateUnit: csvValue,
+	v16.ChargingScheduleMaxPeriods:              intValue,
+	v16.MaxChargingProfilesInstalled:            intValue,
+	v16.ConnectorSwitch3to1PhaseSupported:       boolValue,
+	security.SecurityProfileKey:                 intValue,
+	security.CertificateStoreMaxLengthKey:       intValue,
+	security.CertificateSignedMaxChainSizeKey:   intValue,
+	security.AdditionalRootCertificateCheckKey:  boolValue,
+}
+
+var (
+	supportedProtocolVersions = []string{string(settings.OCPP16), string(settings.OCPP201)}
+
+	supportedFeatureProfiles = []string{
+		"Core", "FirmwareManagement", "LocalAuthListManagement", "Reservation", "SmartCharging", "RemoteTrigger",
+	}
+
+	// measurands are the measurands the power meters can sample, see connector.ReadMeasurand
+	measurands = []string{
+		"Current.Export", "Current.Import",
+		"Energy.Active.Export.Register", "Energy.Active.Import.Register",
+		"Energy.Active.Export.Interval", "Energy.Active.Import.Interval",
+		"Power.Active.Export", "Power.Active.Import",
+		"Voltage",
+	}
+)
+
+// maxConfigurationValueLength is the maximum length of a configuration value defined by the OCPP 1.6 specification.
+const maxConfigurationValueLength = 500
+
+// ValidateSettings validates the settings using the struct tags and checks the server uri, the protocol version, the
+// session policies and the tariff.
+func ValidateSettings(conf *settings.Settings) error {
+	err := validator.New().Struct(conf)
+	if err != nil {
+		return err
+	}
+
+	err = ValidateServerUri(conf.ChargePoint.Info.ServerUri)
+	if err != nil {
+		return err
+	}
+
+	if !containsString(supportedProtocolVersions, conf.ChargePoint.Info.ProtocolVersion) {
+		return fmt.Errorf("%w: %s", ErrUnsupportedProtocolVersion, conf.ChargePoint.Info.ProtocolVersion)
+	}
+
+	err = policy.ValidatePolicies(conf.ChargePoint.SessionPolicies, conf.ChargePoint.Info.MaxChargingTime)
+	if err != nil {
+		return err
+	}
+
+	_, err = tariff.NewTariff(conf.ChargePoint.Tariff)
+	return err
+}
+
+// ValidateServerUri checks that the server uri contains a host and no scheme, since the scheme is determined by the TLS settings.
+func ValidateServerUri(serverUri string) error {
+	if strings.Contains(serverUri, "://") {
+		return fmt.Errorf("%w: %s must not contain a scheme", ErrInvalidServerUri, serverUri)
+	}
+
+	parsedUrl, err := url.Parse("ws://" + serverUri)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidServerUri, err)
+	}
+
+	if parsedUrl.Hostname() == "" {
+		return fmt.Errorf("%w: %s has no host", ErrInvalidServerUri, serverUri)
+	}
+
+	if port := parsedUrl.Port(); port != "" {
+		portNumber, err := strconv.Atoi(port)
+		if err != nil || portNumber <= 0 || portNumber > 65535 {
+			return fmt.Errorf("%w: invalid port %s", ErrInvalidServerUri, port)
+		}
+	}
+
+	return nil
+}
+
+// ValidateOcppConfiguration reads the OCPP configuration file, checks that all the mandatory keys are present and
+// validates the values of the known keys.
+func ValidateOcppConfiguration(filePath string, version configuration.ProtocolVersion) error {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	var ocppConfig configuration.Config
+	err = json.Unmarshal(content, &ocppConfig)
+	if err != nil {
+		return err
+	}
+
+	if version != configuration.OCPP16 {
+		return nil
+	}
+
+	var (
+		missingKeys []string
+		keys        = map[string]bool{}
+	)
+
+	for _, key := range ocppConfig.Keys {
+		if keys[key.Key] {
+			return fmt.Errorf("%w: %s", ErrDuplicateConfigurationKey, key.Key)
+		}
+
+		keys[key.Key] = true
+
+		err = ValidateOcppKey(key.Key, key.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, mandatoryKey := range v16.MandatoryCoreKeys {
+		if !keys[mandatoryKey.String()] {
+			missingKeys = append(missingKeys, mandatoryKey.String())
+		}
+	}
+
+	if len(missingKeys) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingMandatoryKeys, strings.Join(missingKeys, ", "))
+	}
+
+	return nil
+}
+
+// ValidateOcppKey validates the value of an OCPP 1.6 configuration key. Unknown (vendor specific) keys are not validated.
+func ValidateOcppKey(key, value string) error {
+	if strings.TrimSpace(key) == "" {
+		return ErrEmptyConfigurationKey
+	}
+
+	if len(value) > maxConfigurationValueLength {
+		return fmt.Errorf("%w: %s", ErrConfigurationValueTooLong, key)
+	}
+
+	valueType, isKnown := ocppKeySchema[configuration.Key(key)]
+	if !isKnown {
+		return nil
+	}
+
+	switch valueType {
+	case boolValue:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%w: %s must be true or false, got %q", ErrInvalidConfigurationValue, key, value)
+		}
+	case intValue:
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 0 {
+			return fmt.Errorf("%w: %s must be a non-negative integer, got %q", ErrInvalidConfigurationValue, key, value)
+		}
+	case positiveIntValue:
+		number, err := strconv.Atoi(value)
+		if err != nil || number <= 0 {
+			return fmt.Errorf("%w: %s must be a positive integer, got %q", ErrInvalidConfigurationValue, key, value)
+		}
+	case measurandListValue:
+		for _, measurand := range splitCsv(value) {
+			if !containsString(measurands, measurand) {
+				return fmt.Errorf("%w: %s in %s", ErrInvalidMeasurandInConfigKey, measurand, key)
+			}
+		}
+	case profileListValue:
+		for _, profile := range splitCsv(value) {
+			if !containsString(supportedFeatureProfiles, profile) {
+				return fmt.Errorf("%w: %s in %s", ErrUnsupportedFeatureProfile, profile, key)
+			}
+		}
+	}
+
+	return nil
+}
+
+func splitCsv(value string) []string {
+	var values []string
+
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,135 @@
+package settings
+
+import (
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/policy"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/tariff"
+	settingsData "github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type ValidationTestSuite struct {
+	suite.Suite
+	tempDir string
+}
+
+func (s *ValidationTestSuite) SetupTest() {
+	tempDir, err := ioutil.TempDir("", "chargepi")
+	s.Require().NoError(err)
+	s.tempDir = tempDir
+}
+
+func (s *ValidationTestSuite) TearDownTest() {
+	_ = os.RemoveAll(s.tempDir)
+}
+
+func (s *ValidationTestSuite) TestValidateServerUri() {
+	s.Assert().NoError(ValidateServerUri("example.com"))
+	s.Assert().NoError(ValidateServerUri("172.0.1.121:8080/steve/websocket/CentralSystemService"))
+
+	s.Assert().ErrorIs(ValidateServerUri("ws://example.com"), ErrInvalidServerUri)
+	s.Assert().ErrorIs(ValidateServerUri(":8080/steve"), ErrInvalidServerUri)
+	s.Assert().ErrorIs(ValidateServerUri("example.com:70000"), ErrInvalidServerUri)
+}
+
+func (s *ValidationTestSuite) TestValidateSettings() {
+	conf := NewDefaultSettings("ChargePi", "example.com", "1.6")
+	s.Assert().NoError(ValidateSettings(conf))
+
+	conf.ChargePoint.Tariff.Bands = []settingsData.TariffBand{{Start: "22:00", End: "6 AM"}}
+	s.Assert().ErrorIs(ValidateSettings(conf), tariff.ErrInvalidTariff)
+	conf.ChargePoint.Tariff.Bands = nil
+
+	conf.ChargePoint.SessionPolicies.Default.MaxDuration = conf.ChargePoint.Info.MaxChargingTime + 1
+	s.Assert().ErrorIs(ValidateSettings(conf), policy.ErrMaxDurationExceeded)
+	conf.ChargePoint.SessionPolicies.Default.MaxDuration = 0
+
+	conf.ChargePoint.Info.ProtocolVersion = "1.5"
+	s.Assert().ErrorIs(ValidateSettings(conf), ErrUnsupportedProtocolVersion)
+
+	conf.ChargePoint.Info.Id = ""
+	s.Assert().Error(ValidateSettings(conf))
+}
+
+func (s *ValidationTestSuite) TestValidateOcppKey() {
+	s.Assert().NoError(ValidateOcppKey("HeartbeatInterval", "60"))
+	s.Assert().NoError(ValidateOcppKey("AuthorizationCacheEnabled", "true"))
+	s.Assert().NoError(ValidateOcppKey("MeterValuesSampledData", "Energy.Active.Import.Register, Power.Active.Import"))
+	s.Assert().NoError(ValidateOcppKey("MeterValuesAlignedData", ""))
+	s.Assert().NoError(ValidateOcppKey("SupportedFeatureProfiles", "Core,Reservation"))
+	s.Assert().NoError(ValidateOcppKey("VendorSpecificKey", "anything"))
+
+	s.Assert().ErrorIs(ValidateOcppKey("", "60"), ErrEmptyConfigurationKey)
+	s.Assert().ErrorIs(ValidateOcppKey("HeartbeatInterval", "-1"), ErrInvalidConfigurationValue)
+	s.Assert().ErrorIs(ValidateOcppKey("HeartbeatInterval", "0"), ErrInvalidConfigurationValue)
+	s.Assert().NoError(ValidateOcppKey("MeterValueSampleInterval", "0"))
+	s.Assert().ErrorIs(ValidateOcppKey("AuthorizationCacheEnabled", "yes"), ErrInvalidConfigurationValue)
+	s.Assert().ErrorIs(ValidateOcppKey("MeterValuesAlignedData", "false"), ErrInvalidMeasurandInConfigKey)
+	// Valid OCPP measurands, which the power meters cannot sample
+	s.Assert().ErrorIs(ValidateOcppKey("MeterValuesSampledData", "Energy.Active.Import.Register,SoC"), ErrInvalidMeasurandInConfigKey)
+	s.Assert().ErrorIs(ValidateOcppKey("StopTxnSampledData", "Temperature"), ErrInvalidMeasurandInConfigKey)
+	s.Assert().ErrorIs(ValidateOcppKey("SupportedFeatureProfiles", "Core,Security"), ErrUnsupportedFeatureProfile)
+	s.Assert().ErrorIs(ValidateOcppKey("VendorSpecificKey", strings.Repeat("a", 501)), ErrConfigurationValueTooLong)
+}
+
+func (s *ValidationTestSuite) TestValidateOcppConfiguration() {
+	filePath := filepath.Join(s.tempDir, "configuration.json")
+
+	// The example configuration is valid
+	s.Assert().NoError(ValidateOcppConfiguration("../../../configs/configuration.json", configuration.OCPP16))
+
+	s.Require().NoError(ioutil.WriteFile(filePath, []byte(`{"version": 1, "keys": [{"key": "HeartbeatInterval", "value": "60"}, {"key": "HeartbeatInterval", "value": "30"}]}`), 0644))
+	s.Assert().ErrorIs(ValidateOcppConfiguration(filePath, configuration.OCPP16), ErrDuplicateConfigurationKey)
+
+	s.Require().NoError(ioutil.WriteFile(filePath, []byte(`{"version": 1, "keys": [{"key": "HeartbeatInterval", "value": "abc"}]}`), 0644))
+	s.Assert().ErrorIs(ValidateOcppConfiguration(filePath, configuration.OCPP16), ErrInvalidConfigurationValue)
+
+	s.Require().NoError(ioutil.WriteFile(filePath, []byte(`{"version": 1, "keys": [{"key": "HeartbeatInterval", "value": "60"}]}`), 0644))
+	s.Assert().ErrorIs(ValidateOcppConfiguration(filePath, configuration.OCPP16), ErrMissingMandatoryKeys)
+}
+
+func (s *ValidationTestSuite) TestGenerateFiles() {
+	var (
+		conf       = NewDefaultSettings("ChargePi", "example.com", "1.6")
+		connectors = []*settingsData.Connector{NewDefaultConnector(1, 1, 26), NewDefaultConnector(1, 2, 27)}
+	)
+
+	files, err := GenerateFiles(s.tempDir, YamlFile, conf, connectors, false)
+	s.Require().NoError(err)
+	s.Assert().Len(files, 3)
+
+	loadedConnectors, err := LoadConnectors(filepath.Join(s.tempDir, "connectors"))
+	s.Require().NoError(err)
+	s.Assert().Len(loadedConnectors, 2)
+
+	// Existing files are not overwritten
+	_, err = GenerateFiles(s.tempDir, YamlFile, conf, connectors, false)
+	s.Assert().ErrorIs(err, os.ErrExist)
+
+	_, err = GenerateFiles(s.tempDir, YamlFile, conf, connectors, true)
+	s.Assert().NoError(err)
+
+	// Invalid settings are not written
+	_, err = GenerateFiles(s.tempDir, YamlFile, NewDefaultSettings("ChargePi", "ws://example.com", "1.6"), connectors, true)
+	s.Assert().ErrorIs(err, ErrInvalidServerUri)
+}
+
+func (s *ValidationTestSuite) TestMaskSecrets() {
+	conf := NewDefaultSettings("ChargePi", "example.com", "1.6")
+	conf.ChargePoint.Info.BasicAuthPassword = "secret"
+	conf.Mqtt.Password = "secret"
+
+	masked := MaskSecrets(conf)
+	s.Assert().EqualValues(maskedValue, masked.ChargePoint.Info.BasicAuthPassword)
+	s.Assert().EqualValues(maskedValue, masked.Mqtt.Password)
+	s.Assert().EqualValues("secret", conf.Mqtt.Password)
+}
+
+func TestValidation(t *testing.T) {
+	suite.Run(t, new(ValidationTestSuite))
+}
@@ -0,0 +1,214 @@
+package settings
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	"path/filepath"
+	"time"
+)
+
+// reloadDelay is the time to wait for further changes before reloading, since editors usually write a file in multiple steps.
+const reloadDelay = time.Millisecond * 500
+
+const (
+	settingsFile = fileType(iota)
+	connectorFile
+	ocppConfigurationFile
+)
+
+type (
+	fileType int
+
+	// ReloadHandler applies the settings and connectors that changed while the charge point is running.
+	ReloadHandler interface {
+		ApplySettings(ctx context.Context, settings *settings.Settings) error
+		ApplyConnectors(connectors []*settings.Connector) error
+	}
+
+	// Watcher watches the settings file, the connectors folder and the OCPP configuration file and reloads
+	// the configuration when any of them changes.
+	Watcher struct {
+		watcher              *fsnotify.Watcher
+		handler              ReloadHandler
+		settingsFilePath     string
+		connectorsFolderPath string
+		ocppConfigFilePath   string
+		ocppVersion          configuration.ProtocolVersion
+		logger               *log.Logger
+	}
+)
+
+// NewWatcher creates a Watcher for the files. If the settings file path is empty, the settings file used by viper is watched.
+func NewWatcher(
+	settingsFilePath, connectorsFolderPath, ocppConfigFilePath string,
+	ocppVersion configuration.ProtocolVersion,
+	handler ReloadHandler,
+	logger *log.Logger,
+) (*Watcher, error) {
+	if logger == nil {
+		logger = log.StandardLogger()
+	}
+
+	if settingsFilePath == "" {
+		settingsFilePath = viper.ConfigFileUsed()
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		watcher:              fsWatcher,
+		handler:              handler,
+		settingsFilePath:     absolutePath(settingsFilePath),
+		connectorsFolderPath: absolutePath(connectorsFolderPath),
+		ocppConfigFilePath:   absolutePath(ocppConfigFilePath),
+		ocppVersion:          ocppVersion,
+		logger:               logger,
+	}
+
+	// Watch the directories instead of the files, as the files are often replaced instead of modified
+	for _, dir := range []string{filepath.Dir(w.settingsFilePath), w.connectorsFolderPath, filepath.Dir(w.ocppConfigFilePath)} {
+		err = fsWatcher.Add(dir)
+		if err != nil {
+			_ = fsWatcher.Close()
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+// Run listens for file changes until the context is done.
+func (w *Watcher) Run(ctx context.Context) {
+	var (
+		pending = map[fileType]bool{}
+		timer   = time.NewTimer(reloadDelay)
+	)
+
+	timer.Stop()
+	defer w.watcher.Close()
+
+	w.logger.Info("Watching the configuration files for changes")
+
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case event, isOpen := <-w.watcher.Events:
+			if !isOpen {
+				return
+			}
+
+			changedFile, isWatched := w.getFileType(event)
+			if !isWatched {
+				continue
+			}
+
+			pending[changedFile] = true
+			timer.Reset(reloadDelay)
+		case err, isOpen := <-w.watcher.Errors:
+			if !isOpen {
+				return
+			}
+
+			w.logger.WithError(err).Warn("Error watching the configuration files")
+		case <-timer.C:
+			for changedFile := range pending {
+				w.reload(ctx, changedFile)
+			}
+
+			pending = map[fileType]bool{}
+		}
+	}
+}
+
+// getFileType determines which configuration was changed by the event.
+func (w *Watcher) getFileType(event fsnotify.Event) (fileType, bool) {
+	if event.Op == fsnotify.Chmod {
+		return 0, false
+	}
+
+	path := absolutePath(event.Name)
+	switch {
+	case path == w.settingsFilePath:
+		return settingsFile, true
+	case path == w.ocppConfigFilePath:
+		return ocppConfigurationFile, true
+	case filepath.Dir(path) == w.connectorsFolderPath:
+		return connectorFile, true
+	default:
+		return 0, false
+	}
+}
+
+func (w *Watcher) reload(ctx context.Context, changedFile fileType) {
+	var (
+		err     error
+		logInfo *log.Entry
+	)
+
+	switch changedFile {
+	case settingsFile:
+		logInfo = w.logger.WithField("file", w.settingsFilePath)
+		err = w.reloadSettings(ctx)
+	case connectorFile:
+		logInfo = w.logger.WithField("folder", w.connectorsFolderPath)
+		err = w.reloadConnectors()
+	case ocppConfigurationFile:
+		logInfo = w.logger.WithField("file", w.ocppConfigFilePath)
+		err = ReloadOcppConfiguration(w.ocppConfigFilePath, w.ocppVersion)
+	default:
+		return
+	}
+
+	if err != nil {
+		logInfo.WithError(err).Error("Configuration change rejected")
+		return
+	}
+
+	logInfo.Info("Configuration reloaded")
+}
+
+func (w *Watcher) reloadSettings(ctx context.Context) error {
+	err := viper.ReadInConfig()
+	if err != nil {
+		return err
+	}
+
+	newSettings, err := LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	return w.handler.ApplySettings(ctx, newSettings)
+}
+
+func (w *Watcher) reloadConnectors() error {
+	connectors, err := LoadConnectors(w.connectorsFolderPath)
+	if err != nil {
+		return err
+	}
+
+	err = ValidateConnectors(connectors)
+	if err != nil {
+		return err
+	}
+
+	return w.handler.ApplyConnectors(connectors)
+}
+
+func absolutePath(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+
+	return absPath
+}
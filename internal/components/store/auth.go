@@ -0,0 +1,98 @@
+package store
+
+import (
+	"encoding/json"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	bolt "go.etcd.io/bbolt"
+)
+
+const authInfoKey = "info"
+
+type (
+	// AuthInfo contains the version of the local authorization list and the limit of the cached tags.
+	AuthInfo struct {
+		Version       int `json:"version"`
+		MaxCachedTags int `json:"maxCachedTags"`
+	}
+
+	AuthRepository interface {
+		GetAuthInfo() (*AuthInfo, error)
+		SetAuthInfo(info AuthInfo) error
+		GetTags() (map[string]types.IdTagInfo, error)
+		SaveTag(tagId string, tagInfo types.IdTagInfo) error
+		DeleteTag(tagId string) error
+		ReplaceTags(tags map[string]types.IdTagInfo) error
+	}
+)
+
+// GetAuthInfo returns the stored authorization info or ErrNotFound.
+func (s *Store) GetAuthInfo() (*AuthInfo, error) {
+	var info AuthInfo
+
+	err := s.get(authBucket, authInfoKey, &info)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// SetAuthInfo stores the authorization info.
+func (s *Store) SetAuthInfo(info AuthInfo) error {
+	return s.put(authBucket, authInfoKey, info)
+}
+
+// GetTags returns all the stored tags, mapped by the tag id.
+func (s *Store) GetTags() (map[string]types.IdTagInfo, error) {
+	tags := map[string]types.IdTagInfo{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(authTagsBucket)).ForEach(func(key, value []byte) error {
+			var tagInfo types.IdTagInfo
+
+			err := json.Unmarshal(value, &tagInfo)
+			if err != nil {
+				return err
+			}
+
+			tags[string(key)] = tagInfo
+			return nil
+		})
+	})
+
+	return tags, err
+}
+
+// SaveTag stores or replaces the tag.
+func (s *Store) SaveTag(tagId string, tagInfo types.IdTagInfo) error {
+	return s.put(authTagsBucket, tagId, tagInfo)
+}
+
+// DeleteTag removes the tag.
+func (s *Store) DeleteTag(tagId string) error {
+	return s.delete(authTagsBucket, tagId)
+}
+
+// ReplaceTags replaces all the stored tags in a single transaction.
+func (s *Store) ReplaceTags(tags map[string]types.IdTagInfo) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := tx.DeleteBucket([]byte(authTagsBucket))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.CreateBucket([]byte(authTagsBucket))
+		if err != nil {
+			return err
+		}
+
+		for tagId, tagInfo := range tags {
+			err = putValue(tx, authTagsBucket, tagId, tagInfo)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	bolt "go.etcd.io/bbolt"
+)
+
+const chargePointStateKey = "state"
+
+type (
+	// ChargePointState is the state of the charge point, which is restored after a restart.
+	ChargePointState struct {
+		Availability core.AvailabilityType `json:"availability"`
+		// InoperativeConnectors are the connectors made inoperative by the central system
+		InoperativeConnectors []int `json:"inoperativeConnectors,omitempty"`
+	}
+
+	ChargePointRepository interface {
+		GetChargePointState() (*ChargePointState, error)
+		UpdateAvailability(availability core.AvailabilityType) error
+		UpdateConnectorAvailability(connectorId int, availability core.AvailabilityType) error
+	}
+)
+
+// GetChargePointState returns the stored state of the charge point or ErrNotFound.
+func (s *Store) GetChargePointState() (*ChargePointState, error) {
+	var state ChargePointState
+
+	err := s.get(chargePointBucket, chargePointStateKey, &state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// UpdateAvailability stores the availability of the charge point.
+func (s *Store) UpdateAvailability(availability core.AvailabilityType) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		var state ChargePointState
+
+		err := getValue(tx, chargePointBucket, chargePointStateKey, &state)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+
+		state.Availability = availability
+		return putValue(tx, chargePointBucket, chargePointStateKey, state)
+	})
+}
+
+// UpdateConnectorAvailability stores the availability of the connector.
+func (s *Store) UpdateConnectorAvailability(connectorId int, availability core.AvailabilityType) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		var state ChargePointState
+
+		err := getValue(tx, chargePointBucket, chargePointStateKey, &state)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+
+		inoperativeConnectors := []int{}
+		for _, inoperativeConnector := range state.InoperativeConnectors {
+			if inoperativeConnector != connectorId {
+				inoperativeConnectors = append(inoperativeConnectors, inoperativeConnector)
+			}
+		}
+
+		if availability == core.AvailabilityTypeInoperative {
+			inoperativeConnectors = append(inoperativeConnectors, connectorId)
+		}
+
+		state.InoperativeConnectors = inoperativeConnectors
+		return putValue(tx, chargePointBucket, chargePointStateKey, state)
+	})
+}
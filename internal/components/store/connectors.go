@@ -0,0 +1,80 @@
+package store
+
+import (
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	bolt "go.etcd.io/bbolt"
+)
+
+type (
+	// ConnectorState is the state of the connector, which is restored after a restart.
+	ConnectorState struct {
+		EvseId      int                    `json:"evseId"`
+		ConnectorId int                    `json:"connectorId"`
+		Status      core.ChargePointStatus `json:"status"`
+		Session     settings.Session       `json:"session"`
+	}
+
+	ConnectorRepository interface {
+		GetConnectorState(evseId, connectorId int) (*ConnectorState, error)
+		UpdateConnectorStatus(evseId, connectorId int, status core.ChargePointStatus) error
+		UpdateConnectorSession(evseId, connectorId int, session settings.Session) error
+		DeleteConnectorState(evseId, connectorId int) error
+	}
+)
+
+func connectorKey(evseId, connectorId int) string {
+	return fmt.Sprintf("connectorEvse%dId%d", evseId, connectorId)
+}
+
+// GetConnectorState returns the stored state of the connector or ErrNotFound.
+func (s *Store) GetConnectorState(evseId, connectorId int) (*ConnectorState, error) {
+	var state ConnectorState
+
+	err := s.get(connectorsBucket, connectorKey(evseId, connectorId), &state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// UpdateConnectorStatus stores the status of the connector.
+func (s *Store) UpdateConnectorStatus(evseId, connectorId int, status core.ChargePointStatus) error {
+	return s.updateConnectorState(evseId, connectorId, func(state *ConnectorState) {
+		state.Status = status
+	})
+}
+
+// UpdateConnectorSession stores the charging session of the connector.
+func (s *Store) UpdateConnectorSession(evseId, connectorId int, session settings.Session) error {
+	return s.updateConnectorState(evseId, connectorId, func(state *ConnectorState) {
+		state.Session = session
+	})
+}
+
+// DeleteConnectorState removes the state of the connector.
+func (s *Store) DeleteConnectorState(evseId, connectorId int) error {
+	return s.delete(connectorsBucket, connectorKey(evseId, connectorId))
+}
+
+// updateConnectorState reads, modifies and writes the connector state in a single transaction.
+func (s *Store) updateConnectorState(evseId, connectorId int, update func(state *ConnectorState)) error {
+	key := connectorKey(evseId, connectorId)
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		state := ConnectorState{
+			EvseId:      evseId,
+			ConnectorId: connectorId,
+		}
+
+		err := getValue(tx, connectorsBucket, key, &state)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+
+		update(&state)
+		return putValue(tx, connectorsBucket, key, state)
+	})
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	bolt "go.etcd.io/bbolt"
+	"time"
+)
+
+type (
+	// FreeVendTransaction is a free vend transaction that is not yet reported to the central system, which is
+	// restored after a restart.
+	FreeVendTransaction struct {
+		LocalTransactionId string    `json:"localTransactionId"`
+		ConnectorId        int       `json:"connectorId"`
+		IdTag              string    `json:"idTag"`
+		Started            time.Time `json:"started"`
+		ReservationId      *int      `json:"reservationId,omitempty"`
+		// TransactionId is assigned by the central system when it confirms the transaction
+		TransactionId *int `json:"transactionId,omitempty"`
+		// StopRequest is sent when the transaction is stopped before the central system confirmed it
+		StopRequest *core.StopTransactionRequest `json:"stopRequest,omitempty"`
+	}
+
+	FreeVendRepository interface {
+		SaveFreeVendTransaction(transaction FreeVendTransaction) error
+		DeleteFreeVendTransaction(localTransactionId string) error
+		GetFreeVendTransactions() ([]FreeVendTransaction, error)
+	}
+)
+
+func freeVendTransactionKey(localTransactionId string) string {
+	return fmt.Sprintf("freeVend%s", localTransactionId)
+}
+
+// SaveFreeVendTransaction adds or replaces the free vend transaction.
+func (s *Store) SaveFreeVendTransaction(transaction FreeVendTransaction) error {
+	return s.put(freeVendBucket, freeVendTransactionKey(transaction.LocalTransactionId), transaction)
+}
+
+// DeleteFreeVendTransaction removes the free vend transaction.
+func (s *Store) DeleteFreeVendTransaction(localTransactionId string) error {
+	return s.delete(freeVendBucket, freeVendTransactionKey(localTransactionId))
+}
+
+// GetFreeVendTransactions returns all the stored free vend transactions.
+func (s *Store) GetFreeVendTransactions() ([]FreeVendTransaction, error) {
+	transactions := []FreeVendTransaction{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(freeVendBucket)).ForEach(func(key, value []byte) error {
+			var transaction FreeVendTransaction
+
+			err := json.Unmarshal(value, &transaction)
+			if err != nil {
+				return err
+			}
+
+			transactions = append(transactions, transaction)
+			return nil
+		})
+	})
+
+	return transactions, err
+}
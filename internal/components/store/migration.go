@@ -0,0 +1,111 @@
+package store
+
+import (
+	"encoding/json"
+	"github.com/agrison/go-commons-lang/stringUtils"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	bolt "go.etcd.io/bbolt"
+	"io/ioutil"
+	"os"
+)
+
+// currentMigrationVersion is increased every time a new migration is added.
+const currentMigrationVersion = 1
+
+// Migrate imports the connector states and the authorization tags from the files, which were used before the store was
+// introduced. The migration is done in a single transaction and runs only once. The OCPP configuration is imported
+// by the OcppConfigurationManager.
+func (s *Store) Migrate(connectors []*settings.Connector, authFilePath string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		var migrationVersion int
+
+		err := getValue(tx, metaBucket, migrationVersionKey, &migrationVersion)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+
+		if migrationVersion >= currentMigrationVersion {
+			return nil
+		}
+
+		log.Info("Migrating the connector states and the authorization tags to the store")
+
+		err = migrateConnectors(tx, connectors)
+		if err != nil {
+			return err
+		}
+
+		err = migrateAuthFile(tx, authFilePath)
+		if err != nil {
+			return err
+		}
+
+		return putValue(tx, metaBucket, migrationVersionKey, currentMigrationVersion)
+	})
+}
+
+func migrateConnectors(tx *bolt.Tx, connectors []*settings.Connector) error {
+	for _, connector := range connectors {
+		var (
+			key   = connectorKey(connector.EvseId, connector.ConnectorId)
+			state ConnectorState
+		)
+
+		// Do not overwrite the state, which was stored already
+		err := getValue(tx, connectorsBucket, key, &state)
+		if err != ErrNotFound {
+			continue
+		}
+
+		err = putValue(tx, connectorsBucket, key, ConnectorState{
+			EvseId:      connector.EvseId,
+			ConnectorId: connector.ConnectorId,
+			Status:      core.ChargePointStatus(connector.Status),
+			Session:     connector.Session,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func migrateAuthFile(tx *bolt.Tx, authFilePath string) error {
+	if stringUtils.IsEmpty(authFilePath) {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(authFilePath)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	var auth settings.AuthorizationFile
+	err = json.Unmarshal(content, &auth)
+	if err != nil {
+		// The file is not valid, so there is nothing to migrate
+		log.WithError(err).Warnf("Unable to migrate the authorization file %s", authFilePath)
+		return nil
+	}
+
+	err = putValue(tx, authBucket, authInfoKey, AuthInfo{Version: auth.Version, MaxCachedTags: auth.MaxCachedTags})
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range auth.Tags {
+		// The tags were stored by the parent id tag
+		err = putValue(tx, authTagsBucket, tag.ParentIdTag, tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	"github.com/xBlaz3kx/ocppManager-go/manager"
+	bolt "go.etcd.io/bbolt"
+	"io/ioutil"
+)
+
+const (
+	ocppConfigurationKey = "configuration"
+	ocppChecksumKey      = "fileChecksum"
+)
+
+type (
+	OcppConfigurationRepository interface {
+		GetOcppConfiguration() (*configuration.Config, string, error)
+		SaveOcppConfiguration(config configuration.Config, fileChecksum string) error
+	}
+
+	// OcppConfigurationManager keeps the OCPP configuration in the store instead of the configuration file.
+	// The configuration file is only imported if it was changed since the last import.
+	OcppConfigurationManager struct {
+		manager.Manager
+		repository OcppConfigurationRepository
+		filePath   string
+	}
+)
+
+// GetOcppConfiguration returns the stored OCPP configuration and the checksum of the file it was imported from.
+func (s *Store) GetOcppConfiguration() (*configuration.Config, string, error) {
+	var (
+		config   configuration.Config
+		checksum string
+	)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		err := getValue(tx, ocppConfigurationBucket, ocppConfigurationKey, &config)
+		if err != nil {
+			return err
+		}
+
+		return getValue(tx, ocppConfigurationBucket, ocppChecksumKey, &checksum)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &config, checksum, nil
+}
+
+// SaveOcppConfiguration stores the OCPP configuration and the checksum of the file it was imported from.
+func (s *Store) SaveOcppConfiguration(config configuration.Config, fileChecksum string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := putValue(tx, ocppConfigurationBucket, ocppConfigurationKey, config)
+		if err != nil {
+			return err
+		}
+
+		return putValue(tx, ocppConfigurationBucket, ocppChecksumKey, fileChecksum)
+	})
+}
+
+// NewOcppConfigurationManager wraps the OCPP configuration manager so that the configuration is persisted in the store.
+func NewOcppConfigurationManager(configurationManager manager.Manager, repository OcppConfigurationRepository, filePath string) *OcppConfigurationManager {
+	return &OcppConfigurationManager{
+		Manager:    configurationManager,
+		repository: repository,
+		filePath:   filePath,
+	}
+}
+
+// LoadConfiguration loads the configuration from the store. If the configuration file was changed since the
+// last import or the store is empty, the file is imported into the store.
+func (m *OcppConfigurationManager) LoadConfiguration() error {
+	storedConfig, storedChecksum, storeErr := m.repository.GetOcppConfiguration()
+	if storeErr != nil && storeErr != ErrNotFound {
+		return storeErr
+	}
+
+	content, err := ioutil.ReadFile(m.filePath)
+	switch {
+	case err != nil && storeErr == nil:
+		log.WithError(err).Warn("Unable to read the OCPP configuration file, using the stored configuration")
+		return m.Manager.SetConfiguration(*storedConfig)
+	case err != nil:
+		return err
+	}
+
+	checksum := fileChecksum(content)
+	if storeErr == nil && checksum == storedChecksum {
+		return m.Manager.SetConfiguration(*storedConfig)
+	}
+
+	log.Infof("Importing the OCPP configuration from %s", m.filePath)
+
+	var config configuration.Config
+	err = json.Unmarshal(content, &config)
+	if err != nil {
+		return err
+	}
+
+	err = m.Manager.SetConfiguration(config)
+	if err != nil {
+		return err
+	}
+
+	return m.repository.SaveOcppConfiguration(config, checksum)
+}
+
+// UpdateConfigurationFile persists the current configuration in the store. The configuration file is left as is.
+func (m *OcppConfigurationManager) UpdateConfigurationFile() error {
+	keys, err := m.Manager.GetConfiguration()
+	if err != nil {
+		return err
+	}
+
+	var (
+		version                        = 1
+		storedConfig, checksum, getErr = m.repository.GetOcppConfiguration()
+	)
+
+	switch getErr {
+	case nil:
+		version = storedConfig.Version
+	case ErrNotFound:
+	default:
+		return getErr
+	}
+
+	return m.repository.SaveOcppConfiguration(configuration.Config{Version: version, Keys: keys}, checksum)
+}
+
+func fileChecksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	bolt "go.etcd.io/bbolt"
+	"time"
+)
+
+type (
+	// Reservation is a reservation of a connector, which is restored after a restart.
+	Reservation struct {
+		ReservationId int       `json:"reservationId"`
+		EvseId        int       `json:"evseId"`
+		ConnectorId   int       `json:"connectorId"`
+		IdTag         string    `json:"idTag"`
+		ParentIdTag   string    `json:"parentIdTag,omitempty"`
+		ExpiryDate    time.Time `json:"expiryDate"`
+	}
+
+	ReservationRepository interface {
+		SaveReservation(reservation Reservation) error
+		DeleteReservation(reservationId int) error
+		GetReservations() ([]Reservation, error)
+	}
+)
+
+func reservationKey(reservationId int) string {
+	return fmt.Sprintf("reservation%d", reservationId)
+}
+
+// SaveReservation adds or replaces the reservation.
+func (s *Store) SaveReservation(reservation Reservation) error {
+	return s.put(reservationsBucket, reservationKey(reservation.ReservationId), reservation)
+}
+
+// DeleteReservation removes the reservation.
+func (s *Store) DeleteReservation(reservationId int) error {
+	return s.delete(reservationsBucket, reservationKey(reservationId))
+}
+
+// GetReservations returns all the stored reservations.
+func (s *Store) GetReservations() ([]Reservation, error) {
+	reservations := []Reservation{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(reservationsBucket)).ForEach(func(key, value []byte) error {
+			var reservation Reservation
+
+			err := json.Unmarshal(value, &reservation)
+			if err != nil {
+				return err
+			}
+
+			reservations = append(reservations, reservation)
+			return nil
+		})
+	})
+
+	return reservations, err
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
+	bolt "go.etcd.io/bbolt"
+)
+
+// MaxSessionHistory is the number of receipts kept in the session history. The oldest receipts are removed first.
+const MaxSessionHistory = 1000
+
+type SessionRepository interface {
+	SaveReceipt(receipt session.Receipt) error
+	GetReceipt(transactionId string) (*session.Receipt, error)
+	GetReceipts() ([]session.Receipt, error)
+}
+
+// receiptKey orders the receipts by the time the session stopped.
+func receiptKey(receipt session.Receipt) string {
+	return fmt.Sprintf("%020d-%s", receipt.Stopped.UnixNano(), receipt.TransactionId)
+}
+
+// SaveReceipt adds the receipt of a finished session to the session history.
+func (s *Store) SaveReceipt(receipt session.Receipt) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := putValue(tx, sessionsBucket, receiptKey(receipt), receipt)
+		if err != nil {
+			return err
+		}
+
+		var (
+			bucket   = tx.Bucket([]byte(sessionsBucket))
+			cursor   = bucket.Cursor()
+			receipts = 0
+		)
+
+		for key, _ := cursor.First(); key != nil; key, _ = cursor.Next() {
+			receipts++
+		}
+
+		// Remove the oldest receipts
+		for key, _ := cursor.First(); key != nil && receipts > MaxSessionHistory; key, _ = cursor.First() {
+			err = cursor.Delete()
+			if err != nil {
+				return err
+			}
+
+			receipts--
+		}
+
+		return nil
+	})
+}
+
+// GetReceipt returns the receipt of the transaction or ErrNotFound.
+func (s *Store) GetReceipt(transactionId string) (*session.Receipt, error) {
+	receipts, err := s.GetReceipts()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, receipt := range receipts {
+		if receipt.TransactionId == transactionId {
+			return &receipt, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// GetReceipts returns the session history, the most recent session first.
+func (s *Store) GetReceipts() ([]session.Receipt, error) {
+	receipts := []session.Receipt{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket([]byte(sessionsBucket)).Cursor()
+
+		for key, value := cursor.Last(); key != nil; key, value = cursor.Prev() {
+			var receipt session.Receipt
+
+			err := json.Unmarshal(value, &receipt)
+			if err != nil {
+				return err
+			}
+
+			receipts = append(receipts, receipt)
+		}
+
+		return nil
+	})
+
+	return receipts, err
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	connectorsBucket        = "connectors"
+	authBucket              = "auth"
+	authTagsBucket          = "authTags"
+	ocppConfigurationBucket = "ocppConfiguration"
+	metaBucket              = "meta"
+	sessionsBucket          = "sessions"
+	chargePointBucket       = "chargePoint"
+	reservationsBucket      = "reservations"
+	freeVendBucket          = "freeVend"
+
+	migrationVersionKey = "migrationVersion"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+
+	buckets = []string{connectorsBucket, authBucket, authTagsBucket, ocppConfigurationBucket, metaBucket, sessionsBucket, chargePointBucket, reservationsBucket, freeVendBucket}
+)
+
+// Store is an embedded key-value store for the charge point state. Every write is a transaction, which is either
+// fully committed to the disk or not at all, so the state cannot be corrupted by a power loss.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the store at the file path.
+func Open(filePath string) (*Store, error) {
+	log.Debugf("Opening the store at %s", filePath)
+
+	err := os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(filePath, 0600, &bolt.Options{Timeout: time.Second * 5})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range buckets {
+			_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the store.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) get(bucket, key string, value interface{}) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return getValue(tx, bucket, key, value)
+	})
+}
+
+func (s *Store) put(bucket, key string, value interface{}) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putValue(tx, bucket, key, value)
+	})
+}
+
+func (s *Store) delete(bucket, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(bucket)).Delete([]byte(key))
+	})
+}
+
+func getValue(tx *bolt.Tx, bucket, key string, value interface{}) error {
+	data := tx.Bucket([]byte(bucket)).Get([]byte(key))
+	if data == nil {
+		return ErrNotFound
+	}
+
+	return json.Unmarshal(data, value)
+}
+
+func putValue(tx *bolt.Tx, bucket, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket([]byte(bucket)).Put([]byte(key), data)
+}
@@ -0,0 +1,350 @@
+package store
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ocppManager-go/manager"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const ocppConfiguration = `{"version": 2, "keys": [{"key": "HeartbeatInterval", "readOnly": false, "value": "60"}]}`
+
+type StoreTestSuite struct {
+	suite.Suite
+	store   *Store
+	tempDir string
+}
+
+func (s *StoreTestSuite) SetupTest() {
+	tempDir, err := ioutil.TempDir("", "chargepi")
+	s.Require().NoError(err)
+	s.tempDir = tempDir
+
+	s.store, err = Open(filepath.Join(tempDir, "state", "chargepi.db"))
+	s.Require().NoError(err)
+}
+
+func (s *StoreTestSuite) TearDownTest() {
+	_ = s.store.Close()
+	_ = os.RemoveAll(s.tempDir)
+}
+
+func (s *StoreTestSuite) TestConnectorState() {
+	session := settings.Session{IsActive: true, TransactionId: "1234", TagId: "tag"}
+
+	_, err := s.store.GetConnectorState(1, 1)
+	s.Assert().ErrorIs(err, ErrNotFound)
+
+	s.Require().NoError(s.store.UpdateConnectorStatus(1, 1, core.ChargePointStatusCharging))
+	s.Require().NoError(s.store.UpdateConnectorSession(1, 1, session))
+
+	state, err := s.store.GetConnectorState(1, 1)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ConnectorState{
+		EvseId:      1,
+		ConnectorId: 1,
+		Status:      core.ChargePointStatusCharging,
+		Session:     session,
+	}, *state)
+
+	s.Require().NoError(s.store.DeleteConnectorState(1, 1))
+	_, err = s.store.GetConnectorState(1, 1)
+	s.Assert().ErrorIs(err, ErrNotFound)
+}
+
+func (s *StoreTestSuite) TestAuth() {
+	var (
+		expiryDate = types.NewDateTime(time.Now().Add(time.Hour).Truncate(time.Second))
+		tag        = types.IdTagInfo{Status: types.AuthorizationStatusAccepted, ExpiryDate: expiryDate}
+	)
+
+	_, err := s.store.GetAuthInfo()
+	s.Assert().ErrorIs(err, ErrNotFound)
+
+	s.Require().NoError(s.store.SetAuthInfo(AuthInfo{Version: 2, MaxCachedTags: 10}))
+	info, err := s.store.GetAuthInfo()
+	s.Require().NoError(err)
+	s.Assert().EqualValues(AuthInfo{Version: 2, MaxCachedTags: 10}, *info)
+
+	s.Require().NoError(s.store.SaveTag("tag1", tag))
+	s.Require().NoError(s.store.SaveTag("tag2", tag))
+	s.Require().NoError(s.store.DeleteTag("tag2"))
+
+	tags, err := s.store.GetTags()
+	s.Require().NoError(err)
+	s.Assert().Len(tags, 1)
+	s.Assert().True(tags["tag1"].ExpiryDate.Equal(expiryDate.Time))
+
+	s.Require().NoError(s.store.ReplaceTags(map[string]types.IdTagInfo{"tag3": tag}))
+	tags, err = s.store.GetTags()
+	s.Require().NoError(err)
+	s.Assert().Len(tags, 1)
+	s.Assert().Contains(tags, "tag3")
+}
+
+func (s *StoreTestSuite) TestOcppConfigurationManager() {
+	var (
+		filePath             = filepath.Join(s.tempDir, "configuration.json")
+		configurationManager = NewOcppConfigurationManager(manager.NewManager(), s.store, filePath)
+	)
+
+	// The store is empty and the file doesn't exist
+	s.Assert().Error(configurationManager.LoadConfiguration())
+
+	// The file is imported
+	s.Require().NoError(ioutil.WriteFile(filePath, []byte(ocppConfiguration), 0644))
+	s.Require().NoError(configurationManager.LoadConfiguration())
+
+	value, err := configurationManager.GetConfigurationValue("HeartbeatInterval")
+	s.Require().NoError(err)
+	s.Assert().EqualValues("60", value)
+
+	// The updated configuration is kept in the store, the file is not changed
+	s.Require().NoError(configurationManager.UpdateKey("HeartbeatInterval", "120"))
+	s.Require().NoError(configurationManager.UpdateConfigurationFile())
+
+	content, err := ioutil.ReadFile(filePath)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(ocppConfiguration, string(content))
+
+	config, _, err := s.store.GetOcppConfiguration()
+	s.Require().NoError(err)
+	s.Assert().EqualValues(2, config.Version)
+
+	// The unchanged file is not imported again
+	configurationManager = NewOcppConfigurationManager(manager.NewManager(), s.store, filePath)
+	s.Require().NoError(configurationManager.LoadConfiguration())
+
+	value, err = configurationManager.GetConfigurationValue("HeartbeatInterval")
+	s.Require().NoError(err)
+	s.Assert().EqualValues("120", value)
+
+	// The changed file is imported
+	s.Require().NoError(ioutil.WriteFile(filePath, []byte(`{"version": 3, "keys": [{"key": "HeartbeatInterval", "value": "30"}]}`), 0644))
+	s.Require().NoError(configurationManager.LoadConfiguration())
+
+	value, err = configurationManager.GetConfigurationValue("HeartbeatInterval")
+	s.Require().NoError(err)
+	s.Assert().EqualValues("30", value)
+
+	// The stored configuration is used if the file is removed
+	s.Require().NoError(os.Remove(filePath))
+	s.Require().NoError(configurationManager.LoadConfiguration())
+
+	// The wrapped manager can replace the global manager
+	var globalManager manager.Manager = configurationManager
+	s.Assert().NotNil(globalManager)
+}
+
+func (s *StoreTestSuite) TestMigrate() {
+	var (
+		authFilePath = filepath.Join(s.tempDir, "auth.json")
+		connectors   = []*settings.Connector{
+			{EvseId: 1, ConnectorId: 1, Status: "Charging", Session: settings.Session{IsActive: true, TransactionId: "1234"}},
+			{EvseId: 1, ConnectorId: 2, Status: "Available"},
+		}
+	)
+
+	s.Require().NoError(ioutil.WriteFile(authFilePath, []byte(`{"version": 3, "MaxCachedTags": 5, "tags": [{"parentIdTag": "tag1", "status": "Accepted"}]}`), 0644))
+
+	// The state which was already stored is not overwritten
+	s.Require().NoError(s.store.UpdateConnectorStatus(1, 2, core.ChargePointStatusFaulted))
+
+	s.Require().NoError(s.store.Migrate(connectors, authFilePath))
+
+	state, err := s.store.GetConnectorState(1, 1)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.ChargePointStatusCharging, state.Status)
+	s.Assert().EqualValues("1234", state.Session.TransactionId)
+
+	state, err = s.store.GetConnectorState(1, 2)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.ChargePointStatusFaulted, state.Status)
+
+	info, err := s.store.GetAuthInfo()
+	s.Require().NoError(err)
+	s.Assert().EqualValues(AuthInfo{Version: 3, MaxCachedTags: 5}, *info)
+
+	tags, err := s.store.GetTags()
+	s.Require().NoError(err)
+	s.Assert().Contains(tags, "tag1")
+
+	// The migration runs only once
+	s.Require().NoError(s.store.UpdateConnectorStatus(1, 1, core.ChargePointStatusAvailable))
+	s.Require().NoError(s.store.Migrate(connectors, authFilePath))
+
+	state, err = s.store.GetConnectorState(1, 1)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.ChargePointStatusAvailable, state.Status)
+}
+
+func (s *StoreTestSuite) TestReopen() {
+	s.Require().NoError(s.store.UpdateConnectorStatus(1, 1, core.ChargePointStatusCharging))
+	s.Require().NoError(s.store.Close())
+
+	var err error
+	s.store, err = Open(filepath.Join(s.tempDir, "state", "chargepi.db"))
+	s.Require().NoError(err)
+
+	state, err := s.store.GetConnectorState(1, 1)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.ChargePointStatusCharging, state.Status)
+}
+
+func (s *StoreTestSuite) TestChargePointState() {
+	_, err := s.store.GetChargePointState()
+	s.Assert().ErrorIs(err, ErrNotFound)
+
+	s.Require().NoError(s.store.UpdateAvailability(core.AvailabilityTypeInoperative))
+
+	state, err := s.store.GetChargePointState()
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.AvailabilityTypeInoperative, state.Availability)
+
+	s.Require().NoError(s.store.UpdateAvailability(core.AvailabilityTypeOperative))
+
+	state, err = s.store.GetChargePointState()
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.AvailabilityTypeOperative, state.Availability)
+
+	// Connector availability
+	s.Require().NoError(s.store.UpdateConnectorAvailability(1, core.AvailabilityTypeInoperative))
+	s.Require().NoError(s.store.UpdateConnectorAvailability(2, core.AvailabilityTypeInoperative))
+	s.Require().NoError(s.store.UpdateConnectorAvailability(1, core.AvailabilityTypeInoperative))
+
+	state, err = s.store.GetChargePointState()
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.AvailabilityTypeOperative, state.Availability)
+	s.Assert().ElementsMatch([]int{1, 2}, state.InoperativeConnectors)
+
+	s.Require().NoError(s.store.UpdateConnectorAvailability(1, core.AvailabilityTypeOperative))
+
+	state, err = s.store.GetChargePointState()
+	s.Require().NoError(err)
+	s.Assert().EqualValues([]int{2}, state.InoperativeConnectors)
+}
+
+func (s *StoreTestSuite) TestReservations() {
+	var (
+		expiryDate = time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+		first      = Reservation{ReservationId: 1, EvseId: 1, ConnectorId: 1, IdTag: "tag", ExpiryDate: expiryDate}
+		second     = Reservation{ReservationId: 2, EvseId: 1, ConnectorId: 2, IdTag: "tag2", ParentIdTag: "parent", ExpiryDate: expiryDate}
+	)
+
+	reservations, err := s.store.GetReservations()
+	s.Require().NoError(err)
+	s.Assert().Len(reservations, 0)
+
+	s.Require().NoError(s.store.SaveReservation(first))
+	s.Require().NoError(s.store.SaveReservation(second))
+
+	reservations, err = s.store.GetReservations()
+	s.Require().NoError(err)
+	s.Assert().ElementsMatch([]Reservation{first, second}, reservations)
+
+	// Replace the reservation
+	first.IdTag = "tag3"
+	s.Require().NoError(s.store.SaveReservation(first))
+	s.Require().NoError(s.store.DeleteReservation(second.ReservationId))
+
+	reservations, err = s.store.GetReservations()
+	s.Require().NoError(err)
+	s.Assert().Equal([]Reservation{first}, reservations)
+}
+
+func (s *StoreTestSuite) TestFreeVendTransactions() {
+	var (
+		started       = time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+		transactionId = 42
+		stopRequest   = core.NewStopTransactionRequest(1500, types.NewDateTime(started.Add(time.Hour)), 0)
+		first         = FreeVendTransaction{LocalTransactionId: "FreeVend1", ConnectorId: 1, IdTag: "FreeVend", Started: started}
+		second        = FreeVendTransaction{LocalTransactionId: "FreeVend2", ConnectorId: 2, IdTag: "FreeVend", Started: started}
+	)
+	stopRequest.Reason = core.ReasonEVDisconnected
+
+	transactions, err := s.store.GetFreeVendTransactions()
+	s.Require().NoError(err)
+	s.Assert().Empty(transactions)
+
+	s.Require().NoError(s.store.SaveFreeVendTransaction(first))
+	s.Require().NoError(s.store.SaveFreeVendTransaction(second))
+
+	// The central system confirmed the first transaction and the second one was stopped
+	first.TransactionId = &transactionId
+	second.StopRequest = stopRequest
+	s.Require().NoError(s.store.SaveFreeVendTransaction(first))
+	s.Require().NoError(s.store.SaveFreeVendTransaction(second))
+
+	transactions, err = s.store.GetFreeVendTransactions()
+	s.Require().NoError(err)
+	s.Require().Len(transactions, 2)
+	s.Assert().EqualValues(42, *transactions[0].TransactionId)
+	s.Require().NotNil(transactions[1].StopRequest)
+	s.Assert().EqualValues(1500, transactions[1].StopRequest.MeterStop)
+	s.Assert().EqualValues(core.ReasonEVDisconnected, transactions[1].StopRequest.Reason)
+	s.Assert().True(transactions[1].StopRequest.Timestamp.Equal(started.Add(time.Hour)))
+
+	s.Require().NoError(s.store.DeleteFreeVendTransaction(first.LocalTransactionId))
+
+	transactions, err = s.store.GetFreeVendTransactions()
+	s.Require().NoError(err)
+	s.Require().Len(transactions, 1)
+	s.Assert().EqualValues("FreeVend2", transactions[0].LocalTransactionId)
+}
+
+func (s *StoreTestSuite) TestSessionHistory() {
+	var (
+		stopped = time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+		first   = session.Receipt{TransactionId: "1", TagId: "tag", Started: stopped.Add(-time.Hour), Stopped: stopped}
+		second  = session.Receipt{TransactionId: "2", TagId: "tag", Started: stopped, Stopped: stopped.Add(time.Hour)}
+	)
+
+	receipts, err := s.store.GetReceipts()
+	s.Require().NoError(err)
+	s.Assert().Empty(receipts)
+
+	_, err = s.store.GetReceipt("1")
+	s.Assert().ErrorIs(err, ErrNotFound)
+
+	s.Require().NoError(s.store.SaveReceipt(second))
+	s.Require().NoError(s.store.SaveReceipt(first))
+
+	receipt, err := s.store.GetReceipt("1")
+	s.Require().NoError(err)
+	s.Assert().EqualValues("tag", receipt.TagId)
+	s.Assert().True(receipt.Stopped.Equal(stopped))
+
+	// The most recent session is first
+	receipts, err = s.store.GetReceipts()
+	s.Require().NoError(err)
+	s.Require().Len(receipts, 2)
+	s.Assert().EqualValues("2", receipts[0].TransactionId)
+	s.Assert().EqualValues("1", receipts[1].TransactionId)
+
+	// The oldest sessions are removed
+	for i := 0; i < MaxSessionHistory; i++ {
+		s.Require().NoError(s.store.SaveReceipt(session.Receipt{
+			TransactionId: strconv.Itoa(i + 3),
+			Stopped:       stopped.Add(time.Duration(i+2) * time.Hour),
+		}))
+	}
+
+	receipts, err = s.store.GetReceipts()
+	s.Require().NoError(err)
+	s.Assert().Len(receipts, MaxSessionHistory)
+
+	_, err = s.store.GetReceipt("2")
+	s.Assert().ErrorIs(err, ErrNotFound)
+}
+
+func TestStore(t *testing.T) {
+	suite.Run(t, new(StoreTestSuite))
+}
@@ -0,0 +1,180 @@
+package tariff
+
+import (
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
+	"math"
+	"sync"
+	"time"
+)
+
+type (
+	// Sample is a meter reading of the connector.
+	Sample struct {
+		Time time.Time
+		// Energy is the register of the meter in Wh
+		Energy float64
+		// Power in W
+		Power float64
+	}
+
+	// Session calculates the running cost of a charging session from the meter readings. The prices of an interval
+	// between two readings are the prices at the start of the interval.
+	Session struct {
+		mu       sync.Mutex
+		tariff   *Tariff
+		minPower float64
+		started  time.Time
+		last     Sample
+		// energy in Wh consumed since the session started
+		energy     float64
+		hasCharged bool
+		// idleSince is set when the power drops below the minimum power after the EV has charged
+		idleSince *time.Time
+		idleTime  time.Duration
+		cost      session.Cost
+		// totalCost is reported by the central system and replaces the calculated total
+		totalCost *float64
+	}
+)
+
+// NewSession starts calculating the cost of the session from the first meter reading. After the EV has charged, the
+// power below the minimum power is considered idle. A nil tariff is free.
+func NewSession(tariff *Tariff, minPower float64, first Sample) *Session {
+	if tariff == nil {
+		tariff = &Tariff{}
+	}
+
+	return &Session{
+		tariff:   tariff,
+		minPower: minPower,
+		started:  first.Time,
+		last:     first,
+		cost:     session.Cost{SessionFee: tariff.sessionFee},
+	}
+}
+
+// SetEnergy sets the energy in Wh the session consumed before the first meter reading, e.g. before a restart. The
+// energy is charged at the price at the start of the session.
+func (s *Session) SetEnergy(energy float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	energyPrice, _ := s.tariff.prices(s.started)
+	s.energy = energy
+	s.cost.Energy = energy / 1000 * energyPrice
+	s.hasCharged = s.hasCharged || energy > 0
+}
+
+// GetTariff returns the tariff of the session.
+func (s *Session) GetTariff() *Tariff {
+	return s.tariff
+}
+
+// Update adds the meter reading to the session. Readings which are not newer than the last reading are ignored.
+func (s *Session) Update(sample Sample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !sample.Time.After(s.last.Time) {
+		return
+	}
+
+	var (
+		energyPrice, timePrice = s.tariff.prices(s.last.Time)
+		elapsed                = sample.Time.Sub(s.last.Time)
+		energy                 = sample.Energy - s.last.Energy
+	)
+
+	// The meter was reset and started counting from zero
+	if energy < 0 {
+		energy = sample.Energy
+	}
+
+	s.energy += energy
+	s.cost.Energy += energy / 1000 * energyPrice
+	s.cost.Time += elapsed.Minutes() * timePrice
+
+	switch {
+	case sample.Power > 0 && sample.Power >= s.minPower:
+		s.hasCharged = true
+		s.idleSince = nil
+	case s.hasCharged:
+		if s.idleSince == nil {
+			idleSince := s.last.Time
+			s.idleSince = &idleSince
+		}
+
+		s.idleTime += elapsed
+
+		// Only the idle time after the grace period is charged
+		chargedFrom := s.idleSince.Add(s.tariff.idleGracePeriod)
+		if chargedFrom.Before(s.last.Time) {
+			chargedFrom = s.last.Time
+		}
+
+		if sample.Time.After(chargedFrom) {
+			s.cost.Idle += sample.Time.Sub(chargedFrom).Minutes() * s.tariff.idleFee
+		}
+	}
+
+	s.last = sample
+}
+
+// SetTotalCost sets the total cost reported by the central system, which replaces the calculated total.
+func (s *Session) SetTotalCost(totalCost float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.totalCost = &totalCost
+}
+
+// GetEnergy returns the energy in Wh consumed since the session started.
+func (s *Session) GetEnergy() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.energy
+}
+
+// GetCost returns the running cost of the session, rounded to cents.
+func (s *Session) GetCost() session.Cost {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getCost()
+}
+
+func (s *Session) getCost() session.Cost {
+	cost := session.Cost{
+		SessionFee: round(s.cost.SessionFee),
+		Energy:     round(s.cost.Energy),
+		Time:       round(s.cost.Time),
+		Idle:       round(s.cost.Idle),
+	}
+
+	cost.Total = round(cost.SessionFee + cost.Energy + cost.Time + cost.Idle)
+	if s.totalCost != nil {
+		cost.Total = round(*s.totalCost)
+	}
+
+	return cost
+}
+
+// Receipt creates the receipt of the session, which stopped at the last meter reading.
+func (s *Session) Receipt() session.Receipt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return session.Receipt{
+		Started:                 s.started,
+		Stopped:                 s.last.Time,
+		Energy:                  math.Round(s.energy),
+		Duration:                int(s.last.Time.Sub(s.started).Seconds()),
+		IdleTime:                int(s.idleTime.Seconds()),
+		Currency:                s.tariff.currency,
+		Cost:                    s.getCost(),
+		IsCostFromCentralSystem: s.totalCost != nil,
+	}
+}
+
+// round rounds the amount to cents.
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,142 @@
+package tariff
+
+import (
+	"errors"
+	"fmt"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"time"
+)
+
+// timeOfDayLayout is the layout of the start and the end of the tariff bands.
+const timeOfDayLayout = "15:04"
+
+var ErrInvalidTariff = errors.New("invalid tariff")
+
+type (
+	// band replaces the prices of the tariff between the start and the end, which are offsets from midnight.
+	band struct {
+		start       time.Duration
+		end         time.Duration
+		energyPrice float64
+		timePrice   float64
+	}
+
+	// Tariff contains the prices of a charging session.
+	Tariff struct {
+		currency        string
+		energyPrice     float64
+		timePrice       float64
+		sessionFee      float64
+		idleFee         float64
+		idleGracePeriod time.Duration
+		bands           []band
+	}
+)
+
+// NewTariff validates the tariff settings and creates the tariff. If the bands overlap, the first band applies.
+func NewTariff(tariff settings.Tariff) (*Tariff, error) {
+	if tariff.EnergyPrice < 0 || tariff.TimePrice < 0 || tariff.SessionFee < 0 || tariff.IdleFee < 0 {
+		return nil, fmt.Errorf("%w: prices must not be negative", ErrInvalidTariff)
+	}
+
+	if tariff.IdleGracePeriod < 0 {
+		return nil, fmt.Errorf("%w: idle grace period must not be negative", ErrInvalidTariff)
+	}
+
+	t := &Tariff{
+		currency:        tariff.Currency,
+		energyPrice:     tariff.EnergyPrice,
+		timePrice:       tariff.TimePrice,
+		sessionFee:      tariff.SessionFee,
+		idleFee:         tariff.IdleFee,
+		idleGracePeriod: time.Duration(tariff.IdleGracePeriod) * time.Minute,
+	}
+
+	for _, tariffBand := range tariff.Bands {
+		b, err := newBand(tariffBand)
+		if err != nil {
+			return nil, err
+		}
+
+		t.bands = append(t.bands, *b)
+	}
+
+	return t, nil
+}
+
+func newBand(tariffBand settings.TariffBand) (*band, error) {
+	start, err := parseTimeOfDay(tariffBand.Start)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := parseTimeOfDay(tariffBand.End)
+	if err != nil {
+		return nil, err
+	}
+
+	if start == end {
+		return nil, fmt.Errorf("%w: band %s-%s is empty", ErrInvalidTariff, tariffBand.Start, tariffBand.End)
+	}
+
+	if tariffBand.EnergyPrice < 0 || tariffBand.TimePrice < 0 {
+		return nil, fmt.Errorf("%w: prices must not be negative", ErrInvalidTariff)
+	}
+
+	return &band{
+		start:       start,
+		end:         end,
+		energyPrice: tariffBand.EnergyPrice,
+		timePrice:   tariffBand.TimePrice,
+	}, nil
+}
+
+// parseTimeOfDay parses the time of the day as an offset from midnight.
+func parseTimeOfDay(value string) (time.Duration, error) {
+	parsed, err := time.Parse(timeOfDayLayout, value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid time of day %s", ErrInvalidTariff, value)
+	}
+
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
+
+// GetCurrency returns the currency of the prices.
+func (t *Tariff) GetCurrency() string {
+	return t.currency
+}
+
+// IsFree returns true if the tariff has no prices.
+func (t *Tariff) IsFree() bool {
+	for _, b := range t.bands {
+		if b.energyPrice > 0 || b.timePrice > 0 {
+			return false
+		}
+	}
+
+	return t.energyPrice == 0 && t.timePrice == 0 && t.sessionFee == 0 && t.idleFee == 0
+}
+
+// prices returns the energy and the time price at the local time.
+func (t *Tariff) prices(at time.Time) (energyPrice float64, timePrice float64) {
+	timeOfDay := time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute +
+		time.Duration(at.Second())*time.Second
+
+	for _, b := range t.bands {
+		if b.contains(timeOfDay) {
+			return b.energyPrice, b.timePrice
+		}
+	}
+
+	return t.energyPrice, t.timePrice
+}
+
+// contains checks if the time of the day is within the band.
+func (b band) contains(timeOfDay time.Duration) bool {
+	if b.start < b.end {
+		return timeOfDay >= b.start && timeOfDay < b.end
+	}
+
+	// The band ends on the next day
+	return timeOfDay >= b.start || timeOfDay < b.end
+}
@@ -0,0 +1,159 @@
+package tariff
+
+import (
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"testing"
+	"time"
+)
+
+type TariffTestSuite struct {
+	suite.Suite
+	settings settings.Tariff
+}
+
+func (s *TariffTestSuite) SetupTest() {
+	s.settings = settings.Tariff{
+		Currency:        "EUR",
+		EnergyPrice:     0.3,
+		TimePrice:       0.05,
+		SessionFee:      1,
+		IdleFee:         0.2,
+		IdleGracePeriod: 10,
+		Bands: []settings.TariffBand{
+			{Start: "22:00", End: "06:00", EnergyPrice: 0.2},
+		},
+	}
+}
+
+func (s *TariffTestSuite) TestNewTariff() {
+	tariff, err := NewTariff(s.settings)
+	s.Require().NoError(err)
+	s.Assert().EqualValues("EUR", tariff.GetCurrency())
+	s.Assert().False(tariff.IsFree())
+
+	tariff, err = NewTariff(settings.Tariff{})
+	s.Require().NoError(err)
+	s.Assert().True(tariff.IsFree())
+
+	_, err = NewTariff(settings.Tariff{EnergyPrice: -1})
+	s.Assert().ErrorIs(err, ErrInvalidTariff)
+
+	_, err = NewTariff(settings.Tariff{Bands: []settings.TariffBand{{Start: "25:00", End: "06:00"}}})
+	s.Assert().ErrorIs(err, ErrInvalidTariff)
+
+	_, err = NewTariff(settings.Tariff{Bands: []settings.TariffBand{{Start: "06:00", End: "06:00"}}})
+	s.Assert().ErrorIs(err, ErrInvalidTariff)
+}
+
+func (s *TariffTestSuite) TestBands() {
+	tariff, err := NewTariff(s.settings)
+	s.Require().NoError(err)
+
+	energyPrice, timePrice := tariff.prices(time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC))
+	s.Assert().EqualValues(0.3, energyPrice)
+	s.Assert().EqualValues(0.05, timePrice)
+
+	// The band ends on the next day
+	energyPrice, timePrice = tariff.prices(time.Date(2022, 1, 1, 23, 0, 0, 0, time.UTC))
+	s.Assert().EqualValues(0.2, energyPrice)
+	s.Assert().EqualValues(0, timePrice)
+
+	energyPrice, _ = tariff.prices(time.Date(2022, 1, 1, 5, 59, 59, 0, time.UTC))
+	s.Assert().EqualValues(0.2, energyPrice)
+
+	energyPrice, _ = tariff.prices(time.Date(2022, 1, 1, 6, 0, 0, 0, time.UTC))
+	s.Assert().EqualValues(0.3, energyPrice)
+}
+
+func (s *TariffTestSuite) TestSession() {
+	var (
+		started     = time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+		tariff, err = NewTariff(s.settings)
+	)
+	s.Require().NoError(err)
+
+	costSession := NewSession(tariff, 20, Sample{Time: started, Energy: 1000})
+	s.Assert().EqualValues(session.Cost{SessionFee: 1, Total: 1}, costSession.GetCost())
+
+	costSession.Update(Sample{Time: started.Add(30 * time.Minute), Energy: 6000, Power: 10000})
+	s.Assert().EqualValues(5000, costSession.GetEnergy())
+	s.Assert().EqualValues(session.Cost{SessionFee: 1, Energy: 1.5, Time: 1.5, Total: 4}, costSession.GetCost())
+
+	// Older readings are ignored
+	costSession.Update(Sample{Time: started, Energy: 8000})
+	s.Assert().EqualValues(5000, costSession.GetEnergy())
+
+	// The EV is idle since the last reading, the idle fee is charged after the grace period
+	costSession.Update(Sample{Time: started.Add(time.Hour), Energy: 6000, Power: 0})
+	s.Assert().EqualValues(session.Cost{SessionFee: 1, Energy: 1.5, Time: 3, Idle: 4, Total: 9.5}, costSession.GetCost())
+
+	s.Assert().EqualValues(session.Receipt{
+		Started:  started,
+		Stopped:  started.Add(time.Hour),
+		Energy:   5000,
+		Duration: 3600,
+		IdleTime: 1800,
+		Currency: "EUR",
+		Cost:     session.Cost{SessionFee: 1, Energy: 1.5, Time: 3, Idle: 4, Total: 9.5},
+	}, costSession.Receipt())
+}
+
+func (s *TariffTestSuite) TestSessionWithBands() {
+	var (
+		started     = time.Date(2022, 1, 1, 23, 0, 0, 0, time.UTC)
+		tariff, err = NewTariff(s.settings)
+	)
+	s.Require().NoError(err)
+
+	costSession := NewSession(tariff, 20, Sample{Time: started})
+	costSession.Update(Sample{Time: started.Add(time.Hour), Energy: 10000, Power: 10000})
+	costSession.Update(Sample{Time: started.Add(7 * time.Hour), Energy: 20000, Power: 10000})
+	// The interval starts after the band
+	costSession.Update(Sample{Time: started.Add(8 * time.Hour), Energy: 30000, Power: 10000})
+
+	s.Assert().EqualValues(session.Cost{SessionFee: 1, Energy: 7, Time: 3, Total: 11}, costSession.GetCost())
+}
+
+func (s *TariffTestSuite) TestMeterReset() {
+	started := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	costSession := NewSession(nil, 0, Sample{Time: started, Energy: 5000})
+	costSession.Update(Sample{Time: started.Add(time.Minute), Energy: 1000, Power: 1000})
+	s.Assert().EqualValues(1000, costSession.GetEnergy())
+	s.Assert().EqualValues(session.Cost{}, costSession.GetCost())
+}
+
+func (s *TariffTestSuite) TestRestoredEnergy() {
+	started := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	tariff, err := NewTariff(settings.Tariff{EnergyPrice: 0.5})
+	s.Require().NoError(err)
+
+	// The session consumed 2000 Wh before the restart, the meter register was at 7000 Wh
+	costSession := NewSession(tariff, 0, Sample{Time: started, Energy: 7000})
+	costSession.SetEnergy(2000)
+	costSession.Update(Sample{Time: started.Add(time.Hour), Energy: 8000, Power: 1000})
+
+	s.Assert().EqualValues(3000, costSession.GetEnergy())
+	s.Assert().EqualValues(session.Cost{Energy: 1.5, Total: 1.5}, costSession.GetCost())
+	s.Assert().EqualValues(3600, costSession.Receipt().Duration)
+}
+
+func (s *TariffTestSuite) TestTotalCostFromCentralSystem() {
+	started := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	tariff, err := NewTariff(s.settings)
+	s.Require().NoError(err)
+
+	costSession := NewSession(tariff, 20, Sample{Time: started})
+	costSession.SetTotalCost(12.345)
+	s.Assert().EqualValues(12.35, costSession.GetCost().Total)
+
+	receipt := costSession.Receipt()
+	s.Assert().True(receipt.IsCostFromCentralSystem)
+	s.Assert().EqualValues(12.35, receipt.Cost.Total)
+}
+
+func TestTariff(t *testing.T) {
+	suite.Run(t, new(TariffTestSuite))
+}
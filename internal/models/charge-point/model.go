@@ -5,6 +5,11 @@ import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
 	"github.com/reactivex/rxgo/v2"
 	"github.com/xBlaz3kx/ChargePi-go/internal/api"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	authInput "github.com/xBlaz3kx/ChargePi-go/internal/components/auth-input"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 )
 
@@ -15,10 +20,33 @@ type (
 		HandleChargingRequest(tagId string) (*api.HandleChargingResponse, error)
 		StartCharging(tagId string, connectorId int) (*api.StartTransactionResponse, error)
 		StopCharging(tagId string, connectorId int) (*api.StopTransactionResponse, error)
+		StartFreeVend(connectorId int) error
+		ChangeAvailability(connectorId int, availability core.AvailabilityType) error
+		SendDataTransfer(vendorId, messageId string, data interface{}) (*core.DataTransferConfirmation, error)
 		GetConnectorStatus(evseId, connectorId int) (*api.GetConnectorStatusResponse, error)
+		GetSessionHistory() ([]session.Receipt, error)
+		GetReceipt(transactionId string) (*session.Receipt, error)
+		GetTagGroup(tagId string) (*auth.TagGroup, error)
 		CleanUp(reason core.Reason)
 		ListenForTag(ctx context.Context, tagChannel <-chan string)
+		SubmitAuthorization(request authInput.Request) error
+		GetPaymentUrl(connectorId int) (string, error)
 		AddConnectors(connectors []*settings.Connector)
+		ApplySettings(ctx context.Context, settings *settings.Settings) error
+		ApplyConnectors(connectors []*settings.Connector) error
+		AddNotificationListener(listener NotificationListener)
 		ListenForConnectorStatusChange(ctx context.Context, ch <-chan rxgo.Item)
 	}
+
+	// NotificationListener receives the same connector status and meter value notifications the charge point
+	// reports to the central system. Listeners should not block, as they are called from the notification loop.
+	NotificationListener interface {
+		OnConnectorStatusChange(connector connector.Connector)
+		OnMeterValues(notification models.MeterValueNotification)
+	}
+
+	// CurrentLimiter is implemented by charge points that are able to limit the current offered at a connector.
+	CurrentLimiter interface {
+		SetCurrentLimit(connectorId int, limit float64) error
+	}
 )
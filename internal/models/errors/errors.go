@@ -9,6 +9,14 @@ var (
 	ErrNoConnectorWithTransaction = errors.New("no connector with transaction id")
 	ErrNoAvailableConnectors      = errors.New("no available connectors")
 	ErrConnectorUnavailable       = errors.New("connector unavailable")
+	ErrConnectorReserved          = errors.New("connector reserved for another tag")
+	ErrEvseInUse                  = errors.New("another connector of the EVSE is in use")
 	ErrChargePointUnavailable     = errors.New("charge point unavailable")
 	ErrTagUnauthorized            = errors.New("tag unauthorized")
+	ErrAvailabilityChangeRejected = errors.New("availability change rejected")
+	ErrChargePointNotConnected    = errors.New("charge point not connected")
+	ErrSessionHistoryDisabled     = errors.New("session history disabled")
+	ErrAuthCacheDisabled          = errors.New("authorization cache disabled")
+	ErrFreeVendDisabled           = errors.New("free vend disabled on the connector")
+	ErrPaymentDisabled            = errors.New("payment disabled")
 )
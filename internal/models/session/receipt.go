@@ -0,0 +1,34 @@
+package session
+
+import "time"
+
+type (
+	// Cost of a charging session, split by the tariff components.
+	Cost struct {
+		SessionFee float64 `json:"sessionFee"`
+		Energy     float64 `json:"energy"`
+		Time       float64 `json:"time"`
+		Idle       float64 `json:"idle"`
+		Total      float64 `json:"total"`
+	}
+
+	// Receipt summarizes a finished charging session.
+	Receipt struct {
+		TransactionId string    `json:"transactionId"`
+		TagId         string    `json:"tagId"`
+		EvseId        int       `json:"evseId"`
+		ConnectorId   int       `json:"connectorId"`
+		Started       time.Time `json:"started"`
+		Stopped       time.Time `json:"stopped"`
+		StopReason    string    `json:"stopReason,omitempty"`
+		// Energy in Wh
+		Energy float64 `json:"energy"`
+		// Duration and IdleTime in seconds
+		Duration int    `json:"duration"`
+		IdleTime int    `json:"idleTime"`
+		Currency string `json:"currency,omitempty"`
+		Cost     Cost   `json:"cost"`
+		// IsCostFromCentralSystem is set if the total cost was reported by the central system.
+		IsCostFromCentralSystem bool `json:"isCostFromCentralSystem,omitempty"`
+	}
+)
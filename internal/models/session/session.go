@@ -20,8 +20,13 @@ type (
 		IsActive      bool
 		TransactionId string
 		TagId         string
+		ParentIdTag   string
 		Started       string
 		Consumption   []types.MeterValue
+		// Energy in Wh consumed by the session until the MeterReading
+		Energy float64
+		// MeterReading is the last energy register reading of the session in Wh
+		MeterReading float64
 	}
 
 	SessionInterface interface {
@@ -62,9 +67,12 @@ func (session *Session) StartSession(transactionId string, tagId string) error {
 
 	session.TransactionId = transactionId
 	session.TagId = tagId
+	session.ParentIdTag = ""
 	session.IsActive = true
 	session.Started = time.Now().Format(time.RFC3339)
 	session.Consumption = []types.MeterValue{}
+	session.Energy = 0
+	session.MeterReading = 0
 	return nil
 }
 
@@ -74,6 +82,7 @@ func (session *Session) EndSession() {
 		log.Debugf("Ended a session %s for %s", session.TransactionId, session.TagId)
 		session.TransactionId = ""
 		session.TagId = ""
+		session.ParentIdTag = ""
 		session.IsActive = false
 		session.Started = ""
 	}
@@ -4,6 +4,7 @@ type (
 	Settings struct {
 		ChargePoint ChargePoint `fig:"chargePoint" json:"chargePoint" yaml:"chargePoint" mapstructure:"chargePoint"`
 		Api         Api         `fig:"api" json:"api" yaml:"api" mapstructure:"api"`
+		Mqtt        Mqtt        `fig:"mqtt" json:"mqtt" yaml:"mqtt" mapstructure:"mqtt"`
 	}
 
 	ChargePoint struct {
@@ -11,6 +12,14 @@ type (
 		Logging  Logging  `fig:"logging" json:"logging" yaml:"logging" mapstructure:"logging"`
 		TLS      TLS      `fig:"tls" json:"tls" yaml:"tls" mapstructure:"tls"`
 		Hardware Hardware `fig:"hardware" json:"hardware" yaml:"hardware" mapstructure:"hardware"`
+		// SessionPolicies limit the charging sessions
+		SessionPolicies SessionPolicies `fig:"sessionPolicies" json:"sessionPolicies" yaml:"sessionPolicies" mapstructure:"sessionPolicies"`
+		// Tariff is used to calculate the cost of the charging sessions
+		Tariff   Tariff   `fig:"tariff" json:"tariff" yaml:"tariff" mapstructure:"tariff"`
+		Firmware Firmware `fig:"firmware" json:"firmware" yaml:"firmware" mapstructure:"firmware"`
+		Reset    Reset    `fig:"reset" json:"reset" yaml:"reset" mapstructure:"reset"`
+		// Payment shows the drivers where to pay for a session without an RFID card
+		Payment Payment `fig:"payment" json:"payment" yaml:"payment" mapstructure:"payment"`
 	}
 
 	Info struct {
@@ -37,9 +46,41 @@ type (
 		Port   int      `fig:"port" default:"1514" json:"port,omitempty" yaml:"port" mapstructure:"port"`
 	}
 
+	Firmware struct {
+		DownloadFolder string `fig:"downloadFolder" default:"/tmp/chargepi/firmware" json:"downloadFolder,omitempty" yaml:"downloadFolder" mapstructure:"downloadFolder"`
+		InstallCommand string `fig:"installCommand" json:"installCommand,omitempty" yaml:"installCommand" mapstructure:"installCommand"` // the firmware path is appended
+	}
+
+	Reset struct {
+		RebootCommand string `fig:"rebootCommand" default:"sudo reboot" json:"rebootCommand,omitempty" yaml:"rebootCommand" mapstructure:"rebootCommand"` // reboots the system at a hard reset
+	}
+
+	Payment struct {
+		Enabled bool   `fig:"enabled" json:"enabled,omitempty" yaml:"enabled" mapstructure:"enabled"`
+		Url     string `fig:"url" json:"url,omitempty" yaml:"url" mapstructure:"url"` // {chargePointId} and {connectorId} are replaced
+	}
+
 	Api struct {
 		Enabled bool   `fig:"enabled" json:"enabled,omitempty" yaml:"enabled" mapstructure:"enabled"`
 		Address string `fig:"address" json:"address,omitempty" yaml:"address" mapstructure:"address"`
 		Port    int    `fig:"port" json:"port,omitempty" yaml:"port" mapstructure:"port"`
 	}
+
+	Mqtt struct {
+		Enabled       bool          `fig:"enabled" json:"enabled,omitempty" yaml:"enabled" mapstructure:"enabled"`
+		Broker        string        `fig:"broker" json:"broker,omitempty" yaml:"broker" mapstructure:"broker"` // tcp://host:1883, ssl://host:8883
+		ClientId      string        `fig:"clientId" json:"clientId,omitempty" yaml:"clientId" mapstructure:"clientId"`
+		Username      string        `fig:"username" json:"username,omitempty" yaml:"username" mapstructure:"username"`
+		Password      string        `fig:"password" json:"password,omitempty" yaml:"password" mapstructure:"password"`
+		TopicPrefix   string        `fig:"topicPrefix" default:"chargepi" json:"topicPrefix,omitempty" yaml:"topicPrefix" mapstructure:"topicPrefix"`
+		Qos           byte          `fig:"qos" json:"qos,omitempty" yaml:"qos" mapstructure:"qos"`
+		DefaultTagId  string        `fig:"defaultTagId" json:"defaultTagId,omitempty" yaml:"defaultTagId" mapstructure:"defaultTagId"`
+		TLS           TLS           `fig:"tls" json:"tls" yaml:"tls" mapstructure:"tls"`
+		HomeAssistant HomeAssistant `fig:"homeAssistant" json:"homeAssistant" yaml:"homeAssistant" mapstructure:"homeAssistant"`
+	}
+
+	HomeAssistant struct {
+		Enabled         bool   `fig:"enabled" json:"enabled,omitempty" yaml:"enabled" mapstructure:"enabled"`
+		DiscoveryPrefix string `fig:"discoveryPrefix" default:"homeassistant" json:"discoveryPrefix,omitempty" yaml:"discoveryPrefix" mapstructure:"discoveryPrefix"`
+	}
 )
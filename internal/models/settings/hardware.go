@@ -7,6 +7,8 @@ type (
 		Lcd          Lcd          `fig:"lcd" json:"lcd" yaml:"lcd" mapstructure:"lcd"`
 		TagReader    TagReader    `fig:"tagReader" json:"tagReader" yaml:"tagReader" mapstructure:"tagReader"`
 		LedIndicator LedIndicator `fig:"ledIndicator" json:"ledIndicator" yaml:"ledIndicator" mapstructure:"ledIndicator"`
+		PowerMeters  PowerMeters  `fig:"powerMeters" json:"powerMeters" yaml:"powerMeters" mapstructure:"powerMeters"`
+		Keypad       Keypad       `fig:"keypad" json:"keypad" yaml:"keypad" mapstructure:"keypad"`
 	}
 
 	Relay struct {
@@ -20,6 +22,24 @@ type (
 		IndicateCardRead bool   `fig:"IndicateCardRead" json:"IndicateCardRead,omitempty" yaml:"IndicateCardRead" mapstructure:"IndicateCardRead"`
 		Type             string `fig:"Type" json:"type,omitempty" yaml:"type" mapstructure:"type"`
 		Invert           bool   `fig:"Invert" json:"invert,omitempty" yaml:"invert" mapstructure:"invert"`
+		// Pins of the LEDs with the GPIO type, one LED per index
+		Pins []int `fig:"Pins" json:"pins,omitempty" yaml:"pins" mapstructure:"pins"`
+		// Strips of the WS281x type, one strip per index, replace the single strip on the DataPin
+		Strips []LedStrip `fig:"Strips" json:"strips,omitempty" yaml:"strips" mapstructure:"strips"`
+		// Theme overrides the default animations of the statuses and events
+		Theme map[string]IndicatorAnimation `fig:"Theme" json:"theme,omitempty" yaml:"theme" mapstructure:"theme"`
+	}
+
+	LedStrip struct {
+		DataPin int `fig:"DataPin" json:"dataPin,omitempty" yaml:"dataPin" mapstructure:"dataPin"`
+		Length  int `fig:"Length" json:"length,omitempty" yaml:"length" mapstructure:"length"`
+	}
+
+	IndicatorAnimation struct {
+		Color      string `fig:"Color" json:"color,omitempty" yaml:"color" mapstructure:"color"`                     // hex color, e.g. "#00ff00"
+		Pattern    string `fig:"Pattern" json:"pattern,omitempty" yaml:"pattern" mapstructure:"pattern"`             // solid, blink, breathe or chase
+		Brightness int    `fig:"Brightness" json:"brightness,omitempty" yaml:"brightness" mapstructure:"brightness"` // percent, 100 by default
+		Period     int    `fig:"Period" json:"period,omitempty" yaml:"period" mapstructure:"period"`                 // milliseconds
 	}
 
 	TagReader struct {
@@ -29,12 +49,25 @@ type (
 		ResetPin    int    `fig:"ResetPin" json:"ResetPin,omitempty" yaml:"ResetPin" mapstructure:"ResetPin"`
 	}
 
+	// Keypad is a matrix keypad for entering PINs, connected to the GPIO pins or to a PCF8574 I2C expander.
+	Keypad struct {
+		IsEnabled  bool   `fig:"IsEnabled" json:"isEnabled,omitempty" yaml:"isEnabled" mapstructure:"isEnabled"`
+		Driver     string `fig:"Driver" json:"driver,omitempty" yaml:"driver" mapstructure:"driver"`
+		RowPins    []int  `fig:"RowPins" json:"rowPins,omitempty" yaml:"rowPins" mapstructure:"rowPins"`
+		ColumnPins []int  `fig:"ColumnPins" json:"columnPins,omitempty" yaml:"columnPins" mapstructure:"columnPins"`
+		I2CAddress string `fig:"I2CAddress" json:"I2CAddress,omitempty" yaml:"I2CAddress" mapstructure:"I2CAddress"`
+		I2CBus     int    `fig:"I2CBus" json:"I2CBus,omitempty" yaml:"I2CBus" mapstructure:"I2CBus"`
+		Layout     string `fig:"Layout" json:"layout,omitempty" yaml:"layout" mapstructure:"layout"` // keys row by row, default "123A456B789C*0#D"
+	}
+
 	Lcd struct {
 		IsEnabled  bool   `fig:"IsEnabled" json:"ResetPin,omitempty" yaml:"ResetPin" mapstructure:"ResetPin"`
 		Driver     string `fig:"Driver" json:"driver,omitempty" yaml:"driver" mapstructure:"driver"`
 		Language   string `fig:"Language" json:"language,omitempty" yaml:"language" mapstructure:"language"`
 		I2CAddress string `fig:"I2CAddress" json:"I2CAddress,omitempty" yaml:"I2CAddress" mapstructure:"I2CAddress"`
 		I2CBus     int    `fig:"I2CBus" json:"I2CBus,omitempty" yaml:"I2CBus" mapstructure:"I2CBus"`
+		// Folder with the active.<lang>.yaml translations that replace the bundled translations
+		TranslationsFolder string `fig:"TranslationsFolder" json:"translationsFolder,omitempty" yaml:"translationsFolder" mapstructure:"translationsFolder"`
 	}
 
 	PowerMeter struct {
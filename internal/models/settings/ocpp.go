@@ -29,13 +29,31 @@ type (
 		Session     Session    `fig:"Session" json:"session" yaml:"session" mapstructure:"session"`
 		Relay       Relay      `fig:"Relay" json:"relay" yaml:"relay" mapstructure:"relay"`
 		PowerMeter  PowerMeter `fig:"PowerMeter" json:"PowerMeter" yaml:"PowerMeter" mapstructure:"PowerMeter"`
+		// SessionPolicy overrides the default session policy for the connector
+		SessionPolicy SessionPolicy `fig:"SessionPolicy" json:"sessionPolicy,omitempty" yaml:"sessionPolicy" mapstructure:"sessionPolicy"`
+		// FreeVend starts charging without authorization
+		FreeVend FreeVend `fig:"FreeVend" json:"freeVend,omitempty" yaml:"freeVend" mapstructure:"freeVend"`
+	}
+
+	// FreeVend starts a transaction with the IdTag when the EV is detected or the button is pressed. Pins that are not set are not used.
+	FreeVend struct {
+		Enabled      bool   `fig:"Enabled" json:"enabled,omitempty" yaml:"enabled" mapstructure:"enabled"`
+		IdTag        string `fig:"IdTag" json:"idTag,omitempty" yaml:"idTag" mapstructure:"idTag"`
+		EvDetectPin  int    `fig:"EvDetectPin" json:"evDetectPin,omitempty" yaml:"evDetectPin" mapstructure:"evDetectPin"`
+		ButtonPin    int    `fig:"ButtonPin" json:"buttonPin,omitempty" yaml:"buttonPin" mapstructure:"buttonPin"`
+		InverseLogic bool   `fig:"InverseLogic" json:"inverseLogic,omitempty" yaml:"inverseLogic" mapstructure:"inverseLogic"`
 	}
 
 	Session struct {
 		IsActive      bool               `fig:"IsActive" json:"IsActive,omitempty" yaml:"IsActive" mapstructure:"IsActive"`
 		TransactionId string             `fig:"TransactionId" default:"" json:"TransactionId,omitempty" yaml:"TransactionId" mapstructure:"TransactionId"`
 		TagId         string             `fig:"TagId" default:"" json:"TagId,omitempty" yaml:"TagId" mapstructure:"TagId"`
+		ParentIdTag   string             `fig:"ParentIdTag" default:"" json:"parentIdTag,omitempty" yaml:"parentIdTag" mapstructure:"parentIdTag"`
 		Started       string             `fig:"Started" default:"" json:"started,omitempty" yaml:"started" mapstructure:"started"`
 		Consumption   []types.MeterValue `fig:"Consumption" json:"consumption,omitempty" yaml:"consumption" mapstructure:"consumption"`
+		// Energy in Wh consumed by the session until the MeterReading
+		Energy float64 `fig:"Energy" json:"energy,omitempty" yaml:"energy" mapstructure:"energy"`
+		// MeterReading is the last energy register reading of the session in Wh
+		MeterReading float64 `fig:"MeterReading" json:"meterReading,omitempty" yaml:"meterReading" mapstructure:"meterReading"`
 	}
 )
@@ -0,0 +1,17 @@
+package settings
+
+type (
+	// SessionPolicy limits a charging session. Zero values disable the limit.
+	SessionPolicy struct {
+		MaxEnergy       float64 `fig:"maxEnergy" json:"maxEnergy,omitempty" yaml:"maxEnergy" mapstructure:"maxEnergy"`                         // kWh
+		MaxDuration     int     `fig:"maxDuration" json:"maxDuration,omitempty" yaml:"maxDuration" mapstructure:"maxDuration"`                 // minutes
+		StopAfterFull   int     `fig:"stopAfterFull" json:"stopAfterFull,omitempty" yaml:"stopAfterFull" mapstructure:"stopAfterFull"`         // minutes below the minimum power
+		IdleGracePeriod int     `fig:"idleGracePeriod" json:"idleGracePeriod,omitempty" yaml:"idleGracePeriod" mapstructure:"idleGracePeriod"` // minutes after the EV is full
+	}
+
+	// SessionPolicies contains the default policy and the policies of the tag groups, keyed by the parent id tag.
+	SessionPolicies struct {
+		Default SessionPolicy            `fig:"default" json:"default" yaml:"default" mapstructure:"default"`
+		Groups  map[string]SessionPolicy `fig:"groups" json:"groups,omitempty" yaml:"groups" mapstructure:"groups"`
+	}
+)
@@ -0,0 +1,23 @@
+package settings
+
+type (
+	// Tariff defines the prices of a charging session. Zero prices are not charged.
+	Tariff struct {
+		Currency        string       `fig:"currency" json:"currency,omitempty" yaml:"currency" mapstructure:"currency"`
+		EnergyPrice     float64      `fig:"energyPrice" json:"energyPrice,omitempty" yaml:"energyPrice" mapstructure:"energyPrice"`                 // per kWh
+		TimePrice       float64      `fig:"timePrice" json:"timePrice,omitempty" yaml:"timePrice" mapstructure:"timePrice"`                         // per minute
+		SessionFee      float64      `fig:"sessionFee" json:"sessionFee,omitempty" yaml:"sessionFee" mapstructure:"sessionFee"`                     // per session
+		IdleFee         float64      `fig:"idleFee" json:"idleFee,omitempty" yaml:"idleFee" mapstructure:"idleFee"`                                 // per minute after the EV stopped drawing power
+		IdleGracePeriod int          `fig:"idleGracePeriod" json:"idleGracePeriod,omitempty" yaml:"idleGracePeriod" mapstructure:"idleGracePeriod"` // minutes before the idle fee is charged
+		Bands           []TariffBand `fig:"bands" json:"bands,omitempty" yaml:"bands" mapstructure:"bands"`
+	}
+
+	// TariffBand replaces the energy and time price of the tariff between the start and the end time of the day.
+	// The band ends on the next day if the end is before the start.
+	TariffBand struct {
+		Start       string  `fig:"start" json:"start" yaml:"start" mapstructure:"start"` // 15:04
+		End         string  `fig:"end" json:"end" yaml:"end" mapstructure:"end"`         // 15:04
+		EnergyPrice float64 `fig:"energyPrice" json:"energyPrice,omitempty" yaml:"energyPrice" mapstructure:"energyPrice"`
+		TimePrice   float64 `fig:"timePrice" json:"timePrice,omitempty" yaml:"timePrice" mapstructure:"timePrice"`
+	}
+)
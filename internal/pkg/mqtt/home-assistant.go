@@ -0,0 +1,146 @@
+package mqtt
+
+import (
+	"fmt"
+	"github.com/agrison/go-commons-lang/stringUtils"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	chargePoint "github.com/xBlaz3kx/ChargePi-go/internal/models/charge-point"
+	"regexp"
+	"strings"
+)
+
+var nodeIdRegex = regexp.MustCompile("[^a-zA-Z0-9_-]")
+
+type (
+	// discoveryDevice groups all the entities of the charge point into a single Home Assistant device.
+	discoveryDevice struct {
+		Identifiers  []string `json:"identifiers"`
+		Name         string   `json:"name"`
+		Manufacturer string   `json:"manufacturer,omitempty"`
+		Model        string   `json:"model,omitempty"`
+	}
+
+	// discoveryConfig is the Home Assistant MQTT discovery payload.
+	discoveryConfig struct {
+		Name              string          `json:"name"`
+		UniqueId          string          `json:"unique_id"`
+		AvailabilityTopic string          `json:"availability_topic"`
+		StateTopic        string          `json:"state_topic,omitempty"`
+		CommandTopic      string          `json:"command_topic,omitempty"`
+		ValueTemplate     string          `json:"value_template,omitempty"`
+		UnitOfMeasurement string          `json:"unit_of_measurement,omitempty"`
+		DeviceClass       string          `json:"device_class,omitempty"`
+		StateClass        string          `json:"state_class,omitempty"`
+		Options           []string        `json:"options,omitempty"`
+		PayloadPress      string          `json:"payload_press,omitempty"`
+		Min               *float64        `json:"min,omitempty"`
+		Max               *float64        `json:"max,omitempty"`
+		Device            discoveryDevice `json:"device"`
+	}
+
+	// discoveryEntity is a discovery payload with the Home Assistant component it belongs to.
+	discoveryEntity struct {
+		component string
+		objectId  string
+		config    discoveryConfig
+	}
+)
+
+// publishDiscovery publishes the Home Assistant discovery payloads for the connector, if discovery is enabled and the
+// connector wasn't discovered since the last (re)connect.
+func (b *Bridge) publishDiscovery(c connector.Connector) {
+	if !b.settings.HomeAssistant.Enabled {
+		return
+	}
+
+	if _, isDiscovered := b.discovered.LoadOrStore(c.GetConnectorId(), true); isDiscovered {
+		return
+	}
+
+	prefix := b.settings.HomeAssistant.DiscoveryPrefix
+	if stringUtils.IsEmpty(prefix) {
+		prefix = "homeassistant"
+	}
+
+	nodeId := nodeIdRegex.ReplaceAllString(b.info.Id, "_")
+	for _, entity := range b.discoveryEntities(c.GetConnectorId()) {
+		topic := fmt.Sprintf("%s/%s/%s/%s/config", prefix, entity.component, nodeId, entity.objectId)
+		b.publishJson(topic, entity.config, true)
+	}
+}
+
+// discoveryEntities creates the sensors and controls of a connector for the Home Assistant discovery.
+func (b *Bridge) discoveryEntities(connectorId int) []discoveryEntity {
+	var (
+		nodeId     = nodeIdRegex.ReplaceAllString(b.info.Id, "_")
+		namePrefix = fmt.Sprintf("%s connector %d", b.info.Id, connectorId)
+		idPrefix   = fmt.Sprintf("%s_connector_%d", nodeId, connectorId)
+		device     = discoveryDevice{
+			Identifiers:  []string{b.info.Id},
+			Name:         b.info.Id,
+			Manufacturer: b.info.OCPPInfo.Vendor,
+			Model:        b.info.OCPPInfo.Model,
+		}
+		minCurrent = 0.0
+		maxCurrent = 80.0
+	)
+
+	newEntity := func(component, object, name string) discoveryEntity {
+		return discoveryEntity{
+			component: component,
+			objectId:  fmt.Sprintf("%s_%s", idPrefix, object),
+			config: discoveryConfig{
+				Name:              fmt.Sprintf("%s %s", namePrefix, name),
+				UniqueId:          fmt.Sprintf("%s_%s", idPrefix, object),
+				AvailabilityTopic: b.topic(availabilityTopic),
+				Device:            device,
+			},
+		}
+	}
+
+	status := newEntity("sensor", "status", "status")
+	status.config.StateTopic = b.connectorTopic(connectorId, statusTopic)
+	status.config.ValueTemplate = "{{ value_json.status }}"
+
+	power := newEntity("sensor", "power", "power")
+	power.config.StateTopic = b.connectorTopic(connectorId, meterValuesTopic)
+	power.config.ValueTemplate = "{{ value_json['Power.Active.Import'] }}"
+	power.config.UnitOfMeasurement = "W"
+	power.config.DeviceClass = "power"
+	power.config.StateClass = "measurement"
+
+	energy := newEntity("sensor", "energy", "energy")
+	energy.config.StateTopic = b.connectorTopic(connectorId, meterValuesTopic)
+	energy.config.ValueTemplate = "{{ value_json['Energy.Active.Import.Register'] }}"
+	energy.config.UnitOfMeasurement = "Wh"
+	energy.config.DeviceClass = "energy"
+	energy.config.StateClass = "total_increasing"
+
+	// The buttons start charging with the default tag and stop the session on the connector
+	start := newEntity("button", CommandStart, "start charging")
+	start.config.CommandTopic = b.connectorTopic(connectorId, fmt.Sprintf("%s/%s", commandTopic, CommandStart))
+	start.config.PayloadPress = payloadPress
+
+	stop := newEntity("button", CommandStop, "stop charging")
+	stop.config.CommandTopic = b.connectorTopic(connectorId, fmt.Sprintf("%s/%s", commandTopic, CommandStop))
+	stop.config.PayloadPress = payloadPress
+
+	availability := newEntity("select", strings.ToLower(CommandAvailability), "availability")
+	availability.config.CommandTopic = b.connectorTopic(connectorId, fmt.Sprintf("%s/%s", commandTopic, CommandAvailability))
+	availability.config.Options = []string{string(core.AvailabilityTypeOperative), string(core.AvailabilityTypeInoperative)}
+
+	entities := []discoveryEntity{status, power, energy, start, stop, availability}
+
+	// The current limit is only advertised if the charge point is able to limit the current
+	if _, isLimiter := b.chargePoint.(chargePoint.CurrentLimiter); isLimiter {
+		currentLimit := newEntity("number", strings.ToLower(CommandCurrentLimit), "current limit")
+		currentLimit.config.CommandTopic = b.connectorTopic(connectorId, fmt.Sprintf("%s/%s", commandTopic, CommandCurrentLimit))
+		currentLimit.config.UnitOfMeasurement = "A"
+		currentLimit.config.Min = &minCurrent
+		currentLimit.config.Max = &maxCurrent
+		entities = append(entities, currentLimit)
+	}
+
+	return entities
+}
@@ -0,0 +1,460 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/agrison/go-commons-lang/stringUtils"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	log "github.com/sirupsen/logrus"
+	authInput "github.com/xBlaz3kx/ChargePi-go/internal/components/auth-input"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models"
+	chargePoint "github.com/xBlaz3kx/ChargePi-go/internal/models/charge-point"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/pkg/tls"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported commands
+const (
+	CommandStart          = "start"
+	CommandStop           = "stop"
+	CommandAvailability   = "availability"
+	CommandCurrentLimit   = "currentLimit"
+	CommandDataTransfer   = "dataTransfer"
+	CommandTagGroup       = "tagGroup"
+	CommandReceipt        = "receipt"
+	CommandSessionHistory = "sessionHistory"
+	CommandAuthorize      = "authorize"
+)
+
+const (
+	availabilityTopic = "availability"
+	statusTopic       = "status"
+	meterValuesTopic  = "meterValues"
+	commandTopic      = "set"
+	resultTopic       = "result"
+
+	payloadOnline  = "online"
+	payloadOffline = "offline"
+	// payloadPress is sent by the Home Assistant buttons and carries no tag id
+	payloadPress = "PRESS"
+
+	disconnectQuiesce    = 250
+	operationTimeout     = time.Second * 10
+	connectRetryInterval = time.Second * 30
+)
+
+var (
+	ErrCommandNotSupported = errors.New("command not supported")
+	ErrInvalidCommandTopic = errors.New("invalid command topic")
+	ErrNoTagId             = errors.New("no tag id provided")
+	ErrInvalidPayload      = errors.New("invalid command payload")
+	ErrOperationTimeout    = errors.New("mqtt operation timed out")
+)
+
+type (
+	// Bridge publishes the charge point's connector status and meter values to an MQTT broker and routes the commands
+	// received from the broker to the charge point.
+	Bridge struct {
+		client      paho.Client
+		chargePoint chargePoint.ChargePoint
+		settings    settings.Mqtt
+		info        settings.Info
+		baseTopic   string
+		discovered  sync.Map
+		logger      *log.Logger
+	}
+
+	// ConnectorState is the payload published to the connector status topic.
+	ConnectorState struct {
+		EvseId        int    `json:"evseId"`
+		ConnectorId   int    `json:"connectorId"`
+		Status        string `json:"status"`
+		ErrorCode     string `json:"errorCode"`
+		SessionActive bool   `json:"sessionActive"`
+		TransactionId string `json:"transactionId,omitempty"`
+	}
+
+	// CommandResult is the payload published to the connector result topic after a command was executed. Data holds
+	// the response of the commands which query the charge point.
+	CommandResult struct {
+		Command string      `json:"command"`
+		Success bool        `json:"success"`
+		Error   string      `json:"error,omitempty"`
+		Data    interface{} `json:"data,omitempty"`
+	}
+
+	// AuthorizeCommand is the payload of the authorize command.
+	AuthorizeCommand struct {
+		IdTag    string `json:"idTag"`
+		Language string `json:"language,omitempty"`
+	}
+
+	// DataTransferCommand is the payload of the dataTransfer command.
+	DataTransferCommand struct {
+		VendorId  string      `json:"vendorId"`
+		MessageId string      `json:"messageId,omitempty"`
+		Data      interface{} `json:"data,omitempty"`
+	}
+)
+
+// NewBridge creates a new MQTT bridge for the charge point. The broker connection is established with Connect.
+func NewBridge(mqttSettings settings.Mqtt, info settings.Info, handler chargePoint.ChargePoint, logger *log.Logger) (*Bridge, error) {
+	if logger == nil {
+		logger = log.StandardLogger()
+	}
+
+	if stringUtils.IsEmpty(mqttSettings.TopicPrefix) {
+		mqttSettings.TopicPrefix = "chargepi"
+	}
+
+	bridge := &Bridge{
+		chargePoint: handler,
+		settings:    mqttSettings,
+		info:        info,
+		baseTopic:   fmt.Sprintf("%s/%s", mqttSettings.TopicPrefix, info.Id),
+		logger:      logger,
+	}
+
+	clientId := mqttSettings.ClientId
+	if stringUtils.IsEmpty(clientId) {
+		clientId = fmt.Sprintf("chargepi-%s", info.Id)
+	}
+
+	opts := paho.NewClientOptions().
+		AddBroker(mqttSettings.Broker).
+		SetClientID(clientId).
+		SetUsername(mqttSettings.Username).
+		SetPassword(mqttSettings.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(connectRetryInterval).
+		SetWill(bridge.topic(availabilityTopic), payloadOffline, mqttSettings.Qos, true).
+		SetOnConnectHandler(bridge.onConnect).
+		SetConnectionLostHandler(func(client paho.Client, err error) {
+			logger.WithError(err).Warn("Lost connection to the MQTT broker")
+		})
+
+	if mqttSettings.TLS.IsEnabled {
+		tlsConfig, err := tls.GetTLSConfig(
+			mqttSettings.TLS.CACertificatePath,
+			mqttSettings.TLS.ClientCertificatePath,
+			mqttSettings.TLS.ClientKeyPath,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	bridge.client = paho.NewClient(opts)
+	return bridge, nil
+}
+
+// Connect connects to the MQTT broker. Publishing and subscribing starts once the connection is established. If the
+// broker is not reachable, the client keeps retrying in the background.
+func (b *Bridge) Connect() error {
+	b.logger.Infof("Connecting to the MQTT broker: %s", b.settings.Broker)
+
+	err := waitForToken(b.client.Connect())
+	if errors.Is(err, ErrOperationTimeout) {
+		b.logger.Warnf("The MQTT broker is not reachable, retrying every %s", connectRetryInterval)
+		return nil
+	}
+
+	return err
+}
+
+// Close marks the charge point offline and disconnects from the broker. It also stops retrying the connection.
+func (b *Bridge) Close() {
+	b.logger.Info("Disconnecting from the MQTT broker")
+	if b.client.IsConnectionOpen() {
+		b.publish(b.topic(availabilityTopic), payloadOffline, true)
+	}
+
+	b.client.Disconnect(disconnectQuiesce)
+}
+
+// OnConnectorStatusChange publishes the state of the connector.
+func (b *Bridge) OnConnectorStatusChange(c connector.Connector) {
+	if !b.client.IsConnectionOpen() {
+		return
+	}
+
+	b.publishDiscovery(c)
+
+	status, errorCode := c.GetStatus()
+	state := ConnectorState{
+		EvseId:        c.GetEvseId(),
+		ConnectorId:   c.GetConnectorId(),
+		Status:        string(status),
+		ErrorCode:     string(errorCode),
+		TransactionId: c.GetTransactionId(),
+	}
+	state.SessionActive = stringUtils.IsNotEmpty(state.TransactionId)
+
+	b.publishJson(b.connectorTopic(c.GetConnectorId(), statusTopic), state, true)
+}
+
+// OnMeterValues publishes the sampled values for the connector. Each measurand is published as a separate attribute.
+func (b *Bridge) OnMeterValues(notification models.MeterValueNotification) {
+	if !b.client.IsConnectionOpen() {
+		return
+	}
+
+	b.publishJson(b.connectorTopic(notification.ConnectorId, meterValuesTopic), flattenMeterValues(notification), false)
+}
+
+// onConnect is called every time the connection to the broker is (re)established.
+func (b *Bridge) onConnect(client paho.Client) {
+	b.logger.Info("Connected to the MQTT broker")
+
+	// Discovery must be repeated, the broker might have lost the retained messages
+	b.discovered.Range(func(key, value interface{}) bool {
+		b.discovered.Delete(key)
+		return true
+	})
+	b.publish(b.topic(availabilityTopic), payloadOnline, true)
+
+	commandFilter := fmt.Sprintf("%s/connector/+/%s/+", b.baseTopic, commandTopic)
+	token := client.Subscribe(commandFilter, b.settings.Qos, b.handleCommand)
+	if err := waitForToken(token); err != nil {
+		b.logger.WithError(err).Errorf("Unable to subscribe to %s", commandFilter)
+	}
+}
+
+// handleCommand parses the command topic, executes the command and publishes the result.
+func (b *Bridge) handleCommand(client paho.Client, message paho.Message) {
+	logInfo := b.logger.WithField("topic", message.Topic())
+
+	connectorId, command, err := b.parseCommandTopic(message.Topic())
+	if err != nil {
+		logInfo.WithError(err).Warn("Received an invalid command")
+		return
+	}
+
+	logInfo.Infof("Received command %s for connector %d", command, connectorId)
+	result := CommandResult{Command: command, Success: true}
+
+	payload := strings.TrimSpace(string(message.Payload()))
+	if isQuery(command) {
+		result.Data, err = b.executeQuery(command, payload)
+	} else {
+		err = b.executeCommand(connectorId, command, payload)
+	}
+
+	if err != nil {
+		logInfo.WithError(err).Errorf("Unable to execute command")
+		result.Success = false
+		result.Error = err.Error()
+		result.Data = nil
+	}
+
+	b.publishJson(b.connectorTopic(connectorId, resultTopic), result, false)
+}
+
+// executeCommand routes the command to the charge point.
+func (b *Bridge) executeCommand(connectorId int, command, payload string) error {
+	if payload == payloadPress {
+		payload = ""
+	}
+
+	switch command {
+	case CommandStart:
+		tagId := payload
+		if stringUtils.IsEmpty(tagId) {
+			tagId = b.settings.DefaultTagId
+		}
+
+		if stringUtils.IsEmpty(tagId) {
+			return ErrNoTagId
+		}
+
+		_, err := b.chargePoint.StartCharging(strings.ToUpper(tagId), connectorId)
+		return err
+	case CommandStop:
+		_, err := b.chargePoint.StopCharging(strings.ToUpper(payload), connectorId)
+		return err
+	case CommandAvailability:
+		availability := core.AvailabilityType(payload)
+		switch availability {
+		case core.AvailabilityTypeOperative, core.AvailabilityTypeInoperative:
+			return b.chargePoint.ChangeAvailability(connectorId, availability)
+		default:
+			return fmt.Errorf("invalid availability: %s", payload)
+		}
+	case CommandCurrentLimit:
+		limiter, isLimiter := b.chargePoint.(chargePoint.CurrentLimiter)
+		if !isLimiter {
+			return ErrCommandNotSupported
+		}
+
+		limit, err := strconv.ParseFloat(payload, 64)
+		if err != nil {
+			return err
+		}
+
+		return limiter.SetCurrentLimit(connectorId, limit)
+	case CommandAuthorize:
+		var request AuthorizeCommand
+		err := json.Unmarshal([]byte(payload), &request)
+		if err != nil {
+			return ErrInvalidPayload
+		}
+
+		if stringUtils.IsEmpty(request.IdTag) {
+			return ErrNoTagId
+		}
+
+		return b.chargePoint.SubmitAuthorization(authInput.Request{
+			Source:      authInput.SourcePayment,
+			IdTag:       request.IdTag,
+			ConnectorId: connectorId,
+			Language:    request.Language,
+		})
+	default:
+		return ErrCommandNotSupported
+	}
+}
+
+// executeQuery routes the commands, which return data, to the charge point. The queries are not bound to the connector.
+func (b *Bridge) executeQuery(command, payload string) (interface{}, error) {
+	switch command {
+	case CommandDataTransfer:
+		var request DataTransferCommand
+		err := json.Unmarshal([]byte(payload), &request)
+		if err != nil || stringUtils.IsEmpty(request.VendorId) {
+			return nil, ErrInvalidPayload
+		}
+
+		return b.chargePoint.SendDataTransfer(request.VendorId, request.MessageId, request.Data)
+	case CommandTagGroup:
+		if stringUtils.IsEmpty(payload) {
+			return nil, ErrNoTagId
+		}
+
+		return b.chargePoint.GetTagGroup(strings.ToUpper(payload))
+	case CommandReceipt:
+		if stringUtils.IsEmpty(payload) {
+			return nil, ErrInvalidPayload
+		}
+
+		return b.chargePoint.GetReceipt(payload)
+	case CommandSessionHistory:
+		return b.chargePoint.GetSessionHistory()
+	default:
+		return nil, ErrCommandNotSupported
+	}
+}
+
+// isQuery returns true if the command returns data with its result.
+func isQuery(command string) bool {
+	switch command {
+	case CommandDataTransfer, CommandTagGroup, CommandReceipt, CommandSessionHistory:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseCommandTopic extracts the connector id and command from a topic: <prefix>/<id>/connector/<connectorId>/set/<command>.
+func (b *Bridge) parseCommandTopic(topic string) (int, string, error) {
+	if !strings.HasPrefix(topic, b.baseTopic+"/") {
+		return 0, "", ErrInvalidCommandTopic
+	}
+
+	parts := strings.Split(strings.TrimPrefix(topic, b.baseTopic+"/"), "/")
+	if len(parts) != 4 || parts[0] != "connector" || parts[2] != commandTopic {
+		return 0, "", ErrInvalidCommandTopic
+	}
+
+	connectorId, err := strconv.Atoi(parts[1])
+	if err != nil || connectorId < 0 {
+		return 0, "", ErrInvalidCommandTopic
+	}
+
+	return connectorId, parts[3], nil
+}
+
+func (b *Bridge) topic(subtopic string) string {
+	return fmt.Sprintf("%s/%s", b.baseTopic, subtopic)
+}
+
+func (b *Bridge) connectorTopic(connectorId int, subtopic string) string {
+	return fmt.Sprintf("%s/connector/%d/%s", b.baseTopic, connectorId, subtopic)
+}
+
+func (b *Bridge) publishJson(topic string, payload interface{}, retained bool) {
+	marshal, err := json.Marshal(payload)
+	if err != nil {
+		b.logger.WithError(err).Errorf("Unable to marshal the payload for %s", topic)
+		return
+	}
+
+	b.publish(topic, marshal, retained)
+}
+
+func (b *Bridge) publish(topic string, payload interface{}, retained bool) {
+	b.logger.Tracef("Publishing to %s", topic)
+	token := b.client.Publish(topic, b.settings.Qos, retained, payload)
+
+	go func() {
+		if err := waitForToken(token); err != nil {
+			b.logger.WithError(err).Errorf("Unable to publish to %s", topic)
+		}
+	}()
+}
+
+// flattenMeterValues converts the sampled values into a measurand-value map with the timestamp of the latest sample.
+func flattenMeterValues(notification models.MeterValueNotification) map[string]interface{} {
+	values := map[string]interface{}{
+		"evseId":      notification.EvseId,
+		"connectorId": notification.ConnectorId,
+	}
+
+	if notification.TransactionId != nil {
+		values["transactionId"] = *notification.TransactionId
+	}
+
+	for _, meterValue := range notification.MeterValues {
+		if meterValue.Timestamp != nil {
+			values["timestamp"] = meterValue.Timestamp.FormatTimestamp()
+		}
+
+		for _, sample := range meterValue.SampledValue {
+			value, err := strconv.ParseFloat(sample.Value, 64)
+			if err != nil {
+				continue
+			}
+
+			measurand := string(sample.Measurand)
+			if stringUtils.IsEmpty(measurand) {
+				// Default measurand according to the OCPP specification
+				measurand = "Energy.Active.Import.Register"
+			}
+
+			if stringUtils.IsNotEmpty(string(sample.Phase)) {
+				measurand = fmt.Sprintf("%s.%s", measurand, sample.Phase)
+			}
+
+			values[measurand] = value
+		}
+	}
+
+	return values
+}
+
+func waitForToken(token paho.Token) error {
+	if !token.WaitTimeout(operationTimeout) {
+		return ErrOperationTimeout
+	}
+
+	return token.Error()
+}
@@ -0,0 +1,243 @@
+package mqtt
+
+import (
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/api"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	authInput "github.com/xBlaz3kx/ChargePi-go/internal/components/auth-input"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	"testing"
+)
+
+type (
+	chargePointLimiterMock struct {
+		test.ChargePointMock
+	}
+
+	MqttBridgeTestSuite struct {
+		suite.Suite
+		chargePoint *test.ChargePointMock
+		bridge      *Bridge
+	}
+)
+
+func (c *chargePointLimiterMock) SetCurrentLimit(connectorId int, limit float64) error {
+	return c.Called(connectorId, limit).Error(0)
+}
+
+func (s *MqttBridgeTestSuite) SetupTest() {
+	var err error
+	s.chargePoint = new(test.ChargePointMock)
+
+	s.bridge, err = NewBridge(settings.Mqtt{
+		Broker:       "tcp://localhost:1883",
+		DefaultTagId: "defaultTag",
+		HomeAssistant: settings.HomeAssistant{
+			Enabled: true,
+		},
+	}, settings.Info{Id: "ChargePoint.1"}, s.chargePoint, nil)
+	s.Require().NoError(err)
+}
+
+func (s *MqttBridgeTestSuite) TestConnectRetry() {
+	// The connection is retried until the broker is reachable
+	options := s.bridge.client.OptionsReader()
+	s.Assert().True(options.ConnectRetry())
+	s.Assert().EqualValues(connectRetryInterval, options.ConnectRetryInterval())
+	s.Assert().True(options.AutoReconnect())
+}
+
+func (s *MqttBridgeTestSuite) TestParseCommandTopic() {
+	connectorId, command, err := s.bridge.parseCommandTopic("chargepi/ChargePoint.1/connector/2/set/start")
+	s.Require().NoError(err)
+	s.Assert().EqualValues(2, connectorId)
+	s.Assert().EqualValues(CommandStart, command)
+
+	// Invalid topics
+	_, _, err = s.bridge.parseCommandTopic("chargepi/ChargePoint2/connector/2/set/start")
+	s.Assert().ErrorIs(err, ErrInvalidCommandTopic)
+
+	_, _, err = s.bridge.parseCommandTopic("chargepi/ChargePoint.1/connector/abc/set/start")
+	s.Assert().ErrorIs(err, ErrInvalidCommandTopic)
+
+	_, _, err = s.bridge.parseCommandTopic("chargepi/ChargePoint.1/connector/1/status")
+	s.Assert().ErrorIs(err, ErrInvalidCommandTopic)
+}
+
+func (s *MqttBridgeTestSuite) TestExecuteCommand() {
+	s.chargePoint.On("StartCharging", "TAG123", 1).Return(&api.StartTransactionResponse{}, nil).Once()
+	s.chargePoint.On("StartCharging", "DEFAULTTAG", 1).Return(&api.StartTransactionResponse{}, nil).Once()
+	s.chargePoint.On("StopCharging", "", 1).Return(&api.StopTransactionResponse{}, nil).Once()
+	s.chargePoint.On("ChangeAvailability", 1, core.AvailabilityTypeInoperative).Return(nil).Once()
+	s.chargePoint.On("ChangeAvailability", 2, core.AvailabilityTypeOperative).Return(errors.New("rejected")).Once()
+
+	s.Assert().NoError(s.bridge.executeCommand(1, CommandStart, "tag123"))
+	s.Assert().NoError(s.bridge.executeCommand(1, CommandStart, ""))
+	s.Assert().NoError(s.bridge.executeCommand(1, CommandStop, ""))
+	// Home Assistant buttons
+	s.chargePoint.On("StartCharging", "DEFAULTTAG", 2).Return(&api.StartTransactionResponse{}, nil).Once()
+	s.chargePoint.On("StopCharging", "", 2).Return(&api.StopTransactionResponse{}, nil).Once()
+	s.Assert().NoError(s.bridge.executeCommand(2, CommandStart, payloadPress))
+	s.Assert().NoError(s.bridge.executeCommand(2, CommandStop, payloadPress))
+	s.Assert().NoError(s.bridge.executeCommand(1, CommandAvailability, string(core.AvailabilityTypeInoperative)))
+	s.Assert().Error(s.bridge.executeCommand(2, CommandAvailability, string(core.AvailabilityTypeOperative)))
+	s.Assert().Error(s.bridge.executeCommand(1, CommandAvailability, "Maintenance"))
+
+	// The charge point doesn't support current limiting
+	s.Assert().ErrorIs(s.bridge.executeCommand(1, CommandCurrentLimit, "16"), ErrCommandNotSupported)
+	s.Assert().ErrorIs(s.bridge.executeCommand(1, "reboot", ""), ErrCommandNotSupported)
+
+	// No default tag configured
+	s.bridge.settings.DefaultTagId = ""
+	s.Assert().ErrorIs(s.bridge.executeCommand(1, CommandStart, ""), ErrNoTagId)
+
+	s.chargePoint.AssertExpectations(s.T())
+}
+
+func (s *MqttBridgeTestSuite) TestCurrentLimit() {
+	limiter := new(chargePointLimiterMock)
+	limiter.On("SetCurrentLimit", 1, 16.5).Return(nil).Once()
+	s.bridge.chargePoint = limiter
+
+	s.Assert().NoError(s.bridge.executeCommand(1, CommandCurrentLimit, "16.5"))
+	s.Assert().Error(s.bridge.executeCommand(1, CommandCurrentLimit, "abc"))
+
+	limiter.AssertExpectations(s.T())
+}
+
+func (s *MqttBridgeTestSuite) TestAuthorize() {
+	s.chargePoint.On("SubmitAuthorization", authInput.Request{
+		Source:      authInput.SourcePayment,
+		IdTag:       "payment123",
+		ConnectorId: 2,
+		Language:    "de",
+	}).Return(nil).Once()
+
+	s.Assert().NoError(s.bridge.executeCommand(2, CommandAuthorize, `{"idTag":"payment123","language":"de"}`))
+	s.Assert().ErrorIs(s.bridge.executeCommand(2, CommandAuthorize, `{"language":"de"}`), ErrNoTagId)
+	s.Assert().ErrorIs(s.bridge.executeCommand(2, CommandAuthorize, "payment123"), ErrInvalidPayload)
+	s.Assert().False(isQuery(CommandAuthorize))
+
+	s.chargePoint.AssertExpectations(s.T())
+}
+
+func (s *MqttBridgeTestSuite) TestDataTransfer() {
+	response := &core.DataTransferConfirmation{Status: core.DataTransferStatusAccepted, Data: "ok"}
+	s.chargePoint.On("SendDataTransfer", "ChargePi", "Diagnostics", map[string]interface{}{"level": "info"}).Return(response, nil).Once()
+	s.chargePoint.On("SendDataTransfer", "ChargePi", "", "text").Return(nil, errors.New("not connected")).Once()
+
+	data, err := s.bridge.executeQuery(CommandDataTransfer, `{"vendorId":"ChargePi","messageId":"Diagnostics","data":{"level":"info"}}`)
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(response, data)
+
+	_, err = s.bridge.executeQuery(CommandDataTransfer, `{"vendorId":"ChargePi","data":"text"}`)
+	s.Assert().Error(err)
+
+	// Invalid payloads
+	_, err = s.bridge.executeQuery(CommandDataTransfer, `{"messageId":"Diagnostics"}`)
+	s.Assert().ErrorIs(err, ErrInvalidPayload)
+	_, err = s.bridge.executeQuery(CommandDataTransfer, "abc")
+	s.Assert().ErrorIs(err, ErrInvalidPayload)
+
+	s.Assert().True(isQuery(CommandDataTransfer))
+	s.Assert().False(isQuery(CommandStart))
+	s.chargePoint.AssertExpectations(s.T())
+}
+
+func (s *MqttBridgeTestSuite) TestTagGroup() {
+	group := &auth.TagGroup{IdTag: "TAG1", ParentIdTag: "FLEET", Members: []string{"TAG1", "TAG2"}}
+	s.chargePoint.On("GetTagGroup", "TAG1").Return(group, nil).Once()
+	s.chargePoint.On("GetTagGroup", "TAG3").Return(nil, errors.New("cache disabled")).Once()
+
+	data, err := s.bridge.executeQuery(CommandTagGroup, "tag1")
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(group, data)
+
+	_, err = s.bridge.executeQuery(CommandTagGroup, "TAG3")
+	s.Assert().Error(err)
+
+	_, err = s.bridge.executeQuery(CommandTagGroup, "")
+	s.Assert().ErrorIs(err, ErrNoTagId)
+
+	s.Assert().True(isQuery(CommandTagGroup))
+	s.chargePoint.AssertExpectations(s.T())
+}
+
+func (s *MqttBridgeTestSuite) TestReceipts() {
+	receipt := session.Receipt{TransactionId: "1234", Energy: 5000, Currency: "EUR"}
+	s.chargePoint.On("GetReceipt", "1234").Return(&receipt, nil).Once()
+	s.chargePoint.On("GetSessionHistory").Return([]session.Receipt{receipt}, nil).Once()
+
+	data, err := s.bridge.executeQuery(CommandReceipt, "1234")
+	s.Assert().NoError(err)
+	s.Assert().EqualValues(&receipt, data)
+
+	_, err = s.bridge.executeQuery(CommandReceipt, "")
+	s.Assert().ErrorIs(err, ErrInvalidPayload)
+
+	data, err = s.bridge.executeQuery(CommandSessionHistory, "")
+	s.Assert().NoError(err)
+	s.Assert().EqualValues([]session.Receipt{receipt}, data)
+
+	s.Assert().True(isQuery(CommandReceipt))
+	s.Assert().True(isQuery(CommandSessionHistory))
+	s.chargePoint.AssertExpectations(s.T())
+}
+
+func (s *MqttBridgeTestSuite) TestFlattenMeterValues() {
+	transactionId := 1
+	notification := models.MeterValueNotification{
+		EvseId:        1,
+		ConnectorId:   2,
+		TransactionId: &transactionId,
+		MeterValues: []types.MeterValue{
+			{
+				SampledValue: []types.SampledValue{
+					{Value: "1000"},
+					{Value: "230.5", Measurand: types.MeasurandVoltage, Phase: types.PhaseL1},
+					{Value: "abc", Measurand: types.MeasurandCurrentImport},
+				},
+			},
+		},
+	}
+
+	values := flattenMeterValues(notification)
+	s.Assert().EqualValues(1, values["evseId"])
+	s.Assert().EqualValues(2, values["connectorId"])
+	s.Assert().EqualValues(1, values["transactionId"])
+	s.Assert().EqualValues(1000, values["Energy.Active.Import.Register"])
+	s.Assert().EqualValues(230.5, values["Voltage.L1"])
+	s.Assert().NotContains(values, "Current.Import")
+}
+
+func (s *MqttBridgeTestSuite) TestDiscoveryEntities() {
+	// The charge point doesn't support current limiting
+	s.Assert().Len(s.bridge.discoveryEntities(1), 6)
+
+	s.bridge.chargePoint = new(chargePointLimiterMock)
+	entities := s.bridge.discoveryEntities(1)
+	s.Require().Len(entities, 7)
+
+	for _, entity := range entities {
+		s.Assert().Contains(entity.objectId, "ChargePoint_1_connector_1_")
+		s.Assert().EqualValues("chargepi/ChargePoint.1/availability", entity.config.AvailabilityTopic)
+		s.Assert().EqualValues([]string{"ChargePoint.1"}, entity.config.Device.Identifiers)
+		s.Assert().True(entity.config.StateTopic != "" || entity.config.CommandTopic != "")
+	}
+
+	s.Assert().EqualValues("chargepi/ChargePoint.1/connector/1/status", entities[0].config.StateTopic)
+	s.Assert().EqualValues("chargepi/ChargePoint.1/connector/1/set/start", entities[3].config.CommandTopic)
+	s.Assert().EqualValues(payloadPress, entities[3].config.PayloadPress)
+	s.Assert().EqualValues(payloadPress, entities[4].config.PayloadPress)
+	s.Assert().EqualValues("chargepi/ChargePoint.1/connector/1/set/currentLimit", entities[6].config.CommandTopic)
+}
+
+func TestMqttBridge(t *testing.T) {
+	suite.Run(t, new(MqttBridgeTestSuite))
+}
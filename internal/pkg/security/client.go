@@ -0,0 +1,275 @@
+package security
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/lorenzodonini/ocpp-go/ocppj"
+	"github.com/lorenzodonini/ocpp-go/ws"
+	log "github.com/sirupsen/logrus"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultRequestTimeout is the time after which a request without a response is canceled.
+const defaultRequestTimeout = time.Second * 30
+
+var (
+	ErrNotConnected       = errors.New("not connected to the central system")
+	ErrUnsupportedFeature = errors.New("feature not supported by the security profile")
+	ErrRequestTimeout     = errors.New("request timed out")
+)
+
+type (
+	// ChargePointHandler handles the requests of the security extension, sent by the central system.
+	ChargePointHandler interface {
+		OnExtendedTriggerMessage(request *ExtendedTriggerMessageRequest) (*ExtendedTriggerMessageConfirmation, error)
+		OnCertificateSigned(request *CertificateSignedRequest) (*CertificateSignedConfirmation, error)
+		OnInstallCertificate(request *InstallCertificateRequest) (*InstallCertificateConfirmation, error)
+		OnDeleteCertificate(request *DeleteCertificateRequest) (*DeleteCertificateConfirmation, error)
+		OnGetInstalledCertificateIds(request *GetInstalledCertificateIdsRequest) (*GetInstalledCertificateIdsConfirmation, error)
+		OnSignedUpdateFirmware(request *SignedUpdateFirmwareRequest) (*SignedUpdateFirmwareConfirmation, error)
+		OnGetLog(request *GetLogRequest) (*GetLogConfirmation, error)
+	}
+
+	// Client wraps the websocket client of the charge point. The messages of the security extension are handled by
+	// the client, while all the other messages are passed to the OCPP library.
+	Client struct {
+		ws.WsClient
+		mu              sync.Mutex
+		messageHandler  func(data []byte) error
+		handler         ChargePointHandler
+		pendingRequests map[string]*pendingRequest
+		timeout         time.Duration
+	}
+
+	pendingRequest struct {
+		feature  ocpp.Feature
+		callback func(response ocpp.Response, err error)
+		timer    *time.Timer
+	}
+)
+
+// NewClient wraps the websocket client.
+func NewClient(client ws.WsClient) *Client {
+	return &Client{
+		WsClient:        client,
+		pendingRequests: map[string]*pendingRequest{},
+		timeout:         defaultRequestTimeout,
+	}
+}
+
+// SetHandler sets the handler for the requests sent by the central system.
+func (c *Client) SetHandler(handler ChargePointHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler = handler
+}
+
+// SetMessageHandler intercepts the incoming messages before they are passed to the handler of the OCPP library.
+func (c *Client) SetMessageHandler(handler func(data []byte) error) {
+	c.messageHandler = handler
+	c.WsClient.SetMessageHandler(c.handleMessage)
+}
+
+// SendRequestAsync sends a request of the security extension to the central system. The callback is invoked when
+// the response is received or the request times out.
+func (c *Client) SendRequestAsync(request ocpp.Request, callback func(response ocpp.Response, err error)) error {
+	feature, isFound := Profile.Features[request.GetFeatureName()]
+	if !isFound {
+		return ErrUnsupportedFeature
+	}
+
+	if !c.IsConnected() {
+		return ErrNotConnected
+	}
+
+	err := types.Validate.Struct(request)
+	if err != nil {
+		return err
+	}
+
+	var (
+		messageId = strconv.FormatUint(uint64(rand.Uint32()), 10)
+		call      = []interface{}{int(ocppj.CALL), messageId, request.GetFeatureName(), request}
+	)
+
+	data, err := json.Marshal(call)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.pendingRequests[messageId] = &pendingRequest{
+		feature:  feature,
+		callback: callback,
+		timer: time.AfterFunc(c.timeout, func() {
+			if pending := c.completeRequest(messageId); pending != nil {
+				pending.callback(nil, ErrRequestTimeout)
+			}
+		}),
+	}
+	c.mu.Unlock()
+
+	err = c.Write(data)
+	if err != nil {
+		c.completeRequest(messageId)
+	}
+
+	return err
+}
+
+// handleMessage handles the messages of the security extension and passes all the other messages to the OCPP library.
+func (c *Client) handleMessage(data []byte) error {
+	var (
+		message     []json.RawMessage
+		messageType ocppj.MessageType
+		messageId   string
+	)
+
+	if json.Unmarshal(data, &message) != nil || len(message) < 3 ||
+		json.Unmarshal(message[0], &messageType) != nil || json.Unmarshal(message[1], &messageId) != nil {
+		return c.messageHandler(data)
+	}
+
+	switch messageType {
+	case ocppj.CALL:
+		var action string
+		if len(message) == 4 && json.Unmarshal(message[2], &action) == nil && Profile.SupportsFeature(action) {
+			c.handleRequest(messageId, action, message[3])
+			return nil
+		}
+	case ocppj.CALL_RESULT, ocppj.CALL_ERROR:
+		if pending := c.completeRequest(messageId); pending != nil {
+			c.handleResponse(pending, messageType, message)
+			return nil
+		}
+	}
+
+	return c.messageHandler(data)
+}
+
+func (c *Client) handleRequest(messageId, action string, payload []byte) {
+	logInfo := log.WithFields(log.Fields{
+		"messageId": messageId,
+		"action":    action,
+	})
+
+	c.mu.Lock()
+	handler := c.handler
+	c.mu.Unlock()
+
+	if handler == nil {
+		c.sendError(messageId, ocppj.NotSupported, fmt.Sprintf("unsupported action %s on charge point", action))
+		return
+	}
+
+	request := reflect.New(Profile.Features[action].GetRequestType()).Interface()
+	err := json.Unmarshal(payload, request)
+	if err != nil {
+		c.sendError(messageId, ocppj.FormationViolation, err.Error())
+		return
+	}
+
+	err = types.Validate.Struct(request)
+	if err != nil {
+		c.sendError(messageId, ocppj.PropertyConstraintViolation, err.Error())
+		return
+	}
+
+	var response ocpp.Response
+	switch req := request.(type) {
+	case *ExtendedTriggerMessageRequest:
+		response, err = handler.OnExtendedTriggerMessage(req)
+	case *CertificateSignedRequest:
+		response, err = handler.OnCertificateSigned(req)
+	case *InstallCertificateRequest:
+		response, err = handler.OnInstallCertificate(req)
+	case *DeleteCertificateRequest:
+		response, err = handler.OnDeleteCertificate(req)
+	case *GetInstalledCertificateIdsRequest:
+		response, err = handler.OnGetInstalledCertificateIds(req)
+	case *SignedUpdateFirmwareRequest:
+		response, err = handler.OnSignedUpdateFirmware(req)
+	case *GetLogRequest:
+		response, err = handler.OnGetLog(req)
+	default:
+		c.sendError(messageId, ocppj.NotSupported, fmt.Sprintf("unsupported action %s on charge point", action))
+		return
+	}
+
+	if err != nil {
+		logInfo.WithError(err).Errorf("Error handling the request")
+		c.sendError(messageId, ocppj.InternalError, err.Error())
+		return
+	}
+
+	data, err := json.Marshal([]interface{}{int(ocppj.CALL_RESULT), messageId, response})
+	if err != nil {
+		logInfo.WithError(err).Errorf("Cannot marshal the response")
+		return
+	}
+
+	err = c.Write(data)
+	if err != nil {
+		logInfo.WithError(err).Errorf("Cannot send the response")
+	}
+}
+
+func (c *Client) handleResponse(pending *pendingRequest, messageType ocppj.MessageType, message []json.RawMessage) {
+	if messageType == ocppj.CALL_ERROR {
+		var errorCode, description string
+		_ = json.Unmarshal(message[2], &errorCode)
+		if len(message) > 3 {
+			_ = json.Unmarshal(message[3], &description)
+		}
+
+		pending.callback(nil, ocpp.NewError(ocpp.ErrorCode(errorCode), description, ""))
+		return
+	}
+
+	response := reflect.New(pending.feature.GetResponseType()).Interface()
+	err := json.Unmarshal(message[2], response)
+	if err == nil {
+		err = types.Validate.Struct(response)
+	}
+
+	if err != nil {
+		pending.callback(nil, err)
+		return
+	}
+
+	pending.callback(response.(ocpp.Response), nil)
+}
+
+// completeRequest removes the request from the pending requests and returns it, if it exists.
+func (c *Client) completeRequest(messageId string) *pendingRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, isFound := c.pendingRequests[messageId]
+	if !isFound {
+		return nil
+	}
+
+	pending.timer.Stop()
+	delete(c.pendingRequests, messageId)
+	return pending
+}
+
+func (c *Client) sendError(messageId string, errorCode ocpp.ErrorCode, description string) {
+	data, err := json.Marshal([]interface{}{int(ocppj.CALL_ERROR), messageId, errorCode, description, map[string]interface{}{}})
+	if err != nil {
+		return
+	}
+
+	err = c.Write(data)
+	if err != nil {
+		log.WithError(err).Errorf("Cannot send the error response")
+	}
+}
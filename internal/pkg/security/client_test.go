@@ -0,0 +1,222 @@
+package security
+
+import (
+	"encoding/json"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocppj"
+	"github.com/lorenzodonini/ocpp-go/ws"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"sync"
+	"testing"
+	"time"
+)
+
+type (
+	wsClientMock struct {
+		ws.WsClient
+		mu             sync.Mutex
+		messageHandler func(data []byte) error
+		written        [][]byte
+	}
+
+	handlerMock struct {
+		mock.Mock
+	}
+
+	clientTestSuite struct {
+		suite.Suite
+		wsClient  *wsClientMock
+		handler   *handlerMock
+		client    *Client
+		forwarded [][]byte
+	}
+)
+
+func (w *wsClientMock) SetMessageHandler(handler func(data []byte) error) {
+	w.messageHandler = handler
+}
+
+func (w *wsClientMock) IsConnected() bool {
+	return true
+}
+
+func (w *wsClientMock) Write(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = append(w.written, data)
+	return nil
+}
+
+func (w *wsClientMock) lastMessage() []json.RawMessage {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var message []json.RawMessage
+	if len(w.written) > 0 {
+		_ = json.Unmarshal(w.written[len(w.written)-1], &message)
+	}
+
+	return message
+}
+
+func (h *handlerMock) OnExtendedTriggerMessage(request *ExtendedTriggerMessageRequest) (*ExtendedTriggerMessageConfirmation, error) {
+	args := h.Called(request)
+	return args.Get(0).(*ExtendedTriggerMessageConfirmation), args.Error(1)
+}
+
+func (h *handlerMock) OnCertificateSigned(request *CertificateSignedRequest) (*CertificateSignedConfirmation, error) {
+	args := h.Called(request)
+	return args.Get(0).(*CertificateSignedConfirmation), args.Error(1)
+}
+
+func (h *handlerMock) OnInstallCertificate(request *InstallCertificateRequest) (*InstallCertificateConfirmation, error) {
+	args := h.Called(request)
+	return args.Get(0).(*InstallCertificateConfirmation), args.Error(1)
+}
+
+func (h *handlerMock) OnDeleteCertificate(request *DeleteCertificateRequest) (*DeleteCertificateConfirmation, error) {
+	args := h.Called(request)
+	return args.Get(0).(*DeleteCertificateConfirmation), args.Error(1)
+}
+
+func (h *handlerMock) OnGetInstalledCertificateIds(request *GetInstalledCertificateIdsRequest) (*GetInstalledCertificateIdsConfirmation, error) {
+	args := h.Called(request)
+	return args.Get(0).(*GetInstalledCertificateIdsConfirmation), args.Error(1)
+}
+
+func (h *handlerMock) OnSignedUpdateFirmware(request *SignedUpdateFirmwareRequest) (*SignedUpdateFirmwareConfirmation, error) {
+	args := h.Called(request)
+	return args.Get(0).(*SignedUpdateFirmwareConfirmation), args.Error(1)
+}
+
+func (h *handlerMock) OnGetLog(request *GetLogRequest) (*GetLogConfirmation, error) {
+	args := h.Called(request)
+	return args.Get(0).(*GetLogConfirmation), args.Error(1)
+}
+
+func (s *clientTestSuite) SetupTest() {
+	s.wsClient = &wsClientMock{}
+	s.handler = new(handlerMock)
+	s.forwarded = nil
+
+	s.client = NewClient(s.wsClient)
+	s.client.SetHandler(s.handler)
+	s.client.SetMessageHandler(func(data []byte) error {
+		s.forwarded = append(s.forwarded, data)
+		return nil
+	})
+}
+
+func (s *clientTestSuite) TestForwardMessages() {
+	// Core messages are passed to the OCPP library
+	message := []byte(`[2,"1234","Reset",{"type":"Soft"}]`)
+	s.Require().NoError(s.wsClient.messageHandler(message))
+	s.Assert().Len(s.forwarded, 1)
+
+	// Responses to unknown requests are passed to the OCPP library
+	message = []byte(`[3,"1234",{"status":"Accepted"}]`)
+	s.Require().NoError(s.wsClient.messageHandler(message))
+	s.Assert().Len(s.forwarded, 2)
+	s.Assert().Empty(s.wsClient.written)
+}
+
+func (s *clientTestSuite) TestHandleRequest() {
+	s.handler.On("OnInstallCertificate", &InstallCertificateRequest{
+		CertificateType: CertificateUseCentralSystemRoot,
+		Certificate:     "certificate",
+	}).Return(&InstallCertificateConfirmation{Status: CertificateStatusAccepted}, nil)
+
+	message := []byte(`[2,"1234","InstallCertificate",{"certificateType":"CentralSystemRootCertificate","certificate":"certificate"}]`)
+	s.Require().NoError(s.wsClient.messageHandler(message))
+	s.Assert().Empty(s.forwarded)
+	s.handler.AssertExpectations(s.T())
+
+	var (
+		response     = s.wsClient.lastMessage()
+		messageType  ocppj.MessageType
+		confirmation InstallCertificateConfirmation
+	)
+
+	s.Require().Len(response, 3)
+	s.Require().NoError(json.Unmarshal(response[0], &messageType))
+	s.Require().NoError(json.Unmarshal(response[2], &confirmation))
+	s.Assert().EqualValues(ocppj.CALL_RESULT, messageType)
+	s.Assert().EqualValues(CertificateStatusAccepted, confirmation.Status)
+}
+
+func (s *clientTestSuite) TestHandleInvalidRequest() {
+	message := []byte(`[2,"1234","InstallCertificate",{"certificateType":"Invalid","certificate":"certificate"}]`)
+	s.Require().NoError(s.wsClient.messageHandler(message))
+	s.handler.AssertNotCalled(s.T(), "OnInstallCertificate", mock.Anything)
+
+	var (
+		response    = s.wsClient.lastMessage()
+		messageType ocppj.MessageType
+		errorCode   string
+	)
+
+	s.Require().Len(response, 5)
+	s.Require().NoError(json.Unmarshal(response[0], &messageType))
+	s.Require().NoError(json.Unmarshal(response[2], &errorCode))
+	s.Assert().EqualValues(ocppj.CALL_ERROR, messageType)
+	s.Assert().EqualValues(ocppj.PropertyConstraintViolation, errorCode)
+}
+
+func (s *clientTestSuite) TestSendRequest() {
+	responses := make(chan ocpp.Response, 1)
+
+	err := s.client.SendRequestAsync(NewSecurityEventNotificationRequest("StartupOfTheDevice", ""),
+		func(response ocpp.Response, err error) {
+			s.Assert().NoError(err)
+			responses <- response
+		})
+	s.Require().NoError(err)
+
+	var (
+		request   = s.wsClient.lastMessage()
+		messageId string
+		action    string
+	)
+
+	s.Require().Len(request, 4)
+	s.Require().NoError(json.Unmarshal(request[1], &messageId))
+	s.Require().NoError(json.Unmarshal(request[2], &action))
+	s.Assert().EqualValues(SecurityEventNotificationFeatureName, action)
+
+	s.Require().NoError(s.wsClient.messageHandler([]byte(`[3,"` + messageId + `",{}]`)))
+	s.Assert().Empty(s.forwarded)
+
+	select {
+	case response := <-responses:
+		s.Assert().IsType(&SecurityEventNotificationConfirmation{}, response)
+	case <-time.After(time.Second):
+		s.Fail("response not received")
+	}
+}
+
+func (s *clientTestSuite) TestSendRequestTimeout() {
+	s.client.timeout = time.Millisecond * 50
+	errs := make(chan error, 1)
+
+	err := s.client.SendRequestAsync(&SignCertificateRequest{CSR: "csr"}, func(response ocpp.Response, err error) {
+		errs <- err
+	})
+	s.Require().NoError(err)
+
+	select {
+	case err := <-errs:
+		s.Assert().ErrorIs(err, ErrRequestTimeout)
+	case <-time.After(time.Second):
+		s.Fail("request did not time out")
+	}
+
+	// Unsupported features are not sent
+	err = s.client.SendRequestAsync(core.NewHeartbeatRequest(), nil)
+	s.Assert().ErrorIs(err, ErrUnsupportedFeature)
+}
+
+func TestClient(t *testing.T) {
+	suite.Run(t, new(clientTestSuite))
+}
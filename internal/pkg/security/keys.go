@@ -0,0 +1,13 @@
+package security
+
+import "github.com/xBlaz3kx/ocppManager-go/configuration"
+
+// Configuration keys of the OCPP 1.6 security extension
+const (
+	SecurityProfileKey                = configuration.Key("SecurityProfile")
+	AuthorizationKeyKey               = configuration.Key("AuthorizationKey")
+	CpoNameKey                        = configuration.Key("CpoName")
+	CertificateStoreMaxLengthKey      = configuration.Key("CertificateStoreMaxLength")
+	CertificateSignedMaxChainSizeKey  = configuration.Key("CertificateSignedMaxChainSize")
+	AdditionalRootCertificateCheckKey = configuration.Key("AdditionalRootCertificateCheck")
+)
@@ -0,0 +1,373 @@
+package security
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"reflect"
+	"time"
+)
+
+// Messages of the OCPP 1.6 security extension
+const (
+	ProfileName = "Security"
+
+	ExtendedTriggerMessageFeatureName     = "ExtendedTriggerMessage"
+	SignCertificateFeatureName            = "SignCertificate"
+	CertificateSignedFeatureName          = "CertificateSigned"
+	InstallCertificateFeatureName         = "InstallCertificate"
+	DeleteCertificateFeatureName          = "DeleteCertificate"
+	GetInstalledCertificateIdsFeatureName = "GetInstalledCertificateIds"
+	SecurityEventNotificationFeatureName  = "SecurityEventNotification"
+
+	SignedUpdateFirmwareFeatureName             = "SignedUpdateFirmware"
+	SignedFirmwareStatusNotificationFeatureName = "SignedFirmwareStatusNotification"
+	GetLogFeatureName                           = "GetLog"
+	LogStatusNotificationFeatureName            = "LogStatusNotification"
+)
+
+const (
+	MessageTriggerBootNotification           = MessageTrigger("BootNotification")
+	MessageTriggerLogStatusNotification      = MessageTrigger("LogStatusNotification")
+	MessageTriggerFirmwareStatusNotification = MessageTrigger("FirmwareStatusNotification")
+	MessageTriggerHeartbeat                  = MessageTrigger("Heartbeat")
+	MessageTriggerMeterValues                = MessageTrigger("MeterValues")
+	MessageTriggerSignChargePointCertificate = MessageTrigger("SignChargePointCertificate")
+	MessageTriggerStatusNotification         = MessageTrigger("StatusNotification")
+
+	TriggerMessageStatusAccepted       = TriggerMessageStatus("Accepted")
+	TriggerMessageStatusRejected       = TriggerMessageStatus("Rejected")
+	TriggerMessageStatusNotImplemented = TriggerMessageStatus("NotImplemented")
+
+	GenericStatusAccepted = GenericStatus("Accepted")
+	GenericStatusRejected = GenericStatus("Rejected")
+
+	CertificateUseCentralSystemRoot = CertificateUse("CentralSystemRootCertificate")
+	CertificateUseManufacturerRoot  = CertificateUse("ManufacturerRootCertificate")
+
+	CertificateStatusAccepted = CertificateStatus("Accepted")
+	CertificateStatusFailed   = CertificateStatus("Failed")
+	CertificateStatusRejected = CertificateStatus("Rejected")
+
+	DeleteCertificateStatusAccepted = DeleteCertificateStatus("Accepted")
+	DeleteCertificateStatusFailed   = DeleteCertificateStatus("Failed")
+	DeleteCertificateStatusNotFound = DeleteCertificateStatus("NotFound")
+
+	GetInstalledCertificateStatusAccepted = GetInstalledCertificateStatus("Accepted")
+	GetInstalledCertificateStatusNotFound = GetInstalledCertificateStatus("NotFound")
+
+	HashAlgorithmSHA256 = HashAlgorithm("SHA256")
+	HashAlgorithmSHA384 = HashAlgorithm("SHA384")
+	HashAlgorithmSHA512 = HashAlgorithm("SHA512")
+
+	UpdateFirmwareStatusAccepted           = UpdateFirmwareStatus("Accepted")
+	UpdateFirmwareStatusRejected           = UpdateFirmwareStatus("Rejected")
+	UpdateFirmwareStatusAcceptedCanceled   = UpdateFirmwareStatus("AcceptedCanceled")
+	UpdateFirmwareStatusInvalidCertificate = UpdateFirmwareStatus("InvalidCertificate")
+	UpdateFirmwareStatusRevokedCertificate = UpdateFirmwareStatus("RevokedCertificate")
+
+	FirmwareStatusDownloaded                = FirmwareStatus("Downloaded")
+	FirmwareStatusDownloadFailed            = FirmwareStatus("DownloadFailed")
+	FirmwareStatusDownloading               = FirmwareStatus("Downloading")
+	FirmwareStatusDownloadScheduled         = FirmwareStatus("DownloadScheduled")
+	FirmwareStatusDownloadPaused            = FirmwareStatus("DownloadPaused")
+	FirmwareStatusIdle                      = FirmwareStatus("Idle")
+	FirmwareStatusInstallationFailed        = FirmwareStatus("InstallationFailed")
+	FirmwareStatusInstalling                = FirmwareStatus("Installing")
+	FirmwareStatusInstalled                 = FirmwareStatus("Installed")
+	FirmwareStatusInstallRebooting          = FirmwareStatus("InstallRebooting")
+	FirmwareStatusInstallScheduled          = FirmwareStatus("InstallScheduled")
+	FirmwareStatusInstallVerificationFailed = FirmwareStatus("InstallVerificationFailed")
+	FirmwareStatusInvalidSignature          = FirmwareStatus("InvalidSignature")
+	FirmwareStatusSignatureVerified         = FirmwareStatus("SignatureVerified")
+
+	LogTypeDiagnostics = LogType("DiagnosticsLog")
+	LogTypeSecurity    = LogType("SecurityLog")
+
+	LogStatusAccepted         = LogStatus("Accepted")
+	LogStatusRejected         = LogStatus("Rejected")
+	LogStatusAcceptedCanceled = LogStatus("AcceptedCanceled")
+
+	UploadLogStatusBadMessage            = UploadLogStatus("BadMessage")
+	UploadLogStatusIdle                  = UploadLogStatus("Idle")
+	UploadLogStatusNotSupportedOperation = UploadLogStatus("NotSupportedOperation")
+	UploadLogStatusPermissionDenied      = UploadLogStatus("PermissionDenied")
+	UploadLogStatusUploaded              = UploadLogStatus("Uploaded")
+	UploadLogStatusUploadFailure         = UploadLogStatus("UploadFailure")
+	UploadLogStatusUploading             = UploadLogStatus("Uploading")
+)
+
+type (
+	MessageTrigger                string
+	TriggerMessageStatus          string
+	GenericStatus                 string
+	CertificateUse                string
+	CertificateStatus             string
+	DeleteCertificateStatus       string
+	GetInstalledCertificateStatus string
+	HashAlgorithm                 string
+	UpdateFirmwareStatus          string
+	FirmwareStatus                string
+	LogType                       string
+	LogStatus                     string
+	UploadLogStatus               string
+
+	CertificateHashData struct {
+		HashAlgorithm  HashAlgorithm `json:"hashAlgorithm" validate:"required,oneof=SHA256 SHA384 SHA512"`
+		IssuerNameHash string        `json:"issuerNameHash" validate:"required,max=128"`
+		IssuerKeyHash  string        `json:"issuerKeyHash" validate:"required,max=128"`
+		SerialNumber   string        `json:"serialNumber" validate:"required,max=40"`
+	}
+
+	ExtendedTriggerMessageRequest struct {
+		RequestedMessage MessageTrigger `json:"requestedMessage" validate:"required,oneof=BootNotification LogStatusNotification FirmwareStatusNotification Heartbeat MeterValues SignChargePointCertificate StatusNotification"`
+		ConnectorId      *int           `json:"connectorId,omitempty" validate:"omitempty,gt=0"`
+	}
+
+	ExtendedTriggerMessageConfirmation struct {
+		Status TriggerMessageStatus `json:"status" validate:"required,oneof=Accepted Rejected NotImplemented"`
+	}
+
+	SignCertificateRequest struct {
+		CSR string `json:"csr" validate:"required,max=5500"`
+	}
+
+	SignCertificateConfirmation struct {
+		Status GenericStatus `json:"status" validate:"required,oneof=Accepted Rejected"`
+	}
+
+	CertificateSignedRequest struct {
+		CertificateChain string `json:"certificateChain" validate:"required,max=10000"`
+	}
+
+	CertificateSignedConfirmation struct {
+		Status GenericStatus `json:"status" validate:"required,oneof=Accepted Rejected"`
+	}
+
+	InstallCertificateRequest struct {
+		CertificateType CertificateUse `json:"certificateType" validate:"required,oneof=CentralSystemRootCertificate ManufacturerRootCertificate"`
+		Certificate     string         `json:"certificate" validate:"required,max=5500"`
+	}
+
+	InstallCertificateConfirmation struct {
+		Status CertificateStatus `json:"status" validate:"required,oneof=Accepted Failed Rejected"`
+	}
+
+	DeleteCertificateRequest struct {
+		CertificateHashData CertificateHashData `json:"certificateHashData" validate:"required"`
+	}
+
+	DeleteCertificateConfirmation struct {
+		Status DeleteCertificateStatus `json:"status" validate:"required,oneof=Accepted Failed NotFound"`
+	}
+
+	GetInstalledCertificateIdsRequest struct {
+		CertificateType CertificateUse `json:"certificateType" validate:"required,oneof=CentralSystemRootCertificate ManufacturerRootCertificate"`
+	}
+
+	GetInstalledCertificateIdsConfirmation struct {
+		Status              GetInstalledCertificateStatus `json:"status" validate:"required,oneof=Accepted NotFound"`
+		CertificateHashData []CertificateHashData         `json:"certificateHashData,omitempty" validate:"omitempty,dive"`
+	}
+
+	SecurityEventNotificationRequest struct {
+		Type      string          `json:"type" validate:"required,max=50"`
+		Timestamp *types.DateTime `json:"timestamp" validate:"required"`
+		TechInfo  string          `json:"techInfo,omitempty" validate:"max=255"`
+	}
+
+	SecurityEventNotificationConfirmation struct {
+	}
+
+	Firmware struct {
+		Location           string          `json:"location" validate:"required,max=512"`
+		RetrieveDateTime   *types.DateTime `json:"retrieveDateTime" validate:"required"`
+		InstallDateTime    *types.DateTime `json:"installDateTime,omitempty"`
+		SigningCertificate string          `json:"signingCertificate" validate:"required,max=5500"`
+		Signature          string          `json:"signature" validate:"required,max=800"`
+	}
+
+	SignedUpdateFirmwareRequest struct {
+		Retries       *int     `json:"retries,omitempty" validate:"omitempty,gte=0"`
+		RetryInterval *int     `json:"retryInterval,omitempty" validate:"omitempty,gte=0"`
+		RequestId     int      `json:"requestId"`
+		Firmware      Firmware `json:"firmware" validate:"required"`
+	}
+
+	SignedUpdateFirmwareConfirmation struct {
+		Status UpdateFirmwareStatus `json:"status" validate:"required,oneof=Accepted Rejected AcceptedCanceled InvalidCertificate RevokedCertificate"`
+	}
+
+	SignedFirmwareStatusNotificationRequest struct {
+		Status    FirmwareStatus `json:"status" validate:"required"`
+		RequestId *int           `json:"requestId,omitempty"`
+	}
+
+	SignedFirmwareStatusNotificationConfirmation struct {
+	}
+
+	LogParameters struct {
+		RemoteLocation  string          `json:"remoteLocation" validate:"required,max=512"`
+		OldestTimestamp *types.DateTime `json:"oldestTimestamp,omitempty"`
+		LatestTimestamp *types.DateTime `json:"latestTimestamp,omitempty"`
+	}
+
+	GetLogRequest struct {
+		LogType       LogType       `json:"logType" validate:"required,oneof=DiagnosticsLog SecurityLog"`
+		RequestId     int           `json:"requestId"`
+		Retries       *int          `json:"retries,omitempty" validate:"omitempty,gte=0"`
+		RetryInterval *int          `json:"retryInterval,omitempty" validate:"omitempty,gte=0"`
+		Log           LogParameters `json:"log" validate:"required"`
+	}
+
+	GetLogConfirmation struct {
+		Status   LogStatus `json:"status" validate:"required,oneof=Accepted Rejected AcceptedCanceled"`
+		Filename string    `json:"filename,omitempty" validate:"max=255"`
+	}
+
+	LogStatusNotificationRequest struct {
+		Status    UploadLogStatus `json:"status" validate:"required"`
+		RequestId *int            `json:"requestId,omitempty"`
+	}
+
+	LogStatusNotificationConfirmation struct {
+	}
+
+	// feature describes a message of the security extension.
+	feature struct {
+		name     string
+		request  reflect.Type
+		response reflect.Type
+	}
+)
+
+// Profile contains the messages of the OCPP 1.6 security extension.
+var Profile = ocpp.NewProfile(ProfileName,
+	newFeature(ExtendedTriggerMessageFeatureName, ExtendedTriggerMessageRequest{}, ExtendedTriggerMessageConfirmation{}),
+	newFeature(SignCertificateFeatureName, SignCertificateRequest{}, SignCertificateConfirmation{}),
+	newFeature(CertificateSignedFeatureName, CertificateSignedRequest{}, CertificateSignedConfirmation{}),
+	newFeature(InstallCertificateFeatureName, InstallCertificateRequest{}, InstallCertificateConfirmation{}),
+	newFeature(DeleteCertificateFeatureName, DeleteCertificateRequest{}, DeleteCertificateConfirmation{}),
+	newFeature(GetInstalledCertificateIdsFeatureName, GetInstalledCertificateIdsRequest{}, GetInstalledCertificateIdsConfirmation{}),
+	newFeature(SecurityEventNotificationFeatureName, SecurityEventNotificationRequest{}, SecurityEventNotificationConfirmation{}),
+	newFeature(SignedUpdateFirmwareFeatureName, SignedUpdateFirmwareRequest{}, SignedUpdateFirmwareConfirmation{}),
+	newFeature(SignedFirmwareStatusNotificationFeatureName, SignedFirmwareStatusNotificationRequest{}, SignedFirmwareStatusNotificationConfirmation{}),
+	newFeature(GetLogFeatureName, GetLogRequest{}, GetLogConfirmation{}),
+	newFeature(LogStatusNotificationFeatureName, LogStatusNotificationRequest{}, LogStatusNotificationConfirmation{}),
+)
+
+func newFeature(name string, request ocpp.Request, response ocpp.Response) ocpp.Feature {
+	return feature{
+		name:     name,
+		request:  reflect.TypeOf(request),
+		response: reflect.TypeOf(response),
+	}
+}
+
+func (f feature) GetFeatureName() string {
+	return f.name
+}
+
+func (f feature) GetRequestType() reflect.Type {
+	return f.request
+}
+
+func (f feature) GetResponseType() reflect.Type {
+	return f.response
+}
+
+func (r ExtendedTriggerMessageRequest) GetFeatureName() string {
+	return ExtendedTriggerMessageFeatureName
+}
+
+func (c ExtendedTriggerMessageConfirmation) GetFeatureName() string {
+	return ExtendedTriggerMessageFeatureName
+}
+
+func (r SignCertificateRequest) GetFeatureName() string {
+	return SignCertificateFeatureName
+}
+
+func (c SignCertificateConfirmation) GetFeatureName() string {
+	return SignCertificateFeatureName
+}
+
+func (r CertificateSignedRequest) GetFeatureName() string {
+	return CertificateSignedFeatureName
+}
+
+func (c CertificateSignedConfirmation) GetFeatureName() string {
+	return CertificateSignedFeatureName
+}
+
+func (r InstallCertificateRequest) GetFeatureName() string {
+	return InstallCertificateFeatureName
+}
+
+func (c InstallCertificateConfirmation) GetFeatureName() string {
+	return InstallCertificateFeatureName
+}
+
+func (r DeleteCertificateRequest) GetFeatureName() string {
+	return DeleteCertificateFeatureName
+}
+
+func (c DeleteCertificateConfirmation) GetFeatureName() string {
+	return DeleteCertificateFeatureName
+}
+
+func (r GetInstalledCertificateIdsRequest) GetFeatureName() string {
+	return GetInstalledCertificateIdsFeatureName
+}
+
+func (c GetInstalledCertificateIdsConfirmation) GetFeatureName() string {
+	return GetInstalledCertificateIdsFeatureName
+}
+
+func (r SecurityEventNotificationRequest) GetFeatureName() string {
+	return SecurityEventNotificationFeatureName
+}
+
+func (c SecurityEventNotificationConfirmation) GetFeatureName() string {
+	return SecurityEventNotificationFeatureName
+}
+
+func (r SignedUpdateFirmwareRequest) GetFeatureName() string {
+	return SignedUpdateFirmwareFeatureName
+}
+
+func (c SignedUpdateFirmwareConfirmation) GetFeatureName() string {
+	return SignedUpdateFirmwareFeatureName
+}
+
+func (r SignedFirmwareStatusNotificationRequest) GetFeatureName() string {
+	return SignedFirmwareStatusNotificationFeatureName
+}
+
+func (c SignedFirmwareStatusNotificationConfirmation) GetFeatureName() string {
+	return SignedFirmwareStatusNotificationFeatureName
+}
+
+func (r GetLogRequest) GetFeatureName() string {
+	return GetLogFeatureName
+}
+
+func (c GetLogConfirmation) GetFeatureName() string {
+	return GetLogFeatureName
+}
+
+func (r LogStatusNotificationRequest) GetFeatureName() string {
+	return LogStatusNotificationFeatureName
+}
+
+func (c LogStatusNotificationConfirmation) GetFeatureName() string {
+	return LogStatusNotificationFeatureName
+}
+
+// NewSecurityEventNotificationRequest creates a SecurityEventNotification request with the current time.
+func NewSecurityEventNotificationRequest(eventType string, techInfo string) *SecurityEventNotificationRequest {
+	return &SecurityEventNotificationRequest{
+		Type:      eventType,
+		Timestamp: types.NewDateTime(time.Now()),
+		TechInfo:  techInfo,
+	}
+}
@@ -19,6 +19,8 @@ const (
 	connectorsFlag     = "connector-folder"
 	authFileFlag       = "auth"
 	ocppConfigPathFlag = "ocpp-config"
+	storeFileFlag      = "store"
+	certificatesFlag   = "certs"
 )
 
 var (
@@ -27,6 +29,8 @@ var (
 	connectorsFolderPath  string
 	settingsFilePath      string
 	authFilePath          string
+	storeFilePath         string
+	certificatesPath      string
 
 	rootCmd = &cobra.Command{
 		Use:   "chargepi",
@@ -45,7 +49,7 @@ func run(cmd *cobra.Command, args []string) {
 		connectors   = settings.GetConnectors(connectorsFolderPath)
 	)
 
-	chargepoint.Run(isDebug, mainSettings, connectors, configurationFilePath, authFilePath)
+	chargepoint.Run(isDebug, mainSettings, connectors, settingsFilePath, connectorsFolderPath, configurationFilePath, authFilePath, storeFilePath, certificatesPath)
 }
 
 func setupFlags() {
@@ -53,13 +57,17 @@ func setupFlags() {
 		workingDirectory, _   = os.Getwd()
 		connectorsFolderName  = fmt.Sprintf("%s/configs/connectors", workingDirectory)
 		defaultConfigFileName = fmt.Sprintf("%s/configs/configuration.%s", workingDirectory, "json")
+		defaultStoreFileName  = fmt.Sprintf("%s/configs/chargepi.db", workingDirectory)
+		defaultCertsFolder    = fmt.Sprintf("%s/configs/certs", workingDirectory)
 	)
 
 	// Set flags
 	rootCmd.PersistentFlags().StringVar(&settingsFilePath, settingsFlag, "", "config file path")
 	rootCmd.PersistentFlags().StringVar(&connectorsFolderPath, connectorsFlag, connectorsFolderName, "connector folder path")
 	rootCmd.PersistentFlags().StringVar(&configurationFilePath, ocppConfigPathFlag, defaultConfigFileName, "OCPP config file path")
-	rootCmd.PersistentFlags().StringVar(&authFilePath, authFileFlag, "", "authorization file path")
+	rootCmd.PersistentFlags().StringVar(&authFilePath, authFileFlag, "", "authorization file path, migrated to the store at first start")
+	rootCmd.PersistentFlags().StringVar(&storeFilePath, storeFileFlag, defaultStoreFileName, "path to the state store")
+	rootCmd.PersistentFlags().StringVar(&certificatesPath, certificatesFlag, defaultCertsFolder, "folder of the charge point key and certificates")
 	rootCmd.PersistentFlags().BoolP(debugFlag, "d", false, "debug mode")
 
 	// Api flags
@@ -72,6 +80,9 @@ func setupFlags() {
 	_ = viper.BindPFlag(settings.ApiEnabled, rootCmd.PersistentFlags().Lookup(apiFlag))
 	_ = viper.BindPFlag(settings.ApiAddress, rootCmd.PersistentFlags().Lookup(apiAddressFlag))
 	_ = viper.BindPFlag(settings.ApiPort, rootCmd.PersistentFlags().Lookup(apiPortFlag))
+
+	setupConfigFlags()
+	rootCmd.AddCommand(validateCmd, configCmd)
 }
 
 func main() {
@@ -9,6 +9,9 @@ import (
 	lSyslog "github.com/sirupsen/logrus/hooks/syslog"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 	"log/syslog"
+	"os"
+	"path/filepath"
+	"sort"
 	"time"
 )
 
@@ -26,7 +29,10 @@ const (
 	Gelf   = LogFormat("gelf")
 	Json   = LogFormat("json")
 
-	logFilePath = "/var/log/chargepi/chargepi.log"
+	// LogFilePath is the path of the log file, when logging to a file is enabled.
+	LogFilePath = "/var/log/chargepi/chargepi.log"
+	// SecurityLogFilePath is the path of the security log, which is always enabled.
+	SecurityLogFilePath = "/var/log/chargepi/security.log"
 )
 
 // Setup set up all logs
@@ -44,11 +50,13 @@ func Setup(logger *log.Logger, loggingConfig settings.Logging, isDebug bool) {
 
 	logger.SetFormatter(formatter)
 	logger.SetLevel(logLevel)
+	// Remove the hooks from the previous setup, so the logging can be reconfigured
+	logger.ReplaceHooks(make(log.LevelHooks))
 
 	for _, logType := range loggingConfig.Type {
 		switch LogType(logType) {
 		case FileLogging:
-			fileLogging(logger, isDebug, logFilePath)
+			fileLogging(logger, isDebug, LogFilePath)
 			break
 		case RemoteLogging:
 			remoteLogging(logger, loggingConfig.Host, loggingConfig.Port, logFormat)
@@ -94,12 +102,7 @@ func remoteLogging(logger *log.Logger, host string, port int, format LogFormat)
 
 // fileLogging sets up the logging to file.
 func fileLogging(logger *log.Logger, isDebug bool, path string) {
-	writer, err := rotatelogs.New(
-		path+".%Y%m%d%H%M",
-		rotatelogs.WithLinkName(path),
-		rotatelogs.WithMaxAge(time.Duration(86400)*time.Second),
-		rotatelogs.WithRotationTime(time.Duration(604800)*time.Second),
-	)
+	writer, err := newRotatingWriter(path)
 	if err != nil {
 		return
 	}
@@ -119,3 +122,39 @@ func fileLogging(logger *log.Logger, isDebug bool, path string) {
 
 	logger.AddHook(hook)
 }
+
+// NewSecurityLogger creates a logger for the security events, which writes only to its own log file. The security log
+// is kept separately from the other logs, so it can be uploaded to the central system.
+func NewSecurityLogger(path string) (*log.Logger, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := newRotatingWriter(path)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := log.New()
+	logger.SetOutput(writer)
+	logger.SetFormatter(&log.JSONFormatter{})
+	logger.SetLevel(log.InfoLevel)
+	return logger, nil
+}
+
+// GetLogFiles returns the log file and its rotated files, from the oldest to the newest.
+func GetLogFiles(path string) []string {
+	files, _ := filepath.Glob(path + ".*")
+	sort.Strings(files)
+	return files
+}
+
+func newRotatingWriter(path string) (*rotatelogs.RotateLogs, error) {
+	return rotatelogs.New(
+		path+".%Y%m%d%H%M",
+		rotatelogs.WithLinkName(path),
+		rotatelogs.WithMaxAge(time.Duration(86400)*time.Second),
+		rotatelogs.WithRotationTime(time.Duration(604800)*time.Second),
+	)
+}
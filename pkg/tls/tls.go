@@ -3,38 +3,52 @@ package tls
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"github.com/lorenzodonini/ocpp-go/ws"
 	log "github.com/sirupsen/logrus"
 	"io/ioutil"
 )
 
-func GetTLSClient(CACertificatePath, ClientCertificatePath, ClientKeyPath string) *ws.Client {
+var (
+	ErrInvalidCACertificate = errors.New("no valid certificates found in the CA file")
+)
+
+// GetTLSConfig creates a tls.Config with the provided CA certificate added to the system certificate pool and the
+// client certificate loaded from the certificate and key files.
+func GetTLSConfig(CACertificatePath, ClientCertificatePath, ClientKeyPath string) (*tls.Config, error) {
 	certPool, err := x509.SystemCertPool()
 	if err != nil {
-		log.WithError(err).Fatal("Cannot fetch certificate")
+		return nil, err
 	}
 
 	// Load CA cert
 	caCert, err := ioutil.ReadFile(CACertificatePath)
 	if err != nil {
-		log.WithError(err).Errorf("Error reading certificate")
-		return nil
+		return nil, err
 	} else if !certPool.AppendCertsFromPEM(caCert) {
-		log.Errorf("no ca.cert file found, will use system CA certificates")
-		return nil
+		return nil, ErrInvalidCACertificate
 	}
 
 	// Load client certificate
 	certificate, err := tls.LoadX509KeyPair(ClientCertificatePath, ClientKeyPath)
 	if err != nil {
-		log.WithError(err).Errorf("Couldn't load client TLS certificate")
+		return nil, err
+	}
+
+	return &tls.Config{
+		RootCAs:      certPool,
+		Certificates: []tls.Certificate{certificate},
+	}, nil
+}
+
+func GetTLSClient(CACertificatePath, ClientCertificatePath, ClientKeyPath string) *ws.Client {
+	tlsConfig, err := GetTLSConfig(CACertificatePath, ClientCertificatePath, ClientKeyPath)
+	if err != nil {
+		log.WithError(err).Errorf("Couldn't create a TLS configuration")
 		return nil
 	}
 
 	log.Debugf("Creating a TLS client")
 	// Create client with TLS config
-	return ws.NewTLSClient(&tls.Config{
-		RootCAs:      certPool,
-		Certificates: []tls.Certificate{certificate},
-	})
+	return ws.NewTLSClient(tlsConfig)
 }
@@ -32,7 +32,10 @@ func GetTypesToSample() []types.Measurand {
 	}
 
 	for _, measurand := range strings.Split(measurandsString, ",") {
-		measurands = append(measurands, types.Measurand(measurand))
+		measurand = strings.TrimSpace(measurand)
+		if measurand != "" {
+			measurands = append(measurands, types.Measurand(measurand))
+		}
 	}
 
 	return measurands
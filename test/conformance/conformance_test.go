@@ -0,0 +1,70 @@
+package conformance
+
+import (
+	"flag"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"testing"
+)
+
+const ocppConfigurationFilePath = "../../configs/configuration.json"
+
+var reportFilePath = flag.String("report", "", "write the conformance report to the file")
+
+func TestConformance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping conformance test")
+	}
+
+	logger := log.New()
+	logger.SetLevel(log.WarnLevel)
+
+	config := DefaultConfig(ocppConfigurationFilePath)
+	config.Logger = logger
+
+	report := Run(config, All()...)
+	t.Log("\n" + report.String())
+
+	if *reportFilePath != "" {
+		err := ioutil.WriteFile(*reportFilePath, []byte(report.String()), 0644)
+		if err != nil {
+			t.Errorf("Unable to write the report: %v", err)
+		}
+	}
+
+	for _, result := range report.Results {
+		if result.IsFailed() {
+			t.Errorf("%s %s: %s", result.Scenario.Id, result.Scenario.Name, result.Status)
+		}
+	}
+}
+
+func TestField(t *testing.T) {
+	payload := map[string]interface{}{
+		"connectorId": float64(1),
+		"idTagInfo":   map[string]interface{}{"status": "Accepted"},
+		"configurationKey": []interface{}{
+			map[string]interface{}{"key": "HeartbeatInterval", "value": "60"},
+		},
+	}
+
+	if err := Field("connectorId", 1)(payload); err != nil {
+		t.Error(err)
+	}
+
+	if err := Field("idTagInfo.status", "Accepted")(payload); err != nil {
+		t.Error(err)
+	}
+
+	if err := Field("configurationKey.0.value", "60")(payload); err != nil {
+		t.Error(err)
+	}
+
+	if err := Field("idTagInfo.status", "Blocked")(payload); err == nil {
+		t.Error("expected an unexpected value error")
+	}
+
+	if err := HasField("configurationKey.1.key")(payload); err == nil {
+		t.Error("expected a field not found error")
+	}
+}
@@ -0,0 +1,264 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-co-op/gocron"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/reservation"
+	log "github.com/sirupsen/logrus"
+	v16 "github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/v16"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	connectorManager "github.com/xBlaz3kx/ChargePi-go/internal/components/connector-manager"
+	setting "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/store"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/test/csms"
+	evSimulator "github.com/xBlaz3kx/ChargePi-go/test/ev-simulator"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type (
+	// Reader is a simulated tag reader.
+	Reader struct {
+		tagChannel chan string
+		once       sync.Once
+	}
+
+	// Harness is a charge point, wired with simulated hardware and connected to the central system simulator.
+	Harness struct {
+		ChargePointId string
+		Simulator     *csms.Simulator
+		ChargePoint   *v16.ChargePoint
+		Reader        *Reader
+		// Relays of the connectors, by connector id.
+		Relays map[int]*evSimulator.Relay
+		// Pilots are the control pilots of the connectors, by connector id.
+		Pilots map[int]*evSimulator.ControlPilot
+		// Vehicles plugged into the connectors, by connector id.
+		Vehicles map[int]*evSimulator.Vehicle
+		// cursor is the index of the first request not yet matched by ExpectRequest.
+		cursor    int
+		directory string
+		store     *store.Store
+		cancel    context.CancelFunc
+	}
+
+	// simulatedManager creates the connectors from the settings with the simulated hardware.
+	simulatedManager struct {
+		connectorManager.Manager
+		harness *Harness
+		// vehicle is plugged into each connector when it is created
+		vehicle *evSimulator.Config
+	}
+)
+
+const (
+	simulatedVoltage = 230.0
+	simulatedCurrent = 16.0
+)
+
+func newReader() *Reader {
+	return &Reader{tagChannel: make(chan string, 5)}
+}
+
+func (r *Reader) ListenForTags(ctx context.Context) {
+}
+
+func (r *Reader) Cleanup() {
+	r.once.Do(func() {
+		close(r.tagChannel)
+	})
+}
+
+func (r *Reader) Reset() {
+}
+
+func (r *Reader) GetTagChannel() <-chan string {
+	return r.tagChannel
+}
+
+// Present reads the tag.
+func (r *Reader) Present(ctx context.Context, tagId string) error {
+	select {
+	case r.tagChannel <- tagId:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *simulatedManager) AddConnectorFromSettings(maxChargingTime int, c *settings.Connector) error {
+	relay, pilot := evSimulator.NewRelay(simulatedVoltage, simulatedCurrent)
+
+	conn, err := connector.NewConnector(c.EvseId, c.ConnectorId, c.Type, relay, evSimulator.NewPowerMeter(pilot), c.PowerMeter.Enabled, maxChargingTime)
+	if err != nil {
+		return err
+	}
+
+	m.harness.Relays[c.ConnectorId] = relay
+	m.harness.Pilots[c.ConnectorId] = pilot
+
+	if m.vehicle != nil {
+		err = m.harness.PlugIn(c.ConnectorId, *m.vehicle)
+		if err != nil {
+			return err
+		}
+	}
+
+	return m.AddConnector(conn)
+}
+
+func (m *simulatedManager) AddConnectorsFromConfiguration(maxChargingTime int, connectors []*settings.Connector) error {
+	for _, c := range connectors {
+		err := m.AddConnectorFromSettings(maxChargingTime, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewHarness prepares a fresh state and the OCPP configuration for a charge point with simulated hardware.
+func NewHarness(config Config, simulator *csms.Simulator) (*Harness, error) {
+	directory, err := ioutil.TempDir("", "chargepi-conformance")
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Harness{
+		ChargePointId: config.Settings.ChargePoint.Info.Id,
+		Simulator:     simulator,
+		Reader:        newReader(),
+		Relays:        map[int]*evSimulator.Relay{},
+		Pilots:        map[int]*evSimulator.ControlPilot{},
+		Vehicles:      map[int]*evSimulator.Vehicle{},
+		directory:     directory,
+	}
+
+	// Each scenario starts with the same OCPP configuration
+	configurationFile := filepath.Join(directory, "configuration.json")
+	content, err := ioutil.ReadFile(config.ConfigurationFile)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+
+	err = ioutil.WriteFile(configurationFile, content, 0644)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+
+	h.store, err = store.Open(filepath.Join(directory, "chargepi.db"))
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+
+	err = h.store.Migrate(config.Connectors, "")
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+
+	setting.SetConnectorRepository(h.store)
+	setting.SetupOcppConfigurationManager(
+		configurationFile,
+		configuration.OCPP16,
+		nil,
+		core.ProfileName,
+		reservation.ProfileName)
+
+	return h, nil
+}
+
+// Start creates the charge point the same way as the ChargePi client, plugs in the vehicles and connects the
+// charge point to the simulator.
+func (h *Harness) Start(config Config) {
+	var (
+		ctx       context.Context
+		scheduler = gocron.NewScheduler(time.UTC)
+	)
+
+	ctx, h.cancel = context.WithCancel(context.Background())
+
+	// Same as the default scheduler
+	scheduler.WaitForScheduleAll()
+	scheduler.StartAsync()
+
+	manager := &simulatedManager{harness: h, vehicle: config.Vehicle}
+	manager.Manager = connectorManager.NewManager(nil)
+
+	h.ChargePoint = v16.NewChargePoint(
+		manager,
+		scheduler,
+		auth.NewAuthCache(h.store),
+		v16.WithReader(ctx, h.Reader),
+		v16.WithLogger(config.Logger),
+		v16.WithChargePointState(h.store),
+		v16.WithReservations(h.store),
+		v16.WithFreeVendTransactions(h.store),
+	)
+	h.ChargePoint.Init(config.Settings)
+	h.ChargePoint.AddConnectors(config.Connectors)
+	h.ChargePoint.Connect(ctx, h.Simulator.Url())
+}
+
+// PlugIn plugs a new vehicle into the connector.
+func (h *Harness) PlugIn(connectorId int, config evSimulator.Config) error {
+	pilot, isFound := h.Pilots[connectorId]
+	if !isFound {
+		return fmt.Errorf("%w: %d", ErrUnknownConnector, connectorId)
+	}
+
+	vehicle, err := evSimulator.NewVehicle(config)
+	if err != nil {
+		return err
+	}
+
+	err = vehicle.PlugIn(pilot)
+	if err != nil {
+		return err
+	}
+
+	h.Vehicles[connectorId] = vehicle
+	return nil
+}
+
+// Unplug unplugs the vehicle from the connector.
+func (h *Harness) Unplug(connectorId int) error {
+	vehicle, isFound := h.Vehicles[connectorId]
+	if !isFound {
+		return fmt.Errorf("%w: %d", evSimulator.ErrNotPluggedIn, connectorId)
+	}
+
+	delete(h.Vehicles, connectorId)
+	return vehicle.Unplug()
+}
+
+// Close stops the charge point and removes its state.
+func (h *Harness) Close() {
+	if h.ChargePoint != nil {
+		h.cancel()
+		h.ChargePoint.CleanUp(core.ReasonOther)
+	}
+
+	setting.SetConnectorRepository(nil)
+
+	if h.store != nil {
+		err := h.store.Close()
+		if err != nil {
+			log.WithError(err).Warn("Unable to close the store")
+		}
+	}
+
+	_ = os.RemoveAll(h.directory)
+}
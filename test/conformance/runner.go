@@ -0,0 +1,214 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/test/csms"
+	evSimulator "github.com/xBlaz3kx/ChargePi-go/test/ev-simulator"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	v16 "github.com/xBlaz3kx/ocppManager-go/v16"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	StatusPassed     = Status("Passed")
+	StatusFailed     = Status("Failed")
+	StatusSkipped    = Status("Skipped")
+	StatusKnownIssue = Status("KnownIssue")
+	// StatusFixed is reported for the passing scenarios with a known issue.
+	StatusFixed = Status("Fixed")
+)
+
+const DefaultStepTimeout = 15 * time.Second
+
+type (
+	Status string
+
+	// Config of the charge point under test.
+	Config struct {
+		Settings *settings.Settings
+		// Connectors are created with the simulated hardware.
+		Connectors []*settings.Connector
+		// Vehicle is plugged into each connector at the start. If nil, the connectors are empty.
+		Vehicle *evSimulator.Config
+		// ConfigurationFile is the OCPP configuration, copied for each scenario.
+		ConfigurationFile string
+		// StepTimeout limits the duration of each step. Defaults to DefaultStepTimeout.
+		StepTimeout time.Duration
+		Logger      *log.Logger
+	}
+
+	// Result of a scenario.
+	Result struct {
+		Scenario Scenario
+		Status   Status
+		// Step is the description of the failed step.
+		Step     string
+		Error    error
+		Duration time.Duration
+	}
+
+	// Report contains the results of all the scenarios.
+	Report struct {
+		Results []Result
+	}
+)
+
+// Run runs the scenarios in order, each against a new charge point and central system simulator.
+func Run(config Config, scenarios ...Scenario) Report {
+	var report Report
+
+	if config.StepTimeout <= 0 {
+		config.StepTimeout = DefaultStepTimeout
+	}
+
+	if config.Logger == nil {
+		config.Logger = log.StandardLogger()
+	}
+
+	for _, scenario := range scenarios {
+		report.Results = append(report.Results, RunScenario(config, scenario))
+	}
+
+	return report
+}
+
+// RunScenario runs the scenario against a new charge point and central system simulator.
+func RunScenario(config Config, scenario Scenario) Result {
+	var (
+		started   = time.Now()
+		result    = Result{Scenario: scenario}
+		simulator = csms.NewOCPP16Simulator()
+	)
+
+	simulator.Start()
+	defer simulator.Close()
+
+	if scenario.Connectors != nil {
+		config.Connectors = scenario.Connectors
+	}
+
+	harness, err := NewHarness(config, simulator)
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = fmt.Errorf("unable to set up the charge point: %w", err)
+		return result
+	}
+	defer harness.Close()
+
+	if !isProfileSupported(scenario.Profile) {
+		result.Status = StatusSkipped
+		return result
+	}
+
+	harness.Start(config)
+
+	for _, step := range scenario.Steps {
+		ctx, cancel := context.WithTimeout(context.Background(), config.StepTimeout)
+		err = step.Run(ctx, harness)
+		cancel()
+
+		if err != nil {
+			result.Step = step.String()
+			result.Error = err
+			break
+		}
+	}
+
+	result.Duration = time.Since(started)
+
+	switch {
+	case result.Error == nil && scenario.KnownIssue != "":
+		result.Status = StatusFixed
+	case result.Error == nil:
+		result.Status = StatusPassed
+	case scenario.KnownIssue != "":
+		result.Status = StatusKnownIssue
+	default:
+		result.Status = StatusFailed
+	}
+
+	return result
+}
+
+// isProfileSupported checks if the feature profile is listed in the SupportedFeatureProfiles configuration key.
+func isProfileSupported(profile string) bool {
+	profiles, err := ocppManager.GetConfigurationValue(v16.SupportedFeatureProfiles.String())
+	if err != nil {
+		return false
+	}
+
+	for _, supportedProfile := range strings.Split(profiles, ",") {
+		if strings.EqualFold(strings.TrimSpace(supportedProfile), profile) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsFailed checks if the scenario failed or has a known issue, which is fixed.
+func (r Result) IsFailed() bool {
+	return r.Status == StatusFailed || r.Status == StatusFixed
+}
+
+// Passed checks if none of the scenarios failed.
+func (r Report) Passed() bool {
+	for _, result := range r.Results {
+		if result.IsFailed() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Count returns the number of scenarios with the status.
+func (r Report) Count(status Status) int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Write writes the report as a table, followed by a summary.
+func (r Report) Write(w io.Writer) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "Test case\tProfile\tName\tStatus\tDetails")
+
+	for _, result := range r.Results {
+		var details string
+		switch result.Status {
+		case StatusFailed:
+			details = fmt.Sprintf("%s: %v", result.Step, result.Error)
+		case StatusKnownIssue:
+			details = result.Scenario.KnownIssue
+		case StatusFixed:
+			details = "passed, remove the known issue"
+		}
+
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n",
+			result.Scenario.Id, result.Scenario.Profile, result.Scenario.Name, result.Status, details)
+	}
+
+	_, _ = fmt.Fprintf(writer, "\nPassed: %d, failed: %d, known issues: %d, fixed: %d, skipped: %d\n",
+		r.Count(StatusPassed), r.Count(StatusFailed), r.Count(StatusKnownIssue), r.Count(StatusFixed), r.Count(StatusSkipped))
+
+	return writer.Flush()
+}
+
+// String returns the report as a table.
+func (r Report) String() string {
+	var builder strings.Builder
+	_ = r.Write(&builder)
+	return builder.String()
+}
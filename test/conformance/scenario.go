@@ -0,0 +1,333 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	"github.com/xBlaz3kx/ChargePi-go/test/csms"
+	evSimulator "github.com/xBlaz3kx/ChargePi-go/test/ev-simulator"
+	ocppManager "github.com/xBlaz3kx/ocppManager-go"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrFieldNotFound    = errors.New("field not found")
+	ErrUnexpectedValue  = errors.New("unexpected value")
+	ErrUnexpectedRelay  = errors.New("unexpected relay state")
+	ErrUnknownConnector = errors.New("unknown connector")
+)
+
+type (
+	// Scenario is a declarative test case, modelled on a test case of the OCA OCPP compliance test tool.
+	Scenario struct {
+		// Id of the test case in the test tool, e.g. TC_001_CS.
+		Id   string
+		Name string
+		// Profile is the OCPP feature profile the scenario tests. Scenarios of unsupported profiles are skipped.
+		Profile string
+		// Connectors override the connectors of the Config.
+		Connectors []*settings.Connector
+		// KnownIssue describes a known deviation from the specification. A failing scenario with a known issue
+		// does not fail the report, but a passing one does, so the issue is removed once it is fixed.
+		KnownIssue string
+		Steps      []Step
+	}
+
+	// Step is a single action or expectation of a Scenario.
+	Step interface {
+		Run(ctx context.Context, h *Harness) error
+		String() string
+	}
+
+	// Matcher checks the payload of a request or a response.
+	Matcher func(payload map[string]interface{}) error
+
+	step struct {
+		description string
+		run         func(ctx context.Context, h *Harness) error
+	}
+)
+
+func (s step) Run(ctx context.Context, h *Harness) error {
+	return s.run(ctx, h)
+}
+
+func (s step) String() string {
+	return s.description
+}
+
+// Field matches the value of the field in the payload. Nested fields and array indexes are separated with a dot,
+// e.g. idTagInfo.status or configurationKey.0.value.
+func Field(path string, expected interface{}) Matcher {
+	return func(payload map[string]interface{}) error {
+		value, err := lookup(payload, path)
+		if err != nil {
+			return err
+		}
+
+		if fmt.Sprint(value) != fmt.Sprint(expected) {
+			return fmt.Errorf("%w: %s is %v, expected %v", ErrUnexpectedValue, path, value, expected)
+		}
+
+		return nil
+	}
+}
+
+// HasField matches the payloads containing the field.
+func HasField(path string) Matcher {
+	return func(payload map[string]interface{}) error {
+		_, err := lookup(payload, path)
+		return err
+	}
+}
+
+// ExpectRequest waits for the next request with the action, sent by the charge point, which matches all the matchers.
+// Other requests may be sent in between.
+func ExpectRequest(action string, matchers ...Matcher) Step {
+	return step{
+		description: fmt.Sprintf("expect %s", action),
+		run: func(ctx context.Context, h *Harness) error {
+			var lastErr error
+
+			err := h.Simulator.WaitUntil(ctx, h.ChargePointId, func(requests []csms.Message) bool {
+				for i := h.cursor; i < len(requests); i++ {
+					if requests[i].Action != action {
+						continue
+					}
+
+					lastErr = match(requests[i].Payload, matchers)
+					if lastErr == nil {
+						h.cursor = i + 1
+						return true
+					}
+				}
+
+				return false
+			})
+			if err != nil && lastErr != nil {
+				return fmt.Errorf("%s does not match: %w", action, lastErr)
+			}
+
+			return err
+		},
+	}
+}
+
+// InAnyOrder runs the ExpectRequest steps, which may be matched in any order.
+func InAnyOrder(steps ...Step) Step {
+	var descriptions []string
+	for _, s := range steps {
+		descriptions = append(descriptions, s.String())
+	}
+
+	return step{
+		description: strings.Join(descriptions, ", "),
+		run: func(ctx context.Context, h *Harness) error {
+			var (
+				start  = h.cursor
+				cursor = h.cursor
+			)
+
+			for _, s := range steps {
+				h.cursor = start
+
+				err := s.Run(ctx, h)
+				if err != nil {
+					return err
+				}
+
+				if h.cursor > cursor {
+					cursor = h.cursor
+				}
+			}
+
+			h.cursor = cursor
+			return nil
+		},
+	}
+}
+
+// ExpectNoRequest checks that the charge point does not send a request with the action in the duration.
+func ExpectNoRequest(action string, duration time.Duration) Step {
+	return step{
+		description: fmt.Sprintf("expect no %s in %v", action, duration),
+		run: func(ctx context.Context, h *Harness) error {
+			waitCtx, cancel := context.WithTimeout(ctx, duration)
+			defer cancel()
+
+			err := h.Simulator.WaitUntil(waitCtx, h.ChargePointId, func(requests []csms.Message) bool {
+				for i := h.cursor; i < len(requests); i++ {
+					if requests[i].Action == action {
+						return true
+					}
+				}
+
+				return false
+			})
+
+			switch {
+			case err == nil:
+				return fmt.Errorf("unexpected %s", action)
+			case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+				return nil
+			default:
+				return err
+			}
+		},
+	}
+}
+
+// Respond sets the response of the central system to the next request with the action.
+func Respond(action string, response interface{}) Step {
+	return step{
+		description: fmt.Sprintf("respond to %s", action),
+		run: func(ctx context.Context, h *Harness) error {
+			h.Simulator.On(action).For(h.ChargePointId).Once().Respond(response)
+			return nil
+		},
+	}
+}
+
+// SendRequest sends the request from the central system and matches the response of the charge point.
+func SendRequest(action string, request interface{}, matchers ...Matcher) Step {
+	return step{
+		description: fmt.Sprintf("send %s", action),
+		run: func(ctx context.Context, h *Harness) error {
+			response, err := h.Simulator.Call(ctx, h.ChargePointId, action, request)
+			if err != nil {
+				return err
+			}
+
+			err = match(response, matchers)
+			if err != nil {
+				return fmt.Errorf("%s response does not match: %w", action, err)
+			}
+
+			return nil
+		},
+	}
+}
+
+// PresentTag presents the tag to the simulated reader.
+func PresentTag(tagId string) Step {
+	return step{
+		description: fmt.Sprintf("present tag %s", tagId),
+		run: func(ctx context.Context, h *Harness) error {
+			return h.Reader.Present(ctx, tagId)
+		},
+	}
+}
+
+// ExpectRelay waits until the relay of the connector is in the expected state.
+func ExpectRelay(connectorId int, enabled bool) Step {
+	return step{
+		description: fmt.Sprintf("expect relay of connector %d enabled=%v", connectorId, enabled),
+		run: func(ctx context.Context, h *Harness) error {
+			relay, isFound := h.Relays[connectorId]
+			if !isFound {
+				return fmt.Errorf("%w: %d", ErrUnknownConnector, connectorId)
+			}
+
+			for relay.IsEnabled() != enabled {
+				select {
+				case <-ctx.Done():
+					return ErrUnexpectedRelay
+				case <-time.After(50 * time.Millisecond):
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+// PlugIn plugs a new vehicle into the connector.
+func PlugIn(connectorId int, vehicle evSimulator.Config) Step {
+	return step{
+		description: fmt.Sprintf("plug vehicle into connector %d", connectorId),
+		run: func(ctx context.Context, h *Harness) error {
+			return h.PlugIn(connectorId, vehicle)
+		},
+	}
+}
+
+// Unplug unplugs the vehicle from the connector.
+func Unplug(connectorId int) Step {
+	return step{
+		description: fmt.Sprintf("unplug vehicle from connector %d", connectorId),
+		run: func(ctx context.Context, h *Harness) error {
+			return h.Unplug(connectorId)
+		},
+	}
+}
+
+// SetConfiguration changes the OCPP configuration key locally, as a precondition of the scenario.
+func SetConfiguration(key, value string) Step {
+	return step{
+		description: fmt.Sprintf("set %s to %s", key, value),
+		run: func(ctx context.Context, h *Harness) error {
+			return ocppManager.UpdateKey(key, value)
+		},
+	}
+}
+
+// Wait waits for the duration.
+func Wait(duration time.Duration) Step {
+	return step{
+		description: fmt.Sprintf("wait %v", duration),
+		run: func(ctx context.Context, h *Harness) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(duration):
+				return nil
+			}
+		},
+	}
+}
+
+func match(payload json.RawMessage, matchers []Matcher) error {
+	var fields map[string]interface{}
+
+	err := json.Unmarshal(payload, &fields)
+	if err != nil {
+		return err
+	}
+
+	for _, matcher := range matchers {
+		err = matcher(fields)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func lookup(payload map[string]interface{}, path string) (interface{}, error) {
+	var value interface{} = payload
+
+	for _, key := range strings.Split(path, ".") {
+		var isFound bool
+
+		switch container := value.(type) {
+		case map[string]interface{}:
+			value, isFound = container[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err == nil && index >= 0 && index < len(container) {
+				value, isFound = container[index], true
+			}
+		}
+
+		if !isFound {
+			return nil, fmt.Errorf("%w: %s", ErrFieldNotFound, path)
+		}
+	}
+
+	return value, nil
+}
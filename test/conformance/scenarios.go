@@ -0,0 +1,572 @@
+package conformance
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/firmware"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/localauth"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/remotetrigger"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/reservation"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/smartcharging"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
+	evSimulator "github.com/xBlaz3kx/ChargePi-go/test/ev-simulator"
+	"time"
+)
+
+// Feature profiles, as listed in the SupportedFeatureProfiles configuration key.
+const (
+	ProfileCore                    = "Core"
+	ProfileLocalAuthListManagement = "LocalAuthListManagement"
+	ProfileRemoteTrigger           = "RemoteTrigger"
+	ProfileReservation             = "Reservation"
+	ProfileSmartCharging           = "SmartCharging"
+)
+
+const (
+	// TagId is the tag presented in the scenarios. Tags are read in upper case.
+	TagId = "CONFORMANCETAG"
+	// transactionId is the id of the first transaction, assigned by the simulator.
+	transactionId = 1
+	reservationId = 1
+	// unknownConnectorId is not configured on the charge point.
+	unknownConnectorId = 9
+)
+
+// DefaultConfig returns the configuration of a charge point with a single connector.
+func DefaultConfig(configurationFile string) Config {
+	return Config{
+		Settings: &settings.Settings{ChargePoint: settings.ChargePoint{
+			Info: settings.Info{
+				Id:              "conformanceChargePoint",
+				ProtocolVersion: string(settings.OCPP16),
+				MaxChargingTime: 15,
+				OCPPInfo: settings.OCPPInfo{
+					Vendor: "ChargePi",
+					Model:  "Conformance",
+				},
+			},
+		}},
+		Connectors:        singleConnector(),
+		Vehicle:           defaultVehicle(),
+		ConfigurationFile: configurationFile,
+	}
+}
+
+// defaultVehicle charges with a constant current for the duration of any scenario.
+func defaultVehicle() *evSimulator.Config {
+	return &evSimulator.Config{
+		Id:              "conformanceVehicle",
+		BatteryCapacity: 100000,
+		MaxCurrent:      simulatedCurrent,
+		TimeScale:       1,
+	}
+}
+
+func singleConnector() []*settings.Connector {
+	return []*settings.Connector{newConnector(1, 1)}
+}
+
+// twoEvses returns a charge point with two EVSEs, each with a single connector, numbered as in OCPP 1.6.
+func twoEvses() []*settings.Connector {
+	return []*settings.Connector{newConnector(1, 1), newConnector(2, 2)}
+}
+
+func newConnector(evseId, connectorId int) *settings.Connector {
+	return &settings.Connector{
+		EvseId:      evseId,
+		ConnectorId: connectorId,
+		Type:        "Type2",
+		Status:      string(core.ChargePointStatusAvailable),
+		PowerMeter:  settings.PowerMeter{Enabled: true},
+	}
+}
+
+// All returns all the scenarios.
+func All() []Scenario {
+	var scenarios []Scenario
+	scenarios = append(scenarios, CoreScenarios()...)
+	scenarios = append(scenarios, ReservationScenarios()...)
+	scenarios = append(scenarios, RemoteTriggerScenarios()...)
+	scenarios = append(scenarios, LocalAuthScenarios()...)
+	scenarios = append(scenarios, SmartChargingScenarios()...)
+	return scenarios
+}
+
+func statusNotification(connectorId int, status core.ChargePointStatus) Step {
+	return ExpectRequest(core.StatusNotificationFeatureName,
+		Field("connectorId", connectorId),
+		Field("status", status),
+		Field("errorCode", core.NoError),
+	)
+}
+
+// startTransactionWithTag starts a transaction on connector 1 by presenting the tag.
+func startTransactionWithTag() []Step {
+	return []Step{
+		PresentTag(TagId),
+		ExpectRequest(core.AuthorizeFeatureName, Field("idTag", TagId)),
+		ExpectRequest(core.StartTransactionFeatureName, Field("connectorId", 1), Field("idTag", TagId)),
+		statusNotification(1, core.ChargePointStatusCharging),
+		ExpectRelay(1, true),
+	}
+}
+
+// startTransactionRemotely starts a transaction on connector 1 with a RemoteStartTransaction.
+func startTransactionRemotely() []Step {
+	connectorId := 1
+
+	return []Step{
+		SendRequest(core.RemoteStartTransactionFeatureName,
+			core.RemoteStartTransactionRequest{ConnectorId: &connectorId, IdTag: TagId},
+			Field("status", types.RemoteStartStopStatusAccepted)),
+		ExpectRequest(core.StartTransactionFeatureName, Field("connectorId", 1), Field("idTag", TagId)),
+		statusNotification(1, core.ChargePointStatusCharging),
+		ExpectRelay(1, true),
+	}
+}
+
+func steps(groups ...[]Step) []Step {
+	var all []Step
+	for _, group := range groups {
+		all = append(all, group...)
+	}
+
+	return all
+}
+
+// CoreScenarios returns the scenarios of the Core profile.
+func CoreScenarios() []Scenario {
+	return []Scenario{
+		{
+			Id:         "TC_001_CS",
+			Name:       "Cold Boot Charge Point",
+			Profile:    ProfileCore,
+			Connectors: twoEvses(),
+			Steps: []Step{
+				ExpectRequest(core.BootNotificationFeatureName,
+					Field("chargePointVendor", "ChargePi"),
+					Field("chargePointModel", "Conformance")),
+				InAnyOrder(
+					statusNotification(1, core.ChargePointStatusAvailable),
+					statusNotification(2, core.ChargePointStatusAvailable),
+				),
+			},
+		},
+		{
+			Id:      "TC_004_1_CS",
+			Name:    "Regular Charging Session - Identification First",
+			Profile: ProfileCore,
+			Steps: steps(
+				startTransactionWithTag(),
+				[]Step{
+					PresentTag(TagId),
+					ExpectRequest(core.StopTransactionFeatureName, Field("transactionId", transactionId)),
+					statusNotification(1, core.ChargePointStatusAvailable),
+					ExpectRelay(1, false),
+				},
+			),
+		},
+		{
+			Id:      "TC_011_1_CS",
+			Name:    "Remote Start Charging Session - Remote Start First",
+			Profile: ProfileCore,
+			Steps:   startTransactionRemotely(),
+		},
+		{
+			Id:      "TC_012_CS",
+			Name:    "Remote Stop Charging Session",
+			Profile: ProfileCore,
+			Steps: steps(
+				startTransactionRemotely(),
+				[]Step{
+					SendRequest(core.RemoteStopTransactionFeatureName,
+						core.RemoteStopTransactionRequest{TransactionId: transactionId},
+						Field("status", types.RemoteStartStopStatusAccepted)),
+					ExpectRequest(core.StopTransactionFeatureName,
+						Field("transactionId", transactionId),
+						Field("reason", core.ReasonRemote)),
+					statusNotification(1, core.ChargePointStatusAvailable),
+					ExpectRelay(1, false),
+				},
+			),
+		},
+		{
+			Id:      "TC_019_1_CS",
+			Name:    "Retrieve all configuration keys",
+			Profile: ProfileCore,
+			Steps: []Step{
+				SendRequest(core.GetConfigurationFeatureName, core.GetConfigurationRequest{},
+					HasField("configurationKey.0.key")),
+			},
+		},
+		{
+			Id:      "TC_019_2_CS",
+			Name:    "Retrieve specific configuration key",
+			Profile: ProfileCore,
+			Steps: []Step{
+				SendRequest(core.GetConfigurationFeatureName,
+					core.GetConfigurationRequest{Key: []string{"SupportedFeatureProfiles"}},
+					Field("configurationKey.0.key", "SupportedFeatureProfiles")),
+			},
+		},
+		{
+			Id:      "TC_021_CS",
+			Name:    "Change/set Configuration",
+			Profile: ProfileCore,
+			Steps: []Step{
+				SendRequest(core.ChangeConfigurationFeatureName,
+					core.ChangeConfigurationRequest{Key: "MeterValueSampleInterval", Value: "15"},
+					Field("status", core.ConfigurationStatusAccepted)),
+				SendRequest(core.GetConfigurationFeatureName,
+					core.GetConfigurationRequest{Key: []string{"MeterValueSampleInterval"}},
+					Field("configurationKey.0.value", "15")),
+			},
+		},
+		{
+			Id:      "TC_023_1_CS",
+			Name:    "Start Charging Session - Authorize Invalid",
+			Profile: ProfileCore,
+			Steps: []Step{
+				Respond(core.AuthorizeFeatureName, core.NewAuthorizationConfirmation(types.NewIdTagInfo(types.AuthorizationStatusInvalid))),
+				PresentTag(TagId),
+				ExpectRequest(core.AuthorizeFeatureName, Field("idTag", TagId)),
+				ExpectNoRequest(core.StartTransactionFeatureName, 5*time.Second),
+				ExpectRelay(1, false),
+			},
+		},
+		{
+			Id:      "TC_026_CS",
+			Name:    "Remote Start Charging Session - Rejected",
+			Profile: ProfileCore,
+			Steps: []Step{
+				SendRequest(core.RemoteStartTransactionFeatureName,
+					core.RemoteStartTransactionRequest{ConnectorId: intPointer(unknownConnectorId), IdTag: TagId},
+					Field("status", types.RemoteStartStopStatusRejected)),
+				ExpectNoRequest(core.StartTransactionFeatureName, 5*time.Second),
+			},
+		},
+		{
+			Id:      "TC_028_CS",
+			Name:    "Remote Stop Transaction - Rejected",
+			Profile: ProfileCore,
+			Steps: []Step{
+				SendRequest(core.RemoteStopTransactionFeatureName,
+					core.RemoteStopTransactionRequest{TransactionId: 42},
+					Field("status", types.RemoteStartStopStatusRejected)),
+			},
+		},
+		{
+			Id:         "TC_031_CS",
+			Name:       "Unlock Connector - Unknown Connector",
+			KnownIssue: "UnlockConnector responds UnlockFailed instead of NotSupported for unknown connectors",
+			Profile:    ProfileCore,
+			Steps: []Step{
+				SendRequest(core.UnlockConnectorFeatureName,
+					core.UnlockConnectorRequest{ConnectorId: unknownConnectorId},
+					Field("status", core.UnlockStatusNotSupported)),
+			},
+		},
+		{
+			Id:      "TC_040_1_CS",
+			Name:    "Configuration Keys - Not Supported",
+			Profile: ProfileCore,
+			Steps: []Step{
+				SendRequest(core.ChangeConfigurationFeatureName,
+					core.ChangeConfigurationRequest{Key: "UnknownConfigurationKey", Value: "1"},
+					Field("status", core.ConfigurationStatusNotSupported)),
+			},
+		},
+		{
+			Id:      "TC_061_CS",
+			Name:    "Clear Authorization Data in Authorization Cache",
+			Profile: ProfileCore,
+			Steps: []Step{
+				SendRequest(core.ClearCacheFeatureName, core.ClearCacheRequest{},
+					Field("status", core.ClearCacheStatusAccepted)),
+			},
+		},
+		{
+			Id:      "TC_064_CS",
+			Name:    "Data Transfer to a Charge Point",
+			Profile: ProfileCore,
+			Steps: []Step{
+				SendRequest(core.DataTransferFeatureName, core.DataTransferRequest{VendorId: "UnknownVendor"},
+					Field("status", core.DataTransferStatusUnknownVendorId)),
+			},
+		},
+	}
+}
+
+// ReservationScenarios returns the scenarios of the Reservation profile.
+func ReservationScenarios() []Scenario {
+	reserveNow := func(connectorId int, expected reservation.ReservationStatus) Step {
+		return SendRequest(reservation.ReserveNowFeatureName,
+			reservation.NewReserveNowRequest(connectorId, types.NewDateTime(time.Now().Add(time.Hour)), TagId, reservationId),
+			Field("status", expected))
+	}
+
+	return []Scenario{
+		{
+			Id:      "TC_046_CS",
+			Name:    "Reservation of a Connector - Local start transaction",
+			Profile: ProfileReservation,
+			Steps: []Step{
+				reserveNow(1, reservation.ReservationStatusAccepted),
+				statusNotification(1, core.ChargePointStatusReserved),
+				PresentTag(TagId),
+				ExpectRequest(core.StartTransactionFeatureName,
+					Field("connectorId", 1),
+					Field("idTag", TagId),
+					Field("reservationId", reservationId)),
+				statusNotification(1, core.ChargePointStatusCharging),
+			},
+		},
+		{
+			Id:      "TC_048_2_CS",
+			Name:    "Reservation of a Connector - Occupied",
+			Profile: ProfileReservation,
+			Steps: steps(
+				startTransactionWithTag(),
+				[]Step{reserveNow(1, reservation.ReservationStatusOccupied)},
+			),
+		},
+		{
+			Id:      "TC_048_3_CS",
+			Name:    "Reservation of a Connector - Unavailable",
+			Profile: ProfileReservation,
+			Steps: []Step{
+				SendRequest(core.ChangeAvailabilityFeatureName,
+					core.NewChangeAvailabilityRequest(1, core.AvailabilityTypeInoperative),
+					Field("status", core.AvailabilityStatusAccepted)),
+				statusNotification(1, core.ChargePointStatusUnavailable),
+				reserveNow(1, reservation.ReservationStatusUnavailable),
+			},
+		},
+		{
+			Id:      "TC_049_CS",
+			Name:    "Reservation of a Charge Point - Transaction",
+			Profile: ProfileReservation,
+			Steps: []Step{
+				SetConfiguration("ReserveConnectorZeroSupported", "true"),
+				reserveNow(0, reservation.ReservationStatusAccepted),
+				PresentTag(TagId),
+				ExpectRequest(core.StartTransactionFeatureName,
+					Field("idTag", TagId),
+					Field("reservationId", reservationId)),
+			},
+		},
+		{
+			Id:      "TC_051_CS",
+			Name:    "Cancel Reservation",
+			Profile: ProfileReservation,
+			Steps: []Step{
+				reserveNow(1, reservation.ReservationStatusAccepted),
+				statusNotification(1, core.ChargePointStatusReserved),
+				SendRequest(reservation.CancelReservationFeatureName,
+					reservation.NewCancelReservationRequest(reservationId),
+					Field("status", reservation.CancelReservationStatusAccepted)),
+				statusNotification(1, core.ChargePointStatusAvailable),
+			},
+		},
+		{
+			Id:      "TC_052_CS",
+			Name:    "Cancel Reservation - Rejected",
+			Profile: ProfileReservation,
+			Steps: []Step{
+				SendRequest(reservation.CancelReservationFeatureName,
+					reservation.NewCancelReservationRequest(42),
+					Field("status", reservation.CancelReservationStatusRejected)),
+			},
+		},
+	}
+}
+
+// RemoteTriggerScenarios returns the scenarios of the RemoteTrigger profile. The TC_054_CS test case is split by
+// the requested message.
+func RemoteTriggerScenarios() []Scenario {
+	trigger := func(message remotetrigger.MessageTrigger, connectorId *int, expected remotetrigger.TriggerMessageStatus) Step {
+		return SendRequest(remotetrigger.TriggerMessageFeatureName,
+			remotetrigger.TriggerMessageRequest{RequestedMessage: message, ConnectorId: connectorId},
+			Field("status", expected))
+	}
+
+	return []Scenario{
+		{
+			Id:      "TC_054_CS",
+			Name:    "Trigger Message - Heartbeat",
+			Profile: ProfileRemoteTrigger,
+			Steps: []Step{
+				ExpectRequest(core.BootNotificationFeatureName),
+				trigger(core.HeartbeatFeatureName, nil, remotetrigger.TriggerMessageStatusAccepted),
+				ExpectRequest(core.HeartbeatFeatureName),
+			},
+		},
+		{
+			Id:      "TC_054_CS",
+			Name:    "Trigger Message - StatusNotification",
+			Profile: ProfileRemoteTrigger,
+			Steps: []Step{
+				ExpectRequest(core.BootNotificationFeatureName),
+				Wait(time.Second),
+				trigger(core.StatusNotificationFeatureName, intPointer(1), remotetrigger.TriggerMessageStatusAccepted),
+				statusNotification(1, core.ChargePointStatusAvailable),
+			},
+		},
+		{
+			Id:         "TC_054_CS",
+			Name:       "Trigger Message - StatusNotification of the second EVSE",
+			Profile:    ProfileRemoteTrigger,
+			Connectors: twoEvses(),
+			Steps: []Step{
+				ExpectRequest(core.BootNotificationFeatureName),
+				Wait(time.Second),
+				trigger(core.StatusNotificationFeatureName, intPointer(2), remotetrigger.TriggerMessageStatusAccepted),
+				statusNotification(2, core.ChargePointStatusAvailable),
+			},
+		},
+		{
+			Id:      "TC_054_CS",
+			Name:    "Trigger Message - MeterValues",
+			Profile: ProfileRemoteTrigger,
+			Steps: []Step{
+				ExpectRequest(core.BootNotificationFeatureName),
+				trigger(core.MeterValuesFeatureName, intPointer(1), remotetrigger.TriggerMessageStatusAccepted),
+				ExpectRequest(core.MeterValuesFeatureName,
+					Field("connectorId", 1),
+					Field("meterValue.0.sampledValue.0.context", types.ReadingContextTrigger)),
+			},
+		},
+		{
+			Id:         "TC_054_CS",
+			Name:       "Trigger Message - MeterValues of all connectors",
+			Profile:    ProfileRemoteTrigger,
+			Connectors: twoEvses(),
+			Steps: []Step{
+				ExpectRequest(core.BootNotificationFeatureName),
+				trigger(core.MeterValuesFeatureName, nil, remotetrigger.TriggerMessageStatusAccepted),
+				InAnyOrder(
+					ExpectRequest(core.MeterValuesFeatureName,
+						Field("connectorId", 1),
+						Field("meterValue.0.sampledValue.0.context", types.ReadingContextTrigger)),
+					ExpectRequest(core.MeterValuesFeatureName,
+						Field("connectorId", 2),
+						Field("meterValue.0.sampledValue.0.context", types.ReadingContextTrigger)),
+				),
+			},
+		},
+		{
+			Id:      "TC_054_CS",
+			Name:    "Trigger Message - MeterValues during a transaction",
+			Profile: ProfileRemoteTrigger,
+			Steps: steps(
+				[]Step{ExpectRequest(core.BootNotificationFeatureName)},
+				startTransactionWithTag(),
+				[]Step{
+					trigger(core.MeterValuesFeatureName, intPointer(1), remotetrigger.TriggerMessageStatusAccepted),
+					ExpectRequest(core.MeterValuesFeatureName,
+						Field("connectorId", 1),
+						Field("transactionId", transactionId),
+						Field("meterValue.0.sampledValue.0.context", types.ReadingContextTrigger)),
+				},
+			),
+		},
+		{
+			Id:         "TC_054_CS",
+			Name:       "Trigger Message - FirmwareStatusNotification and DiagnosticsStatusNotification",
+			KnownIssue: "FirmwareStatusNotification and DiagnosticsStatusNotification triggers are not implemented",
+			Profile:    ProfileRemoteTrigger,
+			Steps: []Step{
+				ExpectRequest(core.BootNotificationFeatureName),
+				trigger(firmware.FirmwareStatusNotificationFeatureName, nil, remotetrigger.TriggerMessageStatusAccepted),
+				ExpectRequest(firmware.FirmwareStatusNotificationFeatureName, Field("status", firmware.FirmwareStatusIdle)),
+				trigger(firmware.DiagnosticsStatusNotificationFeatureName, nil, remotetrigger.TriggerMessageStatusAccepted),
+				ExpectRequest(firmware.DiagnosticsStatusNotificationFeatureName, Field("status", firmware.DiagnosticsStatusIdle)),
+			},
+		},
+		{
+			Id:      "TC_055_CS",
+			Name:    "Trigger Message - Rejected",
+			Profile: ProfileRemoteTrigger,
+			Steps: []Step{
+				trigger(core.StatusNotificationFeatureName, intPointer(unknownConnectorId), remotetrigger.TriggerMessageStatusRejected),
+			},
+		},
+		{
+			Id:      "TC_055_CS",
+			Name:    "Trigger Message - MeterValues of an unknown connector",
+			Profile: ProfileRemoteTrigger,
+			Steps: []Step{
+				trigger(core.MeterValuesFeatureName, intPointer(unknownConnectorId), remotetrigger.TriggerMessageStatusRejected),
+			},
+		},
+	}
+}
+
+// LocalAuthScenarios returns the scenarios of the LocalAuthListManagement profile.
+func LocalAuthScenarios() []Scenario {
+	return []Scenario{
+		{
+			Id:         "TC_042_2_CS",
+			Name:       "Get Local List Version (empty)",
+			KnownIssue: "The LocalAuthListManagement profile is advertised, but its requests are not handled",
+			Profile:    ProfileLocalAuthListManagement,
+			Steps: []Step{
+				SendRequest(localauth.GetLocalListVersionFeatureName, localauth.NewGetLocalListVersionRequest(),
+					Field("listVersion", 0)),
+			},
+		},
+		{
+			Id:         "TC_043_CS",
+			Name:       "Send Local Authorization List - Full",
+			KnownIssue: "The LocalAuthListManagement profile is advertised, but its requests are not handled",
+			Profile:    ProfileLocalAuthListManagement,
+			Steps: []Step{
+				SendRequest(localauth.SendLocalListFeatureName,
+					localauth.SendLocalListRequest{
+						ListVersion: 1,
+						UpdateType:  localauth.UpdateTypeFull,
+						LocalAuthorizationList: []localauth.AuthorizationData{{
+							IdTag:     TagId,
+							IdTagInfo: types.NewIdTagInfo(types.AuthorizationStatusAccepted),
+						}},
+					},
+					Field("status", localauth.UpdateStatusAccepted)),
+				SendRequest(localauth.GetLocalListVersionFeatureName, localauth.NewGetLocalListVersionRequest(),
+					Field("listVersion", 1)),
+			},
+		},
+	}
+}
+
+// SmartChargingScenarios returns the scenarios of the SmartCharging profile.
+func SmartChargingScenarios() []Scenario {
+	profile := types.NewChargingProfile(1, 0, types.ChargingProfilePurposeTxDefaultProfile, types.ChargingProfileKindAbsolute,
+		types.NewChargingSchedule(types.ChargingRateUnitAmperes, types.NewChargingSchedulePeriod(0, 10)))
+
+	return []Scenario{
+		{
+			Id:      "TC_056_CS",
+			Name:    "Central Smart Charging - TxDefaultProfile",
+			Profile: ProfileSmartCharging,
+			Steps: []Step{
+				SendRequest(smartcharging.SetChargingProfileFeatureName,
+					smartcharging.NewSetChargingProfileRequest(1, profile),
+					Field("status", smartcharging.ChargingProfileStatusAccepted)),
+			},
+		},
+		{
+			Id:      "TC_066_CS",
+			Name:    "Get Composite Schedule",
+			Profile: ProfileSmartCharging,
+			Steps: []Step{
+				SendRequest(smartcharging.GetCompositeScheduleFeatureName,
+					smartcharging.NewGetCompositeScheduleRequest(1, 3600),
+					Field("status", smartcharging.GetCompositeScheduleStatusAccepted)),
+			},
+		},
+	}
+}
+
+func intPointer(value int) *int {
+	return &value
+}
@@ -0,0 +1,54 @@
+package csms
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"time"
+)
+
+// AssertSequence asserts that the charge point sent the requests with the actions in the order. Other requests may
+// be sent in between.
+func (s *Simulator) AssertSequence(t assert.TestingT, chargePointId string, actions ...string) bool {
+	var (
+		received = s.Actions(chargePointId)
+		next     = 0
+	)
+
+	for _, action := range received {
+		if next < len(actions) && action == actions[next] {
+			next++
+		}
+	}
+
+	if next < len(actions) {
+		return assert.Fail(t, "Unexpected message sequence",
+			"expected %v in order, missing %s, received %v", actions, actions[next], received)
+	}
+
+	return true
+}
+
+// AssertNotSent asserts that the charge point did not send any requests with the action.
+func (s *Simulator) AssertNotSent(t assert.TestingT, chargePointId, action string) bool {
+	requests := s.Requests(chargePointId, action)
+	return assert.Empty(t, requests, "expected no %s requests", action)
+}
+
+// EventuallySequence waits until the charge point sends the requests with the actions in the order or the timeout expires.
+func (s *Simulator) EventuallySequence(t assert.TestingT, timeout time.Duration, chargePointId string, actions ...string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_ = s.waitFor(ctx, func() bool {
+		var next = 0
+		for _, request := range s.requests(chargePointId, "") {
+			if next < len(actions) && request.Action == actions[next] {
+				next++
+			}
+		}
+
+		return next == len(actions)
+	})
+
+	return s.AssertSequence(t, chargePointId, actions...)
+}
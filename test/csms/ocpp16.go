@@ -0,0 +1,49 @@
+package csms
+
+import (
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/firmware"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultHeartbeatInterval is the heartbeat interval in the default BootNotification response.
+const DefaultHeartbeatInterval = 300
+
+// NewOCPP16Simulator creates an OCPP 1.6 simulator, which accepts the charge points and all their requests.
+func NewOCPP16Simulator(opts ...Option) *Simulator {
+	simulator := NewSimulator(ProtocolOCPP16, opts...)
+	simulator.SetOCPP16Defaults()
+	return simulator
+}
+
+// SetOCPP16Defaults adds the rules, which accept all the requests sent by an OCPP 1.6 charge point. Transaction ids
+// are assigned incrementally, starting with 1.
+func (s *Simulator) SetOCPP16Defaults() {
+	var transactionId int32
+
+	s.On(core.BootNotificationFeatureName).RespondWith(func(request Message) interface{} {
+		return core.NewBootNotificationConfirmation(types.NewDateTime(time.Now()), DefaultHeartbeatInterval, core.RegistrationStatusAccepted)
+	})
+	s.On(core.HeartbeatFeatureName).RespondWith(func(request Message) interface{} {
+		return core.NewHeartbeatConfirmation(types.NewDateTime(time.Now()))
+	})
+	s.On(core.AuthorizeFeatureName).Respond(core.NewAuthorizationConfirmation(types.NewIdTagInfo(types.AuthorizationStatusAccepted)))
+	s.On(core.StatusNotificationFeatureName).Respond(core.NewStatusNotificationConfirmation())
+	s.On(core.MeterValuesFeatureName).Respond(core.NewMeterValuesConfirmation())
+	s.On(core.StartTransactionFeatureName).RespondWith(func(request Message) interface{} {
+		id := atomic.AddInt32(&transactionId, 1)
+		return core.NewStartTransactionConfirmation(types.NewIdTagInfo(types.AuthorizationStatusAccepted), int(id))
+	})
+	s.On(core.StopTransactionFeatureName).Respond(core.NewStopTransactionConfirmation())
+	s.On(core.DataTransferFeatureName).Respond(core.NewDataTransferConfirmation(core.DataTransferStatusAccepted))
+	s.On(firmware.FirmwareStatusNotificationFeatureName).Respond(firmware.NewFirmwareStatusNotificationConfirmation())
+	s.On(firmware.DiagnosticsStatusNotificationFeatureName).Respond(firmware.NewDiagnosticsStatusNotificationConfirmation())
+
+	// Security extension
+	for _, action := range []string{"SecurityEventNotification", "SignedFirmwareStatusNotification", "LogStatusNotification"} {
+		s.On(action).Respond(struct{}{})
+	}
+	s.On("SignCertificate").Respond(map[string]string{"status": "Accepted"})
+}
@@ -0,0 +1,85 @@
+package csms
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Rule describes how the simulator responds to a request, sent by a charge point.
+type Rule struct {
+	action           string
+	chargePointId    string
+	times            int
+	calls            int
+	delay            time.Duration
+	response         interface{}
+	handler          func(request Message) interface{}
+	errorCode        string
+	errorDescription string
+	disconnect       bool
+}
+
+// For limits the rule to the charge point.
+func (r *Rule) For(chargePointId string) *Rule {
+	r.chargePointId = chargePointId
+	return r
+}
+
+// Respond responds with the payload.
+func (r *Rule) Respond(response interface{}) *Rule {
+	r.response = response
+	return r
+}
+
+// RespondWith responds with the payload returned by the handler.
+func (r *Rule) RespondWith(handler func(request Message) interface{}) *Rule {
+	r.handler = handler
+	return r
+}
+
+// Error responds with a CALLERROR.
+func (r *Rule) Error(code, description string) *Rule {
+	r.errorCode = code
+	r.errorDescription = description
+	return r
+}
+
+// Delay delays the response.
+func (r *Rule) Delay(delay time.Duration) *Rule {
+	r.delay = delay
+	return r
+}
+
+// Disconnect closes the connection instead of responding.
+func (r *Rule) Disconnect() *Rule {
+	r.disconnect = true
+	return r
+}
+
+// Times limits the number of requests the rule is used for. Zero means unlimited.
+func (r *Rule) Times(times int) *Rule {
+	r.times = times
+	return r
+}
+
+// Once uses the rule only for the first matching request.
+func (r *Rule) Once() *Rule {
+	return r.Times(1)
+}
+
+func (r *Rule) matches(request Message) bool {
+	if r.action != request.Action {
+		return false
+	}
+
+	if r.chargePointId != "" && r.chargePointId != request.ChargePointId {
+		return false
+	}
+
+	return r.times == 0 || r.calls < r.times
+}
+
+// Unmarshal parses the payload of the message.
+func (m Message) Unmarshal(v interface{}) error {
+	return json.Unmarshal(m.Payload, v)
+}
@@ -0,0 +1,551 @@
+package csms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ProtocolOCPP16  = "ocpp1.6"
+	ProtocolOCPP201 = "ocpp2.0.1"
+
+	// OCPP-J message types
+	Call       = MessageType(2)
+	CallResult = MessageType(3)
+	CallError  = MessageType(4)
+
+	Incoming = Direction("incoming")
+	Outgoing = Direction("outgoing")
+)
+
+var (
+	ErrNotConnected    = errors.New("charge point not connected")
+	ErrInvalidMessage  = errors.New("invalid OCPP-J message")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrSimulatorClosed = errors.New("simulator closed")
+)
+
+type (
+	MessageType int
+	Direction   string
+
+	// Message is a recorded OCPP-J message, exchanged between the simulator and a charge point.
+	Message struct {
+		Time             time.Time
+		Direction        Direction
+		ChargePointId    string
+		Type             MessageType
+		Id               string
+		Action           string
+		Payload          json.RawMessage
+		ErrorCode        string
+		ErrorDescription string
+	}
+
+	// CallErrorResponse is returned by Call when the charge point responds with a CALLERROR.
+	CallErrorResponse struct {
+		Code        string
+		Description string
+	}
+
+	// Simulator is an in-process central system. It responds to the requests of the charge points according to
+	// the scripted rules, sends requests to the charge points and records all the exchanged messages.
+	Simulator struct {
+		protocol  string
+		username  string
+		password  string
+		server    *httptest.Server
+		upgrader  websocket.Upgrader
+		mu        sync.Mutex
+		cond      *sync.Cond
+		closed    bool
+		rules     []*Rule
+		trace     []Message
+		conns     map[string]*connection
+		connected map[string]int
+	}
+
+	Option func(simulator *Simulator)
+
+	connection struct {
+		chargePointId string
+		conn          *websocket.Conn
+		writeMu       sync.Mutex
+		pending       map[string]chan Message
+	}
+)
+
+func (e *CallErrorResponse) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+// WithBasicAuth requires the charge points to authenticate with HTTP basic authentication.
+func WithBasicAuth(username, password string) Option {
+	return func(simulator *Simulator) {
+		simulator.username = username
+		simulator.password = password
+	}
+}
+
+// NewSimulator creates a simulator for the OCPP-J protocol version (websocket subprotocol). Requests without a matching
+// rule are answered with a NotImplemented CALLERROR.
+func NewSimulator(protocol string, opts ...Option) *Simulator {
+	simulator := &Simulator{
+		protocol:  protocol,
+		conns:     map[string]*connection{},
+		connected: map[string]int{},
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{protocol},
+			CheckOrigin: func(r *http.Request) bool {
+				return true
+			},
+		},
+	}
+	simulator.cond = sync.NewCond(&simulator.mu)
+
+	for _, opt := range opts {
+		opt(simulator)
+	}
+
+	return simulator
+}
+
+// Start starts the simulator on a random local port.
+func (s *Simulator) Start() {
+	s.server = httptest.NewServer(http.HandlerFunc(s.handleConnection))
+}
+
+// Url returns the websocket url of the simulator, without the charge point id.
+func (s *Simulator) Url() string {
+	return strings.Replace(s.server.URL, "http://", "ws://", 1)
+}
+
+// Close disconnects all the charge points and stops the simulator.
+func (s *Simulator) Close() {
+	s.mu.Lock()
+	s.closed = true
+	conns := make([]*connection, 0, len(s.conns))
+	for _, c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		_ = c.conn.Close()
+	}
+
+	if s.server != nil {
+		s.server.Close()
+	}
+}
+
+// On adds a rule for the requests with the action, sent by the charge points. The rules added later take precedence,
+// so the default responses can be overridden.
+func (s *Simulator) On(action string) *Rule {
+	rule := &Rule{action: action}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = append(s.rules, rule)
+	return rule
+}
+
+// Reset removes all the rules and clears the trace.
+func (s *Simulator) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = nil
+	s.trace = nil
+}
+
+// Trace returns all the recorded messages.
+func (s *Simulator) Trace() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message{}, s.trace...)
+}
+
+// Requests returns the requests with the action, sent by the charge point. If the action is empty, all requests are returned.
+func (s *Simulator) Requests(chargePointId, action string) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests(chargePointId, action)
+}
+
+func (s *Simulator) requests(chargePointId, action string) []Message {
+	var requests []Message
+	for _, message := range s.trace {
+		if message.Direction == Incoming && message.Type == Call && message.ChargePointId == chargePointId &&
+			(action == "" || message.Action == action) {
+			requests = append(requests, message)
+		}
+	}
+
+	return requests
+}
+
+// Actions returns the actions of the requests, sent by the charge point, in the order they were received.
+func (s *Simulator) Actions(chargePointId string) []string {
+	var actions []string
+	for _, message := range s.Requests(chargePointId, "") {
+		actions = append(actions, message.Action)
+	}
+
+	return actions
+}
+
+// WaitForRequest waits until the charge point sends the n-th (starting with 1) request with the action.
+func (s *Simulator) WaitForRequest(ctx context.Context, chargePointId, action string, n int) (Message, error) {
+	var message Message
+
+	err := s.waitFor(ctx, func() bool {
+		requests := s.requests(chargePointId, action)
+		if len(requests) >= n {
+			message = requests[n-1]
+			return true
+		}
+
+		return false
+	})
+
+	return message, err
+}
+
+// WaitUntil waits until the condition is met for the requests, sent by the charge point. The condition is called
+// whenever a message is recorded and must not call the simulator.
+func (s *Simulator) WaitUntil(ctx context.Context, chargePointId string, condition func(requests []Message) bool) error {
+	return s.waitFor(ctx, func() bool {
+		return condition(s.requests(chargePointId, ""))
+	})
+}
+
+// WaitForConnection waits until the charge point is connected for the n-th time.
+func (s *Simulator) WaitForConnection(ctx context.Context, chargePointId string, n int) error {
+	return s.waitFor(ctx, func() bool {
+		return s.connected[chargePointId] >= n
+	})
+}
+
+// IsConnected checks if the charge point is connected.
+func (s *Simulator) IsConnected(chargePointId string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, isConnected := s.conns[chargePointId]
+	return isConnected
+}
+
+// waitFor waits until the condition is met. The condition is checked with the lock held.
+func (s *Simulator) waitFor(ctx context.Context, condition func() bool) error {
+	// Wake up the waiting goroutine when the context is done
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.cond.Broadcast()
+			s.mu.Unlock()
+		case <-stop:
+		}
+	}()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for !condition() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if s.closed {
+			return ErrSimulatorClosed
+		}
+
+		s.cond.Wait()
+	}
+
+	return nil
+}
+
+// Disconnect closes the connection to the charge point.
+func (s *Simulator) Disconnect(chargePointId string) error {
+	s.mu.Lock()
+	c, isFound := s.conns[chargePointId]
+	s.mu.Unlock()
+
+	if !isFound {
+		return ErrNotConnected
+	}
+
+	return c.conn.Close()
+}
+
+// Call sends a request to the charge point and waits for the response. If the charge point responds with a
+// CALLERROR, a CallErrorResponse is returned.
+func (s *Simulator) Call(ctx context.Context, chargePointId, action string, request interface{}) (json.RawMessage, error) {
+	s.mu.Lock()
+	c, isFound := s.conns[chargePointId]
+	s.mu.Unlock()
+
+	if !isFound {
+		return nil, ErrNotConnected
+	}
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		messageId = strconv.FormatUint(rand.Uint64(), 36)
+		responses = make(chan Message, 1)
+	)
+
+	s.mu.Lock()
+	c.pending[messageId] = responses
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(c.pending, messageId)
+		s.mu.Unlock()
+	}()
+
+	err = s.write(c, Message{Type: Call, Id: messageId, Action: action, Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case response := <-responses:
+		if response.Type == CallError {
+			return nil, &CallErrorResponse{Code: response.ErrorCode, Description: response.ErrorDescription}
+		}
+
+		return response.Payload, nil
+	}
+}
+
+func (s *Simulator) handleConnection(w http.ResponseWriter, r *http.Request) {
+	chargePointId := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	if s.username != "" || s.password != "" {
+		username, password, isSet := r.BasicAuth()
+		if !isSet || username != s.username || password != s.password {
+			http.Error(w, ErrUnauthorized.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &connection{
+		chargePointId: chargePointId,
+		conn:          conn,
+		pending:       map[string]chan Message{},
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		_ = conn.Close()
+		return
+	}
+
+	s.conns[chargePointId] = c
+	s.connected[chargePointId]++
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	log.WithField("chargePointId", chargePointId).Debug("Charge point connected to the simulator")
+
+	defer func() {
+		s.mu.Lock()
+		if s.conns[chargePointId] == c {
+			delete(s.conns, chargePointId)
+		}
+		s.cond.Broadcast()
+		s.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		message, err := parseMessage(data)
+		if err != nil {
+			log.WithError(err).Warn("Simulator received an invalid message")
+			continue
+		}
+
+		message.ChargePointId = chargePointId
+		message.Direction = Incoming
+		s.record(message)
+
+		switch message.Type {
+		case Call:
+			go s.handleRequest(c, message)
+		case CallResult, CallError:
+			s.mu.Lock()
+			responses, isFound := c.pending[message.Id]
+			s.mu.Unlock()
+
+			if isFound {
+				responses <- message
+			}
+		}
+	}
+}
+
+// handleRequest responds to the request according to the first matching rule.
+func (s *Simulator) handleRequest(c *connection, request Message) {
+	rule := s.findRule(request)
+	if rule == nil {
+		_ = s.write(c, Message{
+			Type:             CallError,
+			Id:               request.Id,
+			ErrorCode:        "NotImplemented",
+			ErrorDescription: fmt.Sprintf("no rule for %s", request.Action),
+		})
+		return
+	}
+
+	if rule.delay > 0 {
+		time.Sleep(rule.delay)
+	}
+
+	if rule.disconnect {
+		_ = c.conn.Close()
+		return
+	}
+
+	if rule.errorCode != "" {
+		_ = s.write(c, Message{Type: CallError, Id: request.Id, ErrorCode: rule.errorCode, ErrorDescription: rule.errorDescription})
+		return
+	}
+
+	var response interface{} = struct{}{}
+	if rule.handler != nil {
+		response = rule.handler(request)
+	} else if rule.response != nil {
+		response = rule.response
+	}
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		log.WithError(err).Error("Simulator cannot marshal the response")
+		return
+	}
+
+	_ = s.write(c, Message{Type: CallResult, Id: request.Id, Payload: payload})
+}
+
+func (s *Simulator) findRule(request Message) *Rule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.rules) - 1; i >= 0; i-- {
+		rule := s.rules[i]
+		if rule.matches(request) {
+			rule.calls++
+			return rule
+		}
+	}
+
+	return nil
+}
+
+func (s *Simulator) write(c *connection, message Message) error {
+	message.ChargePointId = c.chargePointId
+	message.Direction = Outgoing
+	message.Time = time.Now()
+
+	var frame []interface{}
+	switch message.Type {
+	case Call:
+		frame = []interface{}{Call, message.Id, message.Action, message.Payload}
+	case CallResult:
+		frame = []interface{}{CallResult, message.Id, message.Payload}
+	default:
+		frame = []interface{}{CallError, message.Id, message.ErrorCode, message.ErrorDescription, struct{}{}}
+	}
+
+	data, err := json.Marshal(frame)
+	if err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	err = c.conn.WriteMessage(websocket.TextMessage, data)
+	c.writeMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.record(message)
+	return nil
+}
+
+func (s *Simulator) record(message Message) {
+	if message.Time.IsZero() {
+		message.Time = time.Now()
+	}
+
+	s.mu.Lock()
+	s.trace = append(s.trace, message)
+	s.cond.Broadcast()
+	s.mu.Unlock()
+}
+
+// parseMessage parses an OCPP-J message.
+func parseMessage(data []byte) (Message, error) {
+	var (
+		frame   []json.RawMessage
+		message Message
+	)
+
+	if json.Unmarshal(data, &frame) != nil || len(frame) < 3 ||
+		json.Unmarshal(frame[0], &message.Type) != nil || json.Unmarshal(frame[1], &message.Id) != nil {
+		return message, ErrInvalidMessage
+	}
+
+	switch message.Type {
+	case Call:
+		if len(frame) != 4 || json.Unmarshal(frame[2], &message.Action) != nil {
+			return message, ErrInvalidMessage
+		}
+
+		message.Payload = frame[3]
+	case CallResult:
+		message.Payload = frame[2]
+	case CallError:
+		_ = json.Unmarshal(frame[2], &message.ErrorCode)
+		if len(frame) > 3 {
+			_ = json.Unmarshal(frame[3], &message.ErrorDescription)
+		}
+	default:
+		return message, ErrInvalidMessage
+	}
+
+	return message, nil
+}
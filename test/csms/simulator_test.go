@@ -0,0 +1,177 @@
+package csms
+
+import (
+	"context"
+	"errors"
+	"github.com/lorenzodonini/ocpp-go/ocpp"
+	ocpp16 "github.com/lorenzodonini/ocpp-go/ocpp1.6"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
+	"github.com/lorenzodonini/ocpp-go/ocppj"
+	"github.com/lorenzodonini/ocpp-go/ws"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+const chargePointId = "simulatorChargePoint"
+
+type (
+	// coreHandlerMock handles only the requests used in the tests.
+	coreHandlerMock struct {
+		core.ChargePointHandler
+	}
+
+	simulatorTestSuite struct {
+		suite.Suite
+		simulator   *Simulator
+		chargePoint ocpp16.ChargePoint
+	}
+)
+
+func (h *coreHandlerMock) OnGetConfiguration(request *core.GetConfigurationRequest) (*core.GetConfigurationConfirmation, error) {
+	value := "60"
+	return core.NewGetConfigurationConfirmation([]core.ConfigurationKey{{Key: "HeartbeatInterval", Value: value}}), nil
+}
+
+func (h *coreHandlerMock) OnReset(request *core.ResetRequest) (*core.ResetConfirmation, error) {
+	return nil, errors.New("reset failed")
+}
+
+func (s *simulatorTestSuite) SetupTest() {
+	s.simulator = NewOCPP16Simulator()
+	s.simulator.Start()
+
+	s.chargePoint = ocpp16.NewChargePoint(chargePointId, nil, ws.NewClient())
+	s.chargePoint.SetCoreHandler(&coreHandlerMock{})
+	s.Require().NoError(s.chargePoint.Start(s.simulator.Url()))
+}
+
+func (s *simulatorTestSuite) TearDownTest() {
+	s.chargePoint.Stop()
+	s.simulator.Close()
+}
+
+func (s *simulatorTestSuite) TestDefaultResponses() {
+	bootConfirmation, err := s.chargePoint.BootNotification("model", "vendor")
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.RegistrationStatusAccepted, bootConfirmation.Status)
+	s.Assert().EqualValues(DefaultHeartbeatInterval, bootConfirmation.Interval)
+
+	_, err = s.chargePoint.Heartbeat()
+	s.Require().NoError(err)
+
+	startConfirmation, err := s.chargePoint.StartTransaction(1, "tag", 0, types.NewDateTime(time.Now()))
+	s.Require().NoError(err)
+	s.Assert().EqualValues(1, startConfirmation.TransactionId)
+
+	s.simulator.AssertSequence(s.T(), chargePointId,
+		core.BootNotificationFeatureName, core.StartTransactionFeatureName)
+	s.simulator.AssertNotSent(s.T(), chargePointId, core.StopTransactionFeatureName)
+
+	// Each request and response is recorded
+	trace := s.simulator.Trace()
+	s.Require().Len(trace, 6)
+	s.Assert().EqualValues(Incoming, trace[0].Direction)
+	s.Assert().EqualValues(Call, trace[0].Type)
+	s.Assert().EqualValues(Outgoing, trace[1].Direction)
+	s.Assert().EqualValues(CallResult, trace[1].Type)
+	s.Assert().EqualValues(trace[0].Id, trace[1].Id)
+
+	var request core.BootNotificationRequest
+	s.Require().NoError(trace[0].Unmarshal(&request))
+	s.Assert().EqualValues("vendor", request.ChargePointVendor)
+}
+
+func (s *simulatorTestSuite) TestScriptedResponses() {
+	// The later rules override the defaults
+	s.simulator.On(core.BootNotificationFeatureName).Once().
+		Respond(core.NewBootNotificationConfirmation(types.NewDateTime(time.Now()), 10, core.RegistrationStatusRejected))
+	s.simulator.On(core.HeartbeatFeatureName).Once().Error(string(ocppj.InternalError), "heartbeat failed")
+
+	bootConfirmation, err := s.chargePoint.BootNotification("model", "vendor")
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.RegistrationStatusRejected, bootConfirmation.Status)
+
+	// The scripted response is used only once
+	bootConfirmation, err = s.chargePoint.BootNotification("model", "vendor")
+	s.Require().NoError(err)
+	s.Assert().EqualValues(core.RegistrationStatusAccepted, bootConfirmation.Status)
+
+	_, err = s.chargePoint.Heartbeat()
+	s.Require().Error(err)
+
+	var ocppErr *ocpp.Error
+	s.Require().True(errors.As(err, &ocppErr))
+	s.Assert().EqualValues(ocppj.InternalError, ocppErr.Code)
+}
+
+func (s *simulatorTestSuite) TestDelay() {
+	s.simulator.On(core.HeartbeatFeatureName).Delay(200 * time.Millisecond).
+		Respond(core.NewHeartbeatConfirmation(types.NewDateTime(time.Now())))
+
+	started := time.Now()
+	_, err := s.chargePoint.Heartbeat()
+	s.Require().NoError(err)
+	s.Assert().GreaterOrEqual(time.Since(started), 200*time.Millisecond)
+}
+
+func (s *simulatorTestSuite) TestUnscriptedRequest() {
+	s.simulator.Reset()
+
+	_, err := s.chargePoint.Heartbeat()
+	s.Require().Error(err)
+	s.Assert().Empty(s.simulator.Requests(chargePointId, core.BootNotificationFeatureName))
+	s.Assert().Len(s.simulator.Requests(chargePointId, core.HeartbeatFeatureName), 1)
+}
+
+func (s *simulatorTestSuite) TestCall() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s.Require().NoError(s.simulator.WaitForConnection(ctx, chargePointId, 1))
+
+	response, err := s.simulator.Call(ctx, chargePointId, core.GetConfigurationFeatureName, core.NewGetConfigurationRequest(nil))
+	s.Require().NoError(err)
+	s.Assert().Contains(string(response), "HeartbeatInterval")
+
+	_, err = s.simulator.Call(ctx, chargePointId, core.ResetFeatureName, core.NewResetRequest(core.ResetTypeSoft))
+	var callErr *CallErrorResponse
+	s.Require().True(errors.As(err, &callErr))
+
+	_, err = s.simulator.Call(ctx, "unknown", core.ResetFeatureName, core.NewResetRequest(core.ResetTypeSoft))
+	s.Assert().ErrorIs(err, ErrNotConnected)
+}
+
+func (s *simulatorTestSuite) TestDisconnect() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s.Require().NoError(s.simulator.WaitForConnection(ctx, chargePointId, 1))
+	s.Require().NoError(s.simulator.Disconnect(chargePointId))
+
+	// The charge point reconnects automatically
+	s.Require().NoError(s.simulator.WaitForConnection(ctx, chargePointId, 2))
+	s.Assert().True(s.simulator.IsConnected(chargePointId))
+}
+
+func (s *simulatorTestSuite) TestBasicAuth() {
+	simulator := NewOCPP16Simulator(WithBasicAuth(chargePointId, "password"))
+	simulator.Start()
+	defer simulator.Close()
+
+	client := ws.NewClient()
+	client.SetBasicAuth(chargePointId, "invalid")
+	chargePoint := ocpp16.NewChargePoint(chargePointId, nil, client)
+	s.Assert().Error(chargePoint.Start(simulator.Url()))
+
+	client = ws.NewClient()
+	client.SetBasicAuth(chargePointId, "password")
+	chargePoint = ocpp16.NewChargePoint(chargePointId, nil, client)
+	s.Require().NoError(chargePoint.Start(simulator.Url()))
+	chargePoint.Stop()
+}
+
+func TestSimulator(t *testing.T) {
+	suite.Run(t, new(simulatorTestSuite))
+}
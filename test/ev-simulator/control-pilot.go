@@ -0,0 +1,152 @@
+package evSimulator
+
+import (
+	"sync"
+)
+
+// Control pilot states, as defined by IEC 61851-1.
+const (
+	// StateA - no vehicle is connected.
+	StateA = State("A")
+	// StateB - a vehicle is connected, but does not request energy.
+	StateB = State("B")
+	// StateC - a vehicle is connected and requests energy.
+	StateC = State("C")
+)
+
+type (
+	State string
+
+	// Contactor switches the supply of the connector, e.g. the connector relay.
+	Contactor interface {
+		IsEnabled() bool
+	}
+
+	// ControlPilot is a simulated control pilot of a connector. The charge point offers the current with the
+	// control pilot and the connected vehicle signals its state.
+	ControlPilot struct {
+		mu        sync.Mutex
+		contactor Contactor
+		// voltage of the supply in V
+		voltage float64
+		// offeredCurrent per phase in A
+		offeredCurrent float64
+		state          State
+		vehicle        *Vehicle
+		// energy in Wh delivered through the connector
+		energy float64
+	}
+)
+
+// NewControlPilot creates a control pilot of a connector, supplied with the voltage and switched by the contactor.
+func NewControlPilot(contactor Contactor, voltage, offeredCurrent float64) *ControlPilot {
+	return &ControlPilot{
+		contactor:      contactor,
+		voltage:        voltage,
+		offeredCurrent: offeredCurrent,
+		state:          StateA,
+	}
+}
+
+// State returns the state of the control pilot.
+func (p *ControlPilot) State() State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+// OfferedCurrent returns the current per phase in A, which the vehicle is allowed to draw.
+func (p *ControlPilot) OfferedCurrent() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.offeredCurrent
+}
+
+// SetOfferedCurrent changes the current offered to the vehicle, e.g. when the charging limit changes.
+// The vehicle stops charging if the offered current is below the minimum charging current.
+func (p *ControlPilot) SetOfferedCurrent(current float64) {
+	// Apply the old limit up to now
+	p.sync()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.offeredCurrent = current
+}
+
+// Voltage returns the voltage of the supply in V.
+func (p *ControlPilot) Voltage() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.voltage
+}
+
+// IsContactorClosed checks if the contactor supplies the connector.
+func (p *ControlPilot) IsContactorClosed() bool {
+	return p.contactor != nil && p.contactor.IsEnabled()
+}
+
+// Vehicle returns the connected vehicle or nil.
+func (p *ControlPilot) Vehicle() *Vehicle {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.vehicle
+}
+
+// Energy returns the energy in Wh delivered through the connector.
+func (p *ControlPilot) Energy() float64 {
+	p.sync()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.energy
+}
+
+// sync advances the connected vehicle up to now, before the state of the connector changes.
+func (p *ControlPilot) sync() {
+	if vehicle := p.Vehicle(); vehicle != nil {
+		vehicle.sync()
+	}
+}
+
+func (p *ControlPilot) resetEnergy() {
+	p.sync()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.energy = 0
+}
+
+func (p *ControlPilot) connect(vehicle *Vehicle) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.vehicle != nil {
+		return ErrConnectorOccupied
+	}
+
+	p.vehicle = vehicle
+	p.state = StateB
+	return nil
+}
+
+func (p *ControlPilot) disconnect() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.vehicle = nil
+	p.state = StateA
+}
+
+func (p *ControlPilot) setState(state State) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.vehicle != nil {
+		p.state = state
+	}
+}
+
+func (p *ControlPilot) addEnergy(energy float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.energy += energy
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	log "github.com/sirupsen/logrus"
+	"github.com/xBlaz3kx/ChargePi-go/test/conformance"
+	"github.com/xBlaz3kx/ChargePi-go/test/csms"
+	evSimulator "github.com/xBlaz3kx/ChargePi-go/test/ev-simulator"
+	"time"
+)
+
+const connectorId = 1
+
+var (
+	ocppConfigurationFile = flag.String("ocpp-config", "configs/configuration.json", "OCPP config file path")
+	batteryCapacity       = flag.Float64("capacity", 40, "battery capacity in kWh")
+	stateOfCharge         = flag.Float64("soc", 20, "state of charge at plug in, in percent")
+	maxCurrent            = flag.Float64("max-current", 16, "max current of the on-board charger per phase, in A")
+	phases                = flag.Int("phases", 3, "number of phases used for charging")
+	taperStart            = flag.Float64("taper-start", evSimulator.DefaultTaperStart, "state of charge in percent, at which the current starts decreasing")
+	offeredCurrent        = flag.Float64("offered-current", 16, "current per phase in A, offered by the charge point")
+	timeScale             = flag.Float64("time-scale", 60, "speed of the simulated time")
+	plugInDelay           = flag.Duration("plug-in-delay", 5*time.Minute, "simulated time before the vehicle is plugged in")
+	idleTime              = flag.Duration("idle", 15*time.Minute, "simulated time the full vehicle stays plugged in")
+)
+
+// The demo runs a charging session of a simulated vehicle on a charge point with simulated hardware, connected to
+// the central system simulator. The vehicle plugs in, the driver presents a tag, the vehicle charges until full
+// and the driver ends the session with the same tag.
+func main() {
+	flag.Parse()
+
+	simulator := csms.NewOCPP16Simulator()
+	simulator.Start()
+	defer simulator.Close()
+
+	config := conformance.DefaultConfig(*ocppConfigurationFile)
+	config.Settings.ChargePoint.Info.MaxChargingTime = 0
+	// The demo vehicle is plugged in by the script
+	config.Vehicle = nil
+	config.Logger = log.New()
+	config.Logger.SetLevel(log.WarnLevel)
+
+	harness, err := conformance.NewHarness(config, simulator)
+	if err != nil {
+		log.WithError(err).Fatal("Unable to set up the charge point")
+	}
+	defer harness.Close()
+
+	harness.Start(config)
+
+	vehicle, err := evSimulator.NewVehicle(evSimulator.Config{
+		Id:              "demoVehicle",
+		BatteryCapacity: *batteryCapacity * 1000,
+		StateOfCharge:   *stateOfCharge,
+		MaxCurrent:      *maxCurrent,
+		Phases:          *phases,
+		TaperStart:      *taperStart,
+		TimeScale:       *timeScale,
+	})
+	if err != nil {
+		log.WithError(err).Fatal("Invalid vehicle")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err = simulator.WaitForRequest(ctx, harness.ChargePointId, core.BootNotificationFeatureName, 1)
+	if err != nil {
+		log.WithError(err).Fatal("Charge point did not boot")
+	}
+
+	// The charge point offers the current to the vehicle through the control pilot
+	err = harness.ChargePoint.SetCurrentLimit(connectorId, *offeredCurrent)
+	if err != nil {
+		log.WithError(err).Fatal("Unable to set the current limit")
+	}
+
+	pilot := harness.Pilots[connectorId]
+
+	go logProgress(ctx, vehicle, pilot)
+
+	presentTag := evSimulator.Do("present tag", func(ctx context.Context, v *evSimulator.Vehicle) error {
+		return harness.Reader.Present(ctx, conformance.TagId)
+	})
+
+	err = evSimulator.Script{
+		evSimulator.Wait(*plugInDelay),
+		evSimulator.PlugIn(pilot),
+		presentTag,
+		evSimulator.WaitUntilFull(),
+		evSimulator.Wait(*idleTime),
+		presentTag,
+		evSimulator.Unplug(),
+	}.Play(ctx, vehicle)
+	if err != nil {
+		log.WithError(err).Fatal("Session failed")
+	}
+
+	// Let the charge point finish the transaction
+	_, err = simulator.WaitForRequest(ctx, harness.ChargePointId, core.StopTransactionFeatureName, 1)
+	if err != nil {
+		log.WithError(err).Error("Transaction was not stopped")
+	}
+
+	log.WithFields(log.Fields{
+		"energy":        vehicle.Energy(),
+		"stateOfCharge": vehicle.StateOfCharge(),
+	}).Info("Session finished")
+
+	for _, action := range simulator.Actions(harness.ChargePointId) {
+		log.Infof("Charge point sent %s", action)
+	}
+}
+
+// logProgress periodically logs the state of the vehicle and the connector.
+func logProgress(ctx context.Context, vehicle *evSimulator.Vehicle, pilot *evSimulator.ControlPilot) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			log.WithFields(log.Fields{
+				"pilotState":    pilot.State(),
+				"stateOfCharge": int(vehicle.StateOfCharge()),
+				"current":       vehicle.Current(),
+				"energy":        int(pilot.Energy()),
+			}).Info("Vehicle")
+		}
+	}
+}
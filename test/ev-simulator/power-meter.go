@@ -0,0 +1,56 @@
+package evSimulator
+
+import (
+	powerMeter "github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/power-meter"
+)
+
+// PowerMeter is a simulated power meter, which measures the vehicle connected to the control pilot.
+type PowerMeter struct {
+	pilot *ControlPilot
+}
+
+// NewPowerMeter creates a power meter of the connector with the control pilot.
+func NewPowerMeter(pilot *ControlPilot) powerMeter.PowerMeter {
+	return &PowerMeter{pilot: pilot}
+}
+
+func (p *PowerMeter) Reset() {
+	p.pilot.resetEnergy()
+}
+
+// GetEnergy returns the energy in Wh delivered since the last reset.
+func (p *PowerMeter) GetEnergy() float64 {
+	return p.pilot.Energy()
+}
+
+// GetPower returns the power in W, drawn on all phases.
+func (p *PowerMeter) GetPower() float64 {
+	vehicle := p.pilot.Vehicle()
+	if vehicle == nil {
+		return 0
+	}
+
+	return p.GetVoltage() * vehicle.Current() * float64(vehicle.GetConfig().Phases)
+}
+
+// GetCurrent returns the current per phase in A.
+func (p *PowerMeter) GetCurrent() float64 {
+	vehicle := p.pilot.Vehicle()
+	if vehicle == nil {
+		return 0
+	}
+
+	return vehicle.Current()
+}
+
+func (p *PowerMeter) GetVoltage() float64 {
+	return p.pilot.Voltage()
+}
+
+func (p *PowerMeter) GetRMSCurrent() float64 {
+	return p.GetCurrent()
+}
+
+func (p *PowerMeter) GetRMSVoltage() float64 {
+	return p.GetVoltage()
+}
@@ -0,0 +1,60 @@
+package evSimulator
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrInvalidCurrent = errors.New("invalid current")
+
+// Relay is a simulated connector relay, which switches the supply of the control pilot. The vehicle is synchronised
+// before the relay switches, so the vehicle only charges while the relay is enabled. The charge point limits the
+// current offered by the control pilot through the relay.
+type Relay struct {
+	mu      sync.Mutex
+	enabled bool
+	pilot   *ControlPilot
+}
+
+// NewRelay creates a relay and the control pilot it supplies with the voltage and the offered current.
+func NewRelay(voltage, offeredCurrent float64) (*Relay, *ControlPilot) {
+	relay := &Relay{}
+	relay.pilot = NewControlPilot(relay, voltage, offeredCurrent)
+	return relay, relay.pilot
+}
+
+// Enable closes the contactor.
+func (r *Relay) Enable() {
+	r.set(true)
+}
+
+// Disable opens the contactor.
+func (r *Relay) Disable() {
+	r.set(false)
+}
+
+// IsEnabled returns the state of the relay.
+func (r *Relay) IsEnabled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enabled
+}
+
+// SetCurrentLimit changes the current per phase in A offered by the control pilot.
+func (r *Relay) SetCurrentLimit(limit float64) error {
+	if limit < 0 {
+		return ErrInvalidCurrent
+	}
+
+	r.pilot.SetOfferedCurrent(limit)
+	return nil
+}
+
+func (r *Relay) set(enabled bool) {
+	// Apply the previous state of the relay up to now
+	r.pilot.sync()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enabled = enabled
+}
@@ -0,0 +1,114 @@
+package evSimulator
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// pollInterval is the real-time interval, at which the conditions of the steps are checked.
+const pollInterval = 100 * time.Millisecond
+
+type (
+	// Step is a single action of the driver or the vehicle in a Script.
+	Step struct {
+		description string
+		run         func(ctx context.Context, v *Vehicle) error
+	}
+
+	// Script is a sequence of steps, e.g. plug in, charge until full and unplug after a while.
+	Script []Step
+)
+
+// String returns the description of the step.
+func (s Step) String() string {
+	return s.description
+}
+
+// Play runs the steps of the script in order and stops at the first error.
+func (s Script) Play(ctx context.Context, vehicle *Vehicle) error {
+	for _, step := range s {
+		err := step.run(ctx, vehicle)
+		if err != nil {
+			return fmt.Errorf("%s: %w", step, err)
+		}
+	}
+
+	return nil
+}
+
+// Do runs a custom action, e.g. presents a tag to the charge point.
+func Do(description string, action func(ctx context.Context, v *Vehicle) error) Step {
+	return Step{description: description, run: action}
+}
+
+// PlugIn plugs the vehicle into the connector with the control pilot.
+func PlugIn(pilot *ControlPilot) Step {
+	return Do("plug in", func(ctx context.Context, v *Vehicle) error {
+		return v.PlugIn(pilot)
+	})
+}
+
+// Unplug unplugs the vehicle.
+func Unplug() Step {
+	return Do("unplug", func(ctx context.Context, v *Vehicle) error {
+		return v.Unplug()
+	})
+}
+
+// Wait waits for the duration of the simulated time. If the real-time clock is stopped, the vehicle is advanced
+// by the duration instead.
+func Wait(duration time.Duration) Step {
+	return Do(fmt.Sprintf("wait %v", duration), func(ctx context.Context, v *Vehicle) error {
+		if v.config.TimeScale == 0 {
+			v.Advance(duration)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(float64(duration) / v.config.TimeScale)):
+			return nil
+		}
+	})
+}
+
+// WaitForStateOfCharge waits until the battery is charged to the state of charge in percent.
+func WaitForStateOfCharge(stateOfCharge float64) Step {
+	return Do(fmt.Sprintf("wait for %.0f%% state of charge", stateOfCharge), func(ctx context.Context, v *Vehicle) error {
+		return v.waitUntil(ctx, func() bool {
+			return v.StateOfCharge() >= stateOfCharge
+		})
+	})
+}
+
+// WaitUntilFull waits until the vehicle stops charging, because the battery is full.
+func WaitUntilFull() Step {
+	return Do("wait until full", func(ctx context.Context, v *Vehicle) error {
+		return v.waitUntil(ctx, v.IsFull)
+	})
+}
+
+// waitUntil waits until the condition is met. If the real-time clock is stopped, the vehicle is advanced until the
+// condition is met or the vehicle stops charging.
+func (v *Vehicle) waitUntil(ctx context.Context, condition func() bool) error {
+	for !condition() {
+		if v.config.TimeScale == 0 {
+			if v.Current() == 0 {
+				return ErrNotCharging
+			}
+
+			v.Advance(integrationStep)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+
+	return nil
+}
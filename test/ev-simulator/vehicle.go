@@ -0,0 +1,276 @@
+package evSimulator
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// MinimumCurrent is the lowest current in A a vehicle can charge with, as defined by IEC 61851-1.
+	MinimumCurrent = 6.0
+
+	DefaultTaperStart    = 80.0
+	DefaultCutoffCurrent = 1.0
+
+	// integrationStep is the longest period of the simulated time with a constant charging current.
+	integrationStep = time.Second
+)
+
+var (
+	ErrInvalidConfig     = errors.New("invalid vehicle configuration")
+	ErrAlreadyPluggedIn  = errors.New("vehicle already plugged in")
+	ErrNotPluggedIn      = errors.New("vehicle not plugged in")
+	ErrConnectorOccupied = errors.New("connector occupied by another vehicle")
+	ErrNotCharging       = errors.New("vehicle is not charging")
+)
+
+type (
+	// Config of the simulated vehicle.
+	Config struct {
+		Id string
+		// BatteryCapacity in Wh
+		BatteryCapacity float64
+		// StateOfCharge at the start of the simulation in percent
+		StateOfCharge float64
+		// MaxCurrent per phase in A, which the on-board charger can draw
+		MaxCurrent float64
+		// Phases used for charging. Defaults to a single phase.
+		Phases int
+		// TaperStart is the state of charge in percent, at which the constant voltage phase starts. From then on,
+		// the current decreases linearly until it drops below the CutoffCurrent and the vehicle is full.
+		TaperStart    float64
+		CutoffCurrent float64
+		// TimeScale speeds up the simulated time, e.g. 60 simulates an hour in a minute. Zero stops the real-time
+		// clock, so the vehicle is only advanced with Advance.
+		TimeScale float64
+	}
+
+	// Vehicle simulates the battery and the on-board charger of an electric vehicle. It charges with a CC/CV curve:
+	// a constant current up to the TaperStart, followed by a decreasing current until the battery is full. The
+	// current never exceeds the current offered by the control pilot.
+	Vehicle struct {
+		mu     sync.Mutex
+		config Config
+		pilot  *ControlPilot
+		// stateOfCharge in percent
+		stateOfCharge float64
+		// energy in Wh charged since the start of the simulation
+		energy   float64
+		isFull   bool
+		lastSync time.Time
+	}
+)
+
+// NewVehicle creates a vehicle, which is not plugged in.
+func NewVehicle(config Config) (*Vehicle, error) {
+	if config.BatteryCapacity <= 0 || config.MaxCurrent <= 0 || config.Phases < 0 || config.TimeScale < 0 ||
+		config.StateOfCharge < 0 || config.StateOfCharge > 100 ||
+		config.TaperStart < 0 || config.TaperStart >= 100 {
+		return nil, ErrInvalidConfig
+	}
+
+	if config.Phases == 0 {
+		config.Phases = 1
+	}
+
+	if config.TaperStart == 0 {
+		config.TaperStart = DefaultTaperStart
+	}
+
+	if config.CutoffCurrent <= 0 {
+		config.CutoffCurrent = DefaultCutoffCurrent
+	}
+
+	return &Vehicle{
+		config:        config,
+		stateOfCharge: config.StateOfCharge,
+		isFull:        config.StateOfCharge >= 100,
+	}, nil
+}
+
+// GetConfig returns the configuration of the vehicle with the defaults applied.
+func (v *Vehicle) GetConfig() Config {
+	return v.config
+}
+
+// PlugIn connects the vehicle to the control pilot of a connector.
+func (v *Vehicle) PlugIn(pilot *ControlPilot) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.pilot != nil {
+		return ErrAlreadyPluggedIn
+	}
+
+	err := pilot.connect(v)
+	if err != nil {
+		return err
+	}
+
+	v.pilot = pilot
+	v.lastSync = time.Now()
+	v.updateState()
+	return nil
+}
+
+// Unplug disconnects the vehicle from the connector.
+func (v *Vehicle) Unplug() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.pilot == nil {
+		return ErrNotPluggedIn
+	}
+
+	v.syncLocked()
+	v.pilot.disconnect()
+	v.pilot = nil
+	return nil
+}
+
+// IsPluggedIn checks if the vehicle is connected to a connector.
+func (v *Vehicle) IsPluggedIn() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.pilot != nil
+}
+
+// StateOfCharge returns the state of charge of the battery in percent.
+func (v *Vehicle) StateOfCharge() float64 {
+	v.sync()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.stateOfCharge
+}
+
+// Energy returns the energy in Wh charged since the start of the simulation.
+func (v *Vehicle) Energy() float64 {
+	v.sync()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.energy
+}
+
+// IsFull checks if the vehicle stopped charging, because the battery is full.
+func (v *Vehicle) IsFull() bool {
+	v.sync()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.isFull
+}
+
+// Current returns the current per phase in A, which the vehicle draws at the moment.
+func (v *Vehicle) Current() float64 {
+	v.sync()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.current()
+}
+
+// Advance simulates charging for the duration, regardless of the TimeScale.
+func (v *Vehicle) Advance(duration time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.advance(duration)
+}
+
+// sync advances the vehicle by the real time passed since the last sync, multiplied by the TimeScale.
+func (v *Vehicle) sync() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.syncLocked()
+}
+
+func (v *Vehicle) syncLocked() {
+	now := time.Now()
+	elapsed := now.Sub(v.lastSync)
+	v.lastSync = now
+
+	if v.config.TimeScale > 0 && elapsed > 0 {
+		v.advance(time.Duration(float64(elapsed) * v.config.TimeScale))
+	}
+}
+
+func (v *Vehicle) advance(duration time.Duration) {
+	if v.pilot == nil {
+		return
+	}
+
+	voltage := v.pilot.Voltage()
+
+	for duration > 0 {
+		step := integrationStep
+		if duration < step {
+			step = duration
+		}
+
+		duration -= step
+
+		current := v.current()
+		if current == 0 {
+			continue
+		}
+
+		energy := current * voltage * float64(v.config.Phases) * step.Hours()
+		v.energy += energy
+		v.stateOfCharge = math.Min(100, v.stateOfCharge+energy/v.config.BatteryCapacity*100)
+		v.pilot.addEnergy(energy)
+	}
+
+	v.updateState()
+}
+
+// current returns the current per phase, which the vehicle draws with the present state of charge and charging limit.
+func (v *Vehicle) current() float64 {
+	if v.pilot == nil || v.isFull || !v.pilot.IsContactorClosed() {
+		return 0
+	}
+
+	var (
+		offeredCurrent = v.pilot.OfferedCurrent()
+		current        = v.chargerCurrent()
+	)
+
+	if current < v.config.CutoffCurrent {
+		return 0
+	}
+
+	// The vehicle pauses charging until the offered current is sufficient
+	if offeredCurrent < MinimumCurrent {
+		return 0
+	}
+
+	return math.Min(current, offeredCurrent)
+}
+
+// chargerCurrent returns the current of the CC/CV charging curve at the present state of charge.
+func (v *Vehicle) chargerCurrent() float64 {
+	if v.stateOfCharge <= v.config.TaperStart {
+		return v.config.MaxCurrent
+	}
+
+	return v.config.MaxCurrent * (100 - v.stateOfCharge) / (100 - v.config.TaperStart)
+}
+
+// updateState marks the vehicle full and signals the state to the control pilot.
+func (v *Vehicle) updateState() {
+	if v.chargerCurrent() < v.config.CutoffCurrent {
+		v.isFull = true
+	}
+
+	if v.pilot == nil {
+		return
+	}
+
+	if v.isFull {
+		v.pilot.setState(StateB)
+	} else {
+		v.pilot.setState(StateC)
+	}
+}
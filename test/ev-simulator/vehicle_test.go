@@ -0,0 +1,248 @@
+package evSimulator
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/policy"
+	"testing"
+	"time"
+)
+
+type (
+	contactorMock struct {
+		enabled bool
+	}
+
+	VehicleTestSuite struct {
+		suite.Suite
+		contactor *contactorMock
+		pilot     *ControlPilot
+		config    Config
+	}
+)
+
+func (c *contactorMock) IsEnabled() bool {
+	return c.enabled
+}
+
+func (s *VehicleTestSuite) SetupTest() {
+	s.contactor = &contactorMock{enabled: true}
+	s.pilot = NewControlPilot(s.contactor, 230, 16)
+	s.config = Config{
+		Id:              "testVehicle",
+		BatteryCapacity: 10000,
+		StateOfCharge:   20,
+		MaxCurrent:      16,
+	}
+}
+
+func (s *VehicleTestSuite) TestNewVehicle() {
+	vehicle, err := NewVehicle(s.config)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(1, vehicle.GetConfig().Phases)
+	s.Assert().EqualValues(DefaultTaperStart, vehicle.GetConfig().TaperStart)
+	s.Assert().EqualValues(DefaultCutoffCurrent, vehicle.GetConfig().CutoffCurrent)
+	s.Assert().EqualValues(20, vehicle.StateOfCharge())
+	s.Assert().False(vehicle.IsPluggedIn())
+
+	_, err = NewVehicle(Config{MaxCurrent: 16})
+	s.Assert().ErrorIs(err, ErrInvalidConfig)
+
+	_, err = NewVehicle(Config{BatteryCapacity: 10000, MaxCurrent: 16, StateOfCharge: 120})
+	s.Assert().ErrorIs(err, ErrInvalidConfig)
+}
+
+func (s *VehicleTestSuite) TestPlugIn() {
+	vehicle, err := NewVehicle(s.config)
+	s.Require().NoError(err)
+	s.Assert().EqualValues(StateA, s.pilot.State())
+
+	err = vehicle.PlugIn(s.pilot)
+	s.Require().NoError(err)
+	s.Assert().True(vehicle.IsPluggedIn())
+	s.Assert().EqualValues(StateC, s.pilot.State())
+	s.Assert().Equal(vehicle, s.pilot.Vehicle())
+
+	// Only a single vehicle can be plugged in
+	err = vehicle.PlugIn(s.pilot)
+	s.Assert().ErrorIs(err, ErrAlreadyPluggedIn)
+
+	otherVehicle, err := NewVehicle(s.config)
+	s.Require().NoError(err)
+	err = otherVehicle.PlugIn(s.pilot)
+	s.Assert().ErrorIs(err, ErrConnectorOccupied)
+
+	err = vehicle.Unplug()
+	s.Require().NoError(err)
+	s.Assert().EqualValues(StateA, s.pilot.State())
+	s.Assert().Nil(s.pilot.Vehicle())
+
+	err = vehicle.Unplug()
+	s.Assert().ErrorIs(err, ErrNotPluggedIn)
+}
+
+func (s *VehicleTestSuite) TestConstantCurrent() {
+	vehicle, err := NewVehicle(s.config)
+	s.Require().NoError(err)
+	s.Require().NoError(vehicle.PlugIn(s.pilot))
+
+	s.Assert().EqualValues(16, vehicle.Current())
+
+	vehicle.Advance(time.Hour)
+	s.Assert().InDelta(3680, vehicle.Energy(), 0.1)
+	s.Assert().InDelta(3680, s.pilot.Energy(), 0.1)
+	s.Assert().InDelta(56.8, vehicle.StateOfCharge(), 0.01)
+
+	// The vehicle cannot charge while the contactor is open
+	s.contactor.enabled = false
+	s.Assert().EqualValues(0, vehicle.Current())
+	vehicle.Advance(time.Hour)
+	s.Assert().InDelta(56.8, vehicle.StateOfCharge(), 0.01)
+}
+
+func (s *VehicleTestSuite) TestOfferedCurrent() {
+	vehicle, err := NewVehicle(s.config)
+	s.Require().NoError(err)
+	s.Require().NoError(vehicle.PlugIn(s.pilot))
+
+	s.pilot.SetOfferedCurrent(10)
+	s.Assert().EqualValues(10, vehicle.Current())
+
+	// The vehicle pauses below the minimum current, but still requests energy
+	s.pilot.SetOfferedCurrent(5)
+	s.Assert().EqualValues(0, vehicle.Current())
+	s.Assert().EqualValues(StateC, s.pilot.State())
+
+	s.pilot.SetOfferedCurrent(32)
+	s.Assert().EqualValues(16, vehicle.Current())
+}
+
+func (s *VehicleTestSuite) TestRelay() {
+	relay, pilot := NewRelay(230, 16)
+
+	s.config.TimeScale = 3600
+	vehicle, err := NewVehicle(s.config)
+	s.Require().NoError(err)
+	s.Require().NoError(vehicle.PlugIn(pilot))
+
+	// The vehicle does not charge before the relay is enabled
+	time.Sleep(50 * time.Millisecond)
+	s.Assert().EqualValues(0, pilot.Energy())
+	relay.Enable()
+
+	// The vehicle charges until the relay is disabled
+	time.Sleep(50 * time.Millisecond)
+	relay.Disable()
+	energy := pilot.Energy()
+	s.Assert().Greater(energy, 0.0)
+
+	time.Sleep(50 * time.Millisecond)
+	s.Assert().EqualValues(energy, pilot.Energy())
+
+	// The charge point limits the offered current through the relay
+	s.Require().NoError(relay.SetCurrentLimit(10))
+	s.Assert().EqualValues(10, pilot.OfferedCurrent())
+	s.Assert().ErrorIs(relay.SetCurrentLimit(-1), ErrInvalidCurrent)
+}
+
+func (s *VehicleTestSuite) TestTaper() {
+	s.config.StateOfCharge = 90
+	vehicle, err := NewVehicle(s.config)
+	s.Require().NoError(err)
+	s.Require().NoError(vehicle.PlugIn(s.pilot))
+
+	s.Assert().InDelta(8, vehicle.Current(), 0.01)
+
+	err = Script{WaitUntilFull()}.Play(context.Background(), vehicle)
+	s.Require().NoError(err)
+
+	// The vehicle is full when the current drops below the cutoff current
+	s.Assert().True(vehicle.IsFull())
+	s.Assert().EqualValues(0, vehicle.Current())
+	s.Assert().EqualValues(StateB, s.pilot.State())
+	s.Assert().InDelta(98.75, vehicle.StateOfCharge(), 0.01)
+}
+
+func (s *VehicleTestSuite) TestPowerMeter() {
+	s.config.Phases = 3
+	vehicle, err := NewVehicle(s.config)
+	s.Require().NoError(err)
+
+	meter := NewPowerMeter(s.pilot)
+	s.Assert().EqualValues(0, meter.GetPower())
+	s.Assert().EqualValues(230, meter.GetVoltage())
+
+	s.Require().NoError(vehicle.PlugIn(s.pilot))
+	s.Assert().EqualValues(16, meter.GetCurrent())
+	s.Assert().EqualValues(11040, meter.GetPower())
+
+	vehicle.Advance(30 * time.Minute)
+	s.Assert().InDelta(5520, meter.GetEnergy(), 0.1)
+
+	meter.Reset()
+	s.Assert().EqualValues(0, meter.GetEnergy())
+}
+
+func (s *VehicleTestSuite) TestScript() {
+	vehicle, err := NewVehicle(s.config)
+	s.Require().NoError(err)
+
+	err = Script{
+		PlugIn(s.pilot),
+		WaitForStateOfCharge(50),
+		Wait(time.Hour),
+		Unplug(),
+	}.Play(context.Background(), vehicle)
+	s.Require().NoError(err)
+
+	// The current decreases above the taper start, so the hour adds less than 36.8 %
+	s.Assert().False(vehicle.IsPluggedIn())
+	s.Assert().InDelta(85.77, vehicle.StateOfCharge(), 0.01)
+
+	// The vehicle cannot reach the state of charge while unplugged
+	err = Script{WaitForStateOfCharge(90)}.Play(context.Background(), vehicle)
+	s.Assert().ErrorIs(err, ErrNotCharging)
+}
+
+func (s *VehicleTestSuite) TestRealTime() {
+	s.config.TimeScale = 36000
+	s.config.StateOfCharge = 70
+	vehicle, err := NewVehicle(s.config)
+	s.Require().NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = Script{PlugIn(s.pilot), WaitUntilFull(), Unplug()}.Play(ctx, vehicle)
+	s.Require().NoError(err)
+	s.Assert().True(vehicle.IsFull())
+}
+
+func (s *VehicleTestSuite) TestStopAfterFull() {
+	var (
+		now       = time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+		meter     = NewPowerMeter(s.pilot)
+		evaluator = policy.NewEvaluator(policy.Policy{StopAfterFull: 5 * time.Minute, MinPower: 100}, policy.Sample{Time: now})
+		rule      *policy.Rule
+	)
+
+	s.config.StateOfCharge = 75
+	vehicle, err := NewVehicle(s.config)
+	s.Require().NoError(err)
+	s.Require().NoError(vehicle.PlugIn(s.pilot))
+
+	// Sample the power every simulated minute, same as the session policy of the charge point
+	for i := 0; i < 180 && rule == nil; i++ {
+		rule = evaluator.Evaluate(policy.Sample{Time: now, Energy: meter.GetEnergy(), Power: meter.GetPower()})
+		vehicle.Advance(time.Minute)
+		now = now.Add(time.Minute)
+	}
+
+	s.Require().NotNil(rule)
+	s.Assert().EqualValues(policy.RuleStopAfterFull, *rule)
+	s.Assert().True(vehicle.IsFull())
+}
+
+func TestVehicle(t *testing.T) {
+	suite.Run(t, new(VehicleTestSuite))
+}
@@ -40,7 +40,8 @@ func (s *chargePointTestSuite) TestStartStopTransaction() {
 	conn.On("SetNotificationChannel", mock.Anything).Return()
 
 	s.manager.On("GetConnectors").Return([]connector.Connector{conn})
-	s.manager.On("FindConnector", 1, 1).Return(conn)
+	s.manager.On("FindConnectorById", 1).Return(conn)
+	s.manager.On("GetEvseConnectors", 1).Return([]connector.Connector{conn})
 	s.manager.On("FindAvailableConnector").Return(conn)
 	s.manager.On("FindConnectorWithTagId", tagId).Return(nil).Once()
 	s.manager.On("FindConnectorWithTransactionId", "1").Return(nil).Once()
@@ -52,6 +53,7 @@ func (s *chargePointTestSuite) TestStartStopTransaction() {
 	s.manager.On("AddConnectorsFromConfiguration", mock.Anything).Return(nil)
 	s.manager.On("RestoreConnectorStatus", mock.Anything).Return(nil)
 	s.manager.On("SetNotificationChannel").Return()
+	s.manager.On("SetMeterValuesChannel").Return()
 
 	// Create and connect the Charge Point
 	chargePoint := s.setupChargePoint(ctx, nil, nil, s.manager)
@@ -122,7 +124,8 @@ func (s *chargePointTestSuite) TestStartStopTransactionWithReader() {
 	conn.On("SetNotificationChannel", mock.Anything).Return()
 
 	s.manager.On("GetConnectors").Return([]connector.Connector{conn})
-	s.manager.On("FindConnector", 1, 1).Return(conn)
+	s.manager.On("FindConnectorById", 1).Return(conn)
+	s.manager.On("GetEvseConnectors", 1).Return([]connector.Connector{conn})
 	s.manager.On("FindAvailableConnector").Return(conn)
 	s.manager.On("FindConnectorWithTagId", strings.ToUpper(tagId)).Return(nil).Once()
 	s.manager.On("FindConnectorWithTransactionId", "1").Return(nil).Once()
@@ -134,6 +137,7 @@ func (s *chargePointTestSuite) TestStartStopTransactionWithReader() {
 	s.manager.On("AddConnectorsFromConfiguration", mock.Anything).Return(nil)
 	s.manager.On("RestoreConnectorStatus", mock.Anything).Return(nil)
 	s.manager.On("SetNotificationChannel").Return()
+	s.manager.On("SetMeterValuesChannel").Return()
 
 	// Mock tagReader
 	s.tagReader.On("ListenForTags").Return()
@@ -206,7 +210,8 @@ func (s *chargePointTestSuite) TestRemoteStartStopTransaction() {
 	conn.On("SetNotificationChannel", mock.Anything).Return()
 
 	s.manager.On("GetConnectors").Return([]connector.Connector{conn})
-	s.manager.On("FindConnector", 1, 1).Return(conn)
+	s.manager.On("FindConnectorById", 1).Return(conn)
+	s.manager.On("GetEvseConnectors", 1).Return([]connector.Connector{conn})
 	s.manager.On("FindAvailableConnector").Return(conn)
 	s.manager.On("FindConnectorWithTagId", strings.ToUpper(tagId)).Return(nil).Once()
 	s.manager.On("FindConnectorWithTransactionId", "1").Return(nil).Once()
@@ -218,6 +223,7 @@ func (s *chargePointTestSuite) TestRemoteStartStopTransaction() {
 	s.manager.On("AddConnectorsFromConfiguration", mock.Anything).Return(nil)
 	s.manager.On("RestoreConnectorStatus", mock.Anything).Return(nil)
 	s.manager.On("SetNotificationChannel").Return()
+	s.manager.On("SetMeterValuesChannel").Return()
 
 	// Create and connect the Charge Point
 	cp := s.setupChargePoint(ctx, nil, nil, s.manager)
@@ -271,6 +277,7 @@ func (s *chargePointTestSuite) TestGetAndChangeConfiguration() {
 	s.manager.On("AddConnectorsFromConfiguration", mock.Anything).Return(nil)
 	s.manager.On("RestoreConnectorStatus", mock.Anything).Return(nil)
 	s.manager.On("SetNotificationChannel").Return()
+	s.manager.On("SetMeterValuesChannel").Return()
 
 	// Create and connect the Charge Point
 	cp := s.setupChargePoint(ctx, nil, nil, s.manager)
@@ -80,6 +80,7 @@ func (s *chargePointTestSuite) SetupTest() {
 	setting.SetupOcppConfigurationManager(
 		ocppConfigurationFilePath,
 		configuration.OCPP16,
+		nil,
 		core.ProfileName,
 		reservation.ProfileName)
 
@@ -169,7 +170,7 @@ func (s *chargePointTestSuite) setupChargePoint(ctx context.Context, lcd display
 	cp := v16.NewChargePoint(
 		connectorManager,
 		scheduler.GetScheduler(),
-		auth.NewAuthCache("./auth.json"),
+		auth.NewAuthCache(nil),
 		v16.WithDisplay(ctx, lcd),
 		v16.WithReader(ctx, reader),
 		v16.WithLogger(log.StandardLogger()),
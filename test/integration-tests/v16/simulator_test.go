@@ -0,0 +1,124 @@
+package v16
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-co-op/gocron"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/core"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/remotetrigger"
+	"github.com/lorenzodonini/ocpp-go/ocpp1.6/reservation"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	v16 "github.com/xBlaz3kx/ChargePi-go/internal/chargepoint/v16"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
+	setting "github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	"github.com/xBlaz3kx/ChargePi-go/internal/models/charge-point"
+	"github.com/xBlaz3kx/ChargePi-go/test"
+	"github.com/xBlaz3kx/ChargePi-go/test/csms"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	"testing"
+	"time"
+)
+
+// simulatorTestSuite runs the charge point against the in-process central system simulator.
+type simulatorTestSuite struct {
+	suite.Suite
+	simulator   *csms.Simulator
+	manager     *test.ManagerMock
+	chargePoint chargePoint.ChargePoint
+	cancel      context.CancelFunc
+}
+
+func (s *simulatorTestSuite) SetupTest() {
+	var ctx context.Context
+	ctx, s.cancel = context.WithCancel(context.Background())
+
+	setting.SetupOcppConfigurationManager(
+		ocppConfigurationFilePath,
+		configuration.OCPP16,
+		nil,
+		core.ProfileName,
+		reservation.ProfileName)
+
+	s.simulator = csms.NewOCPP16Simulator()
+	s.simulator.Start()
+
+	s.manager = new(test.ManagerMock)
+	s.manager.On("GetConnectors").Return([]connector.Connector{})
+	s.manager.On("FindConnector", mock.Anything, mock.Anything).Return(nil)
+	s.manager.On("FindConnectorById", mock.Anything).Return(nil)
+	s.manager.On("SetNotificationChannel", mock.Anything).Return()
+	s.manager.On("SetMeterValuesChannel", mock.Anything).Return()
+
+	// Each test uses its own scheduler, configured like the default one, so the jobs are removed with the charge point
+	jobScheduler := gocron.NewScheduler(time.UTC)
+	jobScheduler.WaitForScheduleAll()
+	jobScheduler.StartAsync()
+
+	s.chargePoint = v16.NewChargePoint(
+		s.manager,
+		jobScheduler,
+		auth.NewAuthCache(nil),
+		v16.WithLogger(log.StandardLogger()),
+	)
+	s.chargePoint.Init(&chargePointSettings)
+	s.chargePoint.Connect(ctx, s.simulator.Url())
+}
+
+func (s *simulatorTestSuite) TearDownTest() {
+	s.cancel()
+	s.chargePoint.CleanUp(core.ReasonOther)
+	s.simulator.Close()
+}
+
+func (s *simulatorTestSuite) TestBootNotification() {
+	s.simulator.EventuallySequence(s.T(), 5*time.Second, chargePointId,
+		core.BootNotificationFeatureName, "SecurityEventNotification")
+
+	requests := s.simulator.Requests(chargePointId, core.BootNotificationFeatureName)
+	s.Require().Len(requests, 1)
+
+	var request core.BootNotificationRequest
+	s.Require().NoError(requests[0].Unmarshal(&request))
+	s.Assert().EqualValues("exampleVendor", request.ChargePointVendor)
+	s.Assert().EqualValues("exampleModel", request.ChargePointModel)
+}
+
+func (s *simulatorTestSuite) TestGetConfiguration() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response, err := s.simulator.Call(ctx, chargePointId, core.GetConfigurationFeatureName,
+		core.NewGetConfigurationRequest([]string{"HeartbeatInterval"}))
+	s.Require().NoError(err)
+
+	var confirmation core.GetConfigurationConfirmation
+	s.Require().NoError(json.Unmarshal(response, &confirmation))
+	s.Require().Len(confirmation.ConfigurationKey, 1)
+	s.Assert().EqualValues("HeartbeatInterval", confirmation.ConfigurationKey[0].Key)
+}
+
+func (s *simulatorTestSuite) TestTriggerHeartbeat() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s.simulator.EventuallySequence(s.T(), 5*time.Second, chargePointId, core.BootNotificationFeatureName)
+
+	response, err := s.simulator.Call(ctx, chargePointId, remotetrigger.TriggerMessageFeatureName,
+		remotetrigger.NewTriggerMessageRequest(core.HeartbeatFeatureName))
+	s.Require().NoError(err)
+
+	var confirmation remotetrigger.TriggerMessageConfirmation
+	s.Require().NoError(json.Unmarshal(response, &confirmation))
+	s.Assert().EqualValues(remotetrigger.TriggerMessageStatusAccepted, confirmation.Status)
+
+	// The heartbeat is sent with a delay
+	s.simulator.EventuallySequence(s.T(), 10*time.Second, chargePointId,
+		core.BootNotificationFeatureName, core.HeartbeatFeatureName)
+}
+
+func TestSimulator(t *testing.T) {
+	suite.Run(t, new(simulatorTestSuite))
+}
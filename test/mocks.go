@@ -6,10 +6,14 @@ import (
 	"github.com/lorenzodonini/ocpp-go/ocpp1.6/types"
 	"github.com/reactivex/rxgo/v2"
 	"github.com/stretchr/testify/mock"
+	"github.com/xBlaz3kx/ChargePi-go/internal/api"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/auth"
+	authInput "github.com/xBlaz3kx/ChargePi-go/internal/components/auth-input"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/connector"
 	"github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/display"
 	powerMeter "github.com/xBlaz3kx/ChargePi-go/internal/components/hardware/power-meter"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models"
+	chargePoint "github.com/xBlaz3kx/ChargePi-go/internal/models/charge-point"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/session"
 	"github.com/xBlaz3kx/ChargePi-go/internal/models/settings"
 )
@@ -19,6 +23,11 @@ type (
 		mock.Mock
 	}
 
+	// QRCodeDisplayMock is a display that can show a QR code.
+	QRCodeDisplayMock struct {
+		DisplayMock
+	}
+
 	ReaderMock struct {
 		mock.Mock
 	}
@@ -42,6 +51,10 @@ type (
 	RelayMock struct {
 		mock.Mock
 	}
+
+	ChargePointMock struct {
+		mock.Mock
+	}
 )
 
 /*------------------ Manager mock ------------------*/
@@ -59,6 +72,24 @@ func (o *ManagerMock) FindConnector(evseId, connectorID int) connector.Connector
 	return nil
 }
 
+func (o *ManagerMock) GetEvseConnectors(evseId int) []connector.Connector {
+	args := o.Called(evseId)
+	if args.Get(0) != nil {
+		return args.Get(0).([]connector.Connector)
+	}
+
+	return nil
+}
+
+func (o *ManagerMock) FindConnectorById(connectorId int) connector.Connector {
+	args := o.Called(connectorId)
+	if args.Get(0) != nil {
+		return args.Get(0).(connector.Connector)
+	}
+
+	return nil
+}
+
 func (o *ManagerMock) FindAvailableConnector() connector.Connector {
 	args := o.Called()
 	if args.Get(0) != nil {
@@ -111,6 +142,10 @@ func (o *ManagerMock) AddConnector(c connector.Connector) error {
 	return o.Called(c).Error(0)
 }
 
+func (o *ManagerMock) RemoveConnector(evseId, connectorID int) error {
+	return o.Called(evseId, connectorID).Error(0)
+}
+
 func (o *ManagerMock) AddConnectorFromSettings(maxChargingTime int, c *settings.Connector) error {
 	return o.Called(c).Error(0)
 }
@@ -153,6 +188,10 @@ func (l *DisplayMock) GetLcdChannel() chan<- display.LCDMessage {
 	return l.Called().Get(0).(chan display.LCDMessage)
 }
 
+func (l *QRCodeDisplayMock) DisplayQRCode(content string, caption string) {
+	l.Called(content, caption)
+}
+
 /*------------------ Reader mock ------------------*/
 
 func (r *ReaderMock) ListenForTags(ctx context.Context) {
@@ -216,6 +255,11 @@ func (m *ConnectorMock) GetTagId() string {
 	return args.String(0)
 }
 
+func (m *ConnectorMock) GetParentIdTag() string {
+	args := m.Called()
+	return args.String(0)
+}
+
 func (m *ConnectorMock) GetTransactionId() string {
 	args := m.Called()
 	return args.String(0)
@@ -236,8 +280,8 @@ func (m *ConnectorMock) CalculateSessionAvgEnergyConsumption() float64 {
 	return args.Get(0).(float64)
 }
 
-func (m *ConnectorMock) SamplePowerMeter(measurands []types.Measurand) {
-	m.Called(measurands)
+func (m *ConnectorMock) SamplePowerMeter(measurands []types.Measurand, readingContext types.ReadingContext) {
+	m.Called(measurands, readingContext)
 }
 
 func (m *ConnectorMock) SetStatus(status core.ChargePointStatus, errCode core.ChargePointErrorCode) {
@@ -284,6 +328,19 @@ func (m *ConnectorMock) GetMaxChargingTime() int {
 	return args.Int(0)
 }
 
+func (m *ConnectorMock) SetCurrentLimit(limit float64) error {
+	args := m.Called(limit)
+	return args.Error(0)
+}
+
+func (m *ConnectorMock) SetSessionEnergy(energy, meterReading float64) {
+	m.Called(energy, meterReading)
+}
+
+func (m *ConnectorMock) SetSessionParentIdTag(parentIdTag string) {
+	m.Called(parentIdTag)
+}
+
 /*------------------ Indicator mock ------------------*/
 
 func (i *IndicatorMock) DisplayColor(index int, colorHex uint32) error {
@@ -345,3 +402,114 @@ func (r *RelayMock) Enable() {
 func (r *RelayMock) Disable() {
 	r.Called()
 }
+
+/*------------------ ChargePoint mock ------------------*/
+
+func (c *ChargePointMock) Init(settings *settings.Settings) {
+	c.Called(settings)
+}
+
+func (c *ChargePointMock) Connect(ctx context.Context, serverUrl string) {
+	c.Called(serverUrl)
+}
+
+func (c *ChargePointMock) HandleChargingRequest(tagId string) (*api.HandleChargingResponse, error) {
+	args := c.Called(tagId)
+	return args.Get(0).(*api.HandleChargingResponse), args.Error(1)
+}
+
+func (c *ChargePointMock) StartCharging(tagId string, connectorId int) (*api.StartTransactionResponse, error) {
+	args := c.Called(tagId, connectorId)
+	return args.Get(0).(*api.StartTransactionResponse), args.Error(1)
+}
+
+func (c *ChargePointMock) StopCharging(tagId string, connectorId int) (*api.StopTransactionResponse, error) {
+	args := c.Called(tagId, connectorId)
+	return args.Get(0).(*api.StopTransactionResponse), args.Error(1)
+}
+
+func (c *ChargePointMock) ChangeAvailability(connectorId int, availability core.AvailabilityType) error {
+	return c.Called(connectorId, availability).Error(0)
+}
+
+func (c *ChargePointMock) SendDataTransfer(vendorId, messageId string, data interface{}) (*core.DataTransferConfirmation, error) {
+	args := c.Called(vendorId, messageId, data)
+	if args.Get(0) != nil {
+		return args.Get(0).(*core.DataTransferConfirmation), args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (c *ChargePointMock) GetConnectorStatus(evseId, connectorId int) (*api.GetConnectorStatusResponse, error) {
+	args := c.Called(evseId, connectorId)
+	return args.Get(0).(*api.GetConnectorStatusResponse), args.Error(1)
+}
+
+func (c *ChargePointMock) GetSessionHistory() ([]session.Receipt, error) {
+	args := c.Called()
+	if args.Get(0) != nil {
+		return args.Get(0).([]session.Receipt), args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (c *ChargePointMock) GetReceipt(transactionId string) (*session.Receipt, error) {
+	args := c.Called(transactionId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*session.Receipt), args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (c *ChargePointMock) SubmitAuthorization(request authInput.Request) error {
+	return c.Called(request).Error(0)
+}
+
+func (c *ChargePointMock) GetPaymentUrl(connectorId int) (string, error) {
+	args := c.Called(connectorId)
+	return args.String(0), args.Error(1)
+}
+
+func (c *ChargePointMock) StartFreeVend(connectorId int) error {
+	return c.Called(connectorId).Error(0)
+}
+
+func (c *ChargePointMock) GetTagGroup(tagId string) (*auth.TagGroup, error) {
+	args := c.Called(tagId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*auth.TagGroup), args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func (c *ChargePointMock) CleanUp(reason core.Reason) {
+	c.Called(reason)
+}
+
+func (c *ChargePointMock) ListenForTag(ctx context.Context, tagChannel <-chan string) {
+	c.Called()
+}
+
+func (c *ChargePointMock) AddConnectors(connectors []*settings.Connector) {
+	c.Called(connectors)
+}
+
+func (c *ChargePointMock) ApplySettings(ctx context.Context, settings *settings.Settings) error {
+	return c.Called(settings).Error(0)
+}
+
+func (c *ChargePointMock) ApplyConnectors(connectors []*settings.Connector) error {
+	return c.Called(connectors).Error(0)
+}
+
+func (c *ChargePointMock) AddNotificationListener(listener chargePoint.NotificationListener) {
+	c.Called(listener)
+}
+
+func (c *ChargePointMock) ListenForConnectorStatusChange(ctx context.Context, ch <-chan rxgo.Item) {
+	c.Called()
+}
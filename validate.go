@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/xBlaz3kx/ChargePi-go/internal/components/settings"
+	"github.com/xBlaz3kx/ocppManager-go/configuration"
+	"io"
+)
+
+var (
+	ErrInvalidConfiguration = errors.New("configuration is invalid")
+
+	validateCmd = &cobra.Command{
+		Use:          "validate",
+		Short:        "Validate the settings, connectors and OCPP configuration without starting the charge point.",
+		SilenceUsage: true,
+		RunE:         validate,
+	}
+)
+
+func validate(cmd *cobra.Command, args []string) error {
+	var (
+		out     = cmd.OutOrStdout()
+		isValid = true
+		version = configuration.OCPP16
+	)
+
+	report := func(source string, err error) {
+		if err != nil {
+			isValid = false
+			_, _ = fmt.Fprintf(out, "✘ %s: %v\n", source, err)
+			return
+		}
+
+		_, _ = fmt.Fprintf(out, "✔ %s\n", source)
+	}
+
+	err := settings.ReadSettings(settingsFilePath)
+	if err == nil {
+		conf, loadErr := settings.LoadSettings()
+		err = loadErr
+		if conf != nil && conf.ChargePoint.Info.ProtocolVersion != "" {
+			version = configuration.ProtocolVersion(conf.ChargePoint.Info.ProtocolVersion)
+		}
+	}
+	report("settings", err)
+
+	connectors, err := settings.LoadConnectors(connectorsFolderPath)
+	if err == nil {
+		err = settings.ValidateConnectors(connectors)
+	}
+	report(fmt.Sprintf("connectors (%d)", len(connectors)), err)
+
+	report("OCPP configuration", settings.ValidateOcppConfiguration(configurationFilePath, version))
+
+	return validationResult(out, isValid)
+}
+
+func validationResult(out io.Writer, isValid bool) error {
+	if !isValid {
+		return ErrInvalidConfiguration
+	}
+
+	_, _ = fmt.Fprintln(out, "Configuration is valid.")
+	return nil
+}